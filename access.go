@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tool: access_check
+const accessCheckToolDescription = `Find the selected fields of an operation that the current credentials can't access, before invoking it, to avoid responses with authorization errors on some fields and nulls in their place.
+When ROLE_DENIED_FIELDS lists the fields the current role can't access, the operation is checked against it without sending anything. Otherwise the query is probed: it runs once with the current headers, its data is discarded, and the fields whose errors are authorization errors are reported.
+
+Best Practices:
+- Run this before an operation that selects sensitive fields, then remove the denied ones from the selection set or switch credentials (use_header_profile).
+- The current role is the header profile in use when ROLE_DENIED_FIELDS has an entry for it, and ACCESS_ROLE otherwise.
+- Mutations and subscriptions are never probed: they can only be checked against ROLE_DENIED_FIELDS.
+- When probing, the fields below a denied field aren't resolved, so they can't be checked until it is removed.
+
+Arguments:
+- operation (string, Required): The operation to check.
+- variables (string, Optional): JSON-encoded variables, needed to probe a query that declares required variables.
+- operationName (string, Optional): The operation to check when the document contains several.
+
+Example Usage:
+Request:
+  access_check(operation: "query { candidate(id: \"1\") { name salary applications { job { title budget } } } }")
+
+Response:
+  Checked 7 selected fields of query MCPQuery_candidate for role "viewer" against ROLE_DENIED_FIELDS:
+  Denied (2):
+  	candidate.salary (Candidate.salary)
+  	candidate.applications.job.budget (Job.budget)
+  Remove them from the selection set, or use credentials that can access them.
+`
+
+// Role access map. ROLE_DENIED_FIELDS maps roles to the fields they can't
+// access, as "Type.field", or "Type.*" for every field of a type, e.g.
+//
+//	{"viewer": ["Candidate.salary", "Budget.*"]}
+//
+// The current role is the header profile in use when the map has it, and
+// ACCESS_ROLE otherwise.
+var (
+	accessRole                            = getenv("ACCESS_ROLE")
+	roleDeniedFields, roleDeniedFieldsErr = parseRoleDeniedFields(getenv("ROLE_DENIED_FIELDS"))
+)
+
+// parseRoleDeniedFields decodes and validates ROLE_DENIED_FIELDS.
+func parseRoleDeniedFields(config string) (map[string][]string, error) {
+	if config == "" {
+		return nil, nil
+	}
+	var roles map[string][]string
+	if err := json.Unmarshal([]byte(config), &roles); err != nil {
+		return nil, fmt.Errorf("invalid ROLE_DENIED_FIELDS, expected a JSON object mapping roles to lists of fields: %s", jsonErrorPosition(config, err))
+	}
+	for role, fields := range roles {
+		for _, field := range fields {
+			typeName, fieldName, ok := strings.Cut(field, ".")
+			if !ok || typeName == "" || fieldName == "" || strings.Contains(fieldName, ".") {
+				return nil, fmt.Errorf("invalid ROLE_DENIED_FIELDS entry %q of role %q, expected \"Type.field\" or \"Type.*\"", field, role)
+			}
+		}
+	}
+	if _, ok := roles[accessRole]; accessRole != "" && !ok {
+		names := make([]string, 0, len(roles))
+		for role := range roles {
+			names = append(names, role)
+		}
+		return nil, fmt.Errorf("invalid ACCESS_ROLE: %s", unknownKeyProblem(accessRole, "not a role of ROLE_DENIED_FIELDS", accessRole, names))
+	}
+	return roles, nil
+}
+
+// currentAccessRole returns the role access_check checks against, or "".
+func currentAccessRole() string {
+	headerProfiles.Lock()
+	active := headerProfiles.active
+	headerProfiles.Unlock()
+	if _, ok := roleDeniedFields[active]; ok && active != "" {
+		return active
+	}
+	return accessRole
+}
+
+// selectedField is a field selected by an operation.
+type selectedField struct {
+	// Path is the response keys leading to the field, e.g.
+	// "candidate.applications.job"
+	Path   string
+	Parent string
+	Name   string
+	// Nested is set for fields with a selection set
+	Nested bool
+}
+
+// String renders the field with its schema coordinate, e.g.
+// "candidate.salary (Candidate.salary)".
+func (f selectedField) String() string {
+	return f.Path + " (" + f.Parent + "." + f.Name + ")"
+}
+
+// selectedFields lists the fields of the schema op selects, in document
+// order, including those of its fragments.
+func selectedFields(schema *schemaModel, doc *astDocument, op *astOperation) []selectedField {
+	var fields []selectedField
+	seen := make(map[string]bool)
+	var walk func(path, typeName string, sels []*astSelection, depth int)
+	walk = func(path, typeName string, sels []*astSelection, depth int) {
+		// Guard against fragments that (invalidly) select themselves
+		if depth > 64 {
+			return
+		}
+		for _, fs := range doc.fieldSelections(sels) {
+			if strings.HasPrefix(fs.Field.Name, "__") {
+				continue
+			}
+			parent := typeName
+			if fs.TypeCondition != "" {
+				parent = fs.TypeCondition
+			}
+			f := lookupField(schema, parent, fs.Field.Name)
+			if f == nil {
+				continue
+			}
+			field := selectedField{Path: joinResponsePath(path, fs.Field.responseKey()), Parent: parent, Name: f.Name, Nested: len(fs.Field.SelectionSet) > 0}
+			if key := field.Path + " " + field.Parent; !seen[key] {
+				seen[key] = true
+				fields = append(fields, field)
+			}
+			walk(field.Path, f.Type.namedType(), fs.Field.SelectionSet, depth+1)
+		}
+	}
+	walk("", schema.rootType(op.Operation), op.SelectionSet, 0)
+	return fields
+}
+
+// checkAccess reports the fields operation selects that the current
+// credentials can't access, from ROLE_DENIED_FIELDS or by probing.
+func checkAccess(ctx context.Context, operation, variablesJSON, operationName string) (string, error) {
+	named, operationName, err := resolveOperationName(operation, operationName)
+	if err != nil {
+		return "", err
+	}
+	doc, op, err := parseSingleOperation(named, operationName)
+	if err != nil {
+		return "", err
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	fields := selectedFields(schema, doc, op)
+	target := fmt.Sprintf("%s of %s %s", pluralize(len(fields), "selected field", "selected fields"), op.Operation, operationName)
+
+	role := currentAccessRole()
+	if denied, ok := roleDeniedFields[role]; ok {
+		deny := make(map[string]bool, len(denied))
+		for _, d := range denied {
+			deny[d] = true
+		}
+		var lines []string
+		for _, f := range fields {
+			if deny[f.Parent+"."+f.Name] || deny[f.Parent+".*"] {
+				lines = append(lines, "\t"+f.String())
+			}
+		}
+		return accessReport(fmt.Sprintf("Checked %s for role %q against ROLE_DENIED_FIELDS", target, role), lines, nil), nil
+	}
+
+	if op.Operation != "query" {
+		hint := "configure ROLE_DENIED_FIELDS"
+		if len(roleDeniedFields) > 0 {
+			hint = "set ACCESS_ROLE, or use a header profile named after a role of ROLE_DENIED_FIELDS"
+		}
+		return "", fmt.Errorf("a %s can't be probed without running it; %s to check it against the fields the role can't access", op.Operation, hint)
+	}
+	if err := checkOperationAllowed(named); err != nil {
+		return "", err
+	}
+	vars, err := parseVariables(variablesJSON)
+	if err != nil {
+		return "", err
+	}
+	vars = applyDefaultVariables(named, vars)
+	sendVars, secrets, err := resolveSecretReferences(vars)
+	if err != nil {
+		return "", err
+	}
+
+	started := time.Now()
+	res, err := executeGraphQL(ctx, graphqlRequest{Query: named, OperationName: operationName, Variables: sendVars})
+	recordAudit(named, operationName, vars, started, err)
+	var gqlErrors []graphqlError
+	switch e := err.(type) {
+	case nil:
+		gqlErrors = res.Errors
+	case *graphqlResponseError:
+		gqlErrors = e.Errors
+	default:
+		return "", scrubSecretsError(err, secrets)
+	}
+
+	byPath := make(map[string]selectedField, len(fields))
+	for _, f := range fields {
+		byPath[f.Path] = f
+	}
+	var lines, others []string
+	reported := make(map[string]bool)
+	for _, gqlErr := range gqlErrors {
+		message := scrubSecrets(gqlErr.Message, secrets)
+		if !isUnauthenticatedError(gqlErr) && !isForbiddenError(gqlErr) {
+			others = append(others, message)
+			continue
+		}
+		steps := resolveErrorPath(schema, doc, gqlErr.Path)
+		if len(steps) == 0 {
+			return fmt.Sprintf("Probed %s: the whole operation was denied (%s). Check the credentials, e.g. with whoami.", target, message), nil
+		}
+		keys := make([]string, len(steps))
+		for i, s := range steps {
+			keys[i] = s.key
+		}
+		path := strings.Join(keys, ".")
+		if reported[path] {
+			continue
+		}
+		reported[path] = true
+		line := "\t" + path
+		if f, ok := byPath[path]; ok {
+			line = "\t" + f.String()
+			if f.Nested {
+				message += "; the fields below it weren't checked"
+			}
+		}
+		lines = append(lines, line+": "+message)
+	}
+	sort.Strings(lines)
+	return accessReport(fmt.Sprintf("Probed %s by running it once with the current headers", target), lines, others), nil
+}
+
+// accessReport renders the denied fields found by checkAccess, and the other
+// errors of a probe.
+func accessReport(header string, denied, others []string) string {
+	var sb strings.Builder
+	if len(denied) == 0 {
+		sb.WriteString(header + ": all of them are accessible.")
+	} else {
+		fmt.Fprintf(&sb, "%s:\nDenied (%d):\n%s\nRemove them from the selection set, or use credentials that can access them.", header, len(denied), strings.Join(denied, "\n"))
+	}
+	if len(others) > 0 {
+		fmt.Fprintf(&sb, "\nThe probe also returned %s unrelated to access, e.g. %q.", pluralize(len(others), "error", "errors"), others[0])
+	}
+	return sb.String()
+}
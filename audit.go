@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditLogPath is the file that receives one JSON line per invoke_graphql
+// call. Audit logging is disabled when it is empty.
+var auditLogPath = getenv("AUDIT_LOG_PATH")
+
+// auditRedactKeys lists the variable names whose values are replaced before
+// they are written to the audit log. A variable is redacted when its name
+// contains one of the entries, ignoring case.
+var auditRedactKeys = listFromEnv("AUDIT_REDACT_KEYS")
+
+// defaultAuditRedactKeys is used when AUDIT_REDACT_KEYS is not set.
+var defaultAuditRedactKeys = []string{"password", "secret", "token", "authorization", "apikey", "api_key"}
+
+// auditRedacted replaces the value of redacted variables.
+const auditRedacted = "[REDACTED]"
+
+// auditEntry is a single line of the audit log.
+type auditEntry struct {
+	Timestamp     time.Time              `json:"timestamp"`
+	OperationType string                 `json:"operationType,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	RootFields    []string               `json:"rootFields,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	Endpoint      string                 `json:"endpoint"`
+	DurationMs    int64                  `json:"durationMs"`
+	Success       bool                   `json:"success"`
+	Error         string                 `json:"error,omitempty"`
+}
+
+// auditLog serializes writes to the audit file, which is opened on first use
+// and kept open for the lifetime of the server.
+var auditLog struct {
+	sync.Mutex
+	file *os.File
+}
+
+// recordAudit appends an entry describing an invoke_graphql call to the audit
+// log. It does nothing when AUDIT_LOG_PATH is not set. Failures to write are
+// logged but never fail the operation itself.
+func recordAudit(operation, operationName string, vars map[string]interface{}, started time.Time, callErr error) {
+	if auditLogPath == "" {
+		return
+	}
+
+	entry := auditEntry{
+		Timestamp:  started.UTC(),
+		Variables:  redactVariables(vars),
+		Endpoint:   graphqlEndpoint,
+		DurationMs: time.Since(started).Milliseconds(),
+		Success:    callErr == nil,
+	}
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	}
+	if doc, err := parseDocument(operation); err == nil && len(doc.Operations) > 0 {
+		op := doc.Operations[0]
+		for _, o := range doc.Operations {
+			if operationName != "" && o.Name == operationName {
+				op = o
+			}
+		}
+		entry.OperationType = op.Operation
+		entry.OperationName = op.Name
+		entry.RootFields = doc.rootFields(op)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("Warning: failed to encode audit entry: %v", err)
+		return
+	}
+
+	auditLog.Lock()
+	defer auditLog.Unlock()
+	if auditLog.file == nil {
+		f, err := os.OpenFile(auditLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Printf("Warning: failed to open audit log %s: %v", auditLogPath, err)
+			return
+		}
+		auditLog.file = f
+	}
+	if _, err := auditLog.file.Write(append(line, '\n')); err != nil {
+		log.Printf("Warning: failed to write audit log %s: %v", auditLogPath, err)
+	}
+}
+
+// redactVariables returns a copy of vars where the values of sensitive keys,
+// at any depth, are replaced with auditRedacted.
+func redactVariables(vars map[string]interface{}) map[string]interface{} {
+	if vars == nil {
+		return nil
+	}
+	redacted, _ := redactValue(vars).(map[string]interface{})
+	return redacted
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			if isSensitiveKey(k) {
+				out[k] = auditRedacted
+			} else {
+				out[k] = redactValue(val)
+			}
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, val := range v {
+			out[i] = redactValue(val)
+		}
+		return out
+	}
+	return v
+}
+
+// isSensitiveKey reports whether a variable name matches one of the redacted keys.
+func isSensitiveKey(key string) bool {
+	keys := auditRedactKeys
+	if len(keys) == 0 {
+		keys = defaultAuditRedactKeys
+	}
+	key = strings.ToLower(key)
+	for _, k := range keys {
+		if strings.Contains(key, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// authConfig is the list of authentication schemes from AUTH_CONFIG. The
+// first entry matching an endpoint supplies its credentials.
+var authConfig, authConfigErr = parseAuthConfig(getenv("AUTH_CONFIG"))
+
+// oauthExpiryMargin is how long before its expiry an OAuth token is renewed.
+const oauthExpiryMargin = 30 * time.Second
+
+// authEntry configures how requests to one endpoint authenticate.
+type authEntry struct {
+	// Endpoint is a host ("api.example.com") or URL prefix; empty matches
+	// every endpoint.
+	Endpoint string `json:"endpoint"`
+	// Scheme is "bearer", "apiKey" or "basic".
+	Scheme string `json:"scheme"`
+	// Header overrides the header carrying the credential (Authorization,
+	// or X-API-Key for apiKey).
+	Header string `json:"header"`
+	// Username is the basic auth user; the credential is the password.
+	Username   string           `json:"username"`
+	Credential credentialSource `json:"credential"`
+
+	// The OAuth token is fetched once and reused until it expires
+	mu           sync.Mutex
+	token        string
+	tokenExpires time.Time
+}
+
+// credentialSource is where a credential comes from: a static value, an
+// environment variable or an OAuth client credentials grant.
+type credentialSource struct {
+	Value string       `json:"value"`
+	Env   string       `json:"env"`
+	OAuth *oauthSource `json:"oauth"`
+}
+
+// oauthSource describes an OAuth 2.0 client credentials grant.
+type oauthSource struct {
+	TokenURL        string   `json:"tokenUrl"`
+	ClientID        string   `json:"clientId"`
+	ClientSecretEnv string   `json:"clientSecretEnv"`
+	Scopes          []string `json:"scopes"`
+}
+
+// parseAuthConfig decodes and validates the AUTH_CONFIG JSON array.
+func parseAuthConfig(config string) ([]*authEntry, error) {
+	if config == "" {
+		return nil, nil
+	}
+	var entries []*authEntry
+	if err := json.Unmarshal([]byte(config), &entries); err != nil {
+		return nil, fmt.Errorf("invalid AUTH_CONFIG, expected a JSON array: %w", err)
+	}
+	for i, e := range entries {
+		e.Scheme = strings.ToLower(e.Scheme)
+		switch e.Scheme {
+		case "bearer", "apikey":
+		case "basic":
+			if e.Username == "" {
+				return nil, fmt.Errorf("invalid AUTH_CONFIG entry %d: basic auth needs a username", i)
+			}
+		default:
+			return nil, fmt.Errorf("invalid AUTH_CONFIG entry %d: unknown scheme %q, expected \"bearer\", \"apiKey\" or \"basic\"", i, e.Scheme)
+		}
+		sources := 0
+		for _, set := range []bool{e.Credential.Value != "", e.Credential.Env != "", e.Credential.OAuth != nil} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return nil, fmt.Errorf("invalid AUTH_CONFIG entry %d: the credential needs exactly one of \"value\", \"env\" or \"oauth\"", i)
+		}
+		if o := e.Credential.OAuth; o != nil && (o.TokenURL == "" || o.ClientID == "") {
+			return nil, fmt.Errorf("invalid AUTH_CONFIG entry %d: oauth needs a tokenUrl and a clientId", i)
+		}
+	}
+	return entries, nil
+}
+
+// authEntryFor returns the first entry matching endpoint, or nil.
+func authEntryFor(endpoint string) *authEntry {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil
+	}
+	for _, e := range authConfig {
+		switch {
+		case e.Endpoint == "":
+			return e
+		case strings.Contains(e.Endpoint, "://"):
+			if strings.HasPrefix(endpoint, e.Endpoint) {
+				return e
+			}
+		case strings.EqualFold(e.Endpoint, u.Host) || strings.EqualFold(e.Endpoint, u.Hostname()):
+			return e
+		}
+	}
+	return nil
+}
+
+// headerName returns the header the entry's credential is sent in.
+func (e *authEntry) headerName() string {
+	switch {
+	case e.Header != "":
+		return http.CanonicalHeaderKey(e.Header)
+	case e.Scheme == "apikey":
+		return "X-Api-Key"
+	}
+	return "Authorization"
+}
+
+// applyConfiguredAuth adds the credentials AUTH_CONFIG defines for endpoint,
+// or else the session token from LOGIN_URL, to header, unless header already
+// carries one (set_headers, GRAPHQL_HEADERS or basic auth take precedence).
+func applyConfiguredAuth(ctx context.Context, header http.Header, endpoint string) error {
+	e := authEntryFor(endpoint)
+	if e == nil {
+		return applyLoginToken(ctx, header)
+	}
+	name := e.headerName()
+	if header.Get(name) != "" {
+		return nil
+	}
+	credential, err := e.credential(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the credential AUTH_CONFIG defines for %s: %w", endpoint, err)
+	}
+	switch e.Scheme {
+	case "bearer":
+		header.Set(name, "Bearer "+credential)
+	case "apikey":
+		header.Set(name, credential)
+	case "basic":
+		header.Set(name, "Basic "+base64.StdEncoding.EncodeToString([]byte(e.Username+":"+credential)))
+	}
+	return nil
+}
+
+// authHeaderNames lists the headers AUTH_CONFIG and LOGIN_URL send
+// credentials in.
+func authHeaderNames() []string {
+	names := make([]string, len(authConfig))
+	for i, e := range authConfig {
+		names[i] = e.headerName()
+	}
+	if loginURL != "" {
+		names = append(names, loginHeader)
+	}
+	return names
+}
+
+// credential resolves the entry's credential.
+func (e *authEntry) credential(ctx context.Context) (string, error) {
+	c := e.Credential
+	switch {
+	case c.Value != "":
+		return c.Value, nil
+	case c.Env != "":
+		v := os.Getenv(c.Env)
+		if v == "" {
+			return "", fmt.Errorf("environment variable %s is not set", c.Env)
+		}
+		return v, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.token != "" && time.Now().Before(e.tokenExpires) {
+		return e.token, nil
+	}
+	token, expiresIn, err := fetchOAuthToken(ctx, c.OAuth)
+	if err != nil {
+		return "", err
+	}
+	e.token = token
+	e.tokenExpires = time.Now().Add(expiresIn - oauthExpiryMargin)
+	return token, nil
+}
+
+// resetOAuthTokens drops the cached OAuth tokens, so the next request fetches
+// new ones. It reports whether there were any.
+func resetOAuthTokens() bool {
+	reset := false
+	for _, e := range authConfig {
+		e.mu.Lock()
+		if e.token != "" {
+			e.token = ""
+			reset = true
+		}
+		e.mu.Unlock()
+	}
+	return reset
+}
+
+// fetchOAuthToken runs a client credentials grant and returns the access
+// token with its lifetime (an hour when the server doesn't say).
+func fetchOAuthToken(ctx context.Context, o *oauthSource) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}, "client_id": {o.ClientID}}
+	if o.ClientSecretEnv != "" {
+		secret := os.Getenv(o.ClientSecretEnv)
+		if secret == "" {
+			return "", 0, fmt.Errorf("environment variable %s is not set", o.ClientSecretEnv)
+		}
+		form.Set("client_secret", secret)
+	}
+	if len(o.Scopes) > 0 {
+		form.Set("scope", strings.Join(o.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	setUserAgent(req.Header)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("requesting an OAuth token: %w", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("reading the OAuth token response: %w", err)
+	}
+	if !isSuccessStatus(res.StatusCode) {
+		return "", 0, fmt.Errorf("the OAuth token endpoint returned status %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+	var token struct {
+		AccessToken string  `json:"access_token"`
+		ExpiresIn   float64 `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", 0, fmt.Errorf("decoding the OAuth token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", 0, fmt.Errorf("the OAuth token response has no access_token")
+	}
+	expiresIn := time.Hour
+	if token.ExpiresIn > 0 {
+		expiresIn = time.Duration(token.ExpiresIn) * time.Second
+	}
+	return token.AccessToken, expiresIn, nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/base64"
+	"sync"
+)
+
+// Tool: set_basic_auth
+const setBasicAuthToolDescription = `Set HTTP basic auth credentials for GraphQL requests, for endpoints behind a basic-auth gateway.
+The Authorization: Basic header is built from them and sent with introspection, invocations and subscriptions.
+
+Best Practices:
+- Use this tool instead of encoding the credentials into a header with set_headers.
+- An Authorization header set with set_headers (or GRAPHQL_HEADERS) takes precedence over basic auth.
+- Pass an empty username to stop sending basic auth.
+
+Arguments:
+- username (string, Required): The basic auth user name.
+- password (string, Optional): The basic auth password.
+
+Example Usage:
+Request:
+  set_basic_auth(username: "reporting", password: "s3cret")
+
+Response:
+  Basic auth set for user "reporting"
+`
+
+// basicAuth holds the credentials from BASIC_AUTH_USER and BASIC_AUTH_PASS,
+// replaced at runtime by set_basic_auth.
+var basicAuth = struct {
+	sync.Mutex
+	user, pass string
+}{user: getenv("BASIC_AUTH_USER"), pass: getenv("BASIC_AUTH_PASS")}
+
+// setBasicAuth replaces the basic auth credentials; an empty user disables
+// basic auth.
+func setBasicAuth(user, pass string) {
+	basicAuth.Lock()
+	basicAuth.user, basicAuth.pass = user, pass
+	basicAuth.Unlock()
+	leaveHeaderProfile()
+
+	// The visible schema may depend on the credentials, so introspect again
+	invalidateSchemaCache()
+}
+
+// basicAuthHeader returns the Authorization header value for the configured
+// credentials, if any.
+func basicAuthHeader() (string, bool) {
+	basicAuth.Lock()
+	defer basicAuth.Unlock()
+	if basicAuth.user == "" {
+		return "", false
+	}
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(basicAuth.user+":"+basicAuth.pass)), true
+}
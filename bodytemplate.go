@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"text/template"
+)
+
+// bodyTemplate renders the body of GraphQL requests for servers expecting
+// them in a non-standard envelope, from the Go text/template in
+// BODY_TEMPLATE, e.g.
+//
+//	{"request": {"gql": {{json .Operation}}, "params": {{json .Variables}}}}
+//
+// It is nil when BODY_TEMPLATE is unset, and requests are sent as the
+// standard {"query", "variables", "operationName"} object.
+var bodyTemplate, bodyTemplateErr = parseBodyTemplate(getenv("BODY_TEMPLATE"))
+
+// requestBodyData is what BODY_TEMPLATE is executed with.
+type requestBodyData struct {
+	Operation     string
+	Variables     map[string]interface{}
+	OperationName string
+	Extensions    map[string]interface{}
+}
+
+// bodyTemplateFuncs are the functions BODY_TEMPLATE may call besides the
+// builtin ones: json encodes a value, e.g. {{json .Variables}}.
+var bodyTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+}
+
+// parseBodyTemplate parses a BODY_TEMPLATE and renders it once with a sample
+// request, so that a template failing on every request is reported at
+// startup.
+func parseBodyTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("BODY_TEMPLATE").Funcs(bodyTemplateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid BODY_TEMPLATE: %w", err)
+	}
+	sample := requestBodyData{Operation: "query Sample { __typename }", Variables: map[string]interface{}{}, OperationName: "Sample"}
+	if err := tmpl.Execute(new(bytes.Buffer), sample); err != nil {
+		return nil, fmt.Errorf("invalid BODY_TEMPLATE: %w", err)
+	}
+	return tmpl, nil
+}
+
+// encodeGraphQLRequest returns the POST body of req: the standard JSON
+// object, or the rendering of BODY_TEMPLATE when it is set.
+func encodeGraphQLRequest(req graphqlRequest) ([]byte, error) {
+	if bodyTemplate == nil {
+		return json.Marshal(req)
+	}
+	if req.Variables == nil {
+		req.Variables = map[string]interface{}{}
+	}
+	var buf bytes.Buffer
+	data := requestBodyData{Operation: req.Query, Variables: req.Variables, OperationName: req.OperationName, Extensions: req.Extensions}
+	if err := bodyTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("rendering BODY_TEMPLATE: %w", err)
+	}
+	return buf.Bytes(), nil
+}
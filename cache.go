@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// introspectionCacheTTL controls how long an introspection result is reused
+// before the schema is fetched again. A value of 0 disables caching.
+var introspectionCacheTTL = durationFromEnv("INTROSPECTION_CACHE_TTL", 5*time.Minute)
+
+// schemaFile is a local SDL file to load the schema from instead of running
+// introspection against the endpoint.
+var schemaFile = getenv("SCHEMA_FILE")
+
+// prewarmSchema loads the schema at startup, before serving, so the first
+// tool call doesn't wait for introspection.
+var prewarmSchema = boolFromEnv("PREWARM")
+
+// schemaCache holds the schema model shared by all tools.
+// The lock is a one-slot channel rather than a sync.Mutex so that callers
+// waiting for a concurrent introspection can give up when their context ends.
+var schemaCache = struct {
+	lock      chan struct{}
+	schema    *schemaModel
+	fetchedAt time.Time
+	valid     bool
+}{lock: make(chan struct{}, 1)}
+
+// getSchema returns the schema model. When SCHEMA_FILE is set the file is
+// parsed once and kept for the lifetime of the server; otherwise the schema
+// is introspected when there is no cached result or the cached result has expired.
+func getSchema(ctx context.Context) (*schemaModel, error) {
+	select {
+	case schemaCache.lock <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-schemaCache.lock }()
+
+	if schemaCache.valid && (schemaFile != "" || time.Since(schemaCache.fetchedAt) < introspectionCacheTTL) {
+		if schemaCache.schema.Partial {
+			markPartialSchema(ctx)
+		}
+		return schemaCache.schema, nil
+	}
+
+	var schema *schemaModel
+	var err error
+	if schemaFile != "" {
+		schema, err = loadSchemaFile(schemaFile)
+	} else {
+		schema, err = introspect(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	schemaCache.schema = schema
+	schemaCache.fetchedAt = time.Now()
+	schemaCache.valid = true
+	if schema.Partial {
+		markPartialSchema(ctx)
+	}
+	return schemaCache.schema, nil
+}
+
+// invalidateSchemaCache drops the cached introspection result so the next
+// tool call fetches the schema again (e.g. after the headers changed). A
+// schema loaded from SCHEMA_FILE does not depend on the headers and is kept.
+func invalidateSchemaCache() {
+	schemaCache.lock <- struct{}{}
+	defer func() { <-schemaCache.lock }()
+	if schemaFile == "" {
+		schemaCache.valid = false
+	}
+}
+
+// prewarmSchemaCache fills the schema cache at startup. A failure, e.g. an
+// endpoint that needs credentials set later with set_headers, is only logged:
+// the schema is then fetched by the first tool call as usual.
+func prewarmSchemaCache(ctx context.Context) {
+	if schemaFile == "" && introspectionCacheTTL <= 0 {
+		log.Printf("Warning: PREWARM has no effect with INTROSPECTION_CACHE_TTL=0, the schema isn't cached")
+		return
+	}
+	if defaultRequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withRequestTimeout(ctx, defaultRequestTimeout)
+		defer cancel()
+	}
+	started := time.Now()
+	schema, err := getSchema(ctx)
+	if err != nil {
+		log.Printf("Warning: prewarming the schema cache failed, it will be loaded on first use: %v", err)
+		return
+	}
+	log.Printf("Prewarmed the schema cache with %s in %s", pluralize(len(schema.Types), "type", "types"), time.Since(started).Round(time.Millisecond))
+}
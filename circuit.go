@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Circuit breaker. After CIRCUIT_BREAKER_THRESHOLD consecutive requests to
+// the endpoint fail (connection errors, timeouts and 5xx statuses), requests
+// fail fast for CIRCUIT_BREAKER_COOLDOWN; then a single trial request is let
+// through, which closes the circuit if it succeeds and opens it again
+// otherwise. 0 disables the breaker.
+var (
+	circuitBreakerThreshold = intFromEnv("CIRCUIT_BREAKER_THRESHOLD", 5)
+	circuitBreakerCooldown  = durationFromEnv("CIRCUIT_BREAKER_COOLDOWN", 30*time.Second)
+)
+
+// errCircuitOpen is returned for requests refused while the circuit is open.
+var errCircuitOpen = errors.New("endpoint circuit open")
+
+// circuit tracks the health of the endpoint.
+var circuit struct {
+	sync.Mutex
+	failures int
+	lastErr  string
+	openedAt time.Time
+	open     bool
+	// trial is set while the request testing the recovery of the endpoint
+	// (the circuit is half-open) is in flight
+	trial bool
+}
+
+// allowEndpointRequest admits a request to the endpoint, or refuses it while
+// the circuit is open. An admitted request must report its outcome with
+// recordEndpointResult.
+func allowEndpointRequest() error {
+	if circuitBreakerThreshold <= 0 {
+		return nil
+	}
+	circuit.Lock()
+	defer circuit.Unlock()
+	if !circuit.open {
+		return nil
+	}
+	if remaining := circuitBreakerCooldown - time.Since(circuit.openedAt); remaining > 0 || circuit.trial {
+		wait := "until the request testing its recovery completes"
+		if remaining > 0 {
+			wait = "for another " + (remaining + time.Second - 1).Truncate(time.Second).String()
+		}
+		return fmt.Errorf("%w: the last %s to the endpoint failed (last error: %s), so calls fail fast %s; back off and retry later, or check that the endpoint is up", errCircuitOpen, pluralize(circuit.failures, "request", "requests"), circuit.lastErr, wait)
+	}
+	circuit.trial = true
+	log.Printf("Circuit breaker: cooldown elapsed, testing whether the endpoint has recovered")
+	return nil
+}
+
+// endpointDeadlineKey marks a context whose deadline is the bridge's own
+// request timeout, so running out of it means the endpoint hung.
+type endpointDeadlineKey struct{}
+
+// withRequestTimeout returns ctx with the timeout of a call to the endpoint.
+// A call exceeding a timeout of at least REQUEST_TIMEOUT counts as an
+// endpoint failure; one exceeding a shorter timeoutMs, or the caller's own
+// deadline, doesn't.
+func withRequestTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	deadline := time.Now().Add(timeout)
+	if parent, ok := ctx.Deadline(); defaultRequestTimeout > 0 && timeout >= defaultRequestTimeout && (!ok || !parent.Before(deadline)) {
+		ctx = context.WithValue(ctx, endpointDeadlineKey{}, true)
+	}
+	return context.WithDeadline(ctx, deadline)
+}
+
+// endpointTimedOut reports whether ctx ran out of a deadline set by
+// withRequestTimeout that counts against the endpoint.
+func endpointTimedOut(ctx context.Context) bool {
+	marked, _ := ctx.Value(endpointDeadlineKey{}).(bool)
+	return marked && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// recordEndpointResult updates the circuit with the outcome of an admitted
+// request made with ctx. Only failures of the endpoint itself count: other
+// errors, such as a 400, a 401 or a redirect to a login page, show that it is
+// up, and calls cancelled by the caller or running out of a timeout shorter
+// than REQUEST_TIMEOUT say nothing about it.
+func recordEndpointResult(ctx context.Context, err error) {
+	if circuitBreakerThreshold <= 0 {
+		return
+	}
+	circuit.Lock()
+	defer circuit.Unlock()
+	trial := circuit.trial
+	circuit.trial = false
+	switch {
+	case err != nil && endpointTimedOut(ctx):
+		recordEndpointFailure(trial, err)
+	case err != nil && ctx.Err() != nil:
+	case isEndpointFailure(err):
+		recordEndpointFailure(trial, err)
+	default:
+		if circuit.open {
+			log.Printf("Circuit breaker: the endpoint has recovered, closing the circuit")
+		}
+		circuit.failures, circuit.open = 0, false
+	}
+}
+
+// recordEndpointFailure counts a failed request, opening the circuit once
+// the threshold is reached or when the trial request failed. The caller
+// holds the circuit lock.
+func recordEndpointFailure(trial bool, err error) {
+	circuit.failures++
+	circuit.lastErr = truncateRunes(firstLine(err.Error()), maxHistorySummary)
+	if trial || (!circuit.open && circuit.failures >= circuitBreakerThreshold) {
+		if !circuit.open {
+			log.Printf("Circuit breaker: %s to the endpoint failed in a row, failing calls fast for %s (last error: %s)", pluralize(circuit.failures, "request", "requests"), circuitBreakerCooldown, circuit.lastErr)
+		}
+		circuit.open, circuit.openedAt = true, time.Now()
+	}
+}
+
+// isEndpointFailure reports whether err shows the endpoint failing: a 5xx
+// status, or a connection that couldn't be opened or broke, including the
+// transport's own timeouts. Redirects refused by checkRedirect and context
+// errors don't count: recordEndpointResult decides whose deadline expired.
+func isEndpointFailure(err error) bool {
+	if errors.Is(err, errHTTPServer) {
+		return true
+	}
+	var redirectErr *redirectError
+	if err == nil || errors.As(err, &redirectErr) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	// *url.Error is itself a net.Error, so look at what it wraps: the
+	// redirect policy's errors are plain ones
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
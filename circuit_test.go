@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestIsEndpointFailure(t *testing.T) {
+	urlError := func(err error) error {
+		return &url.Error{Op: "Post", URL: "http://example.com/graphql", Err: err}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"success", nil, false},
+		{"server error", &httpStatusError{StatusCode: 503}, true},
+		{"unauthorized", &httpStatusError{StatusCode: 401}, false},
+		{"connection refused", urlError(&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}), true},
+		{"connection reset mid-body", fmt.Errorf("reading response body: %w", io.ErrUnexpectedEOF), true},
+		{"login redirect", urlError(&redirectError{StatusCode: 302, Location: "http://example.com/login", Login: true}), false},
+		{"POST redirected to GET", urlError(&redirectError{StatusCode: 301, Location: "http://example.com/", Reason: "it would resend the operation as a GET without its body"}), false},
+		{"too many redirects", urlError(errors.New("stopped after 10 redirects")), false},
+		{"caller deadline", urlError(context.DeadlineExceeded), false},
+		{"caller cancelled", urlError(context.Canceled), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEndpointFailure(tt.err); got != tt.want {
+				t.Errorf("isEndpointFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// resetCircuit sets the breaker threshold for a test and closes the circuit
+// before and after it.
+func resetCircuit(t *testing.T, threshold int) {
+	t.Helper()
+	previous := circuitBreakerThreshold
+	reset := func() {
+		circuit.Lock()
+		circuit.failures, circuit.open, circuit.trial = 0, false, false
+		circuit.Unlock()
+	}
+	circuitBreakerThreshold = threshold
+	reset()
+	t.Cleanup(func() {
+		circuitBreakerThreshold = previous
+		reset()
+	})
+}
+
+// TestCircuitIgnoresCallerDeadline checks that calls running out of their
+// own time don't open the circuit.
+func TestCircuitIgnoresCallerDeadline(t *testing.T) {
+	resetCircuit(t, 1)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	recordEndpointResult(ctx, &url.Error{Op: "Post", URL: "http://example.com/graphql", Err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("i/o timeout")}})
+	if err := allowEndpointRequest(); err != nil {
+		t.Errorf("the circuit opened after a call ran out of its own time: %v", err)
+	}
+}
+
+// TestCircuitRequestTimeout checks that running out of the bridge's own
+// request timeout counts as a failure, but a shorter timeoutMs or a shorter
+// deadline of the caller doesn't.
+func TestCircuitRequestTimeout(t *testing.T) {
+	previous := defaultRequestTimeout
+	defaultRequestTimeout = 20 * time.Millisecond
+	t.Cleanup(func() { defaultRequestTimeout = previous })
+	timeoutErr := &url.Error{Op: "Post", URL: "http://example.com/graphql", Err: context.DeadlineExceeded}
+
+	tests := []struct {
+		name     string
+		parent   time.Duration
+		timeout  time.Duration
+		wantOpen bool
+	}{
+		{"default timeout", 0, 20 * time.Millisecond, true},
+		{"longer timeoutMs", 0, 30 * time.Millisecond, true},
+		{"shorter timeoutMs", 0, 5 * time.Millisecond, false},
+		{"shorter caller deadline", 5 * time.Millisecond, 20 * time.Millisecond, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCircuit(t, 1)
+			parent := context.Background()
+			if tt.parent > 0 {
+				var cancel context.CancelFunc
+				parent, cancel = context.WithTimeout(parent, tt.parent)
+				defer cancel()
+			}
+			ctx, cancel := withRequestTimeout(parent, tt.timeout)
+			defer cancel()
+			<-ctx.Done()
+			recordEndpointResult(ctx, timeoutErr)
+			if open := errors.Is(allowEndpointRequest(), errCircuitOpen); open != tt.wantOpen {
+				t.Errorf("circuit open = %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
+
+// TestCircuitHungEndpoint checks that an endpoint accepting connections and
+// never answering opens the circuit.
+func TestCircuitHungEndpoint(t *testing.T) {
+	resetCircuit(t, 1)
+	previous := defaultRequestTimeout
+	defaultRequestTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { defaultRequestTimeout = previous })
+	newBlockingServer(t)
+
+	opts := invokeOptions{Timeout: defaultRequestTimeout}
+	if _, err := invokeGraphQLOperation(context.Background(), `query { candidates { id } }`, "", opts); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("first call error = %v, want a timeout", err)
+	}
+	if _, err := invokeGraphQLOperation(context.Background(), `query { candidates { id } }`, "", opts); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("second call error = %v, want the circuit to be open", err)
+	}
+}
+
+// TestCircuitBatch checks that batches count towards the circuit and are
+// refused while it is open.
+func TestCircuitBatch(t *testing.T) {
+	resetCircuit(t, 1)
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	previous := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	t.Cleanup(func() { graphqlEndpoint = previous })
+
+	reqs := []graphqlRequest{{Query: "{ a }"}, {Query: "{ b }"}}
+	if _, err := executeGraphQLBatch(context.Background(), reqs); !errors.Is(err, errHTTPServer) {
+		t.Fatalf("first batch error = %v, want a server error", err)
+	}
+	if _, err := executeGraphQLBatch(context.Background(), reqs); !errors.Is(err, errCircuitOpen) {
+		t.Fatalf("second batch error = %v, want the circuit to be open", err)
+	}
+	if requests != 1 {
+		t.Errorf("the endpoint received %d requests, want 1", requests)
+	}
+}
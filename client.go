@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTP connection pool configuration. Every request to the endpoint goes
+// through httpClient, so connections are reused across tool calls instead of
+// being opened per call; headers are set on each request, not the client.
+var (
+	httpMaxIdleConns        = intFromEnv("HTTP_MAX_IDLE_CONNS", 100)
+	httpMaxIdleConnsPerHost = intFromEnv("HTTP_MAX_IDLE_CONNS_PER_HOST", 32)
+	httpIdleConnTimeout     = durationFromEnv("HTTP_IDLE_CONN_TIMEOUT", 90*time.Second)
+)
+
+// Compression configuration. Responses are always requested gzip-encoded;
+// request bodies of at least GZIP_REQUEST_MIN_BYTES are only compressed when
+// GZIP_REQUESTS is set, since not every server accepts them.
+var (
+	gzipRequests        = boolFromEnv("GZIP_REQUESTS")
+	gzipRequestMinBytes = intFromEnv("GZIP_REQUEST_MIN_BYTES", 1024)
+)
+
+// httpClient is the shared client for GraphQL and introspection requests.
+var httpClient = newHTTPClient()
+
+// newHTTPClient builds a client with a pooled transport. The per-host idle
+// limit matters most: net/http keeps only 2 idle connections per host by
+// default, which forces new connections under concurrent load.
+func newHTTPClient() *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = httpMaxIdleConns
+	t.MaxIdleConnsPerHost = httpMaxIdleConnsPerHost
+	t.IdleConnTimeout = httpIdleConnTimeout
+	return &http.Client{Transport: t, CheckRedirect: checkRedirect}
+}
+
+// graphqlRequest is the JSON body of a GraphQL HTTP request.
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+
+	// header holds headers sent with this request only
+	header http.Header
+}
+
+// graphqlResponse is the JSON body of a GraphQL HTTP response.
+type graphqlResponse struct {
+	Data       json.RawMessage        `json:"data,omitempty"`
+	Errors     []graphqlError         `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+
+	// headers holds the response headers selected by RESPONSE_HEADERS
+	headers map[string]string
+	// status is the HTTP status code and size the length of the decoded body
+	status int
+	size   int
+}
+
+// graphqlError is a single entry of the "errors" array of a response.
+type graphqlError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Locations  []graphqlErrorLocation `json:"locations,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// graphqlErrorLocation points at the part of the operation an error refers to.
+type graphqlErrorLocation struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Categories of non-2xx HTTP responses. Errors carrying an HTTP status unwrap
+// to one of these, so callers can use errors.Is to decide whether to
+// re-authenticate, back off or give up.
+var (
+	errHTTPAuth        = errors.New("authentication error")
+	errHTTPRateLimited = errors.New("rate limited")
+	errHTTPServer      = errors.New("server error")
+	errHTTPClient      = errors.New("client error")
+)
+
+// httpStatusCategory classifies a non-2xx status code.
+func httpStatusCategory(code int) error {
+	switch {
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return errHTTPAuth
+	case code == http.StatusTooManyRequests:
+		return errHTTPRateLimited
+	case code >= 500:
+		return errHTTPServer
+	}
+	return errHTTPClient
+}
+
+// httpStatusHint tells the agent what to do about a category of failure.
+func httpStatusHint(category error) string {
+	switch category {
+	case errHTTPAuth:
+		return "check the credentials, e.g. set an Authorization header with set_headers"
+	case errHTTPRateLimited:
+		return "back off before retrying"
+	case errHTTPServer:
+		return "the server failed; retry later"
+	}
+	return "the server rejected the request"
+}
+
+// isSuccessStatus reports whether code is a 2xx status.
+func isSuccessStatus(code int) bool {
+	return code >= 200 && code <= 299
+}
+
+// httpStatusError is returned when the server answers with a non-2xx status
+// and no GraphQL errors. Body holds the (trimmed) response body.
+type httpStatusError struct {
+	StatusCode int
+	RetryAfter string
+	Body       string
+}
+
+func (e *httpStatusError) Error() string {
+	return formatHTTPStatus(e.StatusCode, e.RetryAfter) + e.detail()
+}
+
+func (e *httpStatusError) Unwrap() error {
+	return httpStatusCategory(e.StatusCode)
+}
+
+func (e *httpStatusError) detail() string {
+	const maxBody = 500
+	body := e.Body
+	if len(body) > maxBody {
+		body = body[:maxBody] + "..."
+	}
+	if body == "" {
+		return ""
+	}
+	return ": " + body
+}
+
+// formatHTTPStatus renders "server returned a non-200 status code: 401
+// (authentication error; check the credentials, ...)".
+func formatHTTPStatus(code int, retryAfter string) string {
+	category := httpStatusCategory(code)
+	hint := httpStatusHint(category)
+	if retryAfter != "" && (code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable) {
+		hint += ", Retry-After: " + retryAfter
+	}
+	return fmt.Sprintf("server returned a non-200 status code: %d (%v; %s)", code, category, hint)
+}
+
+// graphqlResponseError is returned when the server answers with GraphQL
+// errors. Error() keeps the short "graphql: <message>" form, while Verbose()
+// exposes every error with its path, locations and extensions. StatusCode is
+// set when the errors came with a non-2xx HTTP status.
+type graphqlResponseError struct {
+	Errors     []graphqlError
+	Data       json.RawMessage
+	StatusCode int
+	RetryAfter string
+}
+
+func (e *graphqlResponseError) Error() string {
+	msg := "graphql: " + e.Errors[0].Message
+	if len(e.Errors) > 1 {
+		msg += fmt.Sprintf(" (and %d more errors)", len(e.Errors)-1)
+	}
+	if e.StatusCode != 0 && !isSuccessStatus(e.StatusCode) {
+		msg = formatHTTPStatus(e.StatusCode, e.RetryAfter) + ": " + msg
+	}
+	return msg
+}
+
+// Unwrap returns the HTTP status category when the errors came with a
+// non-2xx status.
+func (e *graphqlResponseError) Unwrap() error {
+	if e.StatusCode == 0 || isSuccessStatus(e.StatusCode) {
+		return nil
+	}
+	return httpStatusCategory(e.StatusCode)
+}
+
+// Verbose renders the full errors array, plus any partial data, as JSON.
+func (e *graphqlResponseError) Verbose() string {
+	body := map[string]interface{}{"errors": e.Errors}
+	if len(e.Data) > 0 && string(e.Data) != "null" {
+		body["data"] = e.Data
+	}
+	if e.StatusCode != 0 && !isSuccessStatus(e.StatusCode) {
+		body["httpStatus"] = e.StatusCode
+	}
+	out, err := json.MarshalIndent(body, "", "  ")
+	if err != nil {
+		return e.Error()
+	}
+	return string(out)
+}
+
+// executeGraphQL posts a GraphQL request to the endpoint with the current
+// headers and decodes the response. GraphQL errors are not turned into a Go
+// error here so callers can decide how to surface them, except when they come
+// with a non-2xx status: then a *graphqlResponseError carrying the status is
+// returned. Other non-2xx responses yield an *httpStatusError.
+func executeGraphQL(ctx context.Context, gqlReq graphqlRequest) (gqlRes *graphqlResponse, err error) {
+	ctx, span := startGraphQLSpan(ctx, gqlReq.Query, gqlReq.OperationName)
+	defer func() {
+		if gqlRes != nil && len(gqlRes.Errors) > 0 {
+			span.fail("graphql: " + gqlRes.Errors[0].Message)
+		}
+		span.end(err)
+	}()
+	encoded, err := encodeGraphQLRequest(gqlReq)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newGraphQLHTTPRequest(ctx, encoded)
+	if err != nil {
+		return nil, err
+	}
+	setDefaultHeaders(req.Header)
+	injectTraceContext(ctx, req.Header)
+	for k, v := range getHeaders() {
+		req.Header[k] = v
+	}
+	for k, v := range gqlReq.header {
+		req.Header[k] = v
+	}
+	if err := applyConfiguredAuth(ctx, req.Header, graphqlEndpoint); err != nil {
+		return nil, err
+	}
+
+	release, err := acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := allowEndpointRequest(); err != nil {
+		return nil, err
+	}
+	defer func() { recordEndpointResult(ctx, err) }()
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	span.setAttribute("http.response.status_code", res.StatusCode)
+	if err := redirectResponseError(req, res); err != nil {
+		return nil, err
+	}
+	// Large responses report their download progress when the caller asked
+	if report := progressFromContext(ctx); report != nil {
+		res.Body = struct {
+			io.Reader
+			io.Closer
+		}{&progressReader{r: res.Body, report: report, total: res.ContentLength}, res.Body}
+	}
+	reader, err := responseBodyReader(res)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	gqlRes = &graphqlResponse{}
+	if err := json.Unmarshal(body, gqlRes); err != nil {
+		if !isSuccessStatus(res.StatusCode) {
+			return nil, &httpStatusError{StatusCode: res.StatusCode, RetryAfter: res.Header.Get("Retry-After"), Body: strings.TrimSpace(string(body))}
+		}
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if !isSuccessStatus(res.StatusCode) {
+		if len(gqlRes.Errors) == 0 {
+			return nil, &httpStatusError{StatusCode: res.StatusCode, RetryAfter: res.Header.Get("Retry-After"), Body: strings.TrimSpace(string(body))}
+		}
+		// Keep the GraphQL errors but make the HTTP status visible
+		return nil, &graphqlResponseError{Errors: gqlRes.Errors, Data: gqlRes.Data, StatusCode: res.StatusCode, RetryAfter: res.Header.Get("Retry-After")}
+	}
+	gqlRes.headers = selectResponseHeaders(res.Header)
+	gqlRes.status, gqlRes.size = res.StatusCode, len(body)
+	return gqlRes, nil
+}
+
+// newGraphQLHTTPRequest creates a POST to the endpoint carrying body,
+// gzip-compressed when request compression is enabled and body is large
+// enough. Asking for gzip responses turns off the transport's transparent
+// decompression, so responses must be read with responseBodyReader.
+func newGraphQLHTTPRequest(ctx context.Context, body []byte) (*http.Request, error) {
+	compress := gzipRequests && len(body) >= gzipRequestMinBytes
+	if compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		body = buf.Bytes()
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", graphqlEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	return req, nil
+}
+
+// userAgent identifies the bridge in the endpoint's logs; USER_AGENT
+// replaces it.
+var userAgent = stringFromEnv("USER_AGENT", "graphql-mcp/"+serverVersion)
+
+// setDefaultHeaders sets the content type, accepted media type and user agent
+// of a GraphQL request. The configured headers are applied afterwards, so
+// they can replace all three.
+func setDefaultHeaders(h http.Header) {
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	h.Set("Accept", "application/json; charset=utf-8")
+	setUserAgent(h)
+}
+
+// setUserAgent sets the User-Agent header of an outbound request, unless
+// USER_AGENT is set to an empty value.
+func setUserAgent(h http.Header) {
+	if userAgent != "" {
+		h.Set("User-Agent", userAgent)
+	}
+}
+
+// responseBodyReader returns a reader over the decoded body of res, failing
+// once it exceeds maxResponseBodyBytes.
+func responseBodyReader(res *http.Response) (io.Reader, error) {
+	var body io.Reader = res.Body
+	if strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		zr, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing response body: %w", err)
+		}
+		body = zr
+	}
+	if maxResponseBodyBytes > 0 {
+		body = &limitedBodyReader{r: body, remaining: int64(maxResponseBodyBytes)}
+	}
+	return body, nil
+}
+
+// maxResponseBodyBytes caps the size of a response body read from the
+// endpoint, after decompression, so that a huge response or a gzip bomb
+// can't exhaust the bridge's memory. 0 disables the limit.
+var maxResponseBodyBytes = intFromEnv("MAX_RESPONSE_BODY_BYTES", 100<<20)
+
+// errResponseTooLarge is returned when a response body exceeds
+// maxResponseBodyBytes.
+var errResponseTooLarge = errors.New("response body too large")
+
+// limitedBodyReader reads at most remaining bytes from r, and fails with
+// errResponseTooLarge if r has more.
+type limitedBodyReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedBodyReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		var b [1]byte
+		if n, err := l.r.Read(b[:]); n == 0 {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: it exceeds MAX_RESPONSE_BODY_BYTES (%d bytes); select fewer fields or paginate", errResponseTooLarge, maxResponseBodyBytes)
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// responseHeaders lists the response headers reported with invoke_graphql
+// results. An entry ending in "*" matches every header with that prefix.
+var responseHeaders = listFromEnv("RESPONSE_HEADERS")
+
+// defaultResponseHeaders is used when RESPONSE_HEADERS is not set.
+var defaultResponseHeaders = []string{"X-Request-Id", "RateLimit-*"}
+
+// selectResponseHeaders returns the headers matching RESPONSE_HEADERS, or nil
+// when there are none.
+func selectResponseHeaders(header http.Header) map[string]string {
+	patterns := responseHeaders
+	if len(patterns) == 0 {
+		patterns = defaultResponseHeaders
+	}
+	var selected map[string]string
+	for name, values := range header {
+		for _, p := range patterns {
+			prefix, wildcard := strings.CutSuffix(p, "*")
+			if (wildcard && len(name) >= len(prefix) && strings.EqualFold(name[:len(prefix)], prefix)) || strings.EqualFold(name, p) {
+				if selected == nil {
+					selected = make(map[string]string)
+				}
+				selected[name] = strings.Join(values, ", ")
+				break
+			}
+		}
+	}
+	return selected
+}
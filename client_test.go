@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newGraphQLTestServer starts an endpoint answering every request with
+// status and body, records the last request in got, and points the bridge
+// at it, with the circuit breaker disabled.
+func newGraphQLTestServer(t *testing.T, status int, body string, got *http.Request, gotBody *map[string]interface{}) {
+	t.Helper()
+	resetCircuit(t, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got != nil {
+			*got = *r.Clone(context.Background())
+		}
+		if gotBody != nil {
+			if err := json.NewDecoder(r.Body).Decode(gotBody); err != nil {
+				t.Error(err)
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	previous := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	t.Cleanup(func() { graphqlEndpoint = previous })
+}
+
+func TestExecuteGraphQLRequest(t *testing.T) {
+	var req http.Request
+	var body map[string]interface{}
+	newGraphQLTestServer(t, http.StatusOK, `{"data":{"candidate":{"id":"1"}}}`, &req, &body)
+
+	res, err := executeGraphQL(context.Background(), graphqlRequest{
+		Query:         "query C($id: ID!) { candidate(id: $id) { id } }",
+		OperationName: "C",
+		Variables:     map[string]interface{}{"id": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Data) != `{"candidate":{"id":"1"}}` || res.status != http.StatusOK {
+		t.Errorf("response = %s with status %d", res.Data, res.status)
+	}
+	if req.Method != http.MethodPost || !strings.HasPrefix(req.Header.Get("Content-Type"), "application/json") {
+		t.Errorf("request = %s with Content-Type %q, want a JSON POST", req.Method, req.Header.Get("Content-Type"))
+	}
+	want := map[string]interface{}{
+		"query":         "query C($id: ID!) { candidate(id: $id) { id } }",
+		"operationName": "C",
+		"variables":     map[string]interface{}{"id": "1"},
+	}
+	if !reflect.DeepEqual(body, want) {
+		t.Errorf("request body = %v, want %v", body, want)
+	}
+}
+
+func TestExecuteGraphQLErrors(t *testing.T) {
+	newGraphQLTestServer(t, http.StatusOK, `{
+		"data": {"candidate": null},
+		"errors": [
+			{"message": "Candidate not found", "path": ["candidate", 0, "id"], "locations": [{"line": 1, "column": 3}], "extensions": {"code": "NOT_FOUND"}},
+			{"message": "Not authorized", "extensions": {"code": "FORBIDDEN"}}
+		],
+		"extensions": {"cost": {"requested": 12}}
+	}`, nil, nil)
+
+	res, err := executeGraphQL(context.Background(), graphqlRequest{Query: "{ candidate { id } }"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []graphqlError{
+		{Message: "Candidate not found", Path: []interface{}{"candidate", float64(0), "id"}, Locations: []graphqlErrorLocation{{Line: 1, Column: 3}}, Extensions: map[string]interface{}{"code": "NOT_FOUND"}},
+		{Message: "Not authorized", Extensions: map[string]interface{}{"code": "FORBIDDEN"}},
+	}
+	if !reflect.DeepEqual(res.Errors, want) {
+		t.Errorf("errors = %+v, want %+v", res.Errors, want)
+	}
+	if string(res.Data) != `{"candidate": null}` {
+		t.Errorf("partial data = %s", res.Data)
+	}
+	if want := map[string]interface{}{"cost": map[string]interface{}{"requested": float64(12)}}; !reflect.DeepEqual(res.Extensions, want) {
+		t.Errorf("extensions = %v, want %v", res.Extensions, want)
+	}
+}
+
+func TestExecuteGraphQLStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		category error
+		want     string
+	}{
+		{"GraphQL errors", http.StatusBadRequest, `{"errors":[{"message":"Cannot query field \"nope\"","extensions":{"code":"GRAPHQL_VALIDATION_FAILED"}}]}`, errHTTPClient, `graphql: Cannot query field "nope"`},
+		{"JSON without errors", http.StatusServiceUnavailable, `{"message":"down for maintenance"}`, errHTTPServer, "down for maintenance"},
+		{"HTML page", http.StatusUnauthorized, `<html>Sign in</html>`, errHTTPAuth, "<html>Sign in</html>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newGraphQLTestServer(t, tt.status, tt.body, nil, nil)
+			_, err := executeGraphQL(context.Background(), graphqlRequest{Query: "{ nope }"})
+			if !errors.Is(err, tt.category) || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want a %v containing %q", err, tt.category, tt.want)
+			}
+			var gqlErr *graphqlResponseError
+			if errors.As(err, &gqlErr) {
+				if gqlErr.StatusCode != tt.status || gqlErr.Errors[0].Extensions["code"] != "GRAPHQL_VALIDATION_FAILED" {
+					t.Errorf("GraphQL errors = %+v with status %d", gqlErr.Errors, gqlErr.StatusCode)
+				}
+				return
+			}
+			var statusErr *httpStatusError
+			if !errors.As(err, &statusErr) || statusErr.StatusCode != tt.status {
+				t.Errorf("error = %#v, want an *httpStatusError with status %d", err, tt.status)
+			}
+		})
+	}
+}
+
+// BenchmarkConnectionReuse compares the shared pooled client with a fresh
+// client per request, as each invocation used to create, reporting the
+// connections opened per request.
+func BenchmarkConnectionReuse(b *testing.B) {
+	var conns atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"data":{"candidate":{"id":"1"}}}`)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	post := func(b *testing.B, client *http.Client) {
+		res, err := client.Post(srv.URL, "application/json", bytes.NewReader([]byte(`{"query":"{ candidate(id: 1) { id } }"}`)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+	run := func(b *testing.B, client func() *http.Client) {
+		conns.Store(0)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				post(b, client())
+			}
+		})
+		b.StopTimer()
+		b.ReportMetric(float64(conns.Load())/float64(b.N), "conns/op")
+	}
+
+	b.Run("shared", func(b *testing.B) {
+		shared := newHTTPClient()
+		defer shared.CloseIdleConnections()
+		run(b, func() *http.Client { return shared })
+	})
+	b.Run("fresh", func(b *testing.B) {
+		run(b, func() *http.Client {
+			// Dropping the idle connection right away, as a discarded
+			// client's transport eventually does, keeps the benchmark from
+			// running out of sockets
+			return &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		})
+	})
+}
+
+// TestExecuteGraphQLResponseLimit checks that response bodies are read up to
+// MAX_RESPONSE_BODY_BYTES after decompression, so a gzip bomb fails early.
+func TestExecuteGraphQLResponseLimit(t *testing.T) {
+	previous := maxResponseBodyBytes
+	maxResponseBodyBytes = 1 << 10
+	t.Cleanup(func() { maxResponseBodyBytes = previous })
+	resetCircuit(t, 0)
+
+	padded := func(n int) []byte {
+		return []byte(`{"data":{"blob":"` + strings.Repeat(" ", n) + `"}}`)
+	}
+	var bomb bytes.Buffer
+	zw := gzip.NewWriter(&bomb)
+	zw.Write(padded(10 << 20))
+	zw.Close()
+
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+		wantErr  bool
+	}{
+		{"within the limit", padded(100), "", false},
+		{"exactly the limit", padded(1<<10 - len(padded(0))), "", false},
+		{"over the limit", padded(2 << 10), "", true},
+		{"gzip bomb", bomb.Bytes(), "gzip", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				if tt.encoding != "" {
+					w.Header().Set("Content-Encoding", tt.encoding)
+				}
+				w.Write(tt.body)
+			}))
+			defer srv.Close()
+			previousEndpoint := graphqlEndpoint
+			graphqlEndpoint = srv.URL
+			defer func() { graphqlEndpoint = previousEndpoint }()
+
+			_, err := executeGraphQL(context.Background(), graphqlRequest{Query: "{ blob }"})
+			if tt.wantErr {
+				if !errors.Is(err, errResponseTooLarge) {
+					t.Errorf("error = %v, want the response to be too large", err)
+				}
+			} else if err != nil {
+				t.Errorf("the response was rejected: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// coerceVariables converts variable values to the scalar form expected by the
+// types the operation declares, e.g. a JSON number passed for an ID becomes a
+// string. It returns the coerced variables and a description of every change.
+// Values that can't be converted are left untouched for the server to reject.
+func coerceVariables(schema *schemaModel, operation string, vars map[string]interface{}) (map[string]interface{}, []string, error) {
+	var coercions []string
+	out, err := mapVariableScalars(schema, operation, vars, func(scalar string, v interface{}, path string) interface{} {
+		coerced, ok := coerceScalar(scalar, v)
+		if !ok {
+			return v
+		}
+		coercions = append(coercions, fmt.Sprintf("%s: %s -> %s (%s)", path, describeJSONValue(v), describeJSONValue(coerced), scalar))
+		return coerced
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, coercions, nil
+}
+
+// mapVariableScalars returns a copy of vars where every scalar value, at any
+// depth of lists and input objects, is replaced with what fn returns for it,
+// given the scalar type the operation declares for it and its path (e.g.
+// "$input.tags[0]"). Values that don't have the declared shape are kept.
+func mapVariableScalars(schema *schemaModel, operation string, vars map[string]interface{}, fn func(scalar string, v interface{}, path string) interface{}) (map[string]interface{}, error) {
+	if len(vars) == 0 {
+		return vars, nil
+	}
+	doc, err := parseDocument(operation)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]interface{}, len(vars))
+	for k, v := range vars {
+		out[k] = v
+	}
+	for _, op := range doc.Operations {
+		for _, def := range op.VariableDefinitions {
+			if v, ok := out[def.Name]; ok {
+				out[def.Name] = mapScalarValue(schema, def.Type, v, "$"+def.Name, fn)
+			}
+		}
+	}
+	return out, nil
+}
+
+// mapScalarValue applies fn to the scalars of v, which has the type t,
+// recursing into lists and input objects. path names the value.
+func mapScalarValue(schema *schemaModel, t *astType, v interface{}, path string, fn func(scalar string, v interface{}, path string) interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if t.Elem != nil {
+		list, ok := v.([]interface{})
+		if !ok {
+			return v
+		}
+		out := make([]interface{}, len(list))
+		for i, item := range list {
+			out[i] = mapScalarValue(schema, t.Elem, item, fmt.Sprintf("%s[%d]", path, i), fn)
+		}
+		return out
+	}
+
+	if typ := schema.typeByName(t.Name); typ != nil && typ.Kind == "INPUT_OBJECT" {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		out := make(map[string]interface{}, len(obj))
+		for k, val := range obj {
+			out[k] = val
+		}
+		for _, f := range typ.InputFields {
+			if val, ok := out[f.Name]; ok {
+				out[f.Name] = mapScalarValue(schema, astTypeFromRef(f.Type), val, path+"."+f.Name, fn)
+			}
+		}
+		return out
+	}
+	return fn(t.Name, v, path)
+}
+
+// coerceScalar converts v to the JSON form of a built-in scalar. It reports
+// false when v already has the right form or can't be converted.
+func coerceScalar(scalar string, v interface{}) (interface{}, bool) {
+	switch scalar {
+	case "ID", "String":
+		switch v := v.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), true
+		case bool:
+			if scalar == "String" {
+				return strconv.FormatBool(v), true
+			}
+		}
+	case "Int":
+		if s, ok := v.(string); ok {
+			if n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 32); err == nil {
+				return float64(n), true
+			}
+		}
+	case "Float":
+		if s, ok := v.(string); ok {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+				return f, true
+			}
+		}
+	case "Boolean":
+		if s, ok := v.(string); ok {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				return b, true
+			}
+		}
+	}
+	return v, false
+}
+
+// astTypeFromRef converts a schema type reference into an AST type.
+func astTypeFromRef(ref *typeRef) *astType {
+	switch {
+	case ref == nil:
+		return &astType{}
+	case ref.Kind == "NON_NULL":
+		t := astTypeFromRef(ref.OfType)
+		t.NonNull = true
+		return t
+	case ref.Kind == "LIST":
+		return &astType{Elem: astTypeFromRef(ref.OfType)}
+	}
+	return &astType{Name: ref.Name}
+}
+
+// describeJSONValue renders a decoded JSON value for coercion reports.
+func describeJSONValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
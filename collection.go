@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Tool: export_collection
+const exportCollectionToolDescription = `Export the queries and mutations of the schema as a Postman or Insomnia collection, to import them into an API testing tool.
+Each operation selects the scalar and enum fields of its result and comes with example variables for its required arguments. Requests go to the configured endpoint with the current headers; credentials (Authorization, cookies, API keys and AUTH_CONFIG headers) are replaced with collection variables to fill in after the import.
+
+Best Practices:
+- Save the result to a .json file and import it in Postman (File > Import) or Insomnia (Import > From File).
+- Pass operations to export a few of them instead of the whole API.
+- The example variables are placeholders of the right type: replace them before sending.
+
+Arguments:
+- format (string, Optional): "postman" (default, collection format v2.1) or "insomnia" (export format 4).
+- operations (string, Optional): A comma-separated list of the operations to export, e.g. "query.jobs,mutation.createJob"; all queries and mutations by default.
+
+Example Usage:
+Request:
+  export_collection(operations: "query.job")
+
+Response:
+  {
+    "info": {
+      "name": "api.example.com",
+      "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+    },
+    "item": [
+      {
+        "item": [
+          {
+            "name": "job",
+            "request": {
+              "body": {
+                "graphql": {
+                  "query": "query Job($id: ID!) {\n  job(id: $id) {\n    id\n    title\n  }\n}",
+                  "variables": "{\n  \"id\": \"\"\n}"
+                },
+                "mode": "graphql"
+              },
+              "header": [
+                {
+                  "key": "Authorization",
+                  "value": "{{authorization}}"
+                }
+              ],
+              "method": "POST",
+              "url": {
+                "host": [
+                  "{{endpoint}}"
+                ],
+                "raw": "{{endpoint}}"
+              }
+            }
+          }
+        ],
+        "name": "Queries"
+      }
+    ],
+    "variable": [
+      {
+        "key": "endpoint",
+        "value": "https://api.example.com/graphql"
+      },
+      {
+        "key": "authorization",
+        "value": ""
+      }
+    ]
+  }
+`
+
+// collectionRequest is an operation of an exported collection.
+type collectionRequest struct {
+	Name      string
+	Operation string
+	Variables string
+}
+
+// collectionFolder groups the requests of one kind of operation.
+type collectionFolder struct {
+	Name     string
+	Requests []collectionRequest
+}
+
+// collectionHeader is a header of the exported requests; Variable names the
+// collection variable standing for a credential.
+type collectionHeader struct {
+	Name, Value, Variable string
+}
+
+// exportCollection renders the selected queries and mutations as a Postman
+// or Insomnia collection.
+func exportCollection(ctx context.Context, format, operations string) (string, error) {
+	if graphqlEndpoint == "" {
+		return "", fmt.Errorf("ADDRESS is not set")
+	}
+	switch format {
+	case "", "postman", "insomnia":
+	default:
+		return "", fmt.Errorf("unknown format %q, expected \"postman\" or \"insomnia\"", format)
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	folders, err := collectionFolders(schema, operations)
+	if err != nil {
+		return "", err
+	}
+	headers := collectionHeaders()
+
+	var collection interface{}
+	if format == "insomnia" {
+		collection = insomniaCollection(folders, headers)
+	} else {
+		collection = postmanCollection(folders, headers)
+	}
+	encoded, err := json.MarshalIndent(collection, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// collectionFolders builds the requests of the operations listed in spec, or
+// of every query and mutation when it is empty.
+func collectionFolders(schema *schemaModel, spec string) ([]collectionFolder, error) {
+	selected := make(map[string]bool)
+	if strings.TrimSpace(spec) != "" {
+		var names []string
+		for _, operation := range []string{"query", "mutation"} {
+			for _, f := range schema.operationFields(operation) {
+				names = append(names, operation+"."+f.Name)
+			}
+		}
+		for _, name := range strings.Split(spec, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			found := false
+			for _, candidate := range names {
+				if candidate == name || strings.TrimPrefix(strings.TrimPrefix(candidate, "query."), "mutation.") == name {
+					selected[candidate], found = true, true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("%s", unknownKeyProblem(name, "no such query or mutation", name, names))
+			}
+		}
+	}
+
+	var folders []collectionFolder
+	for _, operation := range []string{"query", "mutation"} {
+		folder := collectionFolder{Name: "Queries"}
+		if operation == "mutation" {
+			folder.Name = "Mutations"
+		}
+		for _, f := range schema.operationFields(operation) {
+			if len(selected) > 0 && !selected[operation+"."+f.Name] {
+				continue
+			}
+			request, err := collectionOperation(schema, operation, f)
+			if err != nil {
+				return nil, err
+			}
+			folder.Requests = append(folder.Requests, request)
+		}
+		if len(folder.Requests) > 0 {
+			folders = append(folders, folder)
+		}
+	}
+	if len(folders) == 0 {
+		return nil, fmt.Errorf("the schema has no queries or mutations to export")
+	}
+	return folders, nil
+}
+
+// operationFields returns the queries or mutations of the schema.
+func (s *schemaModel) operationFields(operation string) []*schemaField {
+	if operation == "mutation" {
+		return s.mutations()
+	}
+	return s.queries()
+}
+
+// collectionOperation builds the request calling the root field f, with a
+// variable per argument and example values for the required ones.
+func collectionOperation(schema *schemaModel, operation string, f *schemaField) (collectionRequest, error) {
+	var definitions, arguments []string
+	variables := skeletonObject{}
+	for _, a := range f.Args {
+		definitions = append(definitions, "$"+a.Name+": "+a.Type.String())
+		arguments = append(arguments, a.Name+": $"+a.Name)
+		if isRequiredArgument(a) {
+			variables = append(variables, skeletonField{a.Name, skeletonValue(schema, a.Type, a.DefaultValue, map[string]bool{}, 0)})
+		}
+	}
+	name := []rune(f.Name)
+	name[0] = unicode.ToUpper(name[0])
+	src := operation + " " + string(name)
+	if len(definitions) > 0 {
+		src += "(" + strings.Join(definitions, ", ") + ")"
+	}
+	src += " { " + f.Name
+	if len(arguments) > 0 {
+		src += "(" + strings.Join(arguments, ", ") + ")"
+	}
+	src += leafSelection(schema, f.Type) + " }"
+	formatted, err := formatOperation(src)
+	if err != nil {
+		return collectionRequest{}, fmt.Errorf("%s.%s: %w", operation, f.Name, err)
+	}
+	encoded, err := json.MarshalIndent(variables, "", "  ")
+	if err != nil {
+		return collectionRequest{}, err
+	}
+	return collectionRequest{Name: f.Name, Operation: formatted, Variables: string(encoded)}, nil
+}
+
+// collectionHeaders returns the current headers, sorted by name, with
+// credentials replaced by variables. The header AUTH_CONFIG would add is
+// included as a variable too.
+func collectionHeaders() []collectionHeader {
+	header := getHeaders()
+	if e := authEntryFor(graphqlEndpoint); e != nil && header.Get(e.headerName()) == "" {
+		header.Set(e.headerName(), "")
+	}
+	sensitive := make(map[string]bool)
+	for _, name := range append(sensitiveHeaders, authHeaderNames()...) {
+		sensitive[http.CanonicalHeaderKey(name)] = true
+	}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	headers := make([]collectionHeader, 0, len(names))
+	for _, name := range names {
+		h := collectionHeader{Name: name, Value: header.Get(name)}
+		if sensitive[http.CanonicalHeaderKey(name)] {
+			h.Variable = collectionVariableName(name)
+			h.Value = ""
+		}
+		headers = append(headers, h)
+	}
+	return headers
+}
+
+// collectionVariableName turns a header name into a variable name, e.g.
+// "X-Api-Key" into "x_api_key".
+func collectionVariableName(header string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return '_'
+	}, header)
+}
+
+// collectionName names a collection after the host of the endpoint.
+func collectionName() string {
+	name := strings.TrimPrefix(strings.TrimPrefix(graphqlEndpoint, "https://"), "http://")
+	if i := strings.IndexAny(name, "/?#"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// postmanCollection renders the folders as a Postman collection (v2.1), with
+// the endpoint and credentials as collection variables.
+func postmanCollection(folders []collectionFolder, headers []collectionHeader) map[string]interface{} {
+	type keyValue struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	requestHeaders := make([]keyValue, 0, len(headers))
+	variables := []keyValue{{"endpoint", graphqlEndpoint}}
+	for _, h := range headers {
+		if h.Variable != "" {
+			requestHeaders = append(requestHeaders, keyValue{h.Name, "{{" + h.Variable + "}}"})
+			variables = append(variables, keyValue{h.Variable, ""})
+		} else {
+			requestHeaders = append(requestHeaders, keyValue{h.Name, h.Value})
+		}
+	}
+
+	items := make([]interface{}, 0, len(folders))
+	for _, folder := range folders {
+		requests := make([]interface{}, 0, len(folder.Requests))
+		for _, r := range folder.Requests {
+			requests = append(requests, map[string]interface{}{
+				"name": r.Name,
+				"request": map[string]interface{}{
+					"method": "POST",
+					"header": requestHeaders,
+					"body": map[string]interface{}{
+						"mode":    "graphql",
+						"graphql": map[string]string{"query": r.Operation, "variables": r.Variables},
+					},
+					"url": map[string]interface{}{"raw": "{{endpoint}}", "host": []string{"{{endpoint}}"}},
+				},
+			})
+		}
+		items = append(items, map[string]interface{}{"name": folder.Name, "item": requests})
+	}
+	return map[string]interface{}{
+		"info": map[string]string{
+			"name":   collectionName(),
+			"schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json",
+		},
+		"item":     items,
+		"variable": variables,
+	}
+}
+
+// insomniaCollection renders the folders as an Insomnia export (format 4):
+// a workspace with a folder per kind of operation, and a base environment
+// holding the endpoint and credentials.
+func insomniaCollection(folders []collectionFolder, headers []collectionHeader) map[string]interface{} {
+	type nameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	requestHeaders := []nameValue{{"Content-Type", "application/json"}}
+	environment := map[string]string{"endpoint": graphqlEndpoint}
+	for _, h := range headers {
+		if h.Variable != "" {
+			requestHeaders = append(requestHeaders, nameValue{h.Name, "{{ _." + h.Variable + " }}"})
+			environment[h.Variable] = ""
+		} else if !strings.EqualFold(h.Name, "Content-Type") {
+			requestHeaders = append(requestHeaders, nameValue{h.Name, h.Value})
+		}
+	}
+
+	resources := []interface{}{
+		map[string]interface{}{"_id": "wrk_graphql_mcp", "_type": "workspace", "name": collectionName(), "scope": "collection"},
+		map[string]interface{}{"_id": "env_graphql_mcp", "_type": "environment", "parentId": "wrk_graphql_mcp", "name": "Base Environment", "data": environment},
+	}
+	for _, folder := range folders {
+		folderID := "fld_" + strings.ToLower(folder.Name)
+		resources = append(resources, map[string]interface{}{"_id": folderID, "_type": "request_group", "parentId": "wrk_graphql_mcp", "name": folder.Name})
+		for _, r := range folder.Requests {
+			body, _ := json.Marshal(map[string]interface{}{"query": r.Operation, "variables": json.RawMessage(r.Variables)})
+			resources = append(resources, map[string]interface{}{
+				"_id":      "req_" + strings.ToLower(folder.Name) + "_" + r.Name,
+				"_type":    "request",
+				"parentId": folderID,
+				"name":     r.Name,
+				"method":   "POST",
+				"url":      "{{ _.endpoint }}",
+				"body":     map[string]string{"mimeType": "application/graphql", "text": string(body)},
+				"headers":  requestHeaders,
+			})
+		}
+	}
+	return map[string]interface{}{
+		"_type":           "export",
+		"__export_format": 4,
+		"__export_source": "graphql-mcp",
+		"resources":       resources,
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tool: check_compatibility
+const checkCompatibilityToolDescription = `Check that the endpoint supports the directives an operation uses, without running it.
+Each directive in the operation is checked against the directives the schema declares (as listed by list_directives): it must be declared, allowed where it is used, and given its required arguments and no unknown ones.
+This catches "unknown directive" errors, e.g. @defer or @stream on servers without incremental delivery, before execution.
+
+Best Practices:
+- Run this before invoke_graphql when an operation uses directives beyond @include and @skip.
+- Fix every reported problem; each one gives the line and column of the directive.
+
+Arguments:
+- operation (string, Required): The GraphQL operation to check, including any fragments.
+
+Example Usage:
+Request:
+  check_compatibility(operation: "query { jobs { id ... @defer { applications { id } } } }")
+
+Response:
+  Found 1 incompatibility:
+  - line 1, column 23: @defer is not declared by the schema; the server doesn't support incremental delivery (@defer/@stream). Remove the directive.
+`
+
+// checkOperationCompatibility checks the directives used by operation
+// against the schema's directive declarations and returns a report.
+func checkOperationCompatibility(ctx context.Context, operation string) (string, error) {
+	doc, err := parseDocument(operation)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse operation: %w", err)
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	declared := make(map[string]*schemaDirective, len(schema.Directives))
+	names := make([]string, 0, len(schema.Directives))
+	for _, d := range schema.Directives {
+		declared[d.Name] = d
+		names = append(names, d.Name)
+	}
+
+	var problems []string
+	used := 0
+	check := func(dirs []*astDirective, location string) {
+		for _, dir := range dirs {
+			used++
+			for _, p := range directiveProblems(declared[dir.Name], dir, location, names) {
+				problems = append(problems, fmt.Sprintf("line %d, column %d: %s", dir.Line, dir.Column, p))
+			}
+		}
+	}
+	var walk func(selections []*astSelection)
+	walk = func(selections []*astSelection) {
+		for _, sel := range selections {
+			switch sel.Kind {
+			case selectionField:
+				check(sel.Directives, "FIELD")
+			case selectionFragmentSpread:
+				check(sel.Directives, "FRAGMENT_SPREAD")
+			case selectionInlineFragment:
+				check(sel.Directives, "INLINE_FRAGMENT")
+			}
+			walk(sel.SelectionSet)
+		}
+	}
+	for _, op := range doc.Operations {
+		check(op.Directives, strings.ToUpper(op.Operation))
+		for _, def := range op.VariableDefinitions {
+			check(def.Directives, "VARIABLE_DEFINITION")
+		}
+		walk(op.SelectionSet)
+	}
+	for _, frag := range doc.Fragments {
+		check(frag.Directives, "FRAGMENT_DEFINITION")
+		walk(frag.SelectionSet)
+	}
+
+	if len(problems) == 0 {
+		if used == 0 {
+			return "Compatible: the operation uses no directives.", nil
+		}
+		return fmt.Sprintf("Compatible: the %s used by the operation are declared by the schema and valid where they are used.", pluralize(used, "directive", "directives")), nil
+	}
+	return fmt.Sprintf("Found %s:\n- %s", pluralize(len(problems), "incompatibility", "incompatibilities"), strings.Join(problems, "\n- ")), nil
+}
+
+// directiveProblems checks a directive applied at location against its
+// declaration, which is nil when the schema doesn't declare it.
+func directiveProblems(decl *schemaDirective, dir *astDirective, location string, declaredNames []string) []string {
+	if decl == nil {
+		problem := fmt.Sprintf("@%s is not declared by the schema", dir.Name)
+		if dir.Name == "defer" || dir.Name == "stream" {
+			return []string{problem + "; the server doesn't support incremental delivery (@defer/@stream). Remove the directive."}
+		}
+		if suggestions := suggestNames(dir.Name, declaredNames, maxSuggestions); len(suggestions) > 0 {
+			return []string{problem + ". Did you mean: @" + strings.Join(suggestions, ", @") + "?"}
+		}
+		return []string{problem}
+	}
+
+	var problems []string
+	allowed := false
+	for _, l := range decl.Locations {
+		allowed = allowed || l == location
+	}
+	if !allowed {
+		problems = append(problems, fmt.Sprintf("@%s can't be used on %s, only on %s", dir.Name, location, strings.Join(decl.Locations, " | ")))
+	}
+	args := make(map[string]bool, len(decl.Args))
+	argNames := make([]string, 0, len(decl.Args))
+	for _, a := range decl.Args {
+		args[a.Name] = true
+		argNames = append(argNames, a.Name)
+	}
+	given := make(map[string]bool, len(dir.Arguments))
+	for _, arg := range dir.Arguments {
+		given[arg.Name] = true
+		if !args[arg.Name] {
+			problems = append(problems, unknownKeyProblem("@"+dir.Name, "unknown argument "+arg.Name, arg.Name, argNames))
+		}
+	}
+	for _, a := range decl.Args {
+		if a.Type.isNonNull() && a.DefaultValue == nil && !given[a.Name] {
+			problems = append(problems, fmt.Sprintf("@%s is missing its required argument %s: %s", dir.Name, a.Name, a.Type))
+		}
+	}
+	return problems
+}
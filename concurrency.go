@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Concurrency limit. At most MAX_CONCURRENT_REQUESTS requests to the endpoint
+// (invocations and introspection) are in flight at once; the others wait up
+// to REQUEST_QUEUE_TIMEOUT for a slot. 0 removes the limit.
+var (
+	maxConcurrentRequests = intFromEnv("MAX_CONCURRENT_REQUESTS", 10)
+	requestQueueTimeout   = durationFromEnv("REQUEST_QUEUE_TIMEOUT", 30*time.Second)
+)
+
+// requestSlots holds a token per request in flight, or is nil when the
+// concurrency is not limited.
+var requestSlots = newRequestSlots(maxConcurrentRequests)
+
+// errBridgeBusy is returned when no request slot frees up in time.
+var errBridgeBusy = errors.New("the bridge is busy")
+
+// newRequestSlots returns a semaphore of n slots, or nil for no limit.
+func newRequestSlots(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
+
+// acquireRequestSlot waits for a request slot and returns the function that
+// frees it. It fails when ctx is done or REQUEST_QUEUE_TIMEOUT elapses first.
+func acquireRequestSlot(ctx context.Context) (func(), error) {
+	if requestSlots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-requestSlots }
+	// Don't start a timer when a slot is free
+	select {
+	case requestSlots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	debugf("waiting for a request slot, %d requests in flight", len(requestSlots))
+	var expired <-chan time.Time
+	if requestQueueTimeout > 0 {
+		timer := time.NewTimer(requestQueueTimeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	select {
+	case requestSlots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-expired:
+		return nil, fmt.Errorf("%w: %s in flight and none finished within %s; retry shortly, or raise MAX_CONCURRENT_REQUESTS or REQUEST_QUEUE_TIMEOUT", errBridgeBusy, pluralize(maxConcurrentRequests, "request to the endpoint is", "requests to the endpoint are"), requestQueueTimeout)
+	}
+}
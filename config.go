@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the optional YAML or JSON file settings are read from. It
+// can only be set in the environment.
+var configFile = os.Getenv("CONFIG_FILE")
+
+// fileSettings holds the settings from CONFIG_FILE, as the strings the
+// environment variables of the same name would hold. Environment variables
+// take precedence over them.
+var fileSettings, fileSettingsErr = loadConfigFile(configFile)
+
+// knownSettings are the settings a config file may define: the environment
+// variables the bridge reads.
+var knownSettings = []string{
+	"ADDRESS", "GRAPHQL_PATH", "GRAPHQL_HEADERS", "BASIC_AUTH_USER", "BASIC_AUTH_PASS", "AUTH_CONFIG",
+	"INTROSPECTION_CACHE_TTL", "INTROSPECTION_DEPTH", "SCHEMA_FILE", "ALLOWED_OPERATIONS", "ALLOWED_QUERY_HASHES",
+	"ALLOWED_QUERY_HASHES_FILE", "DENIED_OPERATIONS", "READ_ONLY", "GRAPHQL_DEFAULT_VARIABLES",
+	"SECRET_ENV_PREFIX", "REQUIRE_MUTATION_CONFIRM", "VERBOSE_ERRORS", "OPERATION_NAME_PREFIX",
+	"HISTORY_SIZE", "AUDIT_LOG_PATH", "AUDIT_REDACT_KEYS", "OPERATIONS_DIR", "QUERIES_DIR",
+	"REQUEST_TIMEOUT", "MAX_REQUEST_TIMEOUT", "MAX_RESPONSE_BYTES", "MAX_RESPONSE_BODY_BYTES", "COST_ESTIMATE_HEADERS",
+	"COST_ESTIMATE_EXTENSIONS", "RESPONSE_HEADERS", "COMPACT_OUTPUT", "HTTP_MAX_IDLE_CONNS",
+	"HTTP_MAX_IDLE_CONNS_PER_HOST", "HTTP_IDLE_CONN_TIMEOUT", "REAUTH_COMMAND", "REAUTH_TIMEOUT",
+	"DISALLOW_REDIRECTS", "GZIP_REQUESTS", "GZIP_REQUEST_MIN_BYTES", "SELFTEST", "DEBUG", "TRANSPORT",
+	"SSE_ADDR", "SSE_BASE_URL", "PROGRESS_CHUNK_BYTES", "SHUTDOWN_GRACE_PERIOD", "SUBSCRIPTIONS_ADDRESS",
+	"DRY_RUN_HEADER", "DRY_RUN_DIRECTIVE", "SCALAR_FORMATS",
+	"MAX_CONCURRENT_REQUESTS", "REQUEST_QUEUE_TIMEOUT", "CIRCUIT_BREAKER_THRESHOLD", "CIRCUIT_BREAKER_COOLDOWN", "PAGINATION_HINTS",
+	"API_VERSION", "API_VERSION_HEADER", "LINT_MAX_DEPTH", "LINT_PAGINATION_ARGS", "DESCRIBE_MAX_ENTITIES",
+	"IDEMPOTENCY_KEY_HEADER", "PREWARM", "RESPONSE_CACHE_TTL", "RESPONSE_CACHE_MAX_ENTRIES",
+	"USER_AGENT", "WHOAMI_QUERY", "DOWNLOADS_DIR", "ERROR_CODE_ENUM", "HTTP_BATCHING", "BODY_TEMPLATE", "LOGIN_URL", "LOGIN_BODY",
+	"LOGIN_TOKEN_PATH", "LOGIN_HEADER", "LOGIN_TOKEN_PREFIX", "ACCESS_ROLE", "ROLE_DENIED_FIELDS",
+	"OTEL_SDK_DISABLED", "OTEL_TRACES_EXPORTER",
+	"OTEL_EXPORTER_OTLP_ENDPOINT", "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "OTEL_EXPORTER_OTLP_HEADERS",
+	"OTEL_EXPORTER_OTLP_TRACES_HEADERS", "OTEL_EXPORTER_OTLP_PROTOCOL", "OTEL_EXPORTER_OTLP_TRACES_PROTOCOL",
+	"OTEL_EXPORTER_OTLP_TIMEOUT", "OTEL_SERVICE_NAME", "OTEL_RESOURCE_ATTRIBUTES",
+}
+
+// jsonSettings hold JSON documents; in a config file they may be written as
+// plain YAML or JSON values instead of encoded strings.
+var jsonSettings = map[string]bool{
+	"GRAPHQL_HEADERS":           true,
+	"AUTH_CONFIG":               true,
+	"GRAPHQL_DEFAULT_VARIABLES": true,
+	"COST_ESTIMATE_HEADERS":     true,
+	"COST_ESTIMATE_EXTENSIONS":  true,
+	"SCALAR_FORMATS":            true,
+	"PAGINATION_HINTS":          true,
+	"ROLE_DENIED_FIELDS":        true,
+}
+
+// loadConfigFile reads a config file mapping setting names to values, e.g.
+//
+//	ADDRESS: https://api.example.com
+//	REQUEST_TIMEOUT: 30s
+//	GRAPHQL_HEADERS:
+//	  X-Tenant: acme
+//	ALLOWED_OPERATIONS: [jobs, candidate]
+//
+// JSON files are read the same way, since JSON is valid YAML.
+func loadConfigFile(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading CONFIG_FILE: %w", err)
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("invalid CONFIG_FILE %s: %w", path, err)
+	}
+
+	known := make(map[string]bool, len(knownSettings))
+	for _, name := range knownSettings {
+		known[name] = true
+	}
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	settings := make(map[string]string, len(raw))
+	var problems []string
+	for _, name := range names {
+		if !known[name] {
+			problems = append(problems, unknownKeyProblem(name, "unknown setting", name, knownSettings))
+			continue
+		}
+		value, err := settingString(name, raw[name])
+		if err != nil {
+			problems = append(problems, name+": "+err.Error())
+			continue
+		}
+		settings[name] = value
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid CONFIG_FILE %s:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+	return settings, nil
+}
+
+// settingString converts a config file value to the string form the
+// environment variable would have: JSON for JSON settings, comma-separated
+// items for lists and the plain value for scalars.
+func settingString(name string, v interface{}) (string, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	if jsonSettings[name] {
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("can't be encoded as JSON: %w", err)
+		}
+		return string(encoded), nil
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("expected a single value or a list, got a mapping")
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			switch item.(type) {
+			case map[string]interface{}, []interface{}:
+				return "", fmt.Errorf("expected a list of single values")
+			}
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ","), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// configSetting is a setting checked before the server starts.
+type configSetting struct {
+	name string
+	err  error
+	// detail describes a valid value in the self-test report; when nil the
+	// report only says whether the setting is set
+	detail func() string
+}
+
+// configSettings lists the settings whose errors stop the server from
+// starting. main refuses to start on the first error and the self-test
+// reports every setting, so the two can't disagree on what a valid
+// configuration is.
+func configSettings() []configSetting {
+	addressErr := graphqlEndpointErr
+	if addressErr == nil && graphqlEndpoint == "" {
+		addressErr = errors.New("ADDRESS is required, in the environment or CONFIG_FILE")
+	}
+	return []configSetting{
+		{"CONFIG_FILE", fileSettingsErr, func() string {
+			if configFile == "" {
+				return "not set"
+			}
+			return fmt.Sprintf("%s, %s", configFile, pluralize(len(fileSettings), "setting", "settings"))
+		}},
+		{"ADDRESS", addressErr, func() string { return graphqlEndpoint }},
+		{"GRAPHQL_HEADERS", envHeadersErr, func() string {
+			if getenv("GRAPHQL_HEADERS") == "" {
+				return "not set"
+			}
+			return pluralize(len(envHeaders), "header", "headers")
+		}},
+		{"SCALAR_FORMATS", scalarFormatsErr, nil},
+		{"PAGINATION_HINTS", paginationHintsErr, nil},
+		{"AUTH_CONFIG", authConfigErr, func() string {
+			if len(authConfig) == 0 {
+				return "not set"
+			}
+			return pluralize(len(authConfig), "entry", "entries")
+		}},
+		{"BODY_TEMPLATE", bodyTemplateErr, nil},
+		{"LOGIN_BODY", loginConfigErr, nil},
+		{"ROLE_DENIED_FIELDS", roleDeniedFieldsErr, nil},
+		{"OTEL_EXPORTER_OTLP_PROTOCOL", otlpConfigErr, func() string {
+			if !tracingEnabled {
+				return "tracing off"
+			}
+			return "exporting spans to " + otlpTracesEndpoint
+		}},
+		{"ALLOWED_QUERY_HASHES", allowedQueryHashesErr, func() string {
+			if len(allowedQueryHashes) == 0 {
+				return "not set"
+			}
+			return pluralize(len(allowedQueryHashes), "hash", "hashes")
+		}},
+		{"READ_ONLY", readOnlyModeErr, func() string { return fmt.Sprint(readOnlyMode) }},
+		{"REQUIRE_MUTATION_CONFIRM", requireMutationConfirmErr, func() string { return fmt.Sprint(requireMutationConfirm) }},
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// requireMutationConfirm makes invoke_graphql refuse to run mutations unless
+// the call sets confirm: true. An invalid value is a startup error rather
+// than dropping the requirement.
+var requireMutationConfirm, requireMutationConfirmErr = strictBoolFromEnv("REQUIRE_MUTATION_CONFIRM")
+
+// confirmationRequiredError is returned instead of executing a mutation that
+// hasn't been confirmed. Its message is a JSON document describing what the
+// mutation would do.
+type confirmationRequiredError struct {
+	Status    string                 `json:"status"`
+	Message   string                 `json:"message"`
+	Mutations []mutationSideEffect   `json:"mutations,omitempty"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// mutationSideEffect describes a root mutation field the operation would call.
+type mutationSideEffect struct {
+	Field       string `json:"field"`
+	Signature   string `json:"signature,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+func (e *confirmationRequiredError) Error() string {
+	out, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return e.Message
+	}
+	return string(out)
+}
+
+// checkMutationConfirmed returns a *confirmationRequiredError when mutation
+// confirmation is required, the operation contains a mutation and the call
+// wasn't confirmed. The operation type is taken from the parsed operation, so
+// it doesn't matter which tool argument carried it. Operations that can't be
+// parsed are treated as mutations.
+func checkMutationConfirmed(ctx context.Context, operation string, vars map[string]interface{}, confirmed bool) error {
+	if !requireMutationConfirm || confirmed {
+		return nil
+	}
+
+	doc, err := parseDocument(operation)
+	if err != nil {
+		return &confirmationRequiredError{
+			Status:    "confirmation_required",
+			Message:   "The operation could not be parsed to verify that it is not a mutation (" + err.Error() + "). Re-run with confirm: true to execute it anyway.",
+			Variables: vars,
+		}
+	}
+
+	var effects []mutationSideEffect
+	for _, op := range doc.Operations {
+		if op.Operation != "mutation" {
+			continue
+		}
+		for _, field := range doc.rootFields(op) {
+			effect := mutationSideEffect{Field: field}
+			if schema, err := getSchema(ctx); err == nil {
+				if f := lookupField(schema, schema.MutationType, field); f != nil {
+					effect.Signature = prettyPrintField(f)
+					effect.Description = f.Description
+				}
+			}
+			effects = append(effects, effect)
+		}
+	}
+	if len(effects) == 0 {
+		return nil
+	}
+	return &confirmationRequiredError{
+		Status:    "confirmation_required",
+		Message:   "This operation contains a mutation and was not executed. Review the side effects below and re-run with confirm: true to execute it.",
+		Mutations: effects,
+		Variables: vars,
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Tool: estimate_cost
+const estimateCostToolDescription = `Ask the server what a query would cost, without executing it, using the server's cost-only mode.
+The cost or complexity data found in the response extensions (e.g. extensions.cost.requestedQueryCost) is returned as is.
+
+Best Practices:
+- Use this tool before running a large query against an API with cost or rate limits.
+- Only works when the server supports a cost-only request and the bridge is configured for it with COST_ESTIMATE_HEADERS or COST_ESTIMATE_EXTENSIONS.
+- Only queries are accepted: a server that ignored the cost-only signal would execute the operation.
+- To see the actual cost of a query you run, pass returnCost to invoke_graphql instead.
+
+Arguments:
+- operation (string, Required): The GraphQL query to estimate.
+- variables (string, Optional): A JSON-encoded string of the variables of the query.
+
+Example Usage:
+Request:
+  estimate_cost(operation: "query { candidates(first: 100) { id jobs { id } } }")
+
+Response:
+  {
+    "cost": {
+      "requestedQueryCost": 202,
+      "throttleStatus": {
+        "currentlyAvailable": 1000,
+        "maximumAvailable": 1000,
+        "restoreRate": 50
+      }
+    }
+  }
+`
+
+// costEstimateHeaders and costEstimateExtensions are how the server is told
+// to compute the cost of an operation without executing it.
+var (
+	costEstimateHeaders    = jsonObjectFromEnv("COST_ESTIMATE_HEADERS")
+	costEstimateExtensions = jsonObjectFromEnv("COST_ESTIMATE_EXTENSIONS")
+)
+
+// costKeyPattern matches the names of response extensions that carry cost,
+// complexity or rate limit information.
+var costKeyPattern = regexp.MustCompile(`(?i)cost|complexity|throttl|rate_?limit|budget`)
+
+// costExtensions returns the response extensions that look like cost data, or
+// nil when there are none.
+func costExtensions(extensions map[string]interface{}) map[string]interface{} {
+	var cost map[string]interface{}
+	for k, v := range extensions {
+		if costKeyPattern.MatchString(k) {
+			if cost == nil {
+				cost = make(map[string]interface{})
+			}
+			cost[k] = v
+		}
+	}
+	return cost
+}
+
+// estimateOperationCost sends operation in the server's cost-only mode and
+// reports the cost data of the response.
+func estimateOperationCost(ctx context.Context, operation, variablesJSON string) (string, error) {
+	if len(costEstimateHeaders) == 0 && len(costEstimateExtensions) == 0 {
+		return "", fmt.Errorf("no cost-only mode is configured: set COST_ESTIMATE_HEADERS or COST_ESTIMATE_EXTENSIONS to what the server expects, or run the query with invoke_graphql and returnCost")
+	}
+	doc, err := parseDocument(operation)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse operation: %w", err)
+	}
+	for _, op := range doc.Operations {
+		if op.Operation != "query" {
+			return "", fmt.Errorf("only queries can be estimated, since a server that ignores the cost-only signal would execute a %s", op.Operation)
+		}
+	}
+	if err := checkOperationAllowed(operation); err != nil {
+		return "", err
+	}
+
+	req := graphqlRequest{header: make(http.Header)}
+	if req.Query, req.OperationName, err = resolveOperationName(operation, ""); err != nil {
+		return "", err
+	}
+	vars, err := parseVariables(variablesJSON)
+	if err != nil {
+		return "", err
+	}
+	var secrets []string
+	if req.Variables, secrets, err = resolveSecretReferences(applyDefaultVariables(operation, vars)); err != nil {
+		return "", err
+	}
+	for k, v := range costEstimateHeaders {
+		req.header.Set(k, fmt.Sprint(v))
+	}
+	req.Extensions = costEstimateExtensions
+
+	res, err := executeGraphQL(ctx, req)
+	if err != nil {
+		return "", scrubSecretsError(err, secrets)
+	}
+	scrubSecretsResponse(res, secrets)
+
+	var sb strings.Builder
+	if cost := costExtensions(res.Extensions); cost != nil {
+		encoded, err := json.MarshalIndent(cost, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		sb.Write(encoded)
+	} else {
+		sb.WriteString("The server returned no cost information in its response extensions.")
+	}
+	// Errors may explain the estimate, e.g. a query over the cost limit
+	for _, e := range res.Errors {
+		sb.WriteString("\nError: " + e.Message)
+	}
+	return sb.String(), nil
+}
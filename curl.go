@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Tool: to_curl
+const toCurlToolDescription = `Print a ready-to-run curl command that sends an operation to the endpoint the way invoke_graphql would, with the current headers.
+Credentials (Authorization, cookies, API keys and AUTH_CONFIG headers) and sensitive variables are replaced with [REDACTED] unless includeSecrets is true.
+
+Best Practices:
+- Use this tool to reproduce a call outside the bridge, e.g. to share it or debug it by hand.
+- Only pass includeSecrets when the command stays on a trusted machine; it then contains live credentials.
+
+Arguments:
+- operation (string, Required): The GraphQL operation.
+- variables (string, Optional): A JSON-encoded object of variables.
+- operationName (string, Optional): The operation to run when the document defines several.
+- includeSecrets (boolean, Optional): Include the real credentials and resolve ${env:NAME} references (default false).
+
+Example Usage:
+Request:
+  to_curl(operation: "query { job(id: $id) { name } }", variables: "{\"id\": \"42\"}")
+
+Response:
+  curl 'https://api.example.com/graphql' \
+    -X POST \
+    -H 'Accept: application/json; charset=utf-8' \
+    -H 'Authorization: [REDACTED]' \
+    -H 'Content-Type: application/json; charset=utf-8' \
+    --data-raw '{"query":"query MCPQuery_job($id: ID!) { job(id: $id) { name } }","variables":{"id":"42"},"operationName":"MCPQuery_job"}'
+`
+
+// sensitiveHeaders are always redacted by to_curl, in addition to the headers
+// AUTH_CONFIG sends credentials in.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+
+// curlCommand renders a curl command equivalent to invoking operation with
+// the given variables.
+func curlCommand(ctx context.Context, operation, variablesJSON, requestedName string, includeSecrets bool) (string, error) {
+	if graphqlEndpoint == "" {
+		return "", fmt.Errorf("ADDRESS is not set")
+	}
+	query, operationName, err := resolveOperationName(operation, requestedName)
+	if err != nil {
+		return "", err
+	}
+	vars, err := parseVariables(variablesJSON)
+	if err != nil {
+		return "", err
+	}
+	req := graphqlRequest{Query: query, OperationName: operationName, Variables: applyDefaultVariables(operation, vars)}
+
+	header := http.Header{}
+	setDefaultHeaders(header)
+	for k, v := range getHeaders() {
+		header[k] = v
+	}
+
+	if includeSecrets {
+		if err := applyConfiguredAuth(ctx, header, graphqlEndpoint); err != nil {
+			return "", err
+		}
+		if req.Variables, _, err = resolveSecretReferences(req.Variables); err != nil {
+			return "", err
+		}
+	} else {
+		// Show where AUTH_CONFIG puts its credential without fetching it
+		if e := authEntryFor(graphqlEndpoint); e != nil && header.Get(e.headerName()) == "" {
+			header.Set(e.headerName(), auditRedacted)
+		}
+		for _, name := range append(sensitiveHeaders, authHeaderNames()...) {
+			if header.Get(name) != "" {
+				header.Set(name, auditRedacted)
+			}
+		}
+		req.Variables = redactVariables(req.Variables)
+	}
+
+	body, err := encodeGraphQLRequest(req)
+	if err != nil {
+		return "", err
+	}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lines := []string{"curl " + shellQuote(graphqlEndpoint), "-X POST"}
+	for _, name := range names {
+		for _, value := range header[name] {
+			lines = append(lines, "-H "+shellQuote(name+": "+value))
+		}
+	}
+	lines = append(lines, "--data-raw "+shellQuote(string(body)))
+	return strings.Join(lines, " \\\n  "), nil
+}
+
+// shellQuote quotes s for a POSIX shell: it is wrapped in single quotes, and
+// each single quote inside closes the quoting, adds an escaped quote and
+// reopens it.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
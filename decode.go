@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// downloadsDir is the directory invoke_graphql may save decoded values to.
+var downloadsDir = getenv("DOWNLOADS_DIR")
+
+// Decoding modes of the decode argument of invoke_graphql.
+const (
+	decodeBase64 = "base64"
+	decodeHex    = "hex"
+)
+
+// decodedValue is a string of the response decoded to bytes.
+type decodedValue struct {
+	// Path is where the value is in the data, e.g. "exportReport.file".
+	Path     string
+	Data     []byte
+	MIMEType string
+	// SavedTo is the file the bytes were written to, if any.
+	SavedTo string
+}
+
+// checkDecodeOptions validates the decoding arguments before the operation
+// runs, so a mutation isn't executed only for its result to be unusable.
+func checkDecodeOptions(opts invokeOptions) error {
+	if opts.DecodePath == "" {
+		if opts.Decode != "" || opts.SaveTo != "" {
+			return errors.New("decode and saveTo need decodePath, the path of the value to decode")
+		}
+		return nil
+	}
+	if opts.Decode != "" && opts.Decode != decodeBase64 && opts.Decode != decodeHex {
+		return fmt.Errorf("unknown decode mode %q: use %q or %q", opts.Decode, decodeBase64, decodeHex)
+	}
+	if opts.SaveTo != "" && downloadsDir == "" {
+		return errors.New("saveTo requires DOWNLOADS_DIR to be set")
+	}
+	return nil
+}
+
+// decodeResponseValue finds the string at path in data, as valueAtPath
+// does, and decodes it with mode.
+func decodeResponseValue(data interface{}, path, mode string) (*decodedValue, error) {
+	if mode == "" {
+		mode = decodeBase64
+	}
+
+	value, err := valueAtPath(data, path)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a string to decode, got %s", path, describeJSONValue(value))
+	}
+
+	decoded := &decodedValue{Path: path}
+	if mode == decodeHex {
+		decoded.Data, err = hex.DecodeString(strings.TrimSpace(s))
+	} else {
+		decoded.Data, decoded.MIMEType, err = decodeBase64Value(s)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s is not valid %s: %w", path, mode, err)
+	}
+	if decoded.MIMEType == "" {
+		decoded.MIMEType = http.DetectContentType(decoded.Data)
+	}
+	return decoded, nil
+}
+
+// valueAtPath returns the value at path in decoded JSON data. Paths are
+// dot-separated field names and list indices, e.g. "files.0.content" or
+// "files[0].content".
+func valueAtPath(data interface{}, path string) (interface{}, error) {
+	value := data
+	normalized := strings.ReplaceAll(strings.ReplaceAll(path, "[", "."), "]", "")
+	var walked []string
+	for _, step := range strings.Split(strings.Trim(normalized, "."), ".") {
+		walked = append(walked, step)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			child, ok := v[step]
+			if !ok {
+				keys := make([]string, 0, len(v))
+				for k := range v {
+					keys = append(keys, k)
+				}
+				return nil, fmt.Errorf("%s", unknownKeyProblem(strings.Join(walked, "."), "not in the response", step, keys))
+			}
+			value = child
+		case []interface{}:
+			i, err := strconv.Atoi(step)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("%s: expected an index between 0 and %d", strings.Join(walked, "."), len(v)-1)
+			}
+			value = v[i]
+		default:
+			return nil, fmt.Errorf("%s: the response has %s there, which has no fields", strings.Join(walked, "."), describeJSONValue(v))
+		}
+	}
+	return value, nil
+}
+
+// decodeBase64Value decodes standard or URL-safe base64, padded or not. A
+// data URL ("data:application/pdf;base64,...") gives its media type too.
+func decodeBase64Value(s string) ([]byte, string, error) {
+	var mediaType string
+	if rest, ok := strings.CutPrefix(s, "data:"); ok {
+		header, payload, found := strings.Cut(rest, ",")
+		if !found || !strings.HasSuffix(header, ";base64") {
+			return nil, "", errors.New("a data URL must be base64-encoded")
+		}
+		mediaType, s = strings.TrimSuffix(header, ";base64"), payload
+	}
+	s = strings.Join(strings.Fields(s), "")
+	var err error
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		var data []byte
+		if data, err = enc.DecodeString(s); err == nil {
+			return data, mediaType, nil
+		}
+	}
+	return nil, "", err
+}
+
+// saveDownload writes data to path inside DOWNLOADS_DIR and returns the file
+// written. Relative paths are resolved against DOWNLOADS_DIR.
+func saveDownload(path string, data []byte) (string, error) {
+	if downloadsDir == "" {
+		return "", errors.New("saveTo requires DOWNLOADS_DIR to be set")
+	}
+	root, err := filepath.Abs(downloadsDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid DOWNLOADS_DIR: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	path = filepath.Clean(path)
+	if !isWithinDir(root, path) || path == root {
+		return "", fmt.Errorf("%s is outside DOWNLOADS_DIR", path)
+	}
+
+	// Refuse to write through a symlink out of the directory: resolve the
+	// deepest directory of path that exists, before creating the missing
+	// ones under it
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return "", fmt.Errorf("failed to save the decoded value: %w", err)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid DOWNLOADS_DIR: %w", err)
+	}
+	existing := filepath.Dir(path)
+	for existing != root {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	realDir, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", fmt.Errorf("failed to save the decoded value: %w", err)
+	}
+	if !isWithinDir(realRoot, realDir) {
+		return "", fmt.Errorf("%s is outside DOWNLOADS_DIR", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to save the decoded value: %w", err)
+	}
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		return "", fmt.Errorf("%s is a symlink, refusing to write through it", path)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to save the decoded value: %w", err)
+	}
+	return path, nil
+}
+
+// isWithinDir reports whether path is dir or inside it; both must be clean
+// absolute paths.
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// String summarizes the decoded value, e.g. "Decoded 10240 bytes
+// (application/pdf) from exportReport.file and saved them to /tmp/r.pdf".
+func (d *decodedValue) String() string {
+	s := fmt.Sprintf("Decoded %s (%s) from %s", pluralize(len(d.Data), "byte", "bytes"), d.MIMEType, d.Path)
+	if d.SavedTo != "" {
+		return s + " and saved them to " + d.SavedTo + "."
+	}
+	return s + "; the bytes follow as a resource."
+}
+
+// blobResource is an embedded resource holding binary data, which the
+// EmbeddedResource type of mcp-go can't carry.
+type blobResource struct {
+	Type     string                   `json:"type"`
+	Resource mcp.BlobResourceContents `json:"resource"`
+}
+
+// content returns the decoded bytes as tool result content: an image for
+// images, an embedded binary resource otherwise.
+func (d *decodedValue) content() interface{} {
+	blob := base64.StdEncoding.EncodeToString(d.Data)
+	mediaType, _, _ := mime.ParseMediaType(d.MIMEType)
+	if strings.HasPrefix(mediaType, "image/") {
+		return mcp.NewImageContent(blob, mediaType)
+	}
+	return blobResource{
+		Type: "resource",
+		Resource: mcp.BlobResourceContents{
+			ResourceContents: mcp.ResourceContents{URI: "graphql-mcp://response/" + d.Path, MIMEType: d.MIMEType},
+			Blob:             blob,
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestSaveDownloadSymlinks checks that saveTo can't escape DOWNLOADS_DIR
+// through a symlink, whether the directories under it exist or not.
+func TestSaveDownloadSymlinks(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	previous := downloadsDir
+	downloadsDir = root
+	t.Cleanup(func() { downloadsDir = previous })
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "target.txt"), filepath.Join(root, "file.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		saveTo  string
+		wantErr string
+	}{
+		{name: "new directories", saveTo: "reports/2026/r.txt"},
+		{name: "absolute path inside", saveTo: filepath.Join(root, "a.txt")},
+		{name: "parent directory", saveTo: "../escape.txt", wantErr: "outside DOWNLOADS_DIR"},
+		{name: "through an existing symlink", saveTo: "link/pwn.txt", wantErr: "outside DOWNLOADS_DIR"},
+		{name: "new directory under a symlink", saveTo: "link/newdir/pwn.txt", wantErr: "outside DOWNLOADS_DIR"},
+		{name: "symlinked file", saveTo: "file.txt", wantErr: "is a symlink"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved, err := saveDownload(tt.saveTo, []byte("data"))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if data, err := os.ReadFile(saved); err != nil || string(data) != "data" {
+					t.Errorf("ReadFile(%s) = %q, %v", saved, data, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("saveDownload(%q) error = %v, want one containing %q", tt.saveTo, err, tt.wantErr)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) > 0 {
+		t.Errorf("files were written outside DOWNLOADS_DIR: %v", entries)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tool: list_deprecated
+const listDeprecatedToolDescription = `Report every deprecated field and enum value of your GraphQL schema, grouped by type, with the deprecation reasons.
+Optionally checks a set of operations and flags the deprecated items they still use.
+
+Best Practices:
+- Use this tool to plan migrations away from deprecated parts of the API.
+- Pass the operations your integration runs in 'operations' to find the usages that need updating.
+- Avoid deprecated fields when writing new operations.
+
+Arguments:
+- operations (string, Optional): One or more GraphQL operations (and fragments) to check for usages of deprecated items.
+
+Example Usage:
+Request:
+  list_deprecated(operations: "query { candidate(id: \"1\") { fullName } }")
+
+Response:
+  Deprecated:
+  Candidate
+  	fullName: No longer supported. Use firstName and lastName.
+  CandidateStatus (enum)
+  	ARCHIVED: Use INACTIVE.
+
+  Usages in the provided operations:
+  Candidate.fullName (line 1, column 35): No longer supported. Use firstName and lastName.
+`
+
+// listDeprecated lists the deprecated fields and enum values of the schema and,
+// when operations is set, the places where those operations use them.
+func listDeprecated(ctx context.Context, operations string) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var doc *astDocument
+	if strings.TrimSpace(operations) != "" {
+		if doc, err = parseDocument(operations); err != nil {
+			return "", fmt.Errorf("failed to parse operations: %w", err)
+		}
+	}
+
+	types := make([]*schemaType, 0, len(schema.Types))
+	for _, t := range schema.Types {
+		if !strings.HasPrefix(t.Name, "__") {
+			types = append(types, t)
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+
+	var sb strings.Builder
+	sb.WriteString("Deprecated:\n")
+	found := false
+	for _, t := range types {
+		var lines []string
+		for _, f := range t.Fields {
+			if f.IsDeprecated {
+				lines = append(lines, fmt.Sprintf("\t%s: %s", f.Name, deprecationReasonOrDefault(f.DeprecationReason)))
+			}
+		}
+		for _, v := range t.EnumValues {
+			if v.IsDeprecated {
+				lines = append(lines, fmt.Sprintf("\t%s: %s", v.Name, deprecationReasonOrDefault(v.DeprecationReason)))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		found = true
+		header := t.Name
+		if t.Kind == "ENUM" {
+			header += " (enum)"
+		}
+		sb.WriteString(header + "\n" + strings.Join(lines, "\n") + "\n")
+	}
+	if !found {
+		sb.WriteString("None\n")
+	}
+
+	if doc != nil {
+		usages := findDeprecatedUsages(schema, doc)
+		sb.WriteString("\nUsages in the provided operations:\n")
+		if len(usages) == 0 {
+			sb.WriteString("None\n")
+		}
+		for _, u := range usages {
+			sb.WriteString(u + "\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// deprecationReasonOrDefault returns the reason, or a placeholder when the
+// schema doesn't give one.
+func deprecationReasonOrDefault(reason string) string {
+	if reason = strings.TrimSpace(reason); reason != "" {
+		return reason
+	}
+	return "(no reason given)"
+}
+
+// findDeprecatedUsages walks the operations of doc and reports every
+// deprecated field they select and every deprecated enum value they pass as
+// a literal argument.
+func findDeprecatedUsages(schema *schemaModel, doc *astDocument) []string {
+	var usages []string
+	seen := make(map[string]bool)
+	report := func(s string) {
+		if !seen[s] {
+			seen[s] = true
+			usages = append(usages, s)
+		}
+	}
+
+	var checkValue func(ref *typeRef, v *astValue, line, column int)
+	checkValue = func(ref *typeRef, v *astValue, line, column int) {
+		if ref == nil || v == nil {
+			return
+		}
+		if ref.isList() && v.Kind == valueList {
+			inner := ref
+			if inner.isNonNull() {
+				inner = inner.OfType
+			}
+			for _, item := range v.List {
+				checkValue(inner.OfType, item, line, column)
+			}
+			return
+		}
+		t := schema.typeByName(ref.namedType())
+		if t == nil {
+			return
+		}
+		switch {
+		case t.Kind == "ENUM" && v.Kind == valueEnum:
+			for _, ev := range t.EnumValues {
+				if ev.Name == v.Raw && ev.IsDeprecated {
+					report(fmt.Sprintf("%s.%s (line %d, column %d): %s", t.Name, ev.Name, line, column, deprecationReasonOrDefault(ev.DeprecationReason)))
+				}
+			}
+		case t.Kind == "INPUT_OBJECT" && v.Kind == valueObject:
+			for _, f := range v.Fields {
+				for _, def := range t.InputFields {
+					if def.Name == f.Name {
+						checkValue(def.Type, f.Value, line, column)
+					}
+				}
+			}
+		}
+	}
+
+	var walk func(sels []*astSelection, parent string, depth int)
+	walk = func(sels []*astSelection, parent string, depth int) {
+		// Guard against fragments that (invalidly) select themselves
+		if depth > 64 {
+			return
+		}
+		for _, fs := range doc.fieldSelections(sels) {
+			typeName := parent
+			if fs.TypeCondition != "" {
+				typeName = fs.TypeCondition
+			}
+			f := lookupField(schema, typeName, fs.Field.Name)
+			if f == nil {
+				continue
+			}
+			if f.IsDeprecated {
+				report(fmt.Sprintf("%s.%s (line %d, column %d): %s", typeName, f.Name, fs.Field.Line, fs.Field.Column, deprecationReasonOrDefault(f.DeprecationReason)))
+			}
+			for _, arg := range fs.Field.Arguments {
+				for _, def := range f.Args {
+					if def.Name == arg.Name {
+						checkValue(def.Type, arg.Value, fs.Field.Line, fs.Field.Column)
+					}
+				}
+			}
+			walk(fs.Field.SelectionSet, f.Type.namedType(), depth+1)
+		}
+	}
+	for _, op := range doc.Operations {
+		walk(op.SelectionSet, schema.rootType(op.Operation), 0)
+	}
+	return usages
+}
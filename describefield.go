@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tool: describe_field
+const describeFieldToolDescription = `Describe a single field of a type in depth: its signature, description and deprecation, the fully resolved return type, and each argument with its type, nullability, default value and description.
+This is a precise, low-token lookup when describing the whole type would be overkill.
+
+Best Practices:
+- Use this tool when you already know the field you need, e.g. from describe or search_schema.
+- Root operations can be looked up as query.name, mutation.name or subscription.name, or through the root type name (e.g. Query.jobs).
+
+Arguments:
+- field (string, Required): The field as "Type.field", e.g. Job.applications.
+
+Example Usage:
+Request:
+  describe_field(field: "Job.applications")
+
+Response:
+  Job.applications(status: ApplicationStatus, first: Int = 20): [Application!]!
+  	The applications received for the job.
+  Returns: [Application!]! (non-null list of non-null Application, an OBJECT)
+  Arguments:
+  	status: ApplicationStatus (optional, an ENUM) — only applications with this status
+  	first: Int = 20 (optional, a SCALAR) — the maximum number of applications
+`
+
+// describeSchemaField renders the field named "Type.field" with its return
+// type and arguments resolved.
+func describeSchemaField(ctx context.Context, path string) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	typeName, fieldName, ok := strings.Cut(strings.TrimSpace(path), ".")
+	if !ok || typeName == "" || fieldName == "" {
+		return "", fmt.Errorf("invalid field %q, expected Type.field", path)
+	}
+	if root := schema.rootType(typeName); root != "" {
+		typeName = root
+	}
+	t := schema.typeByName(typeName)
+	if t == nil {
+		names := make([]string, 0, len(schema.Types))
+		for _, t := range schema.Types {
+			names = append(names, t.Name)
+		}
+		return "", fmt.Errorf("type '%s' not found in schema. Did you mean: %s?", typeName, strings.Join(suggestNames(typeName, names, maxSuggestions), ", "))
+	}
+	f := t.field(fieldName)
+	if f == nil {
+		names := make([]string, 0, len(t.Fields))
+		for _, f := range t.Fields {
+			names = append(names, f.Name)
+		}
+		return "", fmt.Errorf("%s has no field '%s'. Did you mean: %s?", t.Name, fieldName, strings.Join(suggestNames(fieldName, names, maxSuggestions), ", "))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(t.Name + "." + argsSignature(f))
+	if desc := strings.TrimSpace(f.Description); desc != "" {
+		sb.WriteString("\n\t" + strings.ReplaceAll(desc, "\n", "\n\t"))
+	}
+	if f.IsDeprecated {
+		sb.WriteString("\n\tDeprecated: " + deprecationReasonOrDefault(f.DeprecationReason))
+	}
+	sb.WriteString("\nReturns: " + f.Type.String() + " (" + resolvedTypeString(schema, f.Type) + ")")
+	if len(f.Args) > 0 {
+		sb.WriteString("\nArguments:")
+		for _, a := range f.Args {
+			required := "optional"
+			if a.Type.isNonNull() && a.DefaultValue == nil {
+				required = "required"
+			}
+			sb.WriteString(fmt.Sprintf("\n\t%s (%s, %s)", inputValueString(a), required, kindArticle(schema, a.Type.namedType())))
+			if desc := strings.Join(strings.Fields(a.Description), " "); desc != "" {
+				sb.WriteString(" — " + desc)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// argsSignature renders a field as "name(arg: Type = default): ReturnType",
+// leaving out the parentheses when it has no arguments.
+func argsSignature(f *schemaField) string {
+	if len(f.Args) == 0 {
+		return f.Name + ": " + f.Type.String()
+	}
+	return fmt.Sprintf("%s(%s): %s", f.Name, argsWithDefaultsToString(f.Args), f.Type.String())
+}
+
+// resolvedTypeString spells out a type reference, e.g. "non-null list of
+// non-null Application, an OBJECT".
+func resolvedTypeString(schema *schemaModel, ref *typeRef) string {
+	var parts []string
+	for r := ref; r != nil; r = r.OfType {
+		switch r.Kind {
+		case "NON_NULL":
+			parts = append(parts, "non-null")
+		case "LIST":
+			parts = append(parts, "list of")
+		default:
+			parts = append(parts, r.Name+", "+kindArticle(schema, r.Name))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// kindArticle names the kind of the named type with its article, e.g.
+// "an OBJECT", "a SCALAR" or "a UNION".
+func kindArticle(schema *schemaModel, name string) string {
+	kind := "SCALAR"
+	if t := schema.typeByName(name); t != nil {
+		kind = t.Kind
+	}
+	if strings.ContainsRune("AEIO", rune(kind[0])) {
+		return "an " + kind
+	}
+	return "a " + kind
+}
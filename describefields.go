@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// fieldFilter limits describe to some fields of some types: it maps a type
+// name to the names of the fields (or input fields, or enum values) to show.
+type fieldFilter map[string]map[string]bool
+
+// parseFieldFilter parses "Type:field1,field2", with several types separated
+// by ";" (e.g. "Job:id,name;Candidate:email"). Type names may carry a kind
+// prefix such as "type.Job".
+func parseFieldFilter(spec string) (fieldFilter, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	filter := make(fieldFilter)
+	for _, part := range strings.Split(spec, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		typeName, fields, ok := strings.Cut(part, ":")
+		typeName = strings.TrimSpace(typeName)
+		if i := strings.Index(typeName, "."); i >= 0 {
+			typeName = typeName[i+1:]
+		}
+		if !ok || typeName == "" {
+			return nil, fmt.Errorf("invalid fields filter %q, expected Type:field1,field2", part)
+		}
+		if filter[typeName] == nil {
+			filter[typeName] = make(map[string]bool)
+		}
+		for _, f := range strings.Split(fields, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				filter[typeName][f] = true
+			}
+		}
+	}
+	return filter, nil
+}
+
+// forEntity returns the fields to keep when describing entity, or nil when
+// the filter doesn't apply to it. Root operation fields are never filtered.
+func (ff fieldFilter) forEntity(schema *schemaModel, entity string) (*schemaType, map[string]bool) {
+	t := entityType(schema, entity)
+	if t == nil || ff[t.Name] == nil {
+		return nil, nil
+	}
+	return t, ff[t.Name]
+}
+
+// entityType returns the type a describe entity names, or nil when it names
+// a root operation.
+func entityType(schema *schemaModel, entity string) *schemaType {
+	prefix, name := "", entity
+	if i := strings.Index(entity, "."); i >= 0 {
+		prefix, name = entity[:i], entity[i+1:]
+	}
+	if prefix == "query" || prefix == "mutation" || prefix == "subscription" {
+		return nil
+	}
+	t := schema.typeByName(name)
+	if t == nil || schema.isRootType(t.Name) {
+		return nil
+	}
+	return t
+}
+
+// withRequiredArguments returns the entity to describe when only the fields
+// requiring arguments are wanted: its type, limited to those of its fields,
+// further limited to keep when it isn't nil. Entities without fields are
+// returned as they are.
+func withRequiredArguments(schema *schemaModel, entity string, t *schemaType, keep map[string]bool) (*schemaType, map[string]bool) {
+	if t == nil {
+		t = entityType(schema, entity)
+	}
+	if t == nil || len(t.Fields) == 0 {
+		return t, keep
+	}
+	limited := make(map[string]bool)
+	for _, f := range t.Fields {
+		if requiresArguments(f) && (keep == nil || keep[f.Name]) {
+			limited[f.Name] = true
+		}
+	}
+	return t, limited
+}
+
+// warnings lists the filter entries that matched nothing: types that aren't
+// among the described entities and field names the type doesn't have.
+func (ff fieldFilter) warnings(schema *schemaModel, described map[string]bool) []string {
+	typeNames := make([]string, 0, len(ff))
+	for name := range ff {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+
+	var warnings []string
+	for _, typeName := range typeNames {
+		if !described[typeName] {
+			warnings = append(warnings, fmt.Sprintf("Warning: the fields filter for %s was ignored, since %s is not one of the described types.", typeName, typeName))
+			continue
+		}
+		t := schema.typeByName(typeName)
+		var names []string
+		for _, f := range t.Fields {
+			names = append(names, f.Name)
+		}
+		for _, f := range t.InputFields {
+			names = append(names, f.Name)
+		}
+		for _, v := range t.EnumValues {
+			names = append(names, v.Name)
+		}
+		known := make(map[string]bool, len(names))
+		for _, n := range names {
+			known[n] = true
+		}
+		var unknown []string
+		for f := range ff[typeName] {
+			if !known[f] {
+				unknown = append(unknown, f)
+			}
+		}
+		sort.Strings(unknown)
+		for _, f := range unknown {
+			warnings = append(warnings, unknownKeyProblem("Warning: "+typeName+"."+f, typeName+" has no field "+f, f, names))
+		}
+	}
+	return warnings
+}
+
+// filterEntityJSON returns a copy of e limited to the fields in keep.
+func filterEntityJSON(e entityJSON, keep map[string]bool) entityJSON {
+	var fields []fieldJSON
+	for _, f := range e.Fields {
+		if keep[f.Name] {
+			fields = append(fields, f)
+		}
+	}
+	var inputFields []inputValueJSON
+	for _, f := range e.InputFields {
+		if keep[f.Name] {
+			inputFields = append(inputFields, f)
+		}
+	}
+	var enumValues []enumValueJSON
+	for _, v := range e.EnumValues {
+		if keep[v.Name] {
+			enumValues = append(enumValues, v)
+		}
+	}
+	e.Fields, e.InputFields, e.EnumValues = fields, inputFields, enumValues
+	return e
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// entityJSON is the structured form of a describe entity: either a root
+// operation field (Kind QUERY, MUTATION or SUBSCRIPTION) or a named type.
+type entityJSON struct {
+	Name              string           `json:"name"`
+	Kind              string           `json:"kind"`
+	Description       string           `json:"description,omitempty"`
+	Type              string           `json:"type,omitempty"`
+	Nullable          *bool            `json:"nullable,omitempty"`
+	Deprecated        bool             `json:"deprecated,omitempty"`
+	DeprecationReason string           `json:"deprecationReason,omitempty"`
+	Args              []inputValueJSON `json:"args,omitempty"`
+	Fields            []fieldJSON      `json:"fields,omitempty"`
+	InputFields       []inputValueJSON `json:"inputFields,omitempty"`
+	EnumValues        []enumValueJSON  `json:"enumValues,omitempty"`
+	Interfaces        []string         `json:"interfaces,omitempty"`
+	PossibleTypes     []string         `json:"possibleTypes,omitempty"`
+	// Format is the SCALAR_FORMATS hint of a custom scalar.
+	Format string `json:"format,omitempty"`
+}
+
+// fieldJSON is a field of an object or interface type.
+type fieldJSON struct {
+	Name              string           `json:"name"`
+	Description       string           `json:"description,omitempty"`
+	Type              string           `json:"type"`
+	Nullable          bool             `json:"nullable"`
+	Deprecated        bool             `json:"deprecated"`
+	DeprecationReason string           `json:"deprecationReason,omitempty"`
+	Args              []inputValueJSON `json:"args,omitempty"`
+	// Recursive is set when the field has the type it belongs to.
+	Recursive bool `json:"recursive,omitempty"`
+	// RequiresArguments is set when the field has a required argument.
+	RequiresArguments bool `json:"requiresArguments,omitempty"`
+}
+
+// inputValueJSON is an argument or input field.
+type inputValueJSON struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description,omitempty"`
+	Type         string  `json:"type"`
+	Nullable     bool    `json:"nullable"`
+	DefaultValue *string `json:"defaultValue,omitempty"`
+	// Recursive is set when an input field has the type it belongs to.
+	Recursive bool `json:"recursive,omitempty"`
+}
+
+// enumValueJSON is a value of an enum type.
+type enumValueJSON struct {
+	Name              string `json:"name"`
+	Description       string `json:"description,omitempty"`
+	Deprecated        bool   `json:"deprecated"`
+	DeprecationReason string `json:"deprecationReason,omitempty"`
+}
+
+// describeEntitiesJSON renders the named entities as a JSON array, resolving
+// names the same way as the text format ("query.jobs", "type.Job", "jobs").
+// Types in filter are limited to the listed fields; described collects the
+// types that were.
+func describeEntitiesJSON(schema *schemaModel, entities []string, filter fieldFilter, requiredArgsOnly bool, described map[string]bool) (string, error) {
+	out := make([]entityJSON, 0, len(entities))
+	for _, entity := range entities {
+		e, ok := cachedEntityJSON(schema, entity)
+		if !ok {
+			names := make([]string, 0, len(schema.entities))
+			for k := range schema.entities {
+				names = append(names, k)
+			}
+			return "", fmt.Errorf("entity '%s' not found in schema. Did you mean: %s?", entity, strings.Join(suggestNames(entity, names, maxSuggestions), ", "))
+		}
+		t, keep := filter.forEntity(schema, entity)
+		if requiredArgsOnly {
+			t, keep = withRequiredArguments(schema, entity, t, keep)
+		}
+		if keep != nil {
+			e = filterEntityJSON(e, keep)
+			described[t.Name] = true
+		}
+		out = append(out, e)
+	}
+	encoded, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// cachedEntityJSON is lookupEntityJSON memoized on the schema model.
+func cachedEntityJSON(schema *schemaModel, entity string) (entityJSON, bool) {
+	if e, ok := schema.describeJSON.Load(entity); ok {
+		debugf("describe cache hit for %q", entity)
+		return e.(entityJSON), true
+	}
+	debugf("describe cache miss for %q", entity)
+	e, ok := lookupEntityJSON(schema, entity)
+	if ok {
+		schema.describeJSON.Store(entity, e)
+	}
+	return e, ok
+}
+
+// lookupEntityJSON resolves an entity name to its structured form.
+func lookupEntityJSON(schema *schemaModel, entity string) (entityJSON, bool) {
+	prefix, name := "", entity
+	if i := strings.Index(entity, "."); i >= 0 {
+		prefix, name = entity[:i], entity[i+1:]
+	}
+
+	rootKinds := []string{"query", "mutation", "subscription"}
+	for _, operation := range rootKinds {
+		if prefix != "" && prefix != operation {
+			continue
+		}
+		root := schema.typeByName(schema.rootType(operation))
+		if root == nil {
+			continue
+		}
+		if f := root.field(name); f != nil {
+			e := entityJSON{
+				Name:              f.Name,
+				Kind:              strings.ToUpper(operation),
+				Description:       f.Description,
+				Type:              f.Type.String(),
+				Nullable:          boolPtr(!f.Type.isNonNull()),
+				Deprecated:        f.IsDeprecated,
+				DeprecationReason: f.DeprecationReason,
+				Args:              inputValuesJSON(f.Args),
+			}
+			return e, true
+		}
+	}
+
+	kinds := map[string]string{"type": "OBJECT", "scalar": "SCALAR", "enum": "ENUM", "interface": "INTERFACE", "input": "INPUT_OBJECT", "union": "UNION"}
+	if prefix != "" && kinds[prefix] == "" {
+		return entityJSON{}, false
+	}
+	t := schema.typeByName(name)
+	if t == nil || schema.isRootType(t.Name) || (prefix != "" && kinds[prefix] != t.Kind) {
+		return entityJSON{}, false
+	}
+	e := entityJSON{
+		Name:        t.Name,
+		Kind:        t.Kind,
+		Description: t.Description,
+		InputFields: inputValuesJSON(t.InputFields),
+		Format:      scalarFormatHint(t.Name),
+	}
+	for _, f := range t.Fields {
+		e.Fields = append(e.Fields, fieldJSON{
+			Name:              f.Name,
+			Description:       f.Description,
+			Type:              f.Type.String(),
+			Nullable:          !f.Type.isNonNull(),
+			Deprecated:        f.IsDeprecated,
+			DeprecationReason: f.DeprecationReason,
+			Args:              inputValuesJSON(f.Args),
+			Recursive:         isSelfReference(t, f.Type),
+			RequiresArguments: requiresArguments(f),
+		})
+	}
+	for i, f := range t.InputFields {
+		e.InputFields[i].Recursive = isSelfReference(t, f.Type)
+	}
+	for _, v := range t.EnumValues {
+		e.EnumValues = append(e.EnumValues, enumValueJSON{Name: v.Name, Description: v.Description, Deprecated: v.IsDeprecated, DeprecationReason: v.DeprecationReason})
+	}
+	for _, ref := range t.Interfaces {
+		e.Interfaces = append(e.Interfaces, ref.namedType())
+	}
+	for _, ref := range t.PossibleTypes {
+		e.PossibleTypes = append(e.PossibleTypes, ref.namedType())
+	}
+	return e, true
+}
+
+// inputValuesJSON converts arguments or input fields.
+func inputValuesJSON(values []*schemaInputValue) []inputValueJSON {
+	var out []inputValueJSON
+	for _, v := range values {
+		out = append(out, inputValueJSON{
+			Name:         v.Name,
+			Description:  v.Description,
+			Type:         v.Type.String(),
+			Nullable:     !v.Type.isNonNull(),
+			DefaultValue: v.DefaultValue,
+		})
+	}
+	return out
+}
+
+// boolPtr returns a pointer to b, for optional JSON booleans.
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tool: diff_operations
+const diffOperationsToolDescription = `Compare two versions of an operation and report what changed: fields added or removed, changed arguments, aliases and directives, and added, removed or changed variables.
+The operations are compared as parsed documents, not as text, so formatting, comments, the order of fields and arguments, and moving fields into inline fragments without a type condition don't count as changes. Fragment spreads are expanded.
+
+Best Practices:
+- Use this tool to find out why the shape of a response changed between two versions of a query.
+- Fields are identified by their path of response keys (aliases or names), e.g. jobs.applications.id; fields of a fragment on a specific type appear as jobs.(on Job).title.
+
+Arguments:
+- before (string, Required): The original operation.
+- after (string, Required): The changed operation.
+- operationName (string, Optional): The operation to compare when the documents define several.
+
+Example Usage:
+Request:
+  diff_operations(before: "query Jobs($first: Int) { jobs(first: $first) { id location } }", after: "query Jobs($first: Int!, $status: JobStatus) { jobs(first: $first, status: $status) { id title } }")
+
+Response:
+  Found 5 differences.
+
+  Variables:
+  + $status: JobStatus
+  ~ $first: Int -> Int!
+
+  Fields:
+  ~ jobs: arguments (first: $first) -> (first: $first, status: $status)
+  - jobs.location
+  + jobs.title
+`
+
+// diffOperations compares the operations selected by operationName in the
+// before and after documents and returns a readable summary of the changes.
+func diffOperations(before, after, operationName string) (string, error) {
+	beforeDoc, beforeOp, err := parseSingleOperation(before, operationName)
+	if err != nil {
+		return "", fmt.Errorf("before: %w", err)
+	}
+	afterDoc, afterOp, err := parseSingleOperation(after, operationName)
+	if err != nil {
+		return "", fmt.Errorf("after: %w", err)
+	}
+
+	var operation []string
+	if beforeOp.Operation != afterOp.Operation || beforeOp.Name != afterOp.Name {
+		operation = append(operation, "~ "+operationLabel(beforeOp)+" -> "+operationLabel(afterOp))
+	}
+	if b, a := directivesString(beforeOp.Directives), directivesString(afterOp.Directives); b != a {
+		operation = append(operation, "~ directives "+orNone(b)+" -> "+orNone(a))
+	}
+	variables := diffVariables(beforeOp.VariableDefinitions, afterOp.VariableDefinitions)
+	var fields []string
+	diffSelections(&fields, "", beforeDoc, beforeOp.SelectionSet, afterDoc, afterOp.SelectionSet)
+
+	total := len(operation) + len(variables) + len(fields)
+	if total == 0 {
+		return "No differences: the operations select the same fields with the same arguments, directives and variables.", nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Found %s.\n", pluralize(total, "difference", "differences"))
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{{"Operation", operation}, {"Variables", variables}, {"Fields", fields}} {
+		if len(section.lines) > 0 {
+			sb.WriteString("\n" + section.title + ":\n" + strings.Join(section.lines, "\n") + "\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// operationLabel names an operation, e.g. "query Jobs".
+func operationLabel(op *astOperation) string {
+	if op.Name == "" {
+		return "anonymous " + op.Operation
+	}
+	return op.Operation + " " + op.Name
+}
+
+// diffVariables reports the variables added, removed or redeclared with a
+// different type, default value or directives.
+func diffVariables(before, after []*astVariableDefinition) []string {
+	declaration := func(def *astVariableDefinition) string {
+		s := def.Type.String()
+		if def.DefaultValue != nil {
+			s += " = " + canonicalValue(def.DefaultValue)
+		}
+		if dirs := directivesString(def.Directives); dirs != "" {
+			s += " " + dirs
+		}
+		return s
+	}
+	old := make(map[string]*astVariableDefinition, len(before))
+	for _, def := range before {
+		old[def.Name] = def
+	}
+	var added, changed, removed []string
+	declared := make(map[string]bool, len(after))
+	for _, def := range after {
+		declared[def.Name] = true
+		prev, ok := old[def.Name]
+		switch {
+		case !ok:
+			added = append(added, "+ $"+def.Name+": "+declaration(def))
+		case declaration(prev) != declaration(def):
+			changed = append(changed, "~ $"+def.Name+": "+declaration(prev)+" -> "+declaration(def))
+		}
+	}
+	for _, def := range before {
+		if !declared[def.Name] {
+			removed = append(removed, "- $"+def.Name+": "+declaration(def))
+		}
+	}
+	return append(append(removed, added...), changed...)
+}
+
+// diffField is a field of a selection set merged across the fragments and
+// repeated selections that select it.
+type diffField struct {
+	Name       string
+	Arguments  string
+	Directives string
+	Selections []*astSelection
+}
+
+// diffFields flattens sels into its fields by response key, in selection
+// order, expanding fragments. Fields of a fragment with a type condition are
+// keyed "(on Type).key"; fields selected several times have their
+// subselections merged, as the server does.
+func diffFields(doc *astDocument, sels []*astSelection) ([]string, map[string]*diffField) {
+	var keys []string
+	fields := make(map[string]*diffField)
+	for _, fs := range doc.fieldSelections(sels) {
+		key := fs.Field.responseKey()
+		if fs.TypeCondition != "" {
+			key = "(on " + fs.TypeCondition + ")." + key
+		}
+		f, ok := fields[key]
+		if !ok {
+			f = &diffField{Name: fs.Field.Name, Arguments: parenthesizedArguments(fs.Field.Arguments), Directives: directivesString(fs.Field.Directives)}
+			fields[key] = f
+			keys = append(keys, key)
+		}
+		f.Selections = append(f.Selections, fs.Field.SelectionSet...)
+	}
+	return keys, fields
+}
+
+// diffSelections appends to out the differences between two selection sets
+// at path: removed fields, then added and changed fields in the order of the
+// after document, descending into the fields both select.
+func diffSelections(out *[]string, path string, beforeDoc *astDocument, before []*astSelection, afterDoc *astDocument, after []*astSelection) {
+	beforeKeys, beforeFields := diffFields(beforeDoc, before)
+	afterKeys, afterFields := diffFields(afterDoc, after)
+	for _, key := range beforeKeys {
+		if afterFields[key] == nil {
+			*out = append(*out, "- "+path+key)
+		}
+	}
+	for _, key := range afterKeys {
+		a, b := afterFields[key], beforeFields[key]
+		if b == nil {
+			*out = append(*out, "+ "+path+key)
+			continue
+		}
+		var changes []string
+		if b.Name != a.Name {
+			changes = append(changes, "field "+b.Name+" -> "+a.Name)
+		}
+		if b.Arguments != a.Arguments {
+			changes = append(changes, "arguments "+orNone(b.Arguments)+" -> "+orNone(a.Arguments))
+		}
+		if b.Directives != a.Directives {
+			changes = append(changes, "directives "+orNone(b.Directives)+" -> "+orNone(a.Directives))
+		}
+		if len(changes) > 0 {
+			*out = append(*out, "~ "+path+key+": "+strings.Join(changes, "; "))
+		}
+		diffSelections(out, path+key+".", beforeDoc, b.Selections, afterDoc, a.Selections)
+	}
+}
+
+// directivesString renders directives with their arguments sorted, e.g.
+// "@include(if: $withTitle)", or "" when there are none.
+func directivesString(dirs []*astDirective) string {
+	rendered := make([]string, len(dirs))
+	for i, d := range dirs {
+		rendered[i] = "@" + d.Name + parenthesizedArguments(d.Arguments)
+	}
+	return strings.Join(rendered, " ")
+}
+
+// parenthesizedArguments renders arguments as written in an operation, e.g.
+// "(first: 10, status: OPEN)", or "" when there are none.
+func parenthesizedArguments(args []*astArgument) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return "(" + argumentsString(args) + ")"
+}
+
+// canonicalValue renders a value as a GraphQL literal with the fields of
+// input objects sorted by name, since their order doesn't matter.
+func canonicalValue(v *astValue) string {
+	switch v.Kind {
+	case valueList:
+		items := make([]string, len(v.List))
+		for i, item := range v.List {
+			items[i] = canonicalValue(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case valueObject:
+		fields := make([]string, len(v.Fields))
+		for i, f := range v.Fields {
+			fields[i] = f.Name + ": " + canonicalValue(f.Value)
+		}
+		sort.Strings(fields)
+		return "{" + strings.Join(fields, ", ") + "}"
+	case valueString:
+		return quoteString(v.Raw)
+	}
+	return v.String()
+}
+
+// orNone returns s, or "(none)" when it is empty.
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
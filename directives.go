@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"strings"
+)
+
+// Tool: list_directives
+const listDirectivesToolDescription = `Retrieve a complete list of all directives declared by your GraphQL schema.
+Directives such as @auth, @deprecated or @cacheControl change how fields and operations behave, so knowing them helps you understand constraints before invoking operations.
+
+Best Practices:
+- Use this tool to discover custom directives (e.g. which fields require authentication).
+- Check the locations to learn where each directive may be applied.
+
+Arguments:
+- None
+
+Example Usage:
+Request:
+  list_directives()
+
+Response:
+  Directives:
+  @auth(requires: Role!) on OBJECT | FIELD_DEFINITION
+  	Restricts access to authenticated users with the given role.
+  @deprecated(reason: String) on FIELD_DEFINITION | ENUM_VALUE
+  	Marks an element of a GraphQL schema as no longer supported.
+`
+
+// listGraphQLDirectives retrieves all directives
+// declared by the GraphQL schema and formats them as a string.
+func listGraphQLDirectives(ctx context.Context) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("Directives:\n")
+	for _, d := range schema.Directives {
+		sb.WriteString(prettyPrintDirective(d) + "\n")
+	}
+	return sb.String(), nil
+}
+
+// prettyPrintDirective renders a directive as "@name(args) on LOCATION | ...",
+// followed by its description on an indented line when present.
+func prettyPrintDirective(d *schemaDirective) string {
+	s := "@" + d.Name
+	if len(d.Args) > 0 {
+		s += "(" + argsToString(d.Args) + ")"
+	}
+	if len(d.Locations) > 0 {
+		s += " on " + strings.Join(d.Locations, " | ")
+	}
+	if desc := strings.TrimSpace(d.Description); desc != "" {
+		s += "\n\t" + strings.ReplaceAll(desc, "\n", "\n\t")
+	}
+	return s
+}
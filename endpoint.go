@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// defaultGraphQLPath is appended to an ADDRESS that has no path when
+// GRAPHQL_PATH is not set.
+const defaultGraphQLPath = "/graphql"
+
+// resolveEndpoint builds the endpoint URL from ADDRESS and GRAPHQL_PATH. An
+// address without a scheme is assumed to be https, and the path is joined to
+// the address's own path with exactly one slash between them.
+func resolveEndpoint(address, path string) (string, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return "", nil
+	}
+	if !strings.Contains(address, "://") {
+		log.Printf("Warning: ADDRESS %q has no scheme, using https://%s", address, address)
+		address = "https://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", fmt.Errorf("invalid ADDRESS %q: %w", address, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid ADDRESS %q: the scheme must be http or https", address)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid ADDRESS %q: it has no host", address)
+	}
+
+	if path == "" && strings.Trim(u.Path, "/") == "" {
+		path = defaultGraphQLPath
+	}
+	if path != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(path, "/")
+		u.RawPath = ""
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationFromEnv parses a time.Duration (e.g. "30s", "5m") from the named
+// environment variable, falling back to def when it is unset or invalid.
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	v := getenv(name)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, using %s: %v", name, v, def, err)
+		return def
+	}
+	return d
+}
+
+// boolFromEnv parses a boolean ("true", "1", "false", ...) from the named
+// environment variable, returning false when it is unset or invalid.
+func boolFromEnv(name string) bool {
+	v := getenv(name)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, expected a boolean: %v", name, v, err)
+		return false
+	}
+	return b
+}
+
+// strictBoolFromEnv parses a boolean from the named environment variable
+// like boolFromEnv, but reports an invalid value as an error instead of
+// reading it as false, for switches that must not silently turn off.
+func strictBoolFromEnv(name string) (bool, error) {
+	v := getenv(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: expected true or false", name, v)
+	}
+	return b, nil
+}
+
+// listFromEnv splits the named environment variable on commas, trimming
+// whitespace and dropping empty entries.
+func listFromEnv(name string) []string {
+	var list []string
+	for _, item := range strings.Split(getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// jsonObjectFromEnv decodes a JSON object from the named environment
+// variable, returning nil when it is unset or invalid.
+func jsonObjectFromEnv(name string) map[string]interface{} {
+	v := getenv(name)
+	if v == "" {
+		return nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(v), &obj); err != nil {
+		log.Printf("Warning: invalid %s, expected a JSON object: %v", name, err)
+		return nil
+	}
+	return obj
+}
+
+// intFromEnv parses an integer from the named environment variable, falling
+// back to def when it is unset or invalid.
+func intFromEnv(name string, def int) int {
+	v := getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Warning: invalid %s %q, using %d: %v", name, v, def, err)
+		return def
+	}
+	return n
+}
+
+// stringFromEnv returns the named environment variable, or def when it is
+// unset.
+func stringFromEnv(name, def string) string {
+	if v, ok := lookupEnv(name); ok {
+		return v
+	}
+	return def
+}
+
+// getenv returns the named setting from the environment or, when it is
+// unset there, from CONFIG_FILE.
+func getenv(name string) string {
+	v, _ := lookupEnv(name)
+	return v
+}
+
+// lookupEnv is like os.LookupEnv, falling back to the settings from
+// CONFIG_FILE.
+func lookupEnv(name string) (string, bool) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, true
+	}
+	v, ok := fileSettings[name]
+	return v, ok
+}
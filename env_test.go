@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestStrictBoolFromEnv(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    bool
+		wantErr bool
+	}{
+		{"", false, false},
+		{"true", true, false},
+		{"1", true, false},
+		{"FALSE", false, false},
+		{"yes", false, true},
+	}
+	for _, tt := range tests {
+		t.Setenv("TEST_STRICT_BOOL", tt.value)
+		got, err := strictBoolFromEnv("TEST_STRICT_BOOL")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("strictBoolFromEnv(%q) = %v, %v, want %v and error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
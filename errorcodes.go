@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Tool: list_error_codes
+const listErrorCodesToolDescription = `List the error codes the schema documents, with their descriptions, to handle specific error conditions (e.g. retry on RATE_LIMITED, ask for other input on VALIDATION_FAILED).
+Codes are taken from the error code enum, ERROR_CODE_ENUM or by default enums named like ErrorCode, UserErrorType or ErrorReason, and from descriptions mentioning errors or codes, such as "Errors: NOT_FOUND - the job doesn't exist" on a field.
+
+Best Practices:
+- Call this once before writing error handling; many schemas don't document their codes, and then the list is empty.
+- Codes found in descriptions are heuristics: check where each one was found, listed in parentheses.
+- The code of an actual error is usually in extensions.code; explain_error interprets a whole error.
+
+Example Usage:
+Request:
+  list_error_codes()
+
+Response:
+  From enum ErrorCode:
+  - FORBIDDEN: The credentials don't allow the operation.
+  - NOT_FOUND: The requested object doesn't exist.
+  - LEGACY_ERROR (deprecated: Use FORBIDDEN.)
+
+  Documented in descriptions:
+  - RATE_LIMITED: Too many requests, retry later. (mutation.createJob)
+  - DUPLICATE_EMAIL (CandidateInput.email)
+`
+
+// errorCodeEnum names the enum listing the API's error codes; when unset,
+// enums matching errorCodeEnumPattern are used.
+var errorCodeEnum = getenv("ERROR_CODE_ENUM")
+
+// errorCodeEnumPattern matches the usual names of error code enums.
+var errorCodeEnumPattern = regexp.MustCompile(`(?i)error(code|type|kind|reason)s?$`)
+
+// errorCodeLinePattern matches description lines that may document codes.
+var errorCodeLinePattern = regexp.MustCompile(`(?i)error|code|fail|throw|raise`)
+
+// errorCodeTokenPattern matches a code in a description: a quoted or
+// backticked upper-case name, or a bare one with an underscore.
+var errorCodeTokenPattern = regexp.MustCompile("[`'\"]([A-Z][A-Z0-9_]{2,})[`'\"]|\\b([A-Z][A-Z0-9]*(?:_[A-Z0-9]+)+)\\b")
+
+// errorCodeExplanationPattern matches the explanation following a code, as
+// in "NOT_FOUND: the job doesn't exist" or "NOT_FOUND - ...".
+var errorCodeExplanationPattern = regexp.MustCompile(`^\s*(?::|-|–|—)\s*(.+)`)
+
+// documentedErrorCode is an error code found in the schema.
+type documentedErrorCode struct {
+	Code        string
+	Description string
+	// Sources are the schema elements whose descriptions mention the code.
+	Sources []string
+}
+
+// listErrorCodes lists the codes of the error code enums and those found in
+// descriptions.
+func listErrorCodes(ctx context.Context) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	enums, err := errorCodeEnums(schema)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	seen := make(map[string]bool)
+	for _, t := range enums {
+		fmt.Fprintf(&sb, "From enum %s:\n", t.Name)
+		for _, v := range t.EnumValues {
+			seen[v.Name] = true
+			sb.WriteString("- " + v.Name)
+			if desc := firstLine(v.Description); desc != "" {
+				sb.WriteString(": " + desc)
+			}
+			if v.IsDeprecated {
+				sb.WriteString(" (deprecated: " + deprecationReasonOrDefault(v.DeprecationReason) + ")")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	documented := documentedErrorCodes(schema, seen)
+	if len(documented) > 0 {
+		sb.WriteString("Documented in descriptions:\n")
+		for _, c := range documented {
+			sb.WriteString("- " + c.Code)
+			if c.Description != "" {
+				sb.WriteString(": " + c.Description)
+			}
+			sb.WriteString(" (" + strings.Join(c.Sources, ", ") + ")\n")
+		}
+	}
+	if sb.Len() == 0 {
+		return "The schema documents no error codes: it has no error code enum (set ERROR_CODE_ENUM to name it) and no description mentions one. Errors may still carry a code in extensions.code; use explain_error on them.", nil
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// errorCodeEnums returns the ERROR_CODE_ENUM enum, or the enums named like
+// error code enums when it is unset.
+func errorCodeEnums(schema *schemaModel) ([]*schemaType, error) {
+	if name := strings.TrimSpace(errorCodeEnum); name != "" {
+		t := schema.typeByName(name)
+		if t == nil || t.Kind != "ENUM" {
+			var enums []string
+			for _, t := range schema.Types {
+				if t.Kind == "ENUM" && !strings.HasPrefix(t.Name, "__") {
+					enums = append(enums, t.Name)
+				}
+			}
+			problem := "unknown enum " + name
+			if t != nil {
+				problem = name + " is " + kindArticle(schema, t.Name) + ", not an enum"
+			}
+			return nil, fmt.Errorf("%s", unknownKeyProblem("ERROR_CODE_ENUM", problem, name, enums))
+		}
+		return []*schemaType{t}, nil
+	}
+	var enums []*schemaType
+	for _, t := range schema.Types {
+		if t.Kind == "ENUM" && !strings.HasPrefix(t.Name, "__") && errorCodeEnumPattern.MatchString(t.Name) {
+			enums = append(enums, t)
+		}
+	}
+	return enums, nil
+}
+
+// documentedErrorCodes scans the descriptions of the schema for codes not in
+// skip, in schema order.
+func documentedErrorCodes(schema *schemaModel, skip map[string]bool) []*documentedErrorCode {
+	var codes []*documentedErrorCode
+	byCode := make(map[string]*documentedErrorCode)
+	scan := func(description, source string) {
+		for _, line := range strings.Split(description, "\n") {
+			if !errorCodeLinePattern.MatchString(line) {
+				continue
+			}
+			matches := errorCodeTokenPattern.FindAllStringSubmatchIndex(line, -1)
+			for i, m := range matches {
+				code := line[max(m[2], m[4]):max(m[3], m[5])]
+				if skip[code] {
+					continue
+				}
+				// The explanation runs to the end of its sentence or the next code
+				rest := line[m[1]:]
+				if i+1 < len(matches) {
+					rest = line[m[1]:matches[i+1][0]]
+				}
+				c := byCode[code]
+				if c == nil {
+					c = &documentedErrorCode{Code: code}
+					byCode[code] = c
+					codes = append(codes, c)
+				}
+				if e := errorCodeExplanationPattern.FindStringSubmatch(rest); c.Description == "" && e != nil {
+					explanation := e[1]
+					if end := strings.Index(explanation, ". "); end >= 0 {
+						explanation = explanation[:end+1]
+					}
+					c.Description = strings.TrimRight(strings.TrimSpace(explanation), ",;")
+				}
+				if !contains(c.Sources, source) {
+					c.Sources = append(c.Sources, source)
+				}
+			}
+		}
+	}
+
+	for _, t := range schema.Types {
+		if strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		prefix := t.Name + "."
+		for _, operation := range []string{"query", "mutation", "subscription"} {
+			if t.Name == schema.rootType(operation) {
+				prefix = operation + "."
+			}
+		}
+		scan(t.Description, t.Name)
+		for _, f := range t.Fields {
+			scan(f.Description, prefix+f.Name)
+			for _, a := range f.Args {
+				scan(a.Description, prefix+f.Name+"("+a.Name+")")
+			}
+		}
+		for _, f := range t.InputFields {
+			scan(f.Description, prefix+f.Name)
+		}
+		for _, v := range t.EnumValues {
+			scan(v.Description, prefix+v.Name)
+		}
+	}
+	for _, d := range schema.Directives {
+		scan(d.Description, "@"+d.Name)
+	}
+	return codes
+}
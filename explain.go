@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Tool: explain_error
+const explainErrorToolDescription = `Explain a GraphQL error in plain language and suggest how to fix it.
+The error's path and locations are correlated with the operation and the schema, so you learn which field failed, its type, and why.
+
+Best Practices:
+- Use this tool when invoke_graphql fails and the server's message is unclear.
+- Pass the full error JSON (ideally from invoke_graphql with verboseErrors) so paths, locations and extension codes can be used.
+- Always pass the operation that produced the error; without it the explanation is limited to the message.
+
+Arguments:
+- error (string, Required): The error message, a single error object, an errors array, or a full response containing "errors".
+- operation (string, Optional): The GraphQL operation that produced the error.
+
+Example Usage:
+Request:
+  explain_error(
+	error: "{\"errors\":[{\"message\":\"Cannot return null for non-nullable field Candidate.email.\",\"path\":[\"candidate\",\"email\"]}]}",
+	operation: "query { candidate(id: \"1\") { id email } }"
+  )
+
+Response:
+  Error 1: Cannot return null for non-nullable field Candidate.email.
+  - Path candidate.email refers to field "email" of type String! on Candidate.
+  - The field is non-null, so the server had to discard its parent as well: the null propagated to candidate (Candidate).
+  Suggested fixes:
+  - The server failed to produce a value for a non-null field; this is usually a server-side data problem. Remove the field from the selection if you don't need it, or report the issue.
+`
+
+// Patterns of common server messages, used to tailor suggestions.
+var (
+	cannotQueryFieldPattern = regexp.MustCompile(`Cannot query field "([^"]+)" on type "([^"]+)"`)
+	unknownArgumentPattern  = regexp.MustCompile(`Unknown argument "([^"]+)" on field "(?:([^".]+)\.)?([^"]+)"`)
+	missingVariablePattern  = regexp.MustCompile(`Variable "\$([^"]+)" of (?:required|non-null) type "([^"]+)" was not provided`)
+	invalidValuePattern     = regexp.MustCompile(`Variable "\$([^"]+)" got invalid value`)
+)
+
+// parseErrorInput accepts a plain message, a single error object, an errors
+// array or a full GraphQL response and returns the errors it contains.
+func parseErrorInput(input string) []graphqlError {
+	input = strings.TrimSpace(input)
+	var res graphqlResponse
+	if err := json.Unmarshal([]byte(input), &res); err == nil && len(res.Errors) > 0 {
+		return res.Errors
+	}
+	var list []graphqlError
+	if err := json.Unmarshal([]byte(input), &list); err == nil && len(list) > 0 {
+		return list
+	}
+	var single graphqlError
+	if err := json.Unmarshal([]byte(input), &single); err == nil && single.Message != "" {
+		return []graphqlError{single}
+	}
+	return []graphqlError{{Message: input}}
+}
+
+// explainGraphQLError builds a plain-language explanation of each error. The
+// schema is used when available but is not required.
+func explainGraphQLError(ctx context.Context, errorInput, operation string) (string, error) {
+	if strings.TrimSpace(errorInput) == "" {
+		return "", fmt.Errorf("no error provided")
+	}
+	errs := parseErrorInput(errorInput)
+
+	var doc *astDocument
+	var notes []string
+	if strings.TrimSpace(operation) != "" {
+		var err error
+		if doc, err = parseDocument(operation); err != nil {
+			notes = append(notes, "The operation itself does not parse: "+err.Error()+". Fix the syntax first (format_operation can help).")
+			doc = nil
+		}
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		notes = append(notes, "The schema could not be loaded, so types are not shown: "+err.Error())
+		schema = nil
+	}
+
+	var sb strings.Builder
+	for _, note := range notes {
+		sb.WriteString("Note: " + note + "\n")
+	}
+	for i, gqlErr := range errs {
+		if i > 0 || len(notes) > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "Error %d: %s\n", i+1, gqlErr.Message)
+		explanation, fixes := explainSingleError(schema, doc, gqlErr)
+		for _, line := range explanation {
+			sb.WriteString("- " + line + "\n")
+		}
+		if len(fixes) > 0 {
+			sb.WriteString("Suggested fixes:\n")
+			for _, fix := range fixes {
+				sb.WriteString("- " + fix + "\n")
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// explainSingleError returns the explanation lines and suggested fixes for one error.
+func explainSingleError(schema *schemaModel, doc *astDocument, gqlErr graphqlError) (explanation, fixes []string) {
+	code, _ := gqlErr.Extensions["code"].(string)
+	if code != "" {
+		explanation = append(explanation, "Error code: "+code+".")
+	}
+
+	// Correlate the locations with the operation's fields
+	if doc != nil {
+		for _, loc := range gqlErr.Locations {
+			if sel := selectionAt(doc, loc.Line, loc.Column); sel != nil {
+				explanation = append(explanation, fmt.Sprintf("Line %d, column %d is the field %q.", loc.Line, loc.Column, sel.responseKey()))
+			} else {
+				explanation = append(explanation, fmt.Sprintf("The error points at line %d, column %d of the operation.", loc.Line, loc.Column))
+			}
+		}
+	}
+
+	// Correlate the path with the operation and the schema
+	nonNullFailure := false
+	if len(gqlErr.Path) > 0 {
+		steps := resolveErrorPath(schema, doc, gqlErr.Path)
+		pathStr := formatErrorPath(gqlErr.Path)
+		if len(steps) > 0 {
+			last := steps[len(steps)-1]
+			if last.field != nil {
+				explanation = append(explanation, fmt.Sprintf("Path %s refers to field %q of type %s on %s.", pathStr, last.field.Name, last.field.Type, last.parentType))
+				if last.field.Type.isNonNull() && strings.Contains(strings.ToLower(gqlErr.Message), "null") {
+					nonNullFailure = true
+					explanation = append(explanation, "The field is non-null, so the server had to discard its parent as well: "+nullPropagation(steps))
+				}
+			} else {
+				explanation = append(explanation, fmt.Sprintf("Path %s refers to %q in the operation.", pathStr, last.key))
+			}
+		} else {
+			explanation = append(explanation, fmt.Sprintf("Path %s could not be matched to the operation.", pathStr))
+		}
+	}
+
+	msg := gqlErr.Message
+	lower := strings.ToLower(msg)
+	switch {
+	case isUnauthenticatedError(gqlErr):
+		explanation = append(explanation, "The request was not authenticated.")
+		fixes = append(fixes, "Set valid credentials with set_headers (e.g. an Authorization header) and retry.")
+	case isForbiddenError(gqlErr):
+		explanation = append(explanation, "The credentials are valid but not allowed to access this data.")
+		fixes = append(fixes, "Use credentials with the required role, or remove the restricted field from the selection.")
+	case cannotQueryFieldPattern.MatchString(msg):
+		m := cannotQueryFieldPattern.FindStringSubmatch(msg)
+		explanation = append(explanation, fmt.Sprintf("Type %s has no field %q.", m[2], m[1]))
+		fix := fmt.Sprintf("Check the fields of %s with describe(entities: %q).", m[2], m[2])
+		if schema != nil {
+			if t := schema.typeByName(m[2]); t != nil && len(t.Fields) > 0 {
+				names := make([]string, 0, len(t.Fields))
+				for _, f := range t.Fields {
+					names = append(names, f.Name)
+				}
+				fix = fmt.Sprintf("Did you mean one of: %s? %s", strings.Join(suggestNames(m[1], names, maxSuggestions), ", "), fix)
+			}
+		}
+		fixes = append(fixes, fix)
+	case unknownArgumentPattern.MatchString(msg):
+		m := unknownArgumentPattern.FindStringSubmatch(msg)
+		explanation = append(explanation, fmt.Sprintf("Field %q does not accept an argument named %q.", m[3], m[1]))
+		if f := lookupField(schema, m[2], m[3]); f != nil {
+			fixes = append(fixes, fmt.Sprintf("The field's signature is %s.", prettyPrintField(f)))
+		} else {
+			fixes = append(fixes, "Check the field's arguments with describe.")
+		}
+	case missingVariablePattern.MatchString(msg):
+		m := missingVariablePattern.FindStringSubmatch(msg)
+		explanation = append(explanation, fmt.Sprintf("The operation declares $%s as %s but no value was sent.", m[1], m[2]))
+		fixes = append(fixes, fmt.Sprintf("Pass %q in the variables, or make the variable nullable / give it a default value.", m[1]))
+	case invalidValuePattern.MatchString(msg) || strings.Contains(lower, "expected type") || strings.Contains(lower, "cannot represent"):
+		explanation = append(explanation, "A variable or argument value has the wrong type or format.")
+		fixes = append(fixes, "Compare the value with the declared type (list_scalars shows custom scalar formats), or retry invoke_graphql with coerceVariables.")
+	case strings.Contains(lower, "syntax error"):
+		explanation = append(explanation, "The operation is not valid GraphQL syntax.")
+		fixes = append(fixes, "Run the operation through format_operation to locate the syntax error.")
+	case code == "NOT_FOUND" || strings.Contains(lower, "not found"):
+		explanation = append(explanation, "The requested object does not exist (or is not visible to the current credentials).")
+		fixes = append(fixes, "Check the identifiers passed in the arguments and variables.")
+	case code == "BAD_USER_INPUT" || code == "GRAPHQL_VALIDATION_FAILED":
+		explanation = append(explanation, "The server rejected the input before executing the operation.")
+		fixes = append(fixes, "Check argument values against the schema with describe.")
+	case strings.Contains(lower, "rate limit") || strings.Contains(lower, "too many requests"):
+		explanation = append(explanation, "The server is rate limiting requests.")
+		fixes = append(fixes, "Wait before retrying and reduce the number of requests.")
+	case nonNullFailure:
+		fixes = append(fixes, "The server failed to produce a value for a non-null field; this is usually a server-side data problem. Remove the field from the selection if you don't need it, or report the issue.")
+	case code == "INTERNAL_SERVER_ERROR" || strings.Contains(lower, "internal"):
+		explanation = append(explanation, "The server failed while resolving the operation.")
+		fixes = append(fixes, "Retry later; if it persists, narrow the selection to find the failing field and report it.")
+	}
+	return explanation, fixes
+}
+
+// errorPathStep is a path segment resolved against the operation and schema.
+// field and parentType are unset when the schema doesn't know the field.
+type errorPathStep struct {
+	key        string
+	selection  *astSelection
+	field      *schemaField
+	parentType string
+}
+
+// isUnauthenticatedError reports whether the error says the request carried
+// no valid credentials.
+func isUnauthenticatedError(gqlErr graphqlError) bool {
+	code, _ := gqlErr.Extensions["code"].(string)
+	lower := strings.ToLower(gqlErr.Message)
+	return code == "UNAUTHENTICATED" || strings.Contains(lower, "unauthenticated") || strings.Contains(lower, "not authenticated") || strings.Contains(lower, "unauthorized")
+}
+
+// isForbiddenError reports whether the error says the credentials are not
+// allowed to access the data.
+func isForbiddenError(gqlErr graphqlError) bool {
+	code, _ := gqlErr.Extensions["code"].(string)
+	lower := strings.ToLower(gqlErr.Message)
+	return code == "FORBIDDEN" || strings.Contains(lower, "forbidden") || strings.Contains(lower, "not authorized") || strings.Contains(lower, "permission")
+}
+
+// resolveErrorPath follows an error path through the operation's selections,
+// skipping list indices, and looks up each field in the schema. It stops at
+// the first segment that can't be matched.
+func resolveErrorPath(schema *schemaModel, doc *astDocument, path []interface{}) []errorPathStep {
+	if doc == nil || len(doc.Operations) == 0 {
+		return nil
+	}
+	var steps []errorPathStep
+	for _, op := range doc.Operations {
+		steps = nil
+		sels := op.SelectionSet
+		typeName := ""
+		if schema != nil {
+			typeName = schema.rootType(op.Operation)
+		}
+		for _, segment := range path {
+			key, ok := segment.(string)
+			if !ok {
+				continue // list index
+			}
+			var match *fieldSelection
+			for _, fs := range doc.fieldSelections(sels) {
+				if fs.Field.responseKey() == key {
+					match = &fs
+					break
+				}
+			}
+			if match == nil {
+				break
+			}
+			step := errorPathStep{key: key, selection: match.Field}
+			if schema != nil {
+				parent := typeName
+				if match.TypeCondition != "" {
+					parent = match.TypeCondition
+				}
+				if f := lookupField(schema, parent, match.Field.Name); f != nil {
+					step.field, step.parentType = f, parent
+					typeName = f.Type.namedType()
+				} else {
+					typeName = ""
+				}
+			}
+			steps = append(steps, step)
+			sels = match.Field.SelectionSet
+		}
+		if len(steps) > 0 {
+			return steps
+		}
+	}
+	return steps
+}
+
+// lookupField returns the field of the named type, or nil.
+func lookupField(schema *schemaModel, typeName, fieldName string) *schemaField {
+	if schema == nil {
+		return nil
+	}
+	if t := schema.typeByName(typeName); t != nil {
+		return t.field(fieldName)
+	}
+	return nil
+}
+
+// nullPropagation describes where a null on the last step ends up: the
+// nearest nullable ancestor, or the whole "data" when every ancestor is non-null.
+func nullPropagation(steps []errorPathStep) string {
+	for i := len(steps) - 2; i >= 0; i-- {
+		f := steps[i].field
+		if f == nil {
+			break
+		}
+		if !f.Type.isNonNull() {
+			return fmt.Sprintf("the null propagated to %s (%s).", steps[i].key, f.Type)
+		}
+	}
+	return "every parent is non-null, so the entire data was set to null."
+}
+
+// formatErrorPath renders a path such as ["jobs", 0, "title"] as "jobs.0.title".
+func formatErrorPath(path []interface{}) string {
+	parts := make([]string, 0, len(path))
+	for _, p := range path {
+		switch p := p.(type) {
+		case float64:
+			parts = append(parts, strconv.Itoa(int(p)))
+		default:
+			parts = append(parts, fmt.Sprint(p))
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// selectionAt returns the field selection starting at line/column, or nil.
+func selectionAt(doc *astDocument, line, column int) *astSelection {
+	var found *astSelection
+	var walk func(sels []*astSelection)
+	walk = func(sels []*astSelection) {
+		for _, sel := range sels {
+			if found != nil {
+				return
+			}
+			if sel.Kind == selectionField && sel.Line == line && sel.Column == column {
+				found = sel
+				return
+			}
+			walk(sel.SelectionSet)
+		}
+	}
+	for _, op := range doc.Operations {
+		walk(op.SelectionSet)
+	}
+	for _, frag := range doc.Fragments {
+		walk(frag.SelectionSet)
+	}
+	return found
+}
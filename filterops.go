@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tool: filter_operations
+const filterOperationsToolDescription = `List the queries, mutations and subscriptions that return a given type and/or accept a given argument type.
+Types are matched after removing list and non-null wrappers. An operation returns a type when its result is that type, an interface or union the type belongs to, or a connection of it (edges.node or nodes). It accepts a type when an argument has that type, directly or nested in an input object.
+
+Best Practices:
+- Use returns to find every way to fetch a type, e.g. returns: "Candidate".
+- Use accepts to find every mutation taking an input, e.g. accepts: "AddressInput", including ones that take it inside a larger input.
+- Combine both to narrow the list further; at least one is required.
+
+Arguments:
+- returns (string, Optional): The type the operations must yield.
+- accepts (string, Optional): The type one of the operations' arguments must have or contain.
+
+Example Usage:
+Request:
+  filter_operations(returns: "Candidate")
+
+Response:
+  Operations returning Candidate:
+  	query.candidate(id: ID!): Candidate
+  	query.candidates(first: Int, after: String): CandidateConnection! (connection of Candidate)
+  	query.search(term: String!): [SearchResult!]! (Candidate is a member of SearchResult)
+  	mutation.createCandidate(input: CandidateInput!): Candidate!
+`
+
+// filterOperations lists the root fields that yield returns and accept an
+// argument of or containing accepts; an empty filter matches everything.
+func filterOperations(ctx context.Context, returns, accepts string) (string, error) {
+	returns, accepts = strings.TrimSpace(returns), strings.TrimSpace(accepts)
+	if returns == "" && accepts == "" {
+		return "", fmt.Errorf("give returns, accepts or both")
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range []string{returns, accepts} {
+		if name != "" && schema.typeByName(name) == nil {
+			names := make([]string, 0, len(schema.Types))
+			for _, t := range schema.Types {
+				names = append(names, t.Name)
+			}
+			return "", fmt.Errorf("type '%s' not found in schema. Did you mean: %s?", name, strings.Join(suggestNames(name, names, maxSuggestions), ", "))
+		}
+	}
+
+	var lines []string
+	for _, operation := range []string{"query", "mutation", "subscription"} {
+		for _, f := range schema.rootFields(schema.rootType(operation)) {
+			var notes []string
+			if returns != "" {
+				how, ok := yieldsType(schema, f.Type.namedType(), returns)
+				if !ok {
+					continue
+				}
+				if how != "" {
+					notes = append(notes, how)
+				}
+			}
+			if accepts != "" {
+				how, ok := acceptsType(schema, f, accepts)
+				if !ok {
+					continue
+				}
+				if how != "" {
+					notes = append(notes, how)
+				}
+			}
+			line := operation + "." + usageString(f)
+			if len(notes) > 0 {
+				line += " (" + strings.Join(notes, "; ") + ")"
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	var filters []string
+	if returns != "" {
+		filters = append(filters, "returning "+returns)
+	}
+	if accepts != "" {
+		filters = append(filters, "accepting "+accepts)
+	}
+	if len(lines) == 0 {
+		return "No operations " + strings.Join(filters, " and ") + ".", nil
+	}
+	return "Operations " + strings.Join(filters, " and ") + ":\n\t" + strings.Join(lines, "\n\t") + "\n", nil
+}
+
+// yieldsType reports whether a field of the named type yields target, and
+// how when it isn't target itself.
+func yieldsType(schema *schemaModel, named, target string) (string, bool) {
+	if named == target {
+		return "", true
+	}
+	t := schema.typeByName(named)
+	if t == nil {
+		return "", false
+	}
+	for _, ref := range t.PossibleTypes {
+		if ref.namedType() == target {
+			kind := "a member"
+			if t.Kind == "INTERFACE" {
+				kind = "an implementation"
+			}
+			return fmt.Sprintf("%s is %s of %s", target, kind, t.Name), true
+		}
+	}
+	// Relay connections hold their items in edges.node, or nodes
+	if nodes := t.field("nodes"); nodes != nil && nodes.Type.namedType() == target {
+		return "connection of " + target, true
+	}
+	if edges := t.field("edges"); edges != nil {
+		if edge := schema.typeByName(edges.Type.namedType()); edge != nil {
+			if node := edge.field("node"); node != nil && node.Type.namedType() == target {
+				return "connection of " + target, true
+			}
+		}
+	}
+	return "", false
+}
+
+// acceptsType reports whether an argument of f has the target type or an
+// input object type containing it, and the path to it when nested.
+func acceptsType(schema *schemaModel, f *schemaField, target string) (string, bool) {
+	for _, a := range f.Args {
+		if a.Type.namedType() == target {
+			return "", true
+		}
+	}
+	// Search input objects breadth-first, so the shortest path is reported
+	type step struct {
+		typeName string
+		path     string
+	}
+	var queue []step
+	seen := make(map[string]bool)
+	for _, a := range f.Args {
+		queue = append(queue, step{a.Type.namedType(), a.Name})
+	}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		t := schema.typeByName(s.typeName)
+		if t == nil || t.Kind != "INPUT_OBJECT" || seen[t.Name] {
+			continue
+		}
+		seen[t.Name] = true
+		for _, field := range t.InputFields {
+			path := s.path + "." + field.Name
+			if field.Type.namedType() == target {
+				return "via " + path, true
+			}
+			queue = append(queue, step{field.Type.namedType(), path})
+		}
+	}
+	return "", false
+}
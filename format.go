@@ -0,0 +1,166 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// Tool: format_operation
+const formatOperationToolDescription = `Format a GraphQL operation with consistent indentation and whitespace.
+The operation is parsed and printed back, so minified or poorly-indented queries become easy to read and compare.
+
+Best Practices:
+- Use this tool to clean up an operation before reviewing or logging it.
+- Syntax errors are reported with their line and column, so this tool also works as a quick syntax check.
+- Comments are not preserved.
+
+Arguments:
+- operation (string, Required): The GraphQL query, mutation or subscription to format, including any fragments.
+
+Example Usage:
+Request:
+  format_operation(operation: "query($id:ID!){candidate(id:$id){id name jobs{title}}}")
+
+Response:
+  query ($id: ID!) {
+    candidate(id: $id) {
+      id
+      name
+      jobs {
+        title
+      }
+    }
+  }
+`
+
+// formatIndent is the indentation used for each nesting level.
+const formatIndent = "  "
+
+// formatOperation parses a GraphQL document and prints it back in canonical form.
+func formatOperation(src string) (string, error) {
+	doc, err := parseDocument(src)
+	if err != nil {
+		return "", err
+	}
+	return printDocument(doc), nil
+}
+
+// printDocument prints the operations and fragments of doc in their original
+// order, separated by blank lines.
+func printDocument(doc *astDocument) string {
+	type definition struct {
+		line, column int
+		text         string
+	}
+	var defs []definition
+	for _, op := range doc.Operations {
+		defs = append(defs, definition{op.Line, op.Column, printOperation(op)})
+	}
+	for _, frag := range doc.Fragments {
+		defs = append(defs, definition{frag.Line, frag.Column, printFragment(frag)})
+	}
+	sort.SliceStable(defs, func(i, j int) bool {
+		if defs[i].line != defs[j].line {
+			return defs[i].line < defs[j].line
+		}
+		return defs[i].column < defs[j].column
+	})
+
+	parts := make([]string, 0, len(defs))
+	for _, d := range defs {
+		parts = append(parts, d.text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func printOperation(op *astOperation) string {
+	var sb strings.Builder
+	// Anonymous queries without variables or directives keep the shorthand form
+	if op.Operation != "query" || op.Name != "" || len(op.VariableDefinitions) > 0 || len(op.Directives) > 0 {
+		sb.WriteString(op.Operation)
+		if op.Name != "" {
+			sb.WriteString(" " + op.Name)
+		}
+		if len(op.VariableDefinitions) > 0 {
+			vars := make([]string, 0, len(op.VariableDefinitions))
+			for _, v := range op.VariableDefinitions {
+				s := "$" + v.Name + ": " + v.Type.String()
+				if v.DefaultValue != nil {
+					s += " = " + v.DefaultValue.String()
+				}
+				vars = append(vars, s+printDirectives(v.Directives))
+			}
+			if op.Name == "" {
+				sb.WriteString(" ")
+			}
+			sb.WriteString("(" + strings.Join(vars, ", ") + ")")
+		}
+		sb.WriteString(printDirectives(op.Directives))
+		sb.WriteString(" ")
+	}
+	printSelectionSet(&sb, op.SelectionSet, 0)
+	return sb.String()
+}
+
+func printFragment(frag *astFragment) string {
+	var sb strings.Builder
+	sb.WriteString("fragment " + frag.Name + " on " + frag.TypeCondition)
+	sb.WriteString(printDirectives(frag.Directives))
+	sb.WriteString(" ")
+	printSelectionSet(&sb, frag.SelectionSet, 0)
+	return sb.String()
+}
+
+// printSelectionSet writes "{ ... }" with one selection per line, indented
+// one level deeper than depth.
+func printSelectionSet(sb *strings.Builder, selections []*astSelection, depth int) {
+	sb.WriteString("{\n")
+	indent := strings.Repeat(formatIndent, depth+1)
+	for _, sel := range selections {
+		sb.WriteString(indent)
+		switch sel.Kind {
+		case selectionFragmentSpread:
+			sb.WriteString("..." + sel.Name)
+		case selectionInlineFragment:
+			sb.WriteString("...")
+			if sel.TypeCondition != "" {
+				sb.WriteString(" on " + sel.TypeCondition)
+			}
+		default:
+			if sel.Alias != "" {
+				sb.WriteString(sel.Alias + ": ")
+			}
+			sb.WriteString(sel.Name)
+			if len(sel.Arguments) > 0 {
+				sb.WriteString("(" + printArguments(sel.Arguments) + ")")
+			}
+		}
+		sb.WriteString(printDirectives(sel.Directives))
+		if len(sel.SelectionSet) > 0 {
+			sb.WriteString(" ")
+			printSelectionSet(sb, sel.SelectionSet, depth+1)
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(strings.Repeat(formatIndent, depth) + "}")
+}
+
+func printArguments(args []*astArgument) string {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		parts = append(parts, a.Name+": "+a.Value.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// printDirectives renders directives with a leading space, or "" when there are none.
+func printDirectives(dirs []*astDirective) string {
+	var sb strings.Builder
+	for _, d := range dirs {
+		sb.WriteString(" @" + d.Name)
+		if len(d.Arguments) > 0 {
+			sb.WriteString("(" + printArguments(d.Arguments) + ")")
+		}
+	}
+	return sb.String()
+}
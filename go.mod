@@ -3,13 +3,8 @@ module github.com/wricardo/graphql-mcp
 go 1.23.0
 
 require (
-	github.com/davecgh/go-spew v1.1.1
 	github.com/mark3labs/mcp-go v0.8.5
+	gopkg.in/yaml.v3 v3.0.1
 )
 
-require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/machinebox/graphql v0.2.2 // indirect
-	github.com/pkg/errors v0.9.1 // indirect
-	github.com/wricardo/graphql v0.0.0-20250303012715-a2833aa153d3 // indirect
-)
+require github.com/google/uuid v1.6.0 // indirect
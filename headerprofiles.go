@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tool: save_header_profile
+const saveHeaderProfileToolDescription = `Save the current headers and basic auth credentials under a name, to switch back to them later with use_header_profile.
+Profiles make it easy to act as several users or roles in one session, e.g. an admin and a regular user, without setting their headers again each time. They are kept in memory until the server stops.
+
+Best Practices:
+- Set the headers of an identity with set_headers or set_basic_auth, save them as a profile, then do the same for the next identity.
+- Saving under an existing name replaces that profile.
+
+Arguments:
+- name (string, Required): The name of the profile, e.g. "admin".
+
+Example Usage:
+Request:
+  save_header_profile(name: "admin")
+
+Response:
+  Saved header profile "admin" with headers Authorization, X-Tenant.
+`
+
+// Tool: use_header_profile
+const useHeaderProfileToolDescription = `Switch to the headers and basic auth credentials saved as a profile with save_header_profile.
+The profile replaces the current headers entirely, rather than being merged into them, and the schema is loaded again, since what it exposes may depend on the identity.
+
+Best Practices:
+- Run whoami after switching to confirm the identity in use.
+- Headers changed with set_headers after switching are not saved into the profile; save it again to keep them.
+
+Arguments:
+- name (string, Required): The name of the profile to use.
+
+Example Usage:
+Request:
+  use_header_profile(name: "viewer")
+
+Response:
+  Using header profile "viewer": headers Authorization.
+`
+
+// Tool: list_header_profiles
+const listHeaderProfilesToolDescription = `List the saved header profiles with the names of their headers and their basic auth user, marking the one in use. Header values are not shown, since they usually hold credentials.
+
+Example Usage:
+Request:
+  list_header_profiles()
+
+Response:
+  admin: headers Authorization, X-Tenant
+  reporting (in use): basic auth as reporting
+`
+
+// Tool: delete_header_profile
+const deleteHeaderProfileToolDescription = `Delete a saved header profile. The headers in use are not changed, even when they came from that profile.
+
+Arguments:
+- name (string, Required): The name of the profile to delete.
+
+Example Usage:
+Request:
+  delete_header_profile(name: "admin")
+
+Response:
+  Deleted header profile "admin".
+`
+
+// headerProfile is a saved set of headers and basic auth credentials.
+type headerProfile struct {
+	headers    http.Header
+	user, pass string
+}
+
+// headerProfiles holds the saved profiles by name, and the name of the one
+// in use until the headers are changed otherwise.
+var headerProfiles = struct {
+	sync.Mutex
+	profiles map[string]*headerProfile
+	active   string
+}{profiles: make(map[string]*headerProfile)}
+
+// saveHeaderProfile saves the current headers and basic auth credentials as
+// the profile name.
+func saveHeaderProfile(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("the profile name is empty")
+	}
+	headers := userHeaders()
+	basicAuth.Lock()
+	profile := &headerProfile{headers: headers, user: basicAuth.user, pass: basicAuth.pass}
+	basicAuth.Unlock()
+
+	headerProfiles.Lock()
+	defer headerProfiles.Unlock()
+	headerProfiles.profiles[name] = profile
+	headerProfiles.active = name
+	return fmt.Sprintf("Saved header profile %q with %s.", name, profile), nil
+}
+
+// useHeaderProfile replaces the current headers and basic auth credentials
+// with those of the profile name.
+func useHeaderProfile(name string) (string, error) {
+	profile, err := lookupHeaderProfile(name)
+	if err != nil {
+		return "", err
+	}
+	replaceHeaders(profile.headers)
+	setBasicAuth(profile.user, profile.pass)
+
+	headerProfiles.Lock()
+	headerProfiles.active = name
+	headerProfiles.Unlock()
+	return fmt.Sprintf("Using header profile %q: %s.", name, profile), nil
+}
+
+// listHeaderProfiles renders the saved profiles, one per line.
+func listHeaderProfiles() string {
+	headerProfiles.Lock()
+	defer headerProfiles.Unlock()
+	if len(headerProfiles.profiles) == 0 {
+		return "No header profiles saved. Save the current headers with save_header_profile."
+	}
+	names := make([]string, 0, len(headerProfiles.profiles))
+	for name := range headerProfiles.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		sb.WriteString(name)
+		if name == headerProfiles.active {
+			sb.WriteString(" (in use)")
+		}
+		sb.WriteString(": " + headerProfiles.profiles[name].String() + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// deleteHeaderProfile removes the profile name.
+func deleteHeaderProfile(name string) error {
+	if _, err := lookupHeaderProfile(name); err != nil {
+		return err
+	}
+	headerProfiles.Lock()
+	defer headerProfiles.Unlock()
+	delete(headerProfiles.profiles, name)
+	if headerProfiles.active == name {
+		headerProfiles.active = ""
+	}
+	return nil
+}
+
+// lookupHeaderProfile returns the profile name, or an error suggesting the
+// closest saved names.
+func lookupHeaderProfile(name string) (*headerProfile, error) {
+	headerProfiles.Lock()
+	defer headerProfiles.Unlock()
+	if profile, ok := headerProfiles.profiles[name]; ok {
+		return profile, nil
+	}
+	if len(headerProfiles.profiles) == 0 {
+		return nil, fmt.Errorf("no header profile %q: none is saved yet, use save_header_profile", name)
+	}
+	names := make([]string, 0, len(headerProfiles.profiles))
+	for n := range headerProfiles.profiles {
+		names = append(names, n)
+	}
+	return nil, fmt.Errorf("%s", unknownKeyProblem(name, "no such header profile", name, names))
+}
+
+// leaveHeaderProfile records that the headers no longer match the profile
+// in use, after they were changed directly.
+func leaveHeaderProfile() {
+	headerProfiles.Lock()
+	headerProfiles.active = ""
+	headerProfiles.Unlock()
+}
+
+// String describes the profile without its values, e.g. "headers
+// Authorization, X-Tenant; basic auth as reporting".
+func (p *headerProfile) String() string {
+	var parts []string
+	if len(p.headers) > 0 {
+		names := make([]string, 0, len(p.headers))
+		for name := range p.headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts = append(parts, "headers "+strings.Join(names, ", "))
+	}
+	if p.user != "" {
+		parts = append(parts, "basic auth as "+p.user)
+	}
+	if len(parts) == 0 {
+		return "no headers"
+	}
+	return strings.Join(parts, "; ")
+}
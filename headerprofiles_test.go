@@ -0,0 +1,44 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestHeaderProfilesConcurrentAccess switches profiles while other calls
+// read the headers; run it with -race.
+func TestHeaderProfilesConcurrentAccess(t *testing.T) {
+	if err := setHeaders(`{"Authorization": "Bearer admin"}`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := saveHeaderProfile("admin"); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := useHeaderProfile("admin"); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := saveHeaderProfile("copy"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = getHeaders()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := getHeaders().Get("Authorization"); got != "Bearer admin" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer admin")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Tool: list_history
+const listHistoryToolDescription = `List the operations executed recently, newest first, with their variables and the outcome of each call.
+Sensitive variables (passwords, tokens, ...) are shown as [REDACTED].
+
+Best Practices:
+- Use this tool to find the index of an operation to run again with replay_last.
+
+Example Usage:
+Request:
+  list_history()
+
+Response:
+  #0 2026-05-04T10:15:02Z ok, 412 bytes
+  	query { candidates(status: $status) { id name } }
+  	variables: {"status":"ACTIVE"}
+  #1 2026-05-04T10:14:40Z error: graphql: Unknown argument "state"
+  	query { candidates(state: $status) { id name } }
+  	variables: {"status":"ACTIVE"}
+`
+
+// Tool: replay_last
+const replayLastToolDescription = `Execute a previous operation again, optionally with different variables, without pasting it again.
+The operation runs with the same options as the first time (compact, responseShape, ...).
+
+Best Practices:
+- Use this tool to re-run an operation while debugging, tweaking only the variables.
+- Check list_history for the index of older operations.
+- Mutations need confirm again when mutation confirmation is required.
+
+Arguments:
+- index (number, Optional): Which operation to replay, 0 being the most recent (default 0).
+- variables (string, Optional): A JSON-encoded object of variables merged over the original ones.
+- confirm (boolean, Optional): Confirm the replay of a mutation when mutation confirmation is required.
+
+Example Usage:
+Request:
+  replay_last(index: 1, variables: "{\"status\": \"INACTIVE\"}")
+
+Response:
+  {
+    "candidates": []
+  }
+`
+
+// historySize is the number of executed operations kept for replay_last.
+var historySize = intFromEnv("HISTORY_SIZE", 20)
+
+// historyEntry is an operation executed by invokeGraphQLOperation.
+type historyEntry struct {
+	Operation string
+	Variables map[string]interface{}
+	Options   invokeOptions
+	Time      time.Time
+	Summary   string
+}
+
+// history holds the most recent operations, oldest first.
+var history struct {
+	sync.Mutex
+	entries []historyEntry
+}
+
+// maxHistorySummary bounds the length of the operations and error summaries
+// shown by list_history.
+const maxHistorySummary = 200
+
+// recordHistory remembers an executed operation and the outcome of the call.
+func recordHistory(operation string, vars map[string]interface{}, opts invokeOptions, started time.Time, res *invokeResult, callErr error) {
+	if historySize <= 0 {
+		return
+	}
+	// A replay must be confirmed on its own
+	opts.Confirmed = false
+	entry := historyEntry{Operation: operation, Variables: vars, Options: opts, Time: started}
+	if callErr != nil {
+		entry.Summary = "error: " + truncateRunes(firstLine(callErr.Error()), maxHistorySummary)
+	} else {
+		entry.Summary = fmt.Sprintf("ok, %d bytes", len(res.Body))
+	}
+
+	history.Lock()
+	defer history.Unlock()
+	history.entries = append(history.entries, entry)
+	if len(history.entries) > historySize {
+		history.entries = history.entries[len(history.entries)-historySize:]
+	}
+}
+
+// historyEntryAt returns the operation executed index calls ago.
+func historyEntryAt(index int) (historyEntry, error) {
+	history.Lock()
+	defer history.Unlock()
+	if len(history.entries) == 0 {
+		return historyEntry{}, fmt.Errorf("no operation has been executed yet")
+	}
+	if index < 0 || index >= len(history.entries) {
+		return historyEntry{}, fmt.Errorf("index %d is out of range, the history holds %d operations (0 to %d)", index, len(history.entries), len(history.entries)-1)
+	}
+	return history.entries[len(history.entries)-1-index], nil
+}
+
+// listHistory renders the history, newest first, with sensitive variables
+// redacted.
+func listHistory() (string, error) {
+	history.Lock()
+	entries := append([]historyEntry(nil), history.entries...)
+	history.Unlock()
+	if len(entries) == 0 {
+		return "No operation has been executed yet.", nil
+	}
+
+	var sb strings.Builder
+	for i := range entries {
+		e := entries[len(entries)-1-i]
+		fmt.Fprintf(&sb, "#%d %s %s\n", i, e.Time.UTC().Format(time.RFC3339), e.Summary)
+		sb.WriteString("\t" + truncateRunes(strings.Join(strings.Fields(e.Operation), " "), maxHistorySummary) + "\n")
+		if len(e.Variables) > 0 {
+			vars, err := json.Marshal(redactVariables(e.Variables))
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString("\tvariables: " + string(vars) + "\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// replayHistory executes the operation executed index calls ago again, with
+// overrideJSON merged over its variables.
+func replayHistory(ctx context.Context, index int, overrideJSON string, confirmed bool) (*invokeResult, error) {
+	entry, err := historyEntryAt(index)
+	if err != nil {
+		return nil, err
+	}
+	override, err := parseVariables(overrideJSON)
+	if err != nil {
+		return nil, err
+	}
+	vars := make(map[string]interface{}, len(entry.Variables)+len(override))
+	for k, v := range entry.Variables {
+		vars[k] = v
+	}
+	for k, v := range override {
+		vars[k] = v
+	}
+	var variablesJSON string
+	if len(vars) > 0 {
+		encoded, err := json.Marshal(vars)
+		if err != nil {
+			return nil, err
+		}
+		variablesJSON = string(encoded)
+	}
+	opts := entry.Options
+	opts.Confirmed = confirmed
+	return invokeGraphQLOperation(ctx, entry.Operation, variablesJSON, opts)
+}
+
+// truncateRunes shortens s to at most n runes, marking the cut with "...".
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "..."
+}
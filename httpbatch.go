@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Tool: invoke_http_batch
+const invokeHTTPBatchToolDescription = `Execute several GraphQL operations in a single HTTP request, sent as a JSON array of requests (transport-level batching), and return the array of their responses in the same order.
+This saves round trips when running many small independent operations, but only works with servers that accept batched requests (e.g. Apollo Server with batching enabled), so it is disabled unless HTTP_BATCHING is true.
+
+Best Practices:
+- Batch independent operations only: the server may run them in any order, and one failing doesn't stop the others.
+- Each response is reported with the name of its operation; GraphQL errors in one response don't fail the call.
+- When the server answers with a single response or an error instead of an array, it doesn't support batching: run the operations one by one with invoke_graphql.
+
+Arguments:
+- operations (string, Required): A JSON array of requests, each with "query" (or "operation"), optional "variables" (an object) and optional "operationName".
+- confirm (boolean, Optional): Confirm the mutations of the batch when REQUIRE_MUTATION_CONFIRM is enabled.
+
+Example Usage:
+Request:
+  invoke_http_batch(operations: "[{\"query\": \"query Job($id: ID!) { job(id: $id) { title } }\", \"variables\": {\"id\": \"1\"}}, {\"query\": \"{ me { login } }\"}]")
+
+Response:
+  [
+    {
+      "operationName": "Job",
+      "data": {
+        "job": {
+          "title": "Engineer"
+        }
+      }
+    },
+    {
+      "operationName": "MCPQuery_me",
+      "data": {
+        "me": null
+      },
+      "errors": [
+        {
+          "message": "Not authenticated"
+        }
+      ]
+    }
+  ]
+
+  Sent 2 operations in one request: 1 succeeded, 1 returned errors (#2 MCPQuery_me: Not authenticated).
+`
+
+// httpBatching enables invoke_http_batch, for servers accepting an array of
+// requests in one POST.
+var httpBatching = boolFromEnv("HTTP_BATCHING")
+
+// errHTTPBatchRejected is returned when the server doesn't answer a batch
+// with an array of responses.
+var errHTTPBatchRejected = errors.New("the server rejected the batch")
+
+// httpBatchOperation is an entry of the operations argument.
+type httpBatchOperation struct {
+	Query         string          `json:"query"`
+	Operation     string          `json:"operation"`
+	Variables     json.RawMessage `json:"variables"`
+	OperationName string          `json:"operationName"`
+}
+
+// httpBatchResult is the response to one operation of a batch.
+type httpBatchResult struct {
+	OperationName string                 `json:"operationName,omitempty"`
+	Data          json.RawMessage        `json:"data,omitempty"`
+	Errors        []graphqlError         `json:"errors,omitempty"`
+	Extensions    map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// invokeHTTPBatch checks the operations against the policy as
+// invoke_graphql does, sends them in one request and returns the responses
+// as a JSON array, followed by a summary.
+func invokeHTTPBatch(ctx context.Context, operationsJSON string, confirmed bool) (string, string, error) {
+	if !httpBatching {
+		return "", "", errors.New("transport-level batching is disabled; set HTTP_BATCHING=true if the server accepts an array of requests in one POST, or run the operations one by one with invoke_graphql")
+	}
+	if bodyTemplate != nil {
+		return "", "", errors.New("transport-level batching can't be used with BODY_TEMPLATE, which shapes the body of a single request; run the operations one by one with invoke_graphql")
+	}
+	var operations []httpBatchOperation
+	if err := json.Unmarshal([]byte(operationsJSON), &operations); err != nil {
+		return "", "", fmt.Errorf("failed to parse operations JSON, expected an array of {\"query\", \"variables\", \"operationName\"} objects: %w", err)
+	}
+	if len(operations) == 0 {
+		return "", "", errors.New("the batch is empty")
+	}
+
+	started := time.Now()
+	reqs := make([]graphqlRequest, len(operations))
+	sendReqs := make([]graphqlRequest, len(operations))
+	var secrets []string
+	for i, op := range operations {
+		operation := op.Query
+		if operation == "" {
+			operation = op.Operation
+		}
+		req, err := prepareBatchRequest(ctx, operation, op, confirmed)
+		if err != nil {
+			return "", "", fmt.Errorf("operation #%d: %w", i+1, err)
+		}
+		reqs[i], sendReqs[i] = req, req
+		var opSecrets []string
+		if sendReqs[i].Variables, opSecrets, err = resolveSecretReferences(req.Variables); err != nil {
+			return "", "", fmt.Errorf("operation #%d: %w", i+1, err)
+		}
+		secrets = append(secrets, opSecrets...)
+	}
+
+	responses, err := executeGraphQLBatch(ctx, sendReqs)
+	for _, req := range reqs {
+		recordAudit(req.Query, req.OperationName, req.Variables, started, err)
+	}
+	if err != nil {
+		return "", "", scrubSecretsError(err, secrets)
+	}
+
+	results := make([]httpBatchResult, len(responses))
+	var failures []string
+	mutated := false
+	for i, res := range responses {
+		scrubSecretsResponse(res, secrets)
+		results[i] = httpBatchResult{OperationName: reqs[i].OperationName, Data: res.Data, Errors: res.Errors, Extensions: res.Extensions}
+		if len(res.Errors) > 0 {
+			failures = append(failures, fmt.Sprintf("#%d %s: %s", i+1, reqs[i].OperationName, res.Errors[0].Message))
+		} else if isMutationRequest(reqs[i]) {
+			mutated = true
+		}
+	}
+	// A mutation may change what the cached queries return
+	if mutated && responseCacheTTL > 0 {
+		clearResponseCache()
+	}
+
+	encoded, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	var data interface{}
+	_ = json.Unmarshal(encoded, &data)
+	body, truncation := truncateResponse(string(encoded), data, maxResponseBytes)
+	summary := fmt.Sprintf("Sent %s in one request: %d succeeded", pluralize(len(results), "operation", "operations"), len(results)-len(failures))
+	if len(failures) > 0 {
+		summary += fmt.Sprintf(", %d returned errors (%s)", len(failures), strings.Join(failures, "; "))
+	}
+	summary += "."
+	if truncation != nil {
+		summary += fmt.Sprintf(" The responses were truncated: they are %d bytes and the limit is %d bytes.", truncation.TotalBytes, truncation.LimitBytes)
+	}
+	return body, summary, nil
+}
+
+// prepareBatchRequest builds the request of one operation of a batch, with
+// its operation name and default variables, after the policy and mutation
+// confirmation checks.
+func prepareBatchRequest(ctx context.Context, operation string, op httpBatchOperation, confirmed bool) (graphqlRequest, error) {
+	if strings.TrimSpace(operation) == "" {
+		return graphqlRequest{}, errors.New("no query given")
+	}
+	if err := checkOperationAllowed(operation); err != nil {
+		return graphqlRequest{}, err
+	}
+	named, operationName, err := resolveOperationName(operation, op.OperationName)
+	if err != nil {
+		return graphqlRequest{}, err
+	}
+	var vars map[string]interface{}
+	if len(op.Variables) > 0 && string(op.Variables) != "null" {
+		if vars, err = parseVariables(string(op.Variables)); err != nil {
+			return graphqlRequest{}, err
+		}
+	}
+	req := graphqlRequest{Query: named, OperationName: operationName, Variables: applyDefaultVariables(operation, vars)}
+	if err := checkMutationConfirmed(ctx, operation, req.Variables, confirmed); err != nil {
+		return graphqlRequest{}, err
+	}
+	return req, nil
+}
+
+// executeGraphQLBatch posts reqs as a JSON array with the current headers
+// and decodes the array of responses. An answer that isn't an array of as
+// many responses is reported as errHTTPBatchRejected.
+func executeGraphQLBatch(ctx context.Context, reqs []graphqlRequest) (responses []*graphqlResponse, err error) {
+	ctx, span := startSpan(ctx, "batch", spanKindClient)
+	span.setAttribute("graphql.batch.size", len(reqs))
+	span.setAttribute("url.full", graphqlEndpoint)
+	defer func() { span.end(err) }()
+
+	encoded, err := json.Marshal(reqs)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newGraphQLHTTPRequest(ctx, encoded)
+	if err != nil {
+		return nil, err
+	}
+	setDefaultHeaders(req.Header)
+	injectTraceContext(ctx, req.Header)
+	for k, v := range getHeaders() {
+		req.Header[k] = v
+	}
+	if err := applyConfiguredAuth(ctx, req.Header, graphqlEndpoint); err != nil {
+		return nil, err
+	}
+
+	release, err := acquireRequestSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if err := allowEndpointRequest(); err != nil {
+		return nil, err
+	}
+	defer func() { recordEndpointResult(ctx, err) }()
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	span.setAttribute("http.response.status_code", res.StatusCode)
+	if err := redirectResponseError(req, res); err != nil {
+		return nil, err
+	}
+	reader, err := responseBodyReader(res)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	trimmed := strings.TrimSpace(string(body))
+	if !isSuccessStatus(res.StatusCode) {
+		statusErr := &httpStatusError{StatusCode: res.StatusCode, RetryAfter: res.Header.Get("Retry-After"), Body: trimmed}
+		if isAuthFailure(statusErr) {
+			return nil, statusErr
+		}
+		return nil, fmt.Errorf("%w (%w); it may not support transport-level batching, run the operations one by one with invoke_graphql", errHTTPBatchRejected, statusErr)
+	}
+	if !strings.HasPrefix(trimmed, "[") {
+		reason := ""
+		var single graphqlResponse
+		if json.Unmarshal(body, &single) == nil && len(single.Errors) > 0 {
+			reason = fmt.Sprintf(" (%q)", single.Errors[0].Message)
+		}
+		return nil, fmt.Errorf("%w: it answered with a single response instead of an array%s, so it probably doesn't support transport-level batching; run the operations one by one with invoke_graphql", errHTTPBatchRejected, reason)
+	}
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return nil, fmt.Errorf("decoding batch response: %w", err)
+	}
+	if len(responses) != len(reqs) {
+		return nil, fmt.Errorf("%w: it returned %s for %s", errHTTPBatchRejected, pluralize(len(responses), "response", "responses"), pluralize(len(reqs), "operation", "operations"))
+	}
+	for _, r := range responses {
+		if r == nil {
+			return nil, fmt.Errorf("%w: it returned a null response", errHTTPBatchRejected)
+		}
+	}
+	return responses, nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// idempotencyKeyHeader is the header carrying the idempotency key of a call,
+// which servers use to run a retried mutation only once.
+var idempotencyKeyHeader = stringFromEnv("IDEMPOTENCY_KEY_HEADER", "Idempotency-Key")
+
+// autoIdempotencyKey is the idempotencyKey value asking for a generated key.
+const autoIdempotencyKey = "auto"
+
+// resolveIdempotencyKey returns the key to send for the idempotencyKey
+// argument: the key itself, or a new random UUID for "auto".
+func resolveIdempotencyKey(given string) (string, error) {
+	given = strings.TrimSpace(given)
+	if !strings.EqualFold(given, autoIdempotencyKey) {
+		return given, nil
+	}
+	return newUUID()
+}
+
+// newUUID returns a random (version 4) UUID in its canonical form.
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating an idempotency key: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// idempotencyNote tells the caller how to retry the call safely.
+func idempotencyNote(key string) string {
+	return fmt.Sprintf("Sent with %s: %s. To retry this call without duplicating its effects, pass the same idempotencyKey.", http.CanonicalHeaderKey(idempotencyKeyHeader), key)
+}
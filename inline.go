@@ -0,0 +1,451 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tool: inline_variables
+const inlineVariablesToolDescription = `Substitute the values of an operation's variables into the operation itself, producing a standalone operation without variable definitions.
+Values are written as GraphQL literals of the declared types: enum values unquoted, input objects as { field: value }. Declared defaults and deployment-wide default variables are applied, and arguments given a nullable variable without a value are left out, as the server would do.
+
+Best Practices:
+- Use this tool for servers or clients that don't support variables, or to get a copy-pasteable query.
+- Don't inline secrets: the values end up in the operation text.
+
+Arguments:
+- operation (string, Required): The GraphQL operation declaring the variables.
+- variables (string, Optional): A JSON-encoded object of variable values.
+- operationName (string, Optional): The operation to transform when the document defines several.
+
+Example Usage:
+Request:
+  inline_variables(operation: "query ($status: JobStatus!, $first: Int = 10) { jobs(status: $status, first: $first) { id } }", variables: "{\"status\": \"OPEN\"}")
+
+Response:
+  query {
+    jobs(status: OPEN, first: 10) {
+      id
+    }
+  }
+`
+
+// Tool: extract_variables
+const extractVariablesToolDescription = `Move the literal field arguments of an operation into variables, the reverse of inline_variables.
+Each variable is declared with the argument's type from the schema, so the definitions are correct for non-null, list and input object types, and the values are returned as a JSON object ready for invoke_graphql.
+
+Best Practices:
+- Use this tool to turn a hand-written query into a reusable one, or to keep values out of the operation text (e.g. for persisted queries or caching).
+- Arguments that already use variables are left as they are.
+
+Arguments:
+- operation (string, Required): The GraphQL operation whose arguments to extract.
+- operationName (string, Optional): The operation to transform when the document defines several.
+
+Example Usage:
+Request:
+  extract_variables(operation: "{ jobs(status: OPEN, first: 10) { id applications(first: 5) { id } } }")
+
+Response:
+  query ($status: JobStatus, $first: Int, $applicationsFirst: Int) {
+    jobs(status: $status, first: $first) {
+      id
+      applications(first: $applicationsFirst) {
+        id
+      }
+    }
+  }
+
+  Variables:
+  {
+    "applicationsFirst": 5,
+    "first": 10,
+    "status": "OPEN"
+  }
+`
+
+// inlineOperationVariables replaces the variables of the selected operation
+// with literals of their values and returns the resulting document.
+func inlineOperationVariables(ctx context.Context, operation, variablesJSON, operationName string) (string, error) {
+	doc, op, err := parseSingleOperation(operation, operationName)
+	if err != nil {
+		return "", err
+	}
+	vars, err := parseVariables(variablesJSON)
+	if err != nil {
+		return "", err
+	}
+	vars = applyDefaultVariables(operation, vars)
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	declared := make([]string, 0, len(op.VariableDefinitions))
+	for _, def := range op.VariableDefinitions {
+		declared = append(declared, def.Name)
+	}
+	var unknown []string
+	for name := range vars {
+		if !contains(declared, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("%s", unknownKeyProblem("$"+unknown[0], "variable not declared by the operation", unknown[0], declared))
+	}
+
+	literals := make(map[string]*astValue, len(op.VariableDefinitions))
+	for _, def := range op.VariableDefinitions {
+		v, ok := vars[def.Name]
+		switch {
+		case ok:
+			if literals[def.Name], err = literalFromJSON(schema, def.Type, v, "$"+def.Name); err != nil {
+				return "", err
+			}
+		case def.DefaultValue != nil:
+			literals[def.Name] = def.DefaultValue
+		case def.Type.NonNull:
+			return "", fmt.Errorf("$%s: required variable of type %s is missing", def.Name, def.Type)
+		}
+	}
+
+	// omitted reports whether v is a variable without a value: the argument
+	// or input field it is given to is left out, as servers do
+	omitted := func(v *astValue) bool {
+		return v.Kind == valueVariable && literals[v.Raw] == nil
+	}
+
+	var substitute func(v *astValue) *astValue
+	var substituteArgs func(args []*astArgument) []*astArgument
+	substitute = func(v *astValue) *astValue {
+		switch v.Kind {
+		case valueVariable:
+			if literal := literals[v.Raw]; literal != nil {
+				return literal
+			}
+			// A list item can't be left out
+			return &astValue{Kind: valueNull, Raw: "null"}
+		case valueList:
+			for i, item := range v.List {
+				v.List[i] = substitute(item)
+			}
+		case valueObject:
+			v.Fields = substituteArgs(v.Fields)
+		}
+		return v
+	}
+	substituteArgs = func(args []*astArgument) []*astArgument {
+		kept := args[:0]
+		for _, a := range args {
+			if !omitted(a.Value) {
+				a.Value = substitute(a.Value)
+				kept = append(kept, a)
+			}
+		}
+		return kept
+	}
+	substituteDirectives := func(dirs []*astDirective) {
+		for _, d := range dirs {
+			d.Arguments = substituteArgs(d.Arguments)
+		}
+	}
+	var walk func(sels []*astSelection)
+	walk = func(sels []*astSelection) {
+		for _, sel := range sels {
+			sel.Arguments = substituteArgs(sel.Arguments)
+			substituteDirectives(sel.Directives)
+			walk(sel.SelectionSet)
+		}
+	}
+	substituteDirectives(op.Directives)
+	walk(op.SelectionSet)
+	for _, frag := range doc.Fragments {
+		substituteDirectives(frag.Directives)
+		walk(frag.SelectionSet)
+	}
+	op.VariableDefinitions = nil
+	return printDocument(doc), nil
+}
+
+// extractOperationVariables replaces the literal field arguments of the
+// selected operation with variables and returns the resulting document
+// followed by the variable values.
+func extractOperationVariables(ctx context.Context, operation, operationName string) (string, error) {
+	doc, op, err := parseSingleOperation(operation, operationName)
+	if err != nil {
+		return "", err
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	taken := make(map[string]bool)
+	for _, def := range op.VariableDefinitions {
+		taken[def.Name] = true
+	}
+	values := make(map[string]interface{})
+	// variableName picks an unused name: the argument name, then the field
+	// name followed by the argument name, then a numbered one
+	variableName := func(field, arg string) string {
+		name := arg
+		if taken[name] {
+			name = field + strings.ToUpper(arg[:1]) + arg[1:]
+		}
+		for i := 2; taken[name]; i++ {
+			name = fmt.Sprintf("%s%s%d", field, strings.ToUpper(arg[:1])+arg[1:], i)
+		}
+		taken[name] = true
+		return name
+	}
+
+	var walk func(sels []*astSelection, parent string, depth int)
+	walk = func(sels []*astSelection, parent string, depth int) {
+		// Guard against fragments that (invalidly) select themselves
+		if depth > 64 {
+			return
+		}
+		for _, fs := range doc.fieldSelections(sels) {
+			typeName := parent
+			if fs.TypeCondition != "" {
+				typeName = fs.TypeCondition
+			}
+			f := lookupField(schema, typeName, fs.Field.Name)
+			if f == nil {
+				continue
+			}
+			for _, arg := range fs.Field.Arguments {
+				var def *schemaInputValue
+				for _, a := range f.Args {
+					if a.Name == arg.Name {
+						def = a
+					}
+				}
+				if def == nil || hasVariables(arg.Value) {
+					continue
+				}
+				name := variableName(fs.Field.Name, arg.Name)
+				values[name] = literalToJSON(arg.Value)
+				op.VariableDefinitions = append(op.VariableDefinitions, &astVariableDefinition{Name: name, Type: astTypeFromRef(def.Type)})
+				arg.Value = &astValue{Kind: valueVariable, Raw: name}
+			}
+			walk(fs.Field.SelectionSet, f.Type.namedType(), depth+1)
+		}
+	}
+	walk(op.SelectionSet, schema.rootType(op.Operation), 0)
+
+	if len(values) == 0 {
+		return printDocument(doc) + "\n\nThe operation has no literal arguments to extract.", nil
+	}
+	encoded, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return printDocument(doc) + "\n\nVariables:\n" + string(encoded), nil
+}
+
+// parseSingleOperation parses operation and returns a document holding only
+// the selected operation and the fragments it uses, along with the operation.
+func parseSingleOperation(operation, operationName string) (*astDocument, *astOperation, error) {
+	doc, err := parseDocument(operation)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse operation: %w", err)
+	}
+	var op *astOperation
+	switch {
+	case len(doc.Operations) == 0:
+		return nil, nil, fmt.Errorf("the document contains no operation")
+	case operationName != "":
+		for _, o := range doc.Operations {
+			if o.Name == operationName {
+				op = o
+			}
+		}
+		if op == nil {
+			return nil, nil, fmt.Errorf("operationName %q does not match an operation in the document (%s)", operationName, strings.Join(operationNames(doc), ", "))
+		}
+	case len(doc.Operations) > 1:
+		return nil, nil, fmt.Errorf("the document contains %d operations (%s); pass operationName to choose one", len(doc.Operations), strings.Join(operationNames(doc), ", "))
+	default:
+		op = doc.Operations[0]
+	}
+
+	// Keep only the fragments the operation spreads, directly or not
+	used := make(map[string]bool)
+	var visit func(sels []*astSelection)
+	visit = func(sels []*astSelection) {
+		for _, sel := range sels {
+			if sel.Kind == selectionFragmentSpread && !used[sel.Name] {
+				used[sel.Name] = true
+				if frag := doc.fragment(sel.Name); frag != nil {
+					visit(frag.SelectionSet)
+				}
+			}
+			visit(sel.SelectionSet)
+		}
+	}
+	visit(op.SelectionSet)
+	single := &astDocument{Operations: []*astOperation{op}}
+	for _, frag := range doc.Fragments {
+		if used[frag.Name] {
+			single.Fragments = append(single.Fragments, frag)
+		}
+	}
+	return single, op, nil
+}
+
+// literalFromJSON converts a decoded JSON value into a GraphQL literal of the
+// type t, quoting strings except for enum values.
+func literalFromJSON(schema *schemaModel, t *astType, v interface{}, path string) (*astValue, error) {
+	if v == nil {
+		if t.NonNull {
+			return nil, fmt.Errorf("%s: expected %s, got null", path, t)
+		}
+		return &astValue{Kind: valueNull, Raw: "null"}, nil
+	}
+	if t.Elem != nil {
+		list, ok := v.([]interface{})
+		if !ok {
+			// A single value is accepted where a list is expected
+			return literalFromJSON(schema, t.Elem, v, path)
+		}
+		literal := &astValue{Kind: valueList, List: []*astValue{}}
+		for i, item := range list {
+			itemLiteral, err := literalFromJSON(schema, t.Elem, item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			literal.List = append(literal.List, itemLiteral)
+		}
+		return literal, nil
+	}
+
+	typ := schema.typeByName(t.Name)
+	switch v := v.(type) {
+	case string:
+		if typ != nil && typ.Kind == "ENUM" {
+			return &astValue{Kind: valueEnum, Raw: v}, nil
+		}
+		return &astValue{Kind: valueString, Raw: v}, nil
+	case map[string]interface{}:
+		if typ == nil || typ.Kind != "INPUT_OBJECT" {
+			return genericLiteral(v), nil
+		}
+		names := make([]string, 0, len(typ.InputFields))
+		fieldTypes := make(map[string]*astType, len(typ.InputFields))
+		for _, f := range typ.InputFields {
+			names = append(names, f.Name)
+			fieldTypes[f.Name] = astTypeFromRef(f.Type)
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		literal := &astValue{Kind: valueObject, Fields: []*astArgument{}}
+		for _, k := range keys {
+			fieldType, ok := fieldTypes[k]
+			if !ok {
+				return nil, fmt.Errorf("%s", unknownKeyProblem(path+"."+k, "unknown field of "+typ.Name, k, names))
+			}
+			fieldLiteral, err := literalFromJSON(schema, fieldType, v[k], path+"."+k)
+			if err != nil {
+				return nil, err
+			}
+			literal.Fields = append(literal.Fields, &astArgument{Name: k, Value: fieldLiteral})
+		}
+		return literal, nil
+	}
+	return genericLiteral(v), nil
+}
+
+// genericLiteral converts a decoded JSON value into a GraphQL literal without
+// type information, e.g. for JSON scalars.
+func genericLiteral(v interface{}) *astValue {
+	switch v := v.(type) {
+	case nil:
+		return &astValue{Kind: valueNull, Raw: "null"}
+	case bool:
+		return &astValue{Kind: valueBoolean, Raw: strconv.FormatBool(v)}
+	case float64:
+		raw := strconv.FormatFloat(v, 'f', -1, 64)
+		if strings.Contains(raw, ".") {
+			return &astValue{Kind: valueFloat, Raw: raw}
+		}
+		return &astValue{Kind: valueInt, Raw: raw}
+	case string:
+		return &astValue{Kind: valueString, Raw: v}
+	case []interface{}:
+		literal := &astValue{Kind: valueList, List: []*astValue{}}
+		for _, item := range v {
+			literal.List = append(literal.List, genericLiteral(item))
+		}
+		return literal
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		literal := &astValue{Kind: valueObject, Fields: []*astArgument{}}
+		for _, k := range keys {
+			literal.Fields = append(literal.Fields, &astArgument{Name: k, Value: genericLiteral(v[k])})
+		}
+		return literal
+	}
+	return &astValue{Kind: valueString, Raw: fmt.Sprint(v)}
+}
+
+// literalToJSON converts a GraphQL literal without variables into the JSON
+// value a variable would carry. Numbers keep their exact text.
+func literalToJSON(v *astValue) interface{} {
+	switch v.Kind {
+	case valueInt, valueFloat:
+		return json.Number(v.Raw)
+	case valueBoolean:
+		return v.Raw == "true"
+	case valueNull:
+		return nil
+	case valueList:
+		list := make([]interface{}, len(v.List))
+		for i, item := range v.List {
+			list[i] = literalToJSON(item)
+		}
+		return list
+	case valueObject:
+		obj := make(map[string]interface{}, len(v.Fields))
+		for _, f := range v.Fields {
+			obj[f.Name] = literalToJSON(f.Value)
+		}
+		return obj
+	}
+	// Strings and enum values
+	return v.Raw
+}
+
+// hasVariables reports whether the literal refers to a variable.
+func hasVariables(v *astValue) bool {
+	switch v.Kind {
+	case valueVariable:
+		return true
+	case valueList:
+		for _, item := range v.List {
+			if hasVariables(item) {
+				return true
+			}
+		}
+	case valueObject:
+		for _, f := range v.Fields {
+			if hasVariables(f.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Tool: get_input_type
+const getInputTypeToolDescription = `Expand an input object type into the full tree of its fields, including nested input objects, with an example JSON skeleton to fill in.
+
+Best Practices:
+- Use this tool before building variables for a mutation that takes a nested input object.
+- Fields marked (required) must be present; the others may be omitted.
+- Start from the example JSON and replace the placeholder values.
+
+Arguments:
+- name (string, Required): The name of the input object type, e.g. CandidateInput.
+
+Example Usage:
+Request:
+  get_input_type(name: "CandidateInput")
+
+Response:
+  CandidateInput
+  	name: String! (required)
+  	status: CandidateStatus = ACTIVE (one of ACTIVE, INACTIVE)
+  	address: AddressInput
+  		zip: String! (required)
+
+  Example JSON:
+  {
+    "name": "",
+    "status": "ACTIVE",
+    "address": {
+      "zip": ""
+    }
+  }
+`
+
+// maxInputTypeDepth bounds the expansion of deeply nested input types.
+const maxInputTypeDepth = 10
+
+// describeInputType renders the field tree of an input object type followed
+// by an example JSON value.
+func describeInputType(ctx context.Context, name string) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	name = strings.TrimPrefix(strings.TrimSpace(name), "input.")
+	t := schema.typeByName(name)
+	if t == nil {
+		var names []string
+		for _, t := range schema.Types {
+			if t.Kind == "INPUT_OBJECT" {
+				names = append(names, t.Name)
+			}
+		}
+		return "", fmt.Errorf("input type '%s' not found in schema. Did you mean: %s?", name, strings.Join(suggestNames(name, names, maxSuggestions), ", "))
+	}
+	if t.Kind != "INPUT_OBJECT" {
+		return "", fmt.Errorf("'%s' is a %s, not an input object type", name, t.Kind)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(t.Name + "\n")
+	writeInputTree(&sb, schema, t, 1, map[string]bool{t.Name: true})
+
+	skeleton, err := json.MarshalIndent(inputSkeleton(schema, t, map[string]bool{t.Name: true}), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString("\nExample JSON:\n")
+	sb.Write(skeleton)
+	return sb.String(), nil
+}
+
+// writeInputTree writes a line per input field of t, expanding nested input
+// objects. ancestors holds the types being expanded, to stop at cycles.
+func writeInputTree(sb *strings.Builder, schema *schemaModel, t *schemaType, depth int, ancestors map[string]bool) {
+	indent := strings.Repeat("\t", depth)
+	for _, f := range t.InputFields {
+		line := indent + inputValueString(f)
+		var notes []string
+		if f.Type.isNonNull() && f.DefaultValue == nil {
+			notes = append(notes, "required")
+		}
+		nested := schema.typeByName(f.Type.namedType())
+		if nested != nil && nested.Kind == "ENUM" {
+			values := make([]string, len(nested.EnumValues))
+			for i, v := range nested.EnumValues {
+				values[i] = v.Name
+			}
+			notes = append(notes, "one of "+strings.Join(values, ", "))
+		}
+		expand := nested != nil && nested.Kind == "INPUT_OBJECT"
+		if expand && ancestors[nested.Name] {
+			notes = append(notes, "recursive, see "+nested.Name+" above")
+			expand = false
+		} else if expand && depth >= maxInputTypeDepth {
+			notes = append(notes, "not expanded, nested too deep")
+			expand = false
+		}
+		if len(notes) > 0 {
+			line += " (" + strings.Join(notes, "; ") + ")"
+		}
+		sb.WriteString(line + "\n")
+		if expand {
+			ancestors[nested.Name] = true
+			writeInputTree(sb, schema, nested, depth+1, ancestors)
+			delete(ancestors, nested.Name)
+		}
+	}
+}
+
+// inputSkeleton builds an example value for an input object: placeholders of
+// the right JSON type, enum defaults or first values, and one-element lists.
+// Recursive fields are left null.
+func inputSkeleton(schema *schemaModel, t *schemaType, ancestors map[string]bool) skeletonObject {
+	obj := make(skeletonObject, 0, len(t.InputFields))
+	for _, f := range t.InputFields {
+		obj = append(obj, skeletonField{f.Name, skeletonValue(schema, f.Type, f.DefaultValue, ancestors, len(ancestors))})
+	}
+	return obj
+}
+
+// skeletonObject is a JSON object that keeps the schema's field order.
+type skeletonObject []skeletonField
+
+type skeletonField struct {
+	Name  string
+	Value interface{}
+}
+
+func (o skeletonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// skeletonValue builds an example value for a type reference.
+func skeletonValue(schema *schemaModel, ref *typeRef, defaultValue *string, ancestors map[string]bool, depth int) interface{} {
+	switch {
+	case ref == nil:
+		return nil
+	case ref.Kind == "NON_NULL":
+		return skeletonValue(schema, ref.OfType, defaultValue, ancestors, depth)
+	case ref.Kind == "LIST":
+		return []interface{}{skeletonValue(schema, ref.OfType, nil, ancestors, depth)}
+	}
+
+	t := schema.typeByName(ref.Name)
+	if t == nil {
+		return nil
+	}
+	switch t.Kind {
+	case "ENUM":
+		if defaultValue != nil {
+			return *defaultValue
+		}
+		if len(t.EnumValues) > 0 {
+			return t.EnumValues[0].Name
+		}
+		return ""
+	case "INPUT_OBJECT":
+		if ancestors[t.Name] || depth >= maxInputTypeDepth {
+			return nil
+		}
+		ancestors[t.Name] = true
+		defer delete(ancestors, t.Name)
+		return inputSkeleton(schema, t, ancestors)
+	}
+	switch t.Name {
+	case "Int", "Float":
+		return 0
+	case "Boolean":
+		return false
+	case "String", "ID":
+		return ""
+	}
+	// Custom scalars have no known representation
+	return nil
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// errIntrospectionDisabled is returned when the server refuses introspection
+// queries, as many production deployments do.
+var errIntrospectionDisabled = errors.New("introspection is disabled on the server")
+
+// errIntrospectionTooComplex is returned when the server rejects an
+// introspection query for exceeding its complexity or depth limits.
+var errIntrospectionTooComplex = errors.New("the introspection query exceeds the server's limits")
+
+// introspectionTooComplexPattern matches the messages servers use to reject
+// queries over their complexity or depth limits, e.g. "Query is too complex"
+// or "exceeds maximum operation depth of 10".
+var introspectionTooComplexPattern = regexp.MustCompile(`(?i)too (complex|deep|large)|complexity|max(imum)?[ _-]*(query |operation )?depth|depth limit|cost limit|exceeds? .*(depth|cost)`)
+
+// introspectionDisabledPattern matches the messages servers use to refuse
+// introspection, e.g. "GraphQL introspection is not allowed" (Apollo) or
+// "Cannot query field "__schema" on type "Query"".
+var introspectionDisabledPattern = regexp.MustCompile(`(?i)introspection.*(disabled|not allowed|not permitted|forbidden|blocked|denied|turned off)|(disabled|disallowed|blocked).*introspection|cannot query field .?__(schema|type)`)
+
+// defaultIntrospectionDepth is the number of list and non-null wrappers the
+// introspection query unwraps by default, enough for [[Type!]!]! and more.
+const defaultIntrospectionDepth = 7
+
+// introspectionDepth is how many levels of list and non-null wrappers the
+// introspection queries unwrap in type references, from INTROSPECTION_DEPTH.
+// Deeper queries describe deeply nested types completely but are larger, and
+// servers with depth limits may reject them; shallower ones lose the
+// innermost wrappers of types nested deeper, e.g. [[Int!]!]! needs 4.
+var introspectionDepth = introspectionDepthFromEnv()
+
+// introspectionQuery is the standard introspection query used to load the
+// full schema.
+var introspectionQuery = introspectionQueryBody + "\n\n" + typeRefFragment(introspectionDepth)
+
+const introspectionQueryBody = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      ...FullType
+    }
+    directives {
+      name
+      description
+      locations
+      args {
+        ...InputValue
+      }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args {
+      ...InputValue
+    }
+    type {
+      ...TypeRef
+    }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields {
+    ...InputValue
+  }
+  interfaces {
+    ...TypeRef
+  }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes {
+    ...TypeRef
+  }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}`
+
+// reducedIntrospectionQueries are tried in order when the server rejects
+// introspectionQuery as too complex. The first leaves out descriptions,
+// deprecation reasons and directive arguments and unwraps types three levels
+// deep (or INTROSPECTION_DEPTH, when it is lower), enough for [Type!]!; the
+// second keeps only type, field and argument names and types.
+var reducedIntrospectionQueries = []string{
+	reducedIntrospectionQueryBody + "\n\n" + compactTypeRefFragment(min(introspectionDepth, 3)),
+	minimalIntrospectionQueryBody + "\n\n" + compactTypeRefFragment(min(introspectionDepth, 3)),
+}
+
+const reducedIntrospectionQueryBody = `query ReducedIntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields(includeDeprecated: true) {
+        name
+        args { name type { ...TypeRef } defaultValue }
+        type { ...TypeRef }
+        isDeprecated
+      }
+      inputFields { name type { ...TypeRef } defaultValue }
+      interfaces { name }
+      enumValues(includeDeprecated: true) { name isDeprecated }
+      possibleTypes { name }
+    }
+    directives { name locations }
+  }
+}`
+
+const minimalIntrospectionQueryBody = `query MinimalIntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types {
+      kind
+      name
+      fields { name args { name type { ...TypeRef } } type { ...TypeRef } }
+      inputFields { name type { ...TypeRef } }
+      enumValues { name }
+    }
+  }
+}`
+
+// introspectionDepthFromEnv reads INTROSPECTION_DEPTH, falling back to the
+// default when it isn't a positive number.
+func introspectionDepthFromEnv() int {
+	depth := intFromEnv("INTROSPECTION_DEPTH", defaultIntrospectionDepth)
+	if depth < 1 {
+		log.Printf("Warning: INTROSPECTION_DEPTH must be at least 1, using %d", defaultIntrospectionDepth)
+		return defaultIntrospectionDepth
+	}
+	return depth
+}
+
+// typeRefFragment renders the TypeRef fragment of the introspection query,
+// unwrapping depth levels of ofType.
+func typeRefFragment(depth int) string {
+	var sb strings.Builder
+	sb.WriteString("fragment TypeRef on __Type {\n  kind\n  name\n")
+	for i := 1; i <= depth; i++ {
+		indent := strings.Repeat("  ", i)
+		sb.WriteString(indent + "ofType {\n" + indent + "  kind\n" + indent + "  name\n")
+	}
+	for i := depth; i >= 1; i-- {
+		sb.WriteString(strings.Repeat("  ", i) + "}\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// compactTypeRefFragment is typeRefFragment on one line, for the reduced
+// introspection queries.
+func compactTypeRefFragment(depth int) string {
+	return "fragment TypeRef on __Type {\n  kind\n  name\n  " + strings.Repeat("ofType { kind name ", depth) + strings.TrimSpace(strings.Repeat("} ", depth)) + "\n}"
+}
+
+// partialSchemaNote explains what a schema loaded with a reduced
+// introspection query lacks.
+const partialSchemaNote = "Note: the schema is partial. The server rejected the full introspection query as too complex, so it was loaded with a reduced one: descriptions, deprecation reasons, directive arguments and the innermost wrappers of deeply nested types may be missing. Set SCHEMA_FILE to an SDL file of the schema for complete results, or lower INTROSPECTION_DEPTH if the server limits the query depth."
+
+// introspectionResult is the "data" portion of an introspection response.
+type introspectionResult struct {
+	Schema struct {
+		QueryType        *struct{ Name string } `json:"queryType"`
+		MutationType     *struct{ Name string } `json:"mutationType"`
+		SubscriptionType *struct{ Name string } `json:"subscriptionType"`
+		Types            []*schemaType          `json:"types"`
+		Directives       []*schemaDirective     `json:"directives"`
+	} `json:"__schema"`
+}
+
+// introspect loads the full schema from the GraphQL endpoint. When the
+// server rejects the standard query as too complex, the reduced queries are
+// tried and the schema is marked partial. The request is bound to ctx, so it
+// is aborted as soon as the caller is cancelled.
+func introspect(ctx context.Context) (*schemaModel, error) {
+	var data introspectionResult
+	partial := false
+	err := runIntrospectionQuery(ctx, introspectionQuery, &data)
+	if isAuthFailure(err) && resetLoginToken() {
+		// The session token may have expired
+		err = runIntrospectionQuery(ctx, introspectionQuery, &data)
+	}
+	for _, query := range reducedIntrospectionQueries {
+		if !errors.Is(err, errIntrospectionTooComplex) {
+			break
+		}
+		log.Printf("Warning: %v; retrying with a reduced introspection query", err)
+		data, partial = introspectionResult{}, true
+		err = runIntrospectionQuery(ctx, query, &data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rootName := func(root *struct{ Name string }) string {
+		if root == nil {
+			return ""
+		}
+		return root.Name
+	}
+	s := data.Schema
+	if cut := truncatedTypeRefs(s.Types); len(cut) > 0 {
+		log.Printf("Warning: the types of %s are nested deeper than the introspection query unwraps, so their innermost types are unknown (first: %s); raise INTROSPECTION_DEPTH (currently %d) to load them completely", pluralize(len(cut), "field or argument", "fields or arguments"), cut[0], introspectionDepth)
+	}
+	schema := newSchemaModel(rootName(s.QueryType), rootName(s.MutationType), rootName(s.SubscriptionType), s.Types, s.Directives)
+	schema.Partial = partial
+	return schema, nil
+}
+
+// truncatedTypeRefs lists the fields, arguments and input fields whose type
+// reference ends in a list or non-null wrapper without its type, because it
+// is nested deeper than the introspection query unwraps.
+func truncatedTypeRefs(types []*schemaType) []string {
+	cut := func(ref *typeRef) bool {
+		for ref != nil && ref.OfType != nil {
+			ref = ref.OfType
+		}
+		return ref != nil && (ref.Kind == "LIST" || ref.Kind == "NON_NULL")
+	}
+	var truncated []string
+	for _, t := range types {
+		for _, f := range t.Fields {
+			if cut(f.Type) {
+				truncated = append(truncated, t.Name+"."+f.Name)
+			}
+			for _, a := range f.Args {
+				if cut(a.Type) {
+					truncated = append(truncated, t.Name+"."+f.Name+"("+a.Name+")")
+				}
+			}
+		}
+		for _, f := range t.InputFields {
+			if cut(f.Type) {
+				truncated = append(truncated, t.Name+"."+f.Name)
+			}
+		}
+	}
+	return truncated
+}
+
+// runIntrospectionQuery sends an introspection query to the GraphQL endpoint
+// with the current headers and decodes the "data" portion of the response into out.
+func runIntrospectionQuery(ctx context.Context, query string, out interface{}) (err error) {
+	ctx, span := startGraphQLSpan(ctx, query, "")
+	defer func() { span.end(err) }()
+	encoded, err := encodeGraphQLRequest(graphqlRequest{Query: query})
+	if err != nil {
+		return err
+	}
+	req, err := newGraphQLHTTPRequest(ctx, encoded)
+	if err != nil {
+		return err
+	}
+	setDefaultHeaders(req.Header)
+	injectTraceContext(ctx, req.Header)
+	for k, v := range getHeaders() {
+		req.Header[k] = v
+	}
+	if err := applyConfiguredAuth(ctx, req.Header, graphqlEndpoint); err != nil {
+		return err
+	}
+
+	release, err := acquireRequestSlot(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if err := allowEndpointRequest(); err != nil {
+		return err
+	}
+	defer func() { recordEndpointResult(ctx, err) }()
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	span.setAttribute("http.response.status_code", res.StatusCode)
+	if err := redirectResponseError(req, res); err != nil {
+		return err
+	}
+	reader, err := responseBodyReader(res)
+	if err != nil {
+		return err
+	}
+	if !isSuccessStatus(res.StatusCode) {
+		body, _ := io.ReadAll(io.LimitReader(reader, 4096))
+		statusErr := &httpStatusError{StatusCode: res.StatusCode, RetryAfter: res.Header.Get("Retry-After"), Body: strings.TrimSpace(string(body))}
+		if introspectionDisabledPattern.Match(body) {
+			return fmt.Errorf("%w: %v", errIntrospectionDisabled, statusErr)
+		}
+		if introspectionTooComplexPattern.Match(body) {
+			return fmt.Errorf("%w: %v", errIntrospectionTooComplex, statusErr)
+		}
+		return statusErr
+	}
+
+	var body struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphqlError  `json:"errors"`
+	}
+	if err := json.NewDecoder(reader).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode introspection response: %w", err)
+	}
+	if len(body.Errors) > 0 {
+		first := body.Errors[0]
+		switch {
+		case introspectionDisabledPattern.MatchString(first.Message):
+			return fmt.Errorf("%w: %s", errIntrospectionDisabled, first.Message)
+		case introspectionTooComplexPattern.MatchString(first.Message):
+			return fmt.Errorf("%w: %s", errIntrospectionTooComplex, first.Message)
+		case isUnauthenticatedError(first) || isForbiddenError(first):
+			return fmt.Errorf("introspection failed: %s (%w)", first.Message, errHTTPAuth)
+		}
+		return fmt.Errorf("introspection failed: %s", first.Message)
+	}
+	return json.Unmarshal(body.Data, out)
+}
+
+// schemaErrorHint suggests what to do about a failure to load the schema,
+// telling a server that disabled introspection apart from rejected
+// credentials and an unreachable endpoint.
+func schemaErrorHint(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded):
+		return ""
+	case errors.Is(err, errHTTPServer) || errors.Is(err, errHTTPRateLimited):
+		// The status category already says what to do
+		return ""
+	case errors.Is(err, errIntrospectionTooComplex):
+		return fmt.Sprintf(". Even the reduced introspection queries exceed the server's limits; if it limits the query depth, lower INTROSPECTION_DEPTH (currently %d) at the cost of the innermost wrappers of deeply nested types, or set SCHEMA_FILE to a local SDL file of the schema instead.", introspectionDepth)
+	case errors.Is(err, errIntrospectionDisabled):
+		return ". The server does not allow introspection; set SCHEMA_FILE to a local SDL file of the schema instead."
+	case errors.Is(err, errHTTPAuth):
+		return ". The server rejected the credentials; send a valid Authorization header with set_headers."
+	case errors.As(err, &netErr):
+		return ". The GraphQL endpoint could not be reached; check ADDRESS and the network."
+	}
+	return ". Do you need no send an Authorization header?"
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newBlockingServer starts an endpoint that never answers, until the test
+// ends, and points the bridge at it.
+func newBlockingServer(t *testing.T) {
+	t.Helper()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+	previous := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	t.Cleanup(func() { graphqlEndpoint = previous })
+}
+
+// assertCancelledPromptly cancels the context of call once it is waiting
+// on the endpoint, and checks that it returns context.Canceled right away.
+func assertCancelledPromptly(t *testing.T, call func(ctx context.Context) error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- call(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancelled := time.Now()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+		if elapsed := time.Since(cancelled); elapsed > time.Second {
+			t.Errorf("returned %s after the context was cancelled", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the call didn't return after the context was cancelled")
+	}
+}
+
+func TestIntrospectCancellation(t *testing.T) {
+	newBlockingServer(t)
+	assertCancelledPromptly(t, func(ctx context.Context) error {
+		_, err := introspect(ctx)
+		return err
+	})
+}
+
+// TestIntrospect checks that an introspection response decodes into the
+// same model as the SDL it was generated from.
+func TestIntrospect(t *testing.T) {
+	schema := parseTestSchema(t, recursiveSchema)
+	var response struct {
+		Data introspectionResult `json:"data"`
+	}
+	s := &response.Data.Schema
+	s.QueryType = &struct{ Name string }{schema.QueryType}
+	s.Types, s.Directives = schema.Types, schema.Directives
+	body, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	defer srv.Close()
+	previous := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	t.Cleanup(func() { graphqlEndpoint = previous })
+
+	introspected, err := introspect(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := printSchemaSDL(introspected), printSchemaSDL(schema); got != want {
+		t.Errorf("introspected schema:\n%s\nwant\n%s", got, want)
+	}
+	if introspected.MutationType != "" || introspected.Partial {
+		t.Errorf("mutation type = %q and partial = %v, want none and false", introspected.MutationType, introspected.Partial)
+	}
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Tool: variables_json_schema
+const variablesJSONSchemaToolDescription = `Generate a JSON Schema (draft 2020-12) describing the variables of an operation, for form generators, validators and other tooling.
+The variable types are resolved against the schema: input objects become objects with their fields, defined once under $defs so recursive inputs are supported; enums list their values; lists become arrays; and nullable types also accept null. Variables and input fields that are non-null without a default are required, and default values and descriptions are carried over.
+
+Best Practices:
+- Use this tool to validate or render the inputs of an operation outside of GraphQL, e.g. to generate a form.
+- Custom scalars accept any value, unless SCALAR_FORMATS gives them a format (date, date-time, time, uuid or a pattern).
+- Deployment-wide default variables are reported as defaults, and those variables aren't required.
+
+Arguments:
+- operation (string, Required): The GraphQL operation declaring the variables.
+- operationName (string, Optional): The operation to use when the document defines several.
+
+Example Usage:
+Request:
+  variables_json_schema(operation: "mutation CreateCandidate($input: CandidateInput!, $notify: Boolean = true) { createCandidate(input: $input) { id } }")
+
+Response:
+  {
+    "$schema": "https://json-schema.org/draft/2020-12/schema",
+    "title": "CreateCandidate variables",
+    "type": "object",
+    "properties": {
+      "input": {
+        "$ref": "#/$defs/CandidateInput"
+      },
+      "notify": {
+        "type": [
+          "boolean",
+          "null"
+        ],
+        "default": true
+      }
+    },
+    "required": [
+      "input"
+    ],
+    "additionalProperties": false,
+    "$defs": {
+      "CandidateInput": {
+        "type": "object",
+        "properties": {
+          "name": {
+            "type": "string",
+            "description": "The full name"
+          },
+          "status": {
+            "anyOf": [
+              {
+                "$ref": "#/$defs/CandidateStatus"
+              },
+              {
+                "type": "null"
+              }
+            ],
+            "default": "ACTIVE"
+          }
+        },
+        "required": [
+          "name"
+        ],
+        "additionalProperties": false
+      },
+      "CandidateStatus": {
+        "type": "string",
+        "enum": [
+          "ACTIVE",
+          "ARCHIVED"
+        ]
+      }
+    }
+  }
+`
+
+// jsonSchemaDraft identifies the JSON Schema dialect of the generated schemas.
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// variablesJSONSchema returns the JSON Schema of the variables of the
+// operation selected by operationName.
+func variablesJSONSchema(ctx context.Context, operation, operationName string) (string, error) {
+	_, op, err := parseSingleOperation(operation, operationName)
+	if err != nil {
+		return "", err
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	b := &jsonSchemaBuilder{schema: schema, defined: make(map[string]bool)}
+	deploymentDefaults := applyDefaultVariables(operation, nil)
+	properties := make(skeletonObject, 0, len(op.VariableDefinitions))
+	var required []string
+	for _, def := range op.VariableDefinitions {
+		prop := b.typeSchema(def.Type)
+		hasDefault := true
+		switch value, ok := deploymentDefaults[def.Name]; {
+		case def.DefaultValue != nil:
+			prop = append(prop, skeletonField{"default", literalToJSON(def.DefaultValue)})
+		case ok:
+			prop = append(prop, skeletonField{"default", value})
+		default:
+			hasDefault = false
+		}
+		properties = append(properties, skeletonField{def.Name, prop})
+		if def.Type.NonNull && !hasDefault {
+			required = append(required, def.Name)
+		}
+	}
+	if b.err != nil {
+		return "", b.err
+	}
+
+	title := "Variables"
+	if op.Name != "" {
+		title = op.Name + " variables"
+	}
+	out := skeletonObject{
+		{"$schema", jsonSchemaDraft},
+		{"title", title},
+		{"type", "object"},
+		{"properties", properties},
+	}
+	if len(required) > 0 {
+		out = append(out, skeletonField{"required", required})
+	}
+	out = append(out, skeletonField{"additionalProperties", false})
+	if len(b.defs) > 0 {
+		out = append(out, skeletonField{"$defs", b.defs})
+	}
+	encoded, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// jsonSchemaBuilder converts GraphQL input types to JSON Schema, collecting
+// the enums and input objects under $defs.
+type jsonSchemaBuilder struct {
+	schema  *schemaModel
+	defs    skeletonObject
+	defined map[string]bool
+	// err is the first type that couldn't be resolved
+	err error
+}
+
+// typeSchema returns the JSON Schema of the values of t.
+func (b *jsonSchemaBuilder) typeSchema(t *astType) skeletonObject {
+	var s skeletonObject
+	if t.Elem != nil {
+		s = skeletonObject{{"type", "array"}, {"items", b.typeSchema(t.Elem)}}
+	} else {
+		s = b.namedTypeSchema(t.Name)
+	}
+	if t.NonNull {
+		return s
+	}
+	return nullableJSONSchema(s)
+}
+
+// namedTypeSchema returns the JSON Schema of a named input type: scalars
+// inline, enums and input objects as references to their definitions.
+func (b *jsonSchemaBuilder) namedTypeSchema(name string) skeletonObject {
+	switch name {
+	case "Int":
+		return skeletonObject{{"type", "integer"}}
+	case "Float":
+		return skeletonObject{{"type", "number"}}
+	case "String":
+		return skeletonObject{{"type", "string"}}
+	case "Boolean":
+		return skeletonObject{{"type", "boolean"}}
+	case "ID":
+		// IDs are serialized as strings but accept integers as input
+		return skeletonObject{{"type", []string{"string", "integer"}}}
+	}
+
+	t := b.schema.typeByName(name)
+	if t == nil {
+		if b.err == nil {
+			b.err = fmt.Errorf("type '%s' not found in schema", name)
+		}
+		return skeletonObject{}
+	}
+	switch t.Kind {
+	case "ENUM", "INPUT_OBJECT":
+		b.define(t)
+		return skeletonObject{{"$ref", "#/$defs/" + t.Name}}
+	case "SCALAR":
+		s := skeletonObject{}
+		if f := scalarFormats[t.Name]; f != nil {
+			s = append(s, skeletonField{"type", "string"})
+			if f.Name != "" {
+				s = append(s, skeletonField{"format", f.Name})
+			} else {
+				s = append(s, skeletonField{"pattern", f.Pattern})
+			}
+		}
+		return s
+	}
+	if b.err == nil {
+		b.err = fmt.Errorf("%s is %s, not an input type", t.Name, kindArticle(b.schema, t.Name))
+	}
+	return skeletonObject{}
+}
+
+// define adds the definition of an enum or input object to $defs, once. The
+// slot is taken before the fields are converted, so recursive input objects
+// refer to the definition being built.
+func (b *jsonSchemaBuilder) define(t *schemaType) {
+	if b.defined[t.Name] {
+		return
+	}
+	b.defined[t.Name] = true
+	slot := len(b.defs)
+	b.defs = append(b.defs, skeletonField{Name: t.Name})
+
+	var def skeletonObject
+	if t.Kind == "ENUM" {
+		values := make([]string, len(t.EnumValues))
+		for i, v := range t.EnumValues {
+			values[i] = v.Name
+		}
+		def = skeletonObject{{"type", "string"}, {"enum", values}}
+	} else {
+		properties := make(skeletonObject, 0, len(t.InputFields))
+		var required []string
+		for _, f := range t.InputFields {
+			fieldType := astTypeFromRef(f.Type)
+			prop := b.typeSchema(fieldType)
+			if desc := strings.TrimSpace(f.Description); desc != "" {
+				prop = append(prop, skeletonField{"description", desc})
+			}
+			if f.DefaultValue != nil {
+				if value, err := parseConstValue(*f.DefaultValue); err == nil {
+					prop = append(prop, skeletonField{"default", literalToJSON(value)})
+				}
+			}
+			properties = append(properties, skeletonField{f.Name, prop})
+			if fieldType.NonNull && f.DefaultValue == nil {
+				required = append(required, f.Name)
+			}
+		}
+		def = skeletonObject{{"type", "object"}, {"properties", properties}}
+		if len(required) > 0 {
+			def = append(def, skeletonField{"required", required})
+		}
+		def = append(def, skeletonField{"additionalProperties", false})
+	}
+	if desc := strings.TrimSpace(t.Description); desc != "" {
+		def = append(def, skeletonField{"description", desc})
+	}
+	b.defs[slot].Value = def
+}
+
+// nullableJSONSchema extends s to also accept null: by adding "null" to its
+// types, or with anyOf for references. A schema accepting anything already
+// accepts null.
+func nullableJSONSchema(s skeletonObject) skeletonObject {
+	for i, f := range s {
+		if f.Name != "type" {
+			continue
+		}
+		switch types := f.Value.(type) {
+		case string:
+			s[i].Value = []string{types, "null"}
+		case []string:
+			s[i].Value = append(types, "null")
+		}
+		return s
+	}
+	if len(s) == 0 {
+		return s
+	}
+	return skeletonObject{{"anyOf", []skeletonObject{s, {{"type", "null"}}}}}
+}
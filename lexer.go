@@ -0,0 +1,290 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind identifies the kind of a lexical token in a GraphQL document.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+	tokenBlockString
+)
+
+// token is a single lexical token. For strings, value holds the decoded text.
+type token struct {
+	kind   tokenKind
+	value  string
+	line   int
+	column int
+}
+
+// syntaxError describes a problem found while lexing or parsing a GraphQL
+// document, with the 1-based line and column where it occurred.
+type syntaxError struct {
+	Message string
+	Line    int
+	Column  int
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("syntax error at line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// lexer splits a GraphQL document into tokens, skipping whitespace, commas
+// and comments, which are insignificant in GraphQL.
+type lexer struct {
+	src    string
+	pos    int
+	line   int
+	column int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1, column: 1}
+}
+
+// advance moves the cursor forward by n bytes, keeping line/column up to date.
+func (l *lexer) advance(n int) {
+	for i := 0; i < n && l.pos < len(l.src); i++ {
+		if l.src[l.pos] == '\n' {
+			l.line++
+			l.column = 1
+		} else if l.src[l.pos]&0xC0 != 0x80 {
+			// Count runes, not UTF-8 continuation bytes
+			l.column++
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) errorf(line, column int, format string, args ...interface{}) error {
+	return &syntaxError{Message: fmt.Sprintf(format, args...), Line: line, Column: column}
+}
+
+// skipIgnored skips whitespace, line terminators, commas, the BOM and comments.
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			l.advance(1)
+		case c == '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.advance(1)
+			}
+		case strings.HasPrefix(l.src[l.pos:], "\ufeff"):
+			l.advance(len("\ufeff"))
+		default:
+			return
+		}
+	}
+}
+
+// next returns the next token in the document.
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	line, column := l.line, l.column
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, line: line, column: column}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.advance(3)
+		return token{kind: tokenPunct, value: "...", line: line, column: column}, nil
+	case strings.ContainsRune("!$&()=:@[]{}|", rune(c)):
+		l.advance(1)
+		return token{kind: tokenPunct, value: string(c), line: line, column: column}, nil
+	case c == '_' || isLetter(c):
+		start := l.pos
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.advance(1)
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], line: line, column: column}, nil
+	case c == '-' || isDigit(c):
+		return l.readNumber(line, column)
+	case strings.HasPrefix(l.src[l.pos:], `"""`):
+		return l.readBlockString(line, column)
+	case c == '"':
+		return l.readString(line, column)
+	}
+
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, l.errorf(line, column, "unexpected character %q", r)
+}
+
+// readNumber reads an IntValue or FloatValue token.
+func (l *lexer) readNumber(line, column int) (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.advance(1)
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '0' {
+		l.advance(1)
+		if l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			return token{}, l.errorf(l.line, l.column, "invalid number, unexpected digit after 0")
+		}
+	} else if err := l.readDigits(); err != nil {
+		return token{}, err
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.advance(1)
+		if err := l.readDigits(); err != nil {
+			return token{}, err
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.advance(1)
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.advance(1)
+		}
+		if err := l.readDigits(); err != nil {
+			return token{}, err
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == '_' || l.src[l.pos] == '.' || isLetter(l.src[l.pos])) {
+		return token{}, l.errorf(l.line, l.column, "invalid number, unexpected character %q", l.src[l.pos])
+	}
+	return token{kind: kind, value: l.src[start:l.pos], line: line, column: column}, nil
+}
+
+func (l *lexer) readDigits() error {
+	if l.pos >= len(l.src) || !isDigit(l.src[l.pos]) {
+		return l.errorf(l.line, l.column, "invalid number, expected digit")
+	}
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.advance(1)
+	}
+	return nil
+}
+
+// readString reads a quoted string, decoding escape sequences.
+func (l *lexer) readString(line, column int) (token, error) {
+	l.advance(1)
+	var sb strings.Builder
+	for {
+		if l.pos >= len(l.src) || l.src[l.pos] == '\n' || l.src[l.pos] == '\r' {
+			return token{}, l.errorf(line, column, "unterminated string")
+		}
+		c := l.src[l.pos]
+		if c == '"' {
+			l.advance(1)
+			return token{kind: tokenString, value: sb.String(), line: line, column: column}, nil
+		}
+		if c != '\\' {
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			sb.WriteRune(r)
+			l.advance(size)
+			continue
+		}
+		if l.pos+1 >= len(l.src) {
+			return token{}, l.errorf(line, column, "unterminated string")
+		}
+		esc := l.src[l.pos+1]
+		switch esc {
+		case '"', '\\', '/':
+			sb.WriteByte(esc)
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'u':
+			if l.pos+6 > len(l.src) {
+				return token{}, l.errorf(l.line, l.column, "invalid unicode escape sequence")
+			}
+			code, err := strconv.ParseUint(l.src[l.pos+2:l.pos+6], 16, 32)
+			if err != nil {
+				return token{}, l.errorf(l.line, l.column, "invalid unicode escape sequence %q", l.src[l.pos:l.pos+6])
+			}
+			sb.WriteRune(rune(code))
+			l.advance(6)
+			continue
+		default:
+			return token{}, l.errorf(l.line, l.column, "invalid escape sequence \\%c", esc)
+		}
+		l.advance(2)
+	}
+}
+
+// readBlockString reads a triple-quoted block string and returns its value
+// with common indentation and leading/trailing blank lines removed.
+func (l *lexer) readBlockString(line, column int) (token, error) {
+	l.advance(3)
+	var sb strings.Builder
+	for {
+		if l.pos >= len(l.src) {
+			return token{}, l.errorf(line, column, "unterminated block string")
+		}
+		if strings.HasPrefix(l.src[l.pos:], `\"""`) {
+			sb.WriteString(`"""`)
+			l.advance(4)
+			continue
+		}
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			l.advance(3)
+			return token{kind: tokenBlockString, value: blockStringValue(sb.String()), line: line, column: column}, nil
+		}
+		sb.WriteByte(l.src[l.pos])
+		l.advance(1)
+	}
+}
+
+// blockStringValue implements the BlockStringValue algorithm of the GraphQL
+// specification: it removes the common indentation and blank edge lines.
+func blockStringValue(raw string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(raw, "\r\n", "\n"), "\r", "\n"), "\n")
+	common := -1
+	for i, line := range lines {
+		if i == 0 {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (common == -1 || indent < common) {
+			common = indent
+		}
+	}
+	if common > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= common {
+				lines[i] = lines[i][common:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
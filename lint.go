@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tool: lint_operation
+const lintOperationToolDescription = `Check a valid operation for common anti-patterns that make it slow, large or fragile, and suggest fixes.
+Rules:
+- depth: selections nested deeper than the maximum depth (LINT_MAX_DEPTH, 6 by default).
+- unbounded-list: list or connection fields that accept a pagination argument (first, limit, ...) without one given, which may return every item.
+- deprecated: deprecated fields and enum values.
+- duplicate-field: a field selected twice under the same response name, redundantly or with conflicting arguments.
+
+Best Practices:
+- Lint operations you wrote before running them with invoke_graphql, especially ones selecting nested lists.
+- Warnings are advice: an operation can run with them, but fixing them keeps responses small and fast.
+
+Arguments:
+- operation (string, Required): The GraphQL operation to lint, including any fragments.
+- disable (string, Optional): Comma-separated rules to skip, e.g. "depth,deprecated".
+- maxDepth (number, Optional): The deepest nesting allowed, overriding LINT_MAX_DEPTH.
+
+Example Usage:
+Request:
+  lint_operation(operation: "query { jobs { id title title applications { id } } }")
+
+Response:
+  Found 3 warnings:
+  - [unbounded-list] Query.jobs (line 1, column 9): returns a list and accepts first, limit, but none is given. Pass first to bound the result
+  - [duplicate-field] Job.title (line 1, column 25): selected twice. Remove the duplicate
+  - [unbounded-list] Job.applications (line 1, column 31): returns a list and accepts first, but none is given. Pass first to bound the result
+`
+
+// Lint settings: the deepest nesting allowed, and the arguments that bound
+// the number of items a list field returns.
+var (
+	lintMaxDepth       = intFromEnv("LINT_MAX_DEPTH", 6)
+	lintPaginationArgs = listFromEnv("LINT_PAGINATION_ARGS")
+)
+
+// defaultLintPaginationArgs are the pagination arguments used when
+// LINT_PAGINATION_ARGS is unset.
+var defaultLintPaginationArgs = []string{"first", "last", "limit", "take", "top", "pageSize", "perPage"}
+
+// lintRules are the rules lint_operation applies.
+var lintRules = []string{"depth", "unbounded-list", "deprecated", "duplicate-field"}
+
+// lintOperation parses operation and reports the anti-patterns it contains,
+// except for the rules listed in disable.
+func lintOperation(ctx context.Context, operation, disable string, maxDepth int) (string, error) {
+	enabled := make(map[string]bool, len(lintRules))
+	for _, rule := range lintRules {
+		enabled[rule] = true
+	}
+	for _, rule := range strings.Split(disable, ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		if !contains(lintRules, rule) {
+			return "", fmt.Errorf("%s", unknownKeyProblem(rule, "unknown rule, expected one of "+strings.Join(lintRules, ", "), rule, lintRules))
+		}
+		enabled[rule] = false
+	}
+	if maxDepth <= 0 {
+		maxDepth = lintMaxDepth
+	}
+	paginationArgs := lintPaginationArgs
+	if len(paginationArgs) == 0 {
+		paginationArgs = defaultLintPaginationArgs
+	}
+
+	doc, err := parseDocument(operation)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse operation: %w", err)
+	}
+	if len(doc.Operations) == 0 {
+		return "", fmt.Errorf("the document contains no operation")
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var warnings []string
+	warn := func(rule, typeName string, field *astSelection, message string) {
+		if enabled[rule] {
+			warnings = append(warnings, fmt.Sprintf("[%s] %s.%s (line %d, column %d): %s", rule, typeName, field.Name, field.Line, field.Column, message))
+		}
+	}
+
+	var walk func(sels []*astSelection, parent string, depth int)
+	walk = func(sels []*astSelection, parent string, depth int) {
+		// Guard against fragments that (invalidly) select themselves
+		if depth > 64 {
+			return
+		}
+		fields := doc.fieldSelections(sels)
+		lintDuplicateFields(fields, parent, warn)
+		for _, fs := range fields {
+			typeName := parent
+			if fs.TypeCondition != "" {
+				typeName = fs.TypeCondition
+			}
+			f := lookupField(schema, typeName, fs.Field.Name)
+			if f == nil {
+				continue
+			}
+			if depth+1 > maxDepth {
+				warn("depth", typeName, fs.Field, fmt.Sprintf("is nested %d levels deep, more than %d. Fetch the nested data in a separate query, e.g. by id", depth+1, maxDepth))
+				continue
+			}
+			if accepted := unboundedListArgs(schema, f, fs.Field, paginationArgs); len(accepted) > 0 {
+				warn("unbounded-list", typeName, fs.Field, fmt.Sprintf("returns a list and accepts %s, but none is given. Pass %s to bound the result", strings.Join(accepted, ", "), accepted[0]))
+			}
+			walk(fs.Field.SelectionSet, f.Type.namedType(), depth+1)
+		}
+	}
+	for _, op := range doc.Operations {
+		walk(op.SelectionSet, schema.rootType(op.Operation), 0)
+	}
+	if enabled["deprecated"] {
+		for _, usage := range findDeprecatedUsages(schema, doc) {
+			warnings = append(warnings, "[deprecated] "+usage+". Use the replacement the reason names, if any")
+		}
+	}
+
+	if len(warnings) == 0 {
+		return "No problems found.", nil
+	}
+	return fmt.Sprintf("Found %s:\n- %s", pluralize(len(warnings), "warning", "warnings"), strings.Join(warnings, "\n- ")), nil
+}
+
+// lintDuplicateFields warns about the fields of a selection set selected
+// more than once under the same response name.
+func lintDuplicateFields(fields []fieldSelection, parent string, warn func(rule, typeName string, field *astSelection, message string)) {
+	first := make(map[string]*astSelection)
+	for _, fs := range fields {
+		typeName := parent
+		if fs.TypeCondition != "" {
+			typeName = fs.TypeCondition
+		}
+		key := typeName + "." + fs.Field.responseKey()
+		prev, ok := first[key]
+		if !ok {
+			first[key] = fs.Field
+			continue
+		}
+		switch {
+		case prev.Name != fs.Field.Name:
+			warn("duplicate-field", typeName, fs.Field, fmt.Sprintf("uses the response name %s of %s (line %d). Give it another alias", fs.Field.responseKey(), prev.Name, prev.Line))
+		case argumentsString(prev.Arguments) != argumentsString(fs.Field.Arguments):
+			warn("duplicate-field", typeName, fs.Field, fmt.Sprintf("selected again with different arguments (line %d), which the server rejects. Alias one of them", prev.Line))
+		case len(fs.Field.SelectionSet) == 0:
+			warn("duplicate-field", typeName, fs.Field, "selected twice. Remove the duplicate")
+		}
+	}
+}
+
+// argumentsString renders arguments sorted by name, to compare them.
+func argumentsString(args []*astArgument) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = a.Name + ": " + canonicalValue(a.Value)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
+
+// unboundedListArgs returns the pagination arguments f accepts when it
+// returns a list or a connection and sel gives none of them.
+func unboundedListArgs(schema *schemaModel, f *schemaField, sel *astSelection, paginationArgs []string) []string {
+	if !f.Type.isList() && !isConnectionType(schema.typeByName(f.Type.namedType())) {
+		return nil
+	}
+	var accepted []string
+	for _, a := range f.Args {
+		if contains(paginationArgs, a.Name) {
+			if a.DefaultValue != nil {
+				return nil
+			}
+			accepted = append(accepted, a.Name)
+		}
+	}
+	for _, arg := range sel.Arguments {
+		if contains(accepted, arg.Name) {
+			return nil
+		}
+	}
+	return accepted
+}
+
+// isConnectionType reports whether t looks like a Relay connection.
+func isConnectionType(t *schemaType) bool {
+	return t != nil && t.field("edges") != nil && t.field("pageInfo") != nil
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
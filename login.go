@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Session login: for APIs whose tokens come from a custom endpoint rather
+// than OAuth, the credentials rendered from LOGIN_BODY are POSTed to
+// LOGIN_URL and the token found at LOGIN_TOKEN_PATH in the JSON response is
+// sent with every request, until the endpoint rejects it.
+var (
+	loginURL         = getenv("LOGIN_URL")
+	loginTokenPath   = stringFromEnv("LOGIN_TOKEN_PATH", "token")
+	loginHeader      = http.CanonicalHeaderKey(stringFromEnv("LOGIN_HEADER", "Authorization"))
+	loginTokenPrefix = stringFromEnv("LOGIN_TOKEN_PREFIX", "Bearer ")
+
+	loginBody, loginConfigErr = parseLoginBody(getenv("LOGIN_BODY"))
+)
+
+// The session token is fetched once and reused until it is reset
+var (
+	loginMu    sync.Mutex
+	loginToken string
+)
+
+// loginBodyFuncs are the functions LOGIN_BODY may call besides the builtin
+// ones: env reads an environment variable, failing when it is unset, and
+// json encodes a value, e.g. {"password": {{env "API_PASSWORD" | json}}}.
+var loginBodyFuncs = template.FuncMap{
+	"env": func(name string) (string, error) {
+		v := os.Getenv(name)
+		if v == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	},
+	"json": bodyTemplateFuncs["json"],
+}
+
+// parseLoginBody parses the LOGIN_BODY template and checks that it comes
+// with a LOGIN_URL.
+func parseLoginBody(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	if getenv("LOGIN_URL") == "" {
+		return nil, fmt.Errorf("LOGIN_BODY is set without a LOGIN_URL to send it to")
+	}
+	tmpl, err := template.New("LOGIN_BODY").Funcs(loginBodyFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOGIN_BODY: %w", err)
+	}
+	return tmpl, nil
+}
+
+// applyLoginToken sets the session token from LOGIN_URL in header, unless
+// LOGIN_URL is unset or header already carries a credential in LOGIN_HEADER.
+func applyLoginToken(ctx context.Context, header http.Header) error {
+	if loginURL == "" || header.Get(loginHeader) != "" {
+		return nil
+	}
+	loginMu.Lock()
+	defer loginMu.Unlock()
+	if loginToken == "" {
+		token, err := fetchLoginToken(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to log in at LOGIN_URL: %w", err)
+		}
+		loginToken = token
+	}
+	header.Set(loginHeader, loginTokenPrefix+loginToken)
+	return nil
+}
+
+// resetLoginToken drops the session token, so the next request logs in
+// again. It reports whether there was one.
+func resetLoginToken() bool {
+	loginMu.Lock()
+	defer loginMu.Unlock()
+	reset := loginToken != ""
+	loginToken = ""
+	return reset
+}
+
+// fetchLoginToken POSTs the rendered LOGIN_BODY to LOGIN_URL and returns the
+// string at LOGIN_TOKEN_PATH in the response.
+func fetchLoginToken(ctx context.Context) (string, error) {
+	var body bytes.Buffer
+	if loginBody != nil {
+		if err := loginBody.Execute(&body, nil); err != nil {
+			return "", fmt.Errorf("rendering LOGIN_BODY: %w", err)
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loginURL, &body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	setUserAgent(req.Header)
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading the login response: %w", err)
+	}
+	if !isSuccessStatus(res.StatusCode) {
+		return "", fmt.Errorf("the login endpoint returned status %d: %s", res.StatusCode, strings.TrimSpace(string(data)))
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return "", fmt.Errorf("decoding the login response: %w", err)
+	}
+	value, err := valueAtPath(decoded, loginTokenPath)
+	if err != nil {
+		return "", fmt.Errorf("finding the token in the login response (LOGIN_TOKEN_PATH): %w", err)
+	}
+	token, ok := value.(string)
+	if !ok || token == "" {
+		return "", fmt.Errorf("%s: expected the token as a non-empty string, got %s", loginTokenPath, describeJSONValue(value))
+	}
+	return token, nil
+}
@@ -7,17 +7,18 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
-
-	// Existing library used for introspection
-	"github.com/wricardo/graphql"
-
-	// Machine Box library aliased to "graphqlMB"
-	graphqlMB "github.com/machinebox/graphql"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -31,10 +32,35 @@ Best Practices:
 - Use when you have identified the desired operation (query or mutation) and know what variables (if any) need to be supplied.
 - Supply 'operation' as the raw GraphQL operation string.
 - Optionally provide 'variables' as a JSON-encoded string if the operation uses variables.
+- Deployment-wide defaults (e.g. a tenant id) may be merged into the variables the operation declares; values you pass always take precedence.
+- Reference secrets such as API keys as "${env:NAME}" inside variable values instead of asking for them; the server substitutes them before sending and never returns them.
+- To include or skip fields conditionally, use @include(if: $var) or @skip(if: $var) with $var declared as Boolean! and passed as a JSON boolean; directives referring to undeclared or non-Boolean variables are rejected before the operation is sent.
+- Set 'verboseErrors' to get every GraphQL error with its path, locations and extensions (e.g. extensions.code UNAUTHENTICATED vs NOT_FOUND).
+- Responses larger than the configured size limit are truncated; when that happens, select fewer fields or paginate.
+- Every result reports the size of the returned text in _meta.responseChars, with a rough token count in _meta.estimatedTokens (about 4 characters per token), to learn how large similar queries get before running them again.
 
 Arguments:
 - operation (string, Required): The entire GraphQL query or mutation text.
 - variables (string, Optional): A JSON-encoded string representing variables for the operation.
+- operationFile (string, Optional): Path of a file inside the configured operations directory to read the operation from. Ignored when an inline operation is given.
+- verboseErrors (boolean, Optional): Return the full GraphQL errors array instead of only the first message.
+- extensions (string, Optional): A JSON-encoded object sent as the top-level "extensions" field of the request, for server features such as Apollo persisted queries or tracing and client metadata.
+- operationName (string, Optional): The name of the operation to execute. Required when the document contains several operations; otherwise it is taken from the document, and anonymous operations are given a generated name such as "MCPQuery_candidate".
+- returnCost (boolean, Optional): Report the cost, complexity or rate limit data the server returns in the response extensions (e.g. extensions.cost), right after the data and in the result's _meta.cost.
+- idempotencyKey (string, Optional): Send the call with an Idempotency-Key header (IDEMPOTENCY_KEY_HEADER), so a server that honors it runs a retried mutation only once. Pass your own key, or "auto" to generate a random UUID; the key used is reported after the data and in the result's _meta.idempotencyKey. Reuse it when retrying the same mutation.
+- decodePath (string, Optional): Path of a base64 or hex string in the data to return decoded instead of the data, for download-style operations returning files, e.g. "exportReport.file" or "files.0.content". The bytes are returned as an image or binary resource with a one-line summary.
+- decode (string, Optional): How the value at decodePath is encoded: "base64" (the default; standard or URL-safe, padded or not, or a data URL) or "hex".
+- saveTo (string, Optional): Save the decoded bytes to this file inside DOWNLOADS_DIR instead of returning them.
+- noCache (boolean, Optional): Fetch fresh data even when the response cache (RESPONSE_CACHE_TTL) holds a response for this query. Results served from the cache say so in a note and carry _meta.cached.
+- includeMeta (boolean, Optional): Report how long the request took, how big the response was and its HTTP status, in a note after the data and in the result's _meta (requestDurationMs, responseBytes, httpStatus). Off by default to keep the output small.
+- pagination (boolean, Optional): Summarize the pagination of the lists in the response (pageInfo, totalCount, hasMore, nextPage and similar fields): how many items were returned, the total, whether more results exist and what to pass for the next page. The summary follows the data and is in the result's _meta.pagination.
+- validateResponse (boolean, Optional): Check the data against the operation's selection set and the schema types, to catch server contract violations: selected fields missing from the response, fields that weren't selected, nulls in non-null fields and values of the wrong type. The problems follow the data and are in the result's _meta.responseViolations; they don't fail the call.
+- responseShape (string, Optional): What the result contains: "data" (default) for only the data object, "full" for the whole response with data, errors and extensions, or "errorsOnly" for only the errors array (empty when there are none). With "full" and "errorsOnly", GraphQL errors are part of the result instead of failing the call.
+- timeoutMs (number, Optional): Timeout for this call in milliseconds, for operations that legitimately take longer than the default. Values above the server's maximum are clamped, and the response says so.
+- compact (boolean, Optional): Return minified JSON, which uses fewer tokens for large responses. Defaults to pretty-printed JSON.
+- format (string, Optional): "json" (default) or "yaml" to return the result as YAML, which can be easier to read for nested data shown to people. compact doesn't apply to YAML. If the conversion fails, the result is returned as JSON with a note saying why.
+- confirm (boolean, Optional): Required to execute mutations when the server is configured to ask for confirmation. Without it, a "confirmation_required" result describes the mutation instead of running it.
+- coerceVariables (boolean, Optional): Convert variable values to the scalar types the operation declares (number to string for ID/String, string to number for Int/Float, string to boolean for Boolean). Performed coercions are reported with the result.
 
 Example Usage:
 Request:
@@ -61,9 +87,11 @@ Best Practices:
 - Use this tool as the first step to understand your GraphQL schema's query capabilities.
 - Employ it to quickly identify available queries before implementing or debugging API calls.
 - Helps in validating schema changes and documenting GraphQL APIs.
+- An argument whose type ends with ! is required unless it has a default value; pass labelArguments to have each argument labelled (required) or (optional).
 
 Arguments:
-- None
+- compact (boolean) - One line per query, sorted by name, with no header: a concise index of a large API. (Optional)
+- labelArguments (boolean) - Follow each argument with its default value, if any, and (required) or (optional), e.g. "jobs(status: JobStatus! (required), first: Int = 10 (optional)): [Job!]!". (Optional)
 
 Example Usage:
 Request:
@@ -84,9 +112,11 @@ Best Practices:
 - Start with this tool to get a high-level view of your schema's mutation capabilities.
 - Use it for quick verification of available mutations after schema updates or during debugging.
 - Helps in integration testing by listing all possible state-changing operations.
+- An argument whose type ends with ! is required unless it has a default value; pass labelArguments to have each argument labelled (required) or (optional).
 
 Arguments:
-- None
+- compact (boolean) - One line per mutation, sorted by name, with no header. (Optional)
+- labelArguments (boolean) - Follow each argument with its default value, if any, and (required) or (optional), like list_queries. (Optional)
 
 Example Usage:
 Request:
@@ -104,22 +134,30 @@ including structure and functionality.
 
 Best Practices:
 - Use this tool to understand the structure and functionality of one or many operations or types.
+- Read the argument descriptions and default values to fill variables correctly.
+- Use fields to focus on a few fields of a large type; misspelled fields are reported as warnings.
+- At most DESCRIBE_MAX_ENTITIES entities (20 by default) are accepted per call; use search_schema or export_sdl to explore the schema in bulk.
+- Fields marked "(recursive)" (or "recursive": true in JSON) have the type they belong to, e.g. "children: [Category] (recursive)"; select them only a few levels deep.
+- Fields marked "(requires arguments)" (or "requiresArguments": true in JSON) have a required argument, e.g. "applications(status: ApplicationStatus!): [Application!]! (requires arguments)"; they can't be selected bare. Use requiredArgsOnly to list only them.
 
 Arguments:
 - entities (string) - A comma-separated list of GraphQL operations or types to describe. (Required)
+- format (string) - "text" (the default), "json" for a structured description of each entity: kind, type, nullability, deprecation, fields and arguments, or "yaml" for the same description as YAML. (Optional)
+- fields (string) - Only show these fields of the described types, as "Type:field1,field2", separating types with ";" (e.g. "Job:id,name;Candidate:email"). (Optional)
+- requiredArgsOnly (boolean) - Only show the fields of the described types that have required arguments; combined with fields, only those of the listed fields. (Optional)
 
 Example Usage:
 Request:
   describe("query.jobs,type.JobQueryParams,JobsPage,job")
 
 Response:
-  # jobs (Query)
+  jobs(page: Int, size: Int, search: String, params: JobQueryParams): JobsPage
+	Paginated list of jobs.
   Arguments:
-	page: Int
-	size: Int
+	page: Int = 1 — the 1-based page number
+	size: Int = 20 — number of jobs per page
 	search: String
 	params: JobQueryParams
-  Return Type: JobsPage
 
   # JobQueryParams (INPUT_OBJECT)
   Input Fields:
@@ -139,6 +177,7 @@ Response:
 Best Practices:
 - Use this tool to configure authentication headers or other necessary HTTP headers.
 - Headers will persist between requests until explicitly changed.
+- Headers set here take precedence over the defaults, including User-Agent, Accept (e.g. "application/vnd.example.v2+json" for APIs versioned by media type) and the API_VERSION header.
 
 Arguments:
 - headers (string, Required): JSON-encoded string of headers to set.
@@ -152,33 +191,89 @@ Response:
 `
 )
 
-// Replace with your actual GraphQL endpoint
-var graphqlEndpoint = os.Getenv("ADDRESS")
+// serverVersion is the version the MCP server reports, also part of the
+// default User-Agent.
+const serverVersion = "1.0.0"
+
+// The GraphQL endpoint, from ADDRESS and GRAPHQL_PATH
+var graphqlEndpoint, graphqlEndpointErr = resolveEndpoint(getenv("ADDRESS"), getenv("GRAPHQL_PATH"))
+
+// currentHeaders holds the headers set by the user. In-flight calls read
+// them while set_headers or REAUTH_COMMAND replace them, so they are only
+// accessed through userHeaders, setHeaders and replaceHeaders.
+var currentHeaders = struct {
+	sync.RWMutex
+	header http.Header
+}{header: make(http.Header)}
+
+// The headers from GRAPHQL_HEADERS; a malformed value stops the server at
+// startup
+var envHeaders, envHeadersErr = parseEnvHeaders(getenv("GRAPHQL_HEADERS"))
+
+// The API version pinned with API_VERSION, sent in the API_VERSION_HEADER
+// header unless the configured headers already set that header
+var (
+	apiVersion       = getenv("API_VERSION")
+	apiVersionHeader = stringFromEnv("API_VERSION_HEADER", "X-API-Version")
+)
+
+// Whether invoke_graphql returns the full GraphQL errors array by default
+var defaultVerboseErrors = boolFromEnv("VERBOSE_ERRORS")
 
-// Global variable to store headers set by the user
-var currentHeaders = make(http.Header)
+// Whether invoke_graphql returns minified JSON by default
+var defaultCompactOutput = boolFromEnv("COMPACT_OUTPUT")
+
+// Whether debugf writes to the log
+var debugLogging = boolFromEnv("DEBUG")
 
 // main initializes and starts the MCP server with GraphQL tools.
 // It validates required environment variables, performs introspection of the GraphQL endpoint,
-// registers the available tools, and serves the MCP server over standard I/O.
+// registers the available tools, loads the schema when PREWARM is set, and
+// serves the MCP server over standard I/O (or SSE when TRANSPORT=sse) until
+// it receives SIGINT or SIGTERM. With
+// --selftest (or SELFTEST=true) it only checks the setup and exits.
 func main() {
+	flag.Parse()
+
+	// In self-test mode, check the setup and exit without serving
+	if *selfTestFlag {
+		ctx, cancel := context.WithCancel(context.Background())
+		if defaultRequestTimeout > 0 {
+			ctx, cancel = context.WithTimeout(context.Background(), defaultRequestTimeout)
+		}
+		ok := runSelfTest(ctx, os.Stdout)
+		cancel()
+		if !ok {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Validate environment variables
-	if graphqlEndpoint == "" {
-		log.Fatal("Environment variable ADDRESS is required")
+	for _, setting := range configSettings() {
+		if setting.err != nil {
+			log.Fatal(setting.err)
+		}
 	}
 
 	// Create a new MCP server
 	srv := server.NewMCPServer(
-		"graphqlServer", "1.0.0", server.WithLogging(),
+		"graphqlServer", serverVersion, server.WithLogging(),
 	)
 
 	// Register tools
 	registerTools(srv)
 
-	// Serve the MCP server over standard I/O
-	if err := server.ServeStdio(srv); err != nil {
+	// Serve the MCP server until SIGINT/SIGTERM, then shut down gracefully
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if prewarmSchema {
+		prewarmSchemaCache(ctx)
+	}
+	err := serve(ctx, srv)
+	shutdownTracing()
+	if err != nil {
 		log.Fatal("Error serving MCP server:", err)
-		os.Exit(1)
 	}
 }
 
@@ -189,16 +284,67 @@ func main() {
 //   - describe
 //   - invoke_graphql
 //   - set_headers
+//   - list_directives
+//   - schema_stats
+//   - subscribe
+//   - format_operation
+//   - list_named_queries
+//   - run_named_query
+//   - list_scalars
+//   - explain_error
+//   - list_deprecated
+//   - probe_fields
+//   - generate_types
+//   - validate_variables
+//   - get_input_type
+//   - set_basic_auth
+//   - operation_types
+//   - find_usages
+//   - estimate_cost
+//   - list_history
+//   - replay_last
+//   - operation_hash
+//   - search_schema
+//   - to_curl
+//   - simulate_mutation
+//   - describe_field
+//   - schema_roots
+//   - unsubscribe
+//   - check_compatibility
+//   - lint_operation
+//   - inline_variables
+//   - extract_variables
+//   - filter_operations
+//   - clear_response_cache
+//   - variables_json_schema
+//   - export_sdl
+//   - whoami
+//   - diff_operations
+//   - list_error_codes
+//   - save_header_profile
+//   - use_header_profile
+//   - list_header_profiles
+//   - delete_header_profile
+//   - invoke_http_batch
+//   - resolve_path
+//   - export_collection
+//   - session_report
+//   - type_graph
+//   - access_check
 func registerTools(srv *server.MCPServer) {
 	// Tool 1: list_queries
 	listQueriesTool := mcp.NewTool(
 		"list_queries",
 		mcp.WithDescription(listQueriesToolDescription),
+		mcp.WithBoolean("compact", mcp.Description("One line per query, sorted by name, without the header")),
+		mcp.WithBoolean("labelArguments", mcp.Description("Label each argument (required) or (optional) and show its default value")),
 	)
-	srv.AddTool(listQueriesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		queries, err := listGraphQLQueries()
+	addTool(srv, listQueriesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		compact, _ := request.Params.Arguments["compact"].(bool)
+		labelled, _ := request.Params.Arguments["labelArguments"].(bool)
+		queries, err := listGraphQLQueries(ctx, compact, labelled)
 		if err != nil {
-			return toolError("Failed to list queries: " + err.Error() + ". Do you need no send an Authorization header?"), nil
+			return toolError("Failed to list queries: " + err.Error() + schemaErrorHint(err)), nil
 		}
 		return toolSuccess(queries), nil
 	})
@@ -207,11 +353,15 @@ func registerTools(srv *server.MCPServer) {
 	listMutationsTool := mcp.NewTool(
 		"list_mutations",
 		mcp.WithDescription(listMutationsToolDescription),
+		mcp.WithBoolean("compact", mcp.Description("One line per mutation, sorted by name, without the header")),
+		mcp.WithBoolean("labelArguments", mcp.Description("Label each argument (required) or (optional) and show its default value")),
 	)
-	srv.AddTool(listMutationsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		mutations, err := listGraphQLMutations()
+	addTool(srv, listMutationsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		compact, _ := request.Params.Arguments["compact"].(bool)
+		labelled, _ := request.Params.Arguments["labelArguments"].(bool)
+		mutations, err := listGraphQLMutations(ctx, compact, labelled)
 		if err != nil {
-			return toolError("Failed to list mutations: " + err.Error() + ". Do you need no send an Authorization header?"), nil
+			return toolError("Failed to list mutations: " + err.Error() + schemaErrorHint(err)), nil
 		}
 		return toolSuccess(mutations), nil
 	})
@@ -221,26 +371,54 @@ func registerTools(srv *server.MCPServer) {
 		"describe",
 		mcp.WithDescription(describeToolDescription),
 		mcp.WithString("entities", mcp.Description("Comma-separated list of operations or types to describe"), mcp.Required()),
+		mcp.WithString("format", mcp.Description("Output format: \"text\" (default), \"json\" or \"yaml\"")),
+		mcp.WithString("fields", mcp.Description("Only show these fields of large types, e.g. \"Job:id,name\" or \"Job:id;Candidate:email\"")),
+		mcp.WithBoolean("requiredArgsOnly", mcp.Description("Only show the fields of types that have required arguments")),
 	)
-	srv.AddTool(describeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	addTool(srv, describeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		entities := request.Params.Arguments["entities"].(string)
-		description, err := describeGraphQLEntities(entities)
+		format, _ := request.Params.Arguments["format"].(string)
+		fields, _ := request.Params.Arguments["fields"].(string)
+		requiredArgsOnly, _ := request.Params.Arguments["requiredArgsOnly"].(bool)
+		description, warnings, err := describeGraphQLEntities(ctx, entities, format, fields, requiredArgsOnly)
 		if err != nil {
-			return toolError("Failed to describe entities: " + err.Error() + ". Do you need no send an Authorization header?"), nil
+			return toolError("Failed to describe entities: " + err.Error() + schemaErrorHint(err)), nil
 		}
-		return toolSuccess(description), nil
+		result := toolSuccess(description)
+		for _, w := range warnings {
+			result.Content = append(result.Content, mcp.NewTextContent(w))
+		}
+		return result, nil
 	})
 
 	// Tool 4: invoke_graphql
-	// Uses the Machine Box graphql client (aliased as graphqlMB)
 	invokeGraphqlTool := mcp.NewTool(
 		"invoke_graphql",
 		mcp.WithDescription(invokeToolDescription),
 		mcp.WithString("query", mcp.Description("The entire GraphQL query"), mcp.Required()),
 		mcp.WithString("mutation", mcp.Description("The entire GraphQL mutation"), mcp.Required()),
 		mcp.WithString("variables", mcp.Description("JSON-encoded variables for the operation")),
+		mcp.WithString("operationFile", mcp.Description("Path of a file inside OPERATIONS_DIR containing the operation, used when no query or mutation is given")),
+		mcp.WithBoolean("verboseErrors", mcp.Description("Return the full GraphQL errors array (message, path, locations, extensions) on failure")),
+		mcp.WithBoolean("compact", mcp.Description("Return minified JSON instead of pretty-printed JSON")),
+		mcp.WithString("format", mcp.Description("Output format: \"json\" (default) or \"yaml\"")),
+		mcp.WithBoolean("coerceVariables", mcp.Description("Convert variable values to the scalar types the operation declares (e.g. a number passed for an ID)")),
+		mcp.WithBoolean("confirm", mcp.Description("Confirm that a mutation should be executed when mutation confirmation is required")),
+		mcp.WithString("extensions", mcp.Description("JSON object sent as the top-level \"extensions\" of the request (e.g. persisted query hashes or client metadata)")),
+		mcp.WithString("operationName", mcp.Description("The operation to execute when the document contains several")),
+		mcp.WithBoolean("returnCost", mcp.Description("Report the cost or complexity data the server returns in the response extensions")),
+		mcp.WithString("idempotencyKey", mcp.Description("Idempotency key sent as a header so retried mutations run once, or \"auto\" to generate a UUID")),
+		mcp.WithBoolean("noCache", mcp.Description("Bypass the query response cache and fetch fresh data")),
+		mcp.WithString("decodePath", mcp.Description("Path of an encoded string in the data to return decoded, e.g. exportReport.file")),
+		mcp.WithString("decode", mcp.Description("Encoding of the value at decodePath: \"base64\" (default) or \"hex\"")),
+		mcp.WithString("saveTo", mcp.Description("File inside DOWNLOADS_DIR to save the decoded bytes to")),
+		mcp.WithBoolean("includeMeta", mcp.Description("Report the request duration, response size and HTTP status")),
+		mcp.WithBoolean("pagination", mcp.Description("Summarize the pagination fields of the response (totals, whether more results exist, next page or cursor)")),
+		mcp.WithBoolean("validateResponse", mcp.Description("Check the data against the selection set and the schema types, reporting missing or unexpected fields, nulls in non-null fields and values of the wrong type")),
+		mcp.WithString("responseShape", mcp.Description("What to return: \"data\" (default) for the data only, \"full\" for the data, errors and extensions, or \"errorsOnly\" for the errors array")),
+		mcp.WithNumber("timeoutMs", mcp.Description("Timeout for this call in milliseconds, overriding the default (capped by the server's maximum)")),
 	)
-	srv.AddTool(invokeGraphqlTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	addTool(srv, invokeGraphqlTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		// Implement panic recovery
 		defer func() {
 			if r := recover(); r != nil {
@@ -269,22 +447,83 @@ func registerTools(srv *server.MCPServer) {
 			}
 		}
 
+		verboseErrors := defaultVerboseErrors
+		if verboseVal, ok := request.Params.Arguments["verboseErrors"].(bool); ok {
+			verboseErrors = verboseVal
+		}
+
+		opts := invokeOptions{Compact: defaultCompactOutput}
+		if compactVal, ok := request.Params.Arguments["compact"].(bool); ok {
+			opts.Compact = compactVal
+		}
+		opts.CoerceVariables, _ = request.Params.Arguments["coerceVariables"].(bool)
+		opts.Confirmed, _ = request.Params.Arguments["confirm"].(bool)
+		opts.Extensions, _ = request.Params.Arguments["extensions"].(string)
+		opts.ResponseShape, _ = request.Params.Arguments["responseShape"].(string)
+		opts.Format, _ = request.Params.Arguments["format"].(string)
+		opts.OperationName, _ = request.Params.Arguments["operationName"].(string)
+		opts.ReturnCost, _ = request.Params.Arguments["returnCost"].(bool)
+		opts.Pagination, _ = request.Params.Arguments["pagination"].(bool)
+		opts.ValidateResponse, _ = request.Params.Arguments["validateResponse"].(bool)
+		opts.IncludeMeta, _ = request.Params.Arguments["includeMeta"].(bool)
+		opts.IdempotencyKey, _ = request.Params.Arguments["idempotencyKey"].(string)
+		opts.NoCache, _ = request.Params.Arguments["noCache"].(bool)
+		opts.DecodePath, _ = request.Params.Arguments["decodePath"].(string)
+		opts.Decode, _ = request.Params.Arguments["decode"].(string)
+		opts.SaveTo, _ = request.Params.Arguments["saveTo"].(string)
+		timeoutMs, _ := request.Params.Arguments["timeoutMs"].(float64)
+		var timeoutNote string
+		opts.Timeout, timeoutNote = requestTimeout(timeoutMs)
+
 		// Determine which operation to use
 		operation := query
 		if mutation != "" {
 			operation = mutation
 		}
 
+		// Large operations can be read from a file instead; inline ones win
+		var warning string
+		if operationFile, _ := request.Params.Arguments["operationFile"].(string); operationFile != "" {
+			if operation != "" {
+				warning = "Warning: both an inline operation and operationFile were provided; operationFile was ignored."
+				log.Printf("invoke_graphql: ignoring operationFile %q because an inline operation was provided", operationFile)
+			} else {
+				fileOperation, err := readOperationFile(operationFile)
+				if err != nil {
+					return toolError("Failed to load operation: " + err.Error()), nil
+				}
+				operation = fileOperation
+			}
+		}
+
 		// Validate we have an operation to execute
 		if operation == "" {
 			return toolError("No valid query or mutation provided"), nil
 		}
 
-		resp, err := invokeGraphQLOperation(ctx, operation, variablesJSON)
+		if report := toolCallProgress(srv, request); report != nil {
+			ctx = withProgress(ctx, report)
+		}
+		resp, err := invokeGraphQLOperation(ctx, operation, variablesJSON, opts)
+		var confirmErr *confirmationRequiredError
+		if errors.As(err, &confirmErr) {
+			return toolError(confirmErr.Error()), nil
+		}
+		var gqlErr *graphqlResponseError
+		if verboseErrors && errors.As(err, &gqlErr) {
+			return toolError(fmt.Sprintf("Failed to invoke GraphQL operation. Operation: %s variables: %v errors:\n%s", operation, variablesJSON, gqlErr.Verbose())), nil
+		}
 		if err != nil {
-			return toolError(fmt.Sprintf("Failed to invoke GraphQL operation. Operation: %s variables: %v error: %v. ", operation, variablesJSON, err)), nil
+			return toolError(fmt.Sprintf("Failed to invoke GraphQL operation. Operation: %s variables: %v error: %v. %s", operation, variablesJSON, err, timeoutNote)), nil
+		}
+		result := invokeSuccess(resp)
+		if warning != "" {
+			result.Content = append(result.Content, mcp.NewTextContent(warning))
+		}
+		if timeoutNote != "" {
+			result.Content = append(result.Content, mcp.NewTextContent(timeoutNote))
 		}
-		return toolSuccess(resp), nil
+		return result, nil
 	})
 
 	// Tool 5: set_headers
@@ -294,26 +533,857 @@ func registerTools(srv *server.MCPServer) {
 		mcp.WithString("headers", mcp.Description("JSON-encoded string of headers to set"), mcp.Required()),
 	)
 
-	srv.AddTool(setHeadersTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	addTool(srv, setHeadersTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 		headersJSON := request.Params.Arguments["headers"].(string)
 		if err := setHeaders(headersJSON); err != nil {
 			return toolError("Failed to set headers: " + err.Error()), nil
 		}
 		return toolSuccess("Headers updated successfully"), nil
 	})
+
+	// Tool 6: list_directives
+	listDirectivesTool := mcp.NewTool(
+		"list_directives",
+		mcp.WithDescription(listDirectivesToolDescription),
+	)
+	addTool(srv, listDirectivesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		directives, err := listGraphQLDirectives(ctx)
+		if err != nil {
+			return toolError("Failed to list directives: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(directives), nil
+	})
+
+	// Tool 7: schema_stats
+	schemaStatsTool := mcp.NewTool(
+		"schema_stats",
+		mcp.WithDescription(schemaStatsToolDescription),
+	)
+	addTool(srv, schemaStatsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		stats, err := getSchemaStats(ctx)
+		if err != nil {
+			return toolError("Failed to compute schema stats: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(stats), nil
+	})
+
+	// Tool 8: subscribe
+	subscribeTool := mcp.NewTool(
+		"subscribe",
+		mcp.WithDescription(subscribeToolDescription),
+		mcp.WithString("subscription", mcp.Description("The entire GraphQL subscription"), mcp.Required()),
+		mcp.WithString("variables", mcp.Description("JSON-encoded variables for the subscription")),
+		mcp.WithNumber("maxMessages", mcp.Description("Stop after this many events (default 10)")),
+		mcp.WithNumber("maxDurationSeconds", mcp.Description("Stop after this many seconds (default 30)")),
+		mcp.WithBoolean("background", mcp.Description("Return a subscription ID right away instead of waiting for the events; collect them with unsubscribe")),
+	)
+	addTool(srv, subscribeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		subscription, _ := request.Params.Arguments["subscription"].(string)
+		if subscription == "" {
+			return toolError("No valid subscription provided"), nil
+		}
+		variablesJSON, _ := request.Params.Arguments["variables"].(string)
+
+		maxMessages := defaultSubscriptionMessages
+		if n, ok := request.Params.Arguments["maxMessages"].(float64); ok && n > 0 {
+			maxMessages = int(n)
+		}
+		maxDuration := defaultSubscriptionDuration
+		if n, ok := request.Params.Arguments["maxDurationSeconds"].(float64); ok && n > 0 {
+			maxDuration = time.Duration(n * float64(time.Second))
+		}
+
+		if background, _ := request.Params.Arguments["background"].(bool); background {
+			id, err := startBackgroundSubscription(subscription, variablesJSON, maxMessages, maxDuration)
+			if err != nil {
+				return toolError("Failed to start subscription: " + err.Error()), nil
+			}
+			return toolSuccess(fmt.Sprintf("Subscription %s started in the background. It stops after %s or %s; call unsubscribe(id: %q) to stop it earlier and get its events.", id, pluralize(maxMessages, "event", "events"), maxDuration, id)), nil
+		}
+
+		events, err := runSubscription(ctx, subscription, variablesJSON, maxMessages, maxDuration)
+		if err != nil {
+			return toolError(fmt.Sprintf("Failed to run subscription. Subscription: %s variables: %v error: %v. ", subscription, variablesJSON, err)), nil
+		}
+		return toolSuccess(events), nil
+	})
+
+	// Tool 9: format_operation
+	formatOperationTool := mcp.NewTool(
+		"format_operation",
+		mcp.WithDescription(formatOperationToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation to format"), mcp.Required()),
+	)
+	addTool(srv, formatOperationTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		formatted, err := formatOperation(operation)
+		if err != nil {
+			return toolError("Failed to format operation: " + err.Error()), nil
+		}
+		return toolSuccess(formatted), nil
+	})
+
+	// Tool 10: list_named_queries
+	listNamedQueriesTool := mcp.NewTool(
+		"list_named_queries",
+		mcp.WithDescription(listNamedQueriesToolDescription),
+	)
+	addTool(srv, listNamedQueriesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		queries, err := formatNamedQueries()
+		if err != nil {
+			return toolError("Failed to list named queries: " + err.Error()), nil
+		}
+		return toolSuccess(queries), nil
+	})
+
+	// Tool 11: run_named_query
+	runNamedQueryTool := mcp.NewTool(
+		"run_named_query",
+		mcp.WithDescription(runNamedQueryToolDescription),
+		mcp.WithString("name", mcp.Description("Name of the operation in the named query library"), mcp.Required()),
+		mcp.WithString("variables", mcp.Description("JSON-encoded variables for the operation")),
+		mcp.WithBoolean("compact", mcp.Description("Return minified JSON instead of pretty-printed JSON")),
+		mcp.WithBoolean("confirm", mcp.Description("Confirm that a mutation should be executed when mutation confirmation is required")),
+	)
+	addTool(srv, runNamedQueryTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, _ := request.Params.Arguments["name"].(string)
+		variablesJSON, _ := request.Params.Arguments["variables"].(string)
+		opts := invokeOptions{Compact: defaultCompactOutput, Timeout: defaultRequestTimeout}
+		if compactVal, ok := request.Params.Arguments["compact"].(bool); ok {
+			opts.Compact = compactVal
+		}
+		opts.Confirmed, _ = request.Params.Arguments["confirm"].(bool)
+
+		operation, err := loadNamedQuery(name)
+		if err != nil {
+			return toolError("Failed to load named query: " + err.Error()), nil
+		}
+		if report := toolCallProgress(srv, request); report != nil {
+			ctx = withProgress(ctx, report)
+		}
+		resp, err := invokeGraphQLOperation(ctx, operation, variablesJSON, opts)
+		var confirmErr *confirmationRequiredError
+		if errors.As(err, &confirmErr) {
+			return toolError(confirmErr.Error()), nil
+		}
+		if err != nil {
+			return toolError(fmt.Sprintf("Failed to run named query. Name: %s variables: %v error: %v. ", name, variablesJSON, err)), nil
+		}
+		return invokeSuccess(resp), nil
+	})
+
+	// Tool 12: list_scalars
+	listScalarsTool := mcp.NewTool(
+		"list_scalars",
+		mcp.WithDescription(listScalarsToolDescription),
+	)
+	addTool(srv, listScalarsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		scalars, err := listGraphQLScalars(ctx)
+		if err != nil {
+			return toolError("Failed to list scalars: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(scalars), nil
+	})
+
+	// Tool 13: explain_error
+	explainErrorTool := mcp.NewTool(
+		"explain_error",
+		mcp.WithDescription(explainErrorToolDescription),
+		mcp.WithString("error", mcp.Description("The error message or JSON (error object, errors array or full response)"), mcp.Required()),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation that produced the error")),
+	)
+	addTool(srv, explainErrorTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		errorInput, _ := request.Params.Arguments["error"].(string)
+		operation, _ := request.Params.Arguments["operation"].(string)
+		explanation, err := explainGraphQLError(ctx, errorInput, operation)
+		if err != nil {
+			return toolError("Failed to explain error: " + err.Error()), nil
+		}
+		return toolSuccess(explanation), nil
+	})
+
+	// Tool 14: list_deprecated
+	listDeprecatedTool := mcp.NewTool(
+		"list_deprecated",
+		mcp.WithDescription(listDeprecatedToolDescription),
+		mcp.WithString("operations", mcp.Description("GraphQL operations to check for usages of deprecated fields and enum values")),
+	)
+	addTool(srv, listDeprecatedTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operations, _ := request.Params.Arguments["operations"].(string)
+		report, err := listDeprecated(ctx, operations)
+		if err != nil {
+			return toolError("Failed to list deprecated items: " + err.Error()), nil
+		}
+		return toolSuccess(report), nil
+	})
+
+	// Tool 15: probe_fields
+	probeFieldsTool := mcp.NewTool(
+		"probe_fields",
+		mcp.WithDescription(probeFieldsToolDescription),
+		mcp.WithString("type", mcp.Description("The name of the type whose fields to probe"), mcp.Required()),
+		mcp.WithString("entry", mcp.Description("A query field returning the type, with its arguments, e.g. candidate(id: \"1\")")),
+	)
+	addTool(srv, probeFieldsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		typeName, _ := request.Params.Arguments["type"].(string)
+		entry, _ := request.Params.Arguments["entry"].(string)
+		report, err := probeFields(ctx, typeName, entry)
+		if err != nil {
+			return toolError("Failed to probe fields: " + err.Error()), nil
+		}
+		return toolSuccess(report), nil
+	})
+
+	// Tool 16: generate_types
+	generateTypesTool := mcp.NewTool(
+		"generate_types",
+		mcp.WithDescription(generateTypesToolDescription),
+		mcp.WithString("names", mcp.Description("Comma-separated type or operation names, or \"all\""), mcp.Required()),
+		mcp.WithString("language", mcp.Description("Target language: \"typescript\" or \"go\""), mcp.Required()),
+	)
+	addTool(srv, generateTypesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		names, _ := request.Params.Arguments["names"].(string)
+		language, _ := request.Params.Arguments["language"].(string)
+		code, err := generateTypes(ctx, names, language)
+		if err != nil {
+			return toolError("Failed to generate types: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(code), nil
+	})
+
+	// Tool 17: validate_variables
+	validateVariablesTool := mcp.NewTool(
+		"validate_variables",
+		mcp.WithDescription(validateVariablesToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation declaring the variables"), mcp.Required()),
+		mcp.WithString("variables", mcp.Description("JSON-encoded variables to check")),
+	)
+	addTool(srv, validateVariablesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		variablesJSON, _ := request.Params.Arguments["variables"].(string)
+		report, err := validateOperationVariables(ctx, operation, variablesJSON)
+		if err != nil {
+			return toolError("Failed to validate variables: " + err.Error()), nil
+		}
+		return toolSuccess(report), nil
+	})
+
+	// Tool 18: get_input_type
+	getInputTypeTool := mcp.NewTool(
+		"get_input_type",
+		mcp.WithDescription(getInputTypeToolDescription),
+		mcp.WithString("name", mcp.Description("The name of the input object type to expand"), mcp.Required()),
+	)
+	addTool(srv, getInputTypeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, _ := request.Params.Arguments["name"].(string)
+		tree, err := describeInputType(ctx, name)
+		if err != nil {
+			return toolError("Failed to describe input type: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(tree), nil
+	})
+
+	// Tool 19: set_basic_auth
+	setBasicAuthTool := mcp.NewTool(
+		"set_basic_auth",
+		mcp.WithDescription(setBasicAuthToolDescription),
+		mcp.WithString("username", mcp.Description("The basic auth user name, or empty to disable basic auth"), mcp.Required()),
+		mcp.WithString("password", mcp.Description("The basic auth password")),
+	)
+	addTool(srv, setBasicAuthTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		username, _ := request.Params.Arguments["username"].(string)
+		password, _ := request.Params.Arguments["password"].(string)
+		setBasicAuth(username, password)
+		if username == "" {
+			return toolSuccess("Basic auth disabled"), nil
+		}
+		message := fmt.Sprintf("Basic auth set for user %q", username)
+		if userHeaders().Get("Authorization") != "" {
+			message += ". Note: an Authorization header set with set_headers takes precedence, so basic auth is not sent until it is removed."
+		}
+		return toolSuccess(message), nil
+	})
+
+	// Tool 20: operation_types
+	operationTypesTool := mcp.NewTool(
+		"operation_types",
+		mcp.WithDescription(operationTypesToolDescription),
+		mcp.WithString("name", mcp.Description("The query or mutation, e.g. \"candidate\" or \"mutation.createCandidate\""), mcp.Required()),
+		mcp.WithNumber("depth", mcp.Description("How many levels of fields to follow (default 5)")),
+	)
+	addTool(srv, operationTypesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, _ := request.Params.Arguments["name"].(string)
+		depth, _ := request.Params.Arguments["depth"].(float64)
+		report, err := listOperationTypes(ctx, name, int(depth))
+		if err != nil {
+			return toolError("Failed to list operation types: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(report), nil
+	})
+
+	// Tool 21: find_usages
+	findUsagesTool := mcp.NewTool(
+		"find_usages",
+		mcp.WithDescription(findUsagesToolDescription),
+		mcp.WithString("type", mcp.Description("The name of the type to find the fields of, e.g. Candidate"), mcp.Required()),
+	)
+	addTool(srv, findUsagesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		typeName, _ := request.Params.Arguments["type"].(string)
+		usages, err := findTypeUsages(ctx, typeName)
+		if err != nil {
+			return toolError("Failed to find usages: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(usages), nil
+	})
+
+	// Tool 22: estimate_cost
+	estimateCostTool := mcp.NewTool(
+		"estimate_cost",
+		mcp.WithDescription(estimateCostToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL query to estimate"), mcp.Required()),
+		mcp.WithString("variables", mcp.Description("JSON-encoded string of the query's variables")),
+	)
+	addTool(srv, estimateCostTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		variablesJSON, _ := request.Params.Arguments["variables"].(string)
+		estimate, err := estimateOperationCost(ctx, operation, variablesJSON)
+		if err != nil {
+			return toolError("Failed to estimate cost: " + err.Error()), nil
+		}
+		return toolSuccess(estimate), nil
+	})
+
+	// Tool 23: list_history
+	listHistoryTool := mcp.NewTool(
+		"list_history",
+		mcp.WithDescription(listHistoryToolDescription),
+	)
+	addTool(srv, listHistoryTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		entries, err := listHistory()
+		if err != nil {
+			return toolError("Failed to list history: " + err.Error()), nil
+		}
+		return toolSuccess(entries), nil
+	})
+
+	// Tool 24: replay_last
+	replayLastTool := mcp.NewTool(
+		"replay_last",
+		mcp.WithDescription(replayLastToolDescription),
+		mcp.WithNumber("index", mcp.Description("Which operation to replay, 0 being the most recent (default 0)")),
+		mcp.WithString("variables", mcp.Description("JSON-encoded variables merged over the original ones")),
+		mcp.WithBoolean("confirm", mcp.Description("Confirm that a mutation should be executed when mutation confirmation is required")),
+	)
+	addTool(srv, replayLastTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		index, _ := request.Params.Arguments["index"].(float64)
+		variablesJSON, _ := request.Params.Arguments["variables"].(string)
+		confirmed, _ := request.Params.Arguments["confirm"].(bool)
+		resp, err := replayHistory(ctx, int(index), variablesJSON, confirmed)
+		var confirmErr *confirmationRequiredError
+		if errors.As(err, &confirmErr) {
+			return toolError(confirmErr.Error()), nil
+		}
+		if err != nil {
+			return toolError("Failed to replay operation: " + err.Error()), nil
+		}
+		return invokeSuccess(resp), nil
+	})
+
+	// Tool 25: operation_hash
+	operationHashTool := mcp.NewTool(
+		"operation_hash",
+		mcp.WithDescription(operationHashToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation to hash"), mcp.Required()),
+	)
+	addTool(srv, operationHashTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		hash, err := describeOperationHash(operation)
+		if err != nil {
+			return toolError("Failed to hash operation: " + err.Error()), nil
+		}
+		return toolSuccess(hash), nil
+	})
+
+	// Tool 26: search_schema
+	searchSchemaTool := mcp.NewTool(
+		"search_schema",
+		mcp.WithDescription(searchSchemaToolDescription),
+		mcp.WithString("term", mcp.Description("The text to look for in names and descriptions"), mcp.Required()),
+		mcp.WithNumber("limit", mcp.Description("The maximum number of results (default 20)")),
+	)
+	addTool(srv, searchSchemaTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		term, _ := request.Params.Arguments["term"].(string)
+		limit, _ := request.Params.Arguments["limit"].(float64)
+		results, err := searchSchema(ctx, term, int(limit))
+		if err != nil {
+			return toolError("Failed to search the schema: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(results), nil
+	})
+
+	// Tool 27: to_curl
+	toCurlTool := mcp.NewTool(
+		"to_curl",
+		mcp.WithDescription(toCurlToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation"), mcp.Required()),
+		mcp.WithString("variables", mcp.Description("JSON-encoded variables for the operation")),
+		mcp.WithString("operationName", mcp.Description("The operation to execute when the document contains several")),
+		mcp.WithBoolean("includeSecrets", mcp.Description("Include the real credentials instead of [REDACTED] (default false)")),
+	)
+	addTool(srv, toCurlTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		variables, _ := request.Params.Arguments["variables"].(string)
+		operationName, _ := request.Params.Arguments["operationName"].(string)
+		includeSecrets, _ := request.Params.Arguments["includeSecrets"].(bool)
+		command, err := curlCommand(ctx, operation, variables, operationName, includeSecrets)
+		if err != nil {
+			return toolError("Failed to build the curl command: " + err.Error()), nil
+		}
+		return toolSuccess(command), nil
+	})
+
+	// Tool 28: simulate_mutation
+	simulateMutationTool := mcp.NewTool(
+		"simulate_mutation",
+		mcp.WithDescription(simulateMutationToolDescription),
+		mcp.WithString("mutation", mcp.Description("The GraphQL mutation to simulate"), mcp.Required()),
+		mcp.WithString("variables", mcp.Description("JSON-encoded variables for the mutation")),
+		mcp.WithString("operationName", mcp.Description("The operation to execute when the document contains several")),
+		mcp.WithNumber("timeoutMs", mcp.Description("Timeout for this call in milliseconds, overriding the default (capped by the server's maximum)")),
+	)
+	addTool(srv, simulateMutationTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		mutation, _ := request.Params.Arguments["mutation"].(string)
+		variables, _ := request.Params.Arguments["variables"].(string)
+		opts := invokeOptions{Compact: defaultCompactOutput, Simulate: true}
+		opts.OperationName, _ = request.Params.Arguments["operationName"].(string)
+		timeoutMs, _ := request.Params.Arguments["timeoutMs"].(float64)
+		var timeoutNote string
+		opts.Timeout, timeoutNote = requestTimeout(timeoutMs)
+		res, err := invokeGraphQLOperation(ctx, mutation, variables, opts)
+		if errors.Is(err, errSimulationNotSupported) {
+			return toolError("Simulation not supported: " + strings.TrimPrefix(err.Error(), errSimulationNotSupported.Error()+": ")), nil
+		}
+		if err != nil {
+			return toolError(strings.TrimSpace("Failed to simulate mutation: " + err.Error() + ". " + timeoutNote)), nil
+		}
+		result := invokeSuccess(res)
+		if timeoutNote != "" {
+			result.Content = append(result.Content, mcp.NewTextContent(timeoutNote))
+		}
+		return result, nil
+	})
+
+	// Tool 29: describe_field
+	describeFieldTool := mcp.NewTool(
+		"describe_field",
+		mcp.WithDescription(describeFieldToolDescription),
+		mcp.WithString("field", mcp.Description("The field as \"Type.field\", e.g. Job.applications or query.jobs"), mcp.Required()),
+	)
+	addTool(srv, describeFieldTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		field, _ := request.Params.Arguments["field"].(string)
+		description, err := describeSchemaField(ctx, field)
+		if err != nil {
+			return toolError("Failed to describe field: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(description), nil
+	})
+
+	// Tool 30: schema_roots
+	schemaRootsTool := mcp.NewTool(
+		"schema_roots",
+		mcp.WithDescription(schemaRootsToolDescription),
+	)
+	addTool(srv, schemaRootsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		roots, err := describeSchemaRoots(ctx)
+		if err != nil {
+			return toolError("Failed to get the schema roots: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(roots), nil
+	})
+
+	// Tool 31: unsubscribe
+	unsubscribeTool := mcp.NewTool(
+		"unsubscribe",
+		mcp.WithDescription(unsubscribeToolDescription),
+		mcp.WithString("id", mcp.Description("The subscription ID returned by subscribe"), mcp.Required()),
+	)
+	addTool(srv, unsubscribeTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		id, _ := request.Params.Arguments["id"].(string)
+		if id == "" {
+			return toolError("No subscription ID provided"), nil
+		}
+		events, err := stopBackgroundSubscription(id)
+		if err != nil {
+			return toolError("Failed to unsubscribe: " + err.Error()), nil
+		}
+		return toolSuccess(events), nil
+	})
+
+	// Tool 32: check_compatibility
+	checkCompatibilityTool := mcp.NewTool(
+		"check_compatibility",
+		mcp.WithDescription(checkCompatibilityToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation to check"), mcp.Required()),
+	)
+	addTool(srv, checkCompatibilityTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		if operation == "" {
+			return toolError("No valid operation provided"), nil
+		}
+		report, err := checkOperationCompatibility(ctx, operation)
+		if err != nil {
+			return toolError("Failed to check compatibility: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(report), nil
+	})
+	// Tool 33: lint_operation
+	lintOperationTool := mcp.NewTool(
+		"lint_operation",
+		mcp.WithDescription(lintOperationToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation to lint"), mcp.Required()),
+		mcp.WithString("disable", mcp.Description("Comma-separated rules to skip: depth, unbounded-list, deprecated, duplicate-field")),
+		mcp.WithNumber("maxDepth", mcp.Description("The deepest nesting allowed (default LINT_MAX_DEPTH)")),
+	)
+	addTool(srv, lintOperationTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		if operation == "" {
+			return toolError("No valid operation provided"), nil
+		}
+		disable, _ := request.Params.Arguments["disable"].(string)
+		maxDepth, _ := request.Params.Arguments["maxDepth"].(float64)
+		report, err := lintOperation(ctx, operation, disable, int(maxDepth))
+		if err != nil {
+			return toolError("Failed to lint operation: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(report), nil
+	})
+	// Tool 34: inline_variables
+	inlineVariablesTool := mcp.NewTool(
+		"inline_variables",
+		mcp.WithDescription(inlineVariablesToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation declaring the variables"), mcp.Required()),
+		mcp.WithString("variables", mcp.Description("JSON-encoded variable values to inline")),
+		mcp.WithString("operationName", mcp.Description("The operation to transform when the document contains several")),
+	)
+	addTool(srv, inlineVariablesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		if operation == "" {
+			return toolError("No valid operation provided"), nil
+		}
+		variablesJSON, _ := request.Params.Arguments["variables"].(string)
+		operationName, _ := request.Params.Arguments["operationName"].(string)
+		inlined, err := inlineOperationVariables(ctx, operation, variablesJSON, operationName)
+		if err != nil {
+			return toolError("Failed to inline variables: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(inlined), nil
+	})
+
+	// Tool 35: extract_variables
+	extractVariablesTool := mcp.NewTool(
+		"extract_variables",
+		mcp.WithDescription(extractVariablesToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation whose literal arguments to extract"), mcp.Required()),
+		mcp.WithString("operationName", mcp.Description("The operation to transform when the document contains several")),
+	)
+	addTool(srv, extractVariablesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		if operation == "" {
+			return toolError("No valid operation provided"), nil
+		}
+		operationName, _ := request.Params.Arguments["operationName"].(string)
+		extracted, err := extractOperationVariables(ctx, operation, operationName)
+		if err != nil {
+			return toolError("Failed to extract variables: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(extracted), nil
+	})
+	// Tool 36: filter_operations
+	filterOperationsTool := mcp.NewTool(
+		"filter_operations",
+		mcp.WithDescription(filterOperationsToolDescription),
+		mcp.WithString("returns", mcp.Description("The type the operations must yield, e.g. Candidate")),
+		mcp.WithString("accepts", mcp.Description("The type an argument of the operations must have or contain, e.g. AddressInput")),
+	)
+	addTool(srv, filterOperationsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		returns, _ := request.Params.Arguments["returns"].(string)
+		accepts, _ := request.Params.Arguments["accepts"].(string)
+		operations, err := filterOperations(ctx, returns, accepts)
+		if err != nil {
+			return toolError("Failed to filter operations: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(operations), nil
+	})
+
+	// Tool 37: clear_response_cache
+	clearResponseCacheTool := mcp.NewTool(
+		"clear_response_cache",
+		mcp.WithDescription(clearResponseCacheToolDescription),
+	)
+	addTool(srv, clearResponseCacheTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if responseCacheTTL <= 0 {
+			return toolSuccess("The response cache is disabled; set RESPONSE_CACHE_TTL to enable it."), nil
+		}
+		return toolSuccess(fmt.Sprintf("Cleared %s.", pluralize(clearResponseCache(), "cached response", "cached responses"))), nil
+	})
+
+	// Tool 38: variables_json_schema
+	variablesJSONSchemaTool := mcp.NewTool(
+		"variables_json_schema",
+		mcp.WithDescription(variablesJSONSchemaToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation declaring the variables"), mcp.Required()),
+		mcp.WithString("operationName", mcp.Description("The operation to use when the document defines several")),
+	)
+	addTool(srv, variablesJSONSchemaTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		operationName, _ := request.Params.Arguments["operationName"].(string)
+		jsonSchema, err := variablesJSONSchema(ctx, operation, operationName)
+		if err != nil {
+			return toolError("Failed to generate the JSON Schema: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(jsonSchema), nil
+	})
+
+	// Tool 39: export_sdl
+	exportSDLTool := mcp.NewTool(
+		"export_sdl",
+		mcp.WithDescription(exportSDLToolDescription),
+	)
+	addTool(srv, exportSDLTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		sdl, err := exportSDL(ctx)
+		if err != nil {
+			return toolError("Failed to export the schema: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(sdl), nil
+	})
+
+	// Tool 40: whoami
+	whoamiTool := mcp.NewTool(
+		"whoami",
+		mcp.WithDescription(whoamiToolDescription),
+	)
+	addTool(srv, whoamiTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		identity, err := whoami(ctx)
+		if errors.Is(err, errNotAuthenticated) {
+			return toolError("Not authenticated: " + strings.TrimPrefix(err.Error(), errNotAuthenticated.Error()+": ")), nil
+		}
+		if err != nil {
+			return toolError("Failed to check authentication: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(identity), nil
+	})
+
+	// Tool 41: diff_operations
+	diffOperationsTool := mcp.NewTool(
+		"diff_operations",
+		mcp.WithDescription(diffOperationsToolDescription),
+		mcp.WithString("before", mcp.Description("The original operation"), mcp.Required()),
+		mcp.WithString("after", mcp.Description("The changed operation"), mcp.Required()),
+		mcp.WithString("operationName", mcp.Description("The operation to compare when the documents contain several")),
+	)
+	addTool(srv, diffOperationsTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		before, _ := request.Params.Arguments["before"].(string)
+		after, _ := request.Params.Arguments["after"].(string)
+		if before == "" || after == "" {
+			return toolError("Both before and after operations are required"), nil
+		}
+		operationName, _ := request.Params.Arguments["operationName"].(string)
+		diff, err := diffOperations(before, after, operationName)
+		if err != nil {
+			return toolError("Failed to compare operations: " + err.Error()), nil
+		}
+		return toolSuccess(diff), nil
+	})
+
+	// Tool 42: list_error_codes
+	listErrorCodesTool := mcp.NewTool(
+		"list_error_codes",
+		mcp.WithDescription(listErrorCodesToolDescription),
+	)
+	addTool(srv, listErrorCodesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		codes, err := listErrorCodes(ctx)
+		if err != nil {
+			return toolError("Failed to list error codes: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(codes), nil
+	})
+
+	// Tool 43: save_header_profile
+	saveHeaderProfileTool := mcp.NewTool(
+		"save_header_profile",
+		mcp.WithDescription(saveHeaderProfileToolDescription),
+		mcp.WithString("name", mcp.Description("The name to save the current headers under, e.g. \"admin\""), mcp.Required()),
+	)
+	addTool(srv, saveHeaderProfileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, _ := request.Params.Arguments["name"].(string)
+		saved, err := saveHeaderProfile(name)
+		if err != nil {
+			return toolError("Failed to save header profile: " + err.Error()), nil
+		}
+		return toolSuccess(saved), nil
+	})
+
+	// Tool 44: use_header_profile
+	useHeaderProfileTool := mcp.NewTool(
+		"use_header_profile",
+		mcp.WithDescription(useHeaderProfileToolDescription),
+		mcp.WithString("name", mcp.Description("The name of the saved profile to switch to"), mcp.Required()),
+	)
+	addTool(srv, useHeaderProfileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, _ := request.Params.Arguments["name"].(string)
+		used, err := useHeaderProfile(name)
+		if err != nil {
+			return toolError("Failed to use header profile: " + err.Error()), nil
+		}
+		return toolSuccess(used), nil
+	})
+
+	// Tool 45: list_header_profiles
+	listHeaderProfilesTool := mcp.NewTool(
+		"list_header_profiles",
+		mcp.WithDescription(listHeaderProfilesToolDescription),
+	)
+	addTool(srv, listHeaderProfilesTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return toolSuccess(listHeaderProfiles()), nil
+	})
+
+	// Tool 46: delete_header_profile
+	deleteHeaderProfileTool := mcp.NewTool(
+		"delete_header_profile",
+		mcp.WithDescription(deleteHeaderProfileToolDescription),
+		mcp.WithString("name", mcp.Description("The name of the profile to delete"), mcp.Required()),
+	)
+	addTool(srv, deleteHeaderProfileTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		name, _ := request.Params.Arguments["name"].(string)
+		if err := deleteHeaderProfile(name); err != nil {
+			return toolError("Failed to delete header profile: " + err.Error()), nil
+		}
+		return toolSuccess(fmt.Sprintf("Deleted header profile %q.", name)), nil
+	})
+
+	// Tool 47: invoke_http_batch
+	invokeHTTPBatchTool := mcp.NewTool(
+		"invoke_http_batch",
+		mcp.WithDescription(invokeHTTPBatchToolDescription),
+		mcp.WithString("operations", mcp.Description("JSON array of requests with query, variables and operationName"), mcp.Required()),
+		mcp.WithBoolean("confirm", mcp.Description("Confirm the mutations of the batch when confirmation is required")),
+	)
+	addTool(srv, invokeHTTPBatchTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operationsJSON, _ := request.Params.Arguments["operations"].(string)
+		if operationsJSON == "" {
+			return toolError("No operations provided"), nil
+		}
+		confirmed, _ := request.Params.Arguments["confirm"].(bool)
+		body, summary, err := invokeHTTPBatch(ctx, operationsJSON, confirmed)
+		if err != nil {
+			return toolError("Failed to execute batch: " + err.Error()), nil
+		}
+		result := toolSuccess(body)
+		result.Content = append(result.Content, mcp.NewTextContent(summary))
+		return result, nil
+	})
+
+	// Tool 48: resolve_path
+	resolvePathTool := mcp.NewTool(
+		"resolve_path",
+		mcp.WithDescription(resolvePathToolDescription),
+		mcp.WithString("path", mcp.Description("A dotted path of fields from a type or root operation, e.g. Candidate.interviews.scorecard or query.jobs.applications"), mcp.Required()),
+	)
+	addTool(srv, resolvePathTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		path, _ := request.Params.Arguments["path"].(string)
+		resolved, err := resolveSchemaPath(ctx, path)
+		if err != nil {
+			return toolError("Failed to resolve path: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(resolved), nil
+	})
+
+	// Tool 49: export_collection
+	exportCollectionTool := mcp.NewTool(
+		"export_collection",
+		mcp.WithDescription(exportCollectionToolDescription),
+		mcp.WithString("format", mcp.Description("\"postman\" (default) or \"insomnia\"")),
+		mcp.WithString("operations", mcp.Description("Comma-separated operations to export, e.g. \"query.jobs,mutation.createJob\"; all queries and mutations by default")),
+	)
+	addTool(srv, exportCollectionTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		format, _ := request.Params.Arguments["format"].(string)
+		operations, _ := request.Params.Arguments["operations"].(string)
+		collection, err := exportCollection(ctx, format, operations)
+		if err != nil {
+			return toolError("Failed to export collection: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(collection), nil
+	})
+
+	// Tool 50: session_report
+	sessionReportTool := mcp.NewTool(
+		"session_report",
+		mcp.WithDescription(sessionReportToolDescription),
+	)
+	addTool(srv, sessionReportTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		report, err := sessionReport()
+		if err != nil {
+			return toolError("Failed to build session report: " + err.Error()), nil
+		}
+		return toolSuccess(report), nil
+	})
+
+	// Tool 51: type_graph
+	typeGraphTool := mcp.NewTool(
+		"type_graph",
+		mcp.WithDescription(typeGraphToolDescription),
+		mcp.WithString("type", mcp.Description("The root type, or query, mutation or subscription"), mcp.Required()),
+		mcp.WithNumber("depth", mcp.Description("How many fields away from the root to follow, 1 to 5 (default 2)")),
+		mcp.WithString("format", mcp.Description("\"json\" (default) or \"dot\"")),
+	)
+	addTool(srv, typeGraphTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		typeName, _ := request.Params.Arguments["type"].(string)
+		depth, _ := request.Params.Arguments["depth"].(float64)
+		format, _ := request.Params.Arguments["format"].(string)
+		graph, err := describeTypeGraph(ctx, typeName, int(depth), format)
+		if err != nil {
+			return toolError("Failed to build type graph: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(graph), nil
+	})
+
+	// Tool 52: access_check
+	accessCheckTool := mcp.NewTool(
+		"access_check",
+		mcp.WithDescription(accessCheckToolDescription),
+		mcp.WithString("operation", mcp.Description("The GraphQL operation to check"), mcp.Required()),
+		mcp.WithString("variables", mcp.Description("JSON-encoded variables, needed to probe a query with required variables")),
+		mcp.WithString("operationName", mcp.Description("The operation to check when the document contains several")),
+	)
+	addTool(srv, accessCheckTool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		operation, _ := request.Params.Arguments["operation"].(string)
+		variablesJSON, _ := request.Params.Arguments["variables"].(string)
+		operationName, _ := request.Params.Arguments["operationName"].(string)
+		report, err := checkAccess(ctx, operation, variablesJSON, operationName)
+		if err != nil {
+			return toolError("Failed to check access: " + err.Error() + schemaErrorHint(err)), nil
+		}
+		return toolSuccess(report), nil
+	})
 }
 
 // listGraphQLQueries performs introspection to retrieve all available
 // queries from the GraphQL schema and formats them as a string.
-func listGraphQLQueries() (string, error) {
-	res, err := graphql.Introspect(graphqlEndpoint, getHeaders())
+func listGraphQLQueries(ctx context.Context, compact, labelled bool) (string, error) {
+	schema, err := getSchema(ctx)
 	if err != nil {
 		return "", err
 	}
+	if compact {
+		return compactFieldList(schema.queries(), labelled), nil
+	}
 	var sb strings.Builder
 	sb.WriteString("Queries:\n")
-	for _, typ := range res.Data.Schema.Queries {
-		fieldStr := graphql.PrettyPrintField(typ)
+	for _, typ := range schema.queries() {
+		fieldStr := prettyPrintField(typ)
+		if labelled {
+			fieldStr = labelledFieldString(typ)
+		}
 		sb.WriteString(fieldStr + "\n")
 	}
 	return sb.String(), nil
@@ -321,88 +1391,486 @@ func listGraphQLQueries() (string, error) {
 
 // listGraphQLMutations performs introspection to retrieve all available
 // mutations from the GraphQL schema and formats them as a string.
-func listGraphQLMutations() (string, error) {
-	res, err := graphql.Introspect(graphqlEndpoint, getHeaders())
+func listGraphQLMutations(ctx context.Context, compact, labelled bool) (string, error) {
+	schema, err := getSchema(ctx)
 	if err != nil {
 		return "", err
 	}
+	if compact {
+		return compactFieldList(schema.mutations(), labelled), nil
+	}
 	var sb strings.Builder
 	sb.WriteString("Mutations:\n")
-	for _, typ := range res.Data.Schema.Mutations {
-		fieldStr := graphql.PrettyPrintField(typ)
+	for _, typ := range schema.mutations() {
+		fieldStr := prettyPrintField(typ)
+		if labelled {
+			fieldStr = labelledFieldString(typ)
+		}
 		sb.WriteString(fieldStr + "\n")
 	}
 	return sb.String(), nil
 }
 
+// maxDescribeEntities bounds the number of entities one describe call
+// accepts, to avoid accidental huge responses; 0 removes the limit.
+var maxDescribeEntities = intFromEnv("DESCRIBE_MAX_ENTITIES", 20)
+
 // describeGraphQLEntities performs detailed introspection on the specified
 // GraphQL entities (types, queries, mutations) and returns their descriptions.
-func describeGraphQLEntities(entities string) (string, error) {
-	res, err := graphql.Introspect(graphqlEndpoint, getHeaders())
+func describeGraphQLEntities(ctx context.Context, entities, format, fields string, requiredArgsOnly bool) (string, []string, error) {
+	schema, err := getSchema(ctx)
 	if err != nil {
-		return "", err
+		return "", nil, err
+	}
+	mapp := schema.entities
+	filter, err := parseFieldFilter(fields)
+	if err != nil {
+		return "", nil, err
 	}
-	mapp := graphql.GetSchemaMapString(res.Data.Schema)
+	described := make(map[string]bool)
 
 	entitiesList := strings.Split(entities, ",")
+	for i := range entitiesList {
+		entitiesList[i] = strings.TrimSpace(entitiesList[i])
+	}
+	if maxDescribeEntities > 0 && len(entitiesList) > maxDescribeEntities {
+		return "", nil, fmt.Errorf("too many entities: %d were requested and describe accepts at most %d per call (DESCRIBE_MAX_ENTITIES). Describe them in smaller batches, find the relevant ones with search_schema, or get the whole schema with export_sdl", len(entitiesList), maxDescribeEntities)
+	}
+	switch format {
+	case "", "text":
+	case "json", "yaml":
+		description, err := describeEntitiesJSON(schema, entitiesList, filter, requiredArgsOnly, described)
+		if err != nil {
+			return "", nil, err
+		}
+		warnings := filter.warnings(schema, described)
+		if format == "yaml" {
+			if converted, err := jsonToYAML([]byte(description)); err != nil {
+				warnings = append(warnings, yamlFallbackNote(err))
+			} else {
+				description = string(converted)
+			}
+		}
+		return description, warnings, nil
+	default:
+		return "", nil, fmt.Errorf("unknown format %q, expected \"text\", \"json\" or \"yaml\"", format)
+	}
+
 	var descriptions []string
 	for _, entity := range entitiesList {
-		entity = strings.TrimSpace(entity)
-		if desc, ok := mapp[entity]; ok {
+		// Filtered types are rendered on demand; the others were rendered
+		// once, when the schema was loaded
+		t, keep := filter.forEntity(schema, entity)
+		if requiredArgsOnly {
+			t, keep = withRequiredArguments(schema, entity, t, keep)
+		}
+		if keep != nil {
+			descriptions = append(descriptions, prettyPrintTypeFields(t, keep))
+			described[t.Name] = true
+		} else if desc, ok := mapp[entity]; ok {
+			debugf("describe cache hit for %q", entity)
 			descriptions = append(descriptions, desc)
 		} else {
-			var example []string
+			names := make([]string, 0, len(mapp))
 			for k := range mapp {
-				example = append(example, k)
-				if len(example) >= 3 {
-					break
-				}
+				names = append(names, k)
 			}
-			return "", fmt.Errorf("entity '%s' not found in schema. Example entities in the schema: %s", entity, strings.Join(example, ", "))
+			return "", nil, fmt.Errorf("entity '%s' not found in schema. Did you mean: %s?", entity, strings.Join(suggestNames(entity, names, maxSuggestions), ", "))
 		}
 	}
-	return strings.Join(descriptions, "\n\n"), nil
+	return strings.Join(descriptions, "\n\n"), filter.warnings(schema, described), nil
+}
+
+// invokeOptions controls how invokeGraphQLOperation prepares the request and
+// formats its result.
+type invokeOptions struct {
+	// Compact returns minified JSON instead of indented JSON.
+	Compact bool
+	// Format is outputFormatJSON (the default) or outputFormatYAML.
+	Format string
+	// CoerceVariables converts variable values to the scalar types the
+	// operation declares before sending them.
+	CoerceVariables bool
+	// Confirmed allows mutations when REQUIRE_MUTATION_CONFIRM is set.
+	Confirmed bool
+	// Timeout bounds the whole call; 0 means no limit.
+	Timeout time.Duration
+	// Extensions is a JSON object sent as the top-level "extensions" of the
+	// request, e.g. for persisted queries or client metadata.
+	Extensions string
+	// ResponseShape selects what the result contains: responseShapeData (the
+	// default), responseShapeFull or responseShapeErrorsOnly.
+	ResponseShape string
+	// OperationName selects the operation of a document with several; it is
+	// derived from the document otherwise.
+	OperationName string
+	// ReturnCost reports the cost data of the response extensions.
+	ReturnCost bool
+	// Simulate sends a mutation in the server's dry-run mode; see
+	// prepareSimulation.
+	Simulate bool
+	// Pagination summarizes the pagination fields found in the response.
+	Pagination bool
+	// ValidateResponse checks the data against the selection set and the
+	// schema types; see checkResponseShape.
+	ValidateResponse bool
+	// IncludeMeta reports the duration, size and HTTP status of the request.
+	IncludeMeta bool
+	// IdempotencyKey is sent in the IDEMPOTENCY_KEY_HEADER header; "auto"
+	// generates one.
+	IdempotencyKey string
+	// NoCache bypasses the response cache.
+	NoCache bool
+	// DecodePath selects a string of the data to return decoded with the
+	// Decode mode instead of the data, saved to SaveTo when set.
+	DecodePath string
+	Decode     string
+	SaveTo     string
+}
+
+// Values of the responseShape argument of invoke_graphql.
+const (
+	responseShapeData       = "data"
+	responseShapeFull       = "full"
+	responseShapeErrorsOnly = "errorsOnly"
+)
+
+// Values of the format argument of invoke_graphql.
+const (
+	outputFormatJSON = "json"
+	outputFormatYAML = "yaml"
+)
+
+// responseEnvelope is the whole GraphQL response, returned for
+// responseShapeFull.
+type responseEnvelope struct {
+	Data       interface{}            `json:"data"`
+	Errors     []graphqlError         `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// invokeResult is the outcome of a successful invokeGraphQLOperation call.
+type invokeResult struct {
+	// Body is the JSON-encoded "data" of the response.
+	Body string
+	// Coercions describes the variable values changed by CoerceVariables.
+	Coercions []string
+	// Truncation is set when Body was cut to MAX_RESPONSE_BYTES.
+	Truncation *responseTruncation
+	// Cost holds the cost data of the response when ReturnCost is set; it is
+	// empty but not nil when the response carried none.
+	Cost map[string]interface{}
+	// Headers holds the response headers selected by RESPONSE_HEADERS.
+	Headers map[string]string
+	// Simulation says how the mutation was simulated, when Simulate is set.
+	Simulation string
+	// Pagination summarizes the paginated lists of the data when Pagination
+	// is set; it is empty but not nil when none were found.
+	Pagination []*paginationSummary
+	// Violations lists how the data departs from the operation when
+	// ValidateResponse is set; it is empty but not nil when it matches.
+	Violations []string
+	// Request describes the HTTP request when IncludeMeta is set.
+	Request *requestMeta
+	// IdempotencyKey is the key the request was sent with, if any.
+	IdempotencyKey string
+	// CachedAge is the age of the response when it came from the response
+	// cache, and nil otherwise.
+	CachedAge *time.Duration
+	// Decoded is the value decoded when DecodePath is set; Body then
+	// summarizes it.
+	Decoded *decodedValue
+	// FormatNote explains why Body isn't in the requested Format.
+	FormatNote string
+}
+
+// requestMeta describes the HTTP request that produced a response.
+type requestMeta struct {
+	DurationMs    int64 `json:"requestDurationMs"`
+	ResponseBytes int   `json:"responseBytes"`
+	HTTPStatus    int   `json:"httpStatus"`
 }
 
 // invokeGraphQLOperation executes a GraphQL operation (query or mutation) with the
-// provided variables and returns the JSON response as a string.
-func invokeGraphQLOperation(ctx context.Context, operation, variablesJSON string) (string, error) {
-	// Create a Machine Box GraphQL client
-	client := graphqlMB.NewClient(graphqlEndpoint)
+// provided variables and returns the JSON-encoded response data.
+func invokeGraphQLOperation(ctx context.Context, operation, variablesJSON string, opts invokeOptions) (invoked *invokeResult, err error) {
+	// Record every call, including rejected ones, in the audit log, the
+	// history and the session report
+	started := time.Now()
+	req := graphqlRequest{Query: operation}
+	defer func() {
+		recordAudit(req.Query, req.OperationName, req.Variables, started, err)
+		recordHistory(operation, req.Variables, opts, started, invoked, err)
+		recordSession(req.Query, req.OperationName, req.Variables, invoked, err)
+	}()
+
+	// Don't start any work if the caller has already gone away
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = withRequestTimeout(ctx, opts.Timeout)
+		defer cancel()
+		defer func() {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("operation timed out after %s; pass a larger timeoutMs if it is expected to take longer: %w", opts.Timeout, err)
+			}
+		}()
+	}
+
+	switch opts.ResponseShape {
+	case "", responseShapeData, responseShapeFull, responseShapeErrorsOnly:
+	default:
+		return nil, fmt.Errorf("unknown responseShape %q: use %q, %q or %q", opts.ResponseShape, responseShapeData, responseShapeFull, responseShapeErrorsOnly)
+	}
+	switch opts.Format {
+	case "", outputFormatJSON, outputFormatYAML:
+	default:
+		return nil, fmt.Errorf("unknown format %q: use %q or %q", opts.Format, outputFormatJSON, outputFormatYAML)
+	}
+	if err := checkDecodeOptions(opts); err != nil {
+		return nil, err
+	}
+
+	// Reject operations that the configured policy doesn't permit
+	if err := checkOperationAllowed(operation); err != nil {
+		return nil, err
+	}
+
+	// Always send an operation name, so servers can trace the call by it
+	named, operationName, err := resolveOperationName(operation, opts.OperationName)
+	if err != nil {
+		return nil, err
+	}
+	// Fields included or skipped with a variable need it declared as Boolean!
+	if doc, op, err := parseSingleOperation(named, operationName); err == nil {
+		if problems := conditionalDirectiveProblems(doc, op); len(problems) > 0 {
+			return nil, fmt.Errorf("invalid @skip/@include directives:\n- %s", strings.Join(problems, "\n- "))
+		}
+	}
+	// A simulated mutation carries the dry-run directive
+	var sim *simulation
+	if opts.Simulate {
+		if sim, err = prepareSimulation(ctx, named, operationName); err != nil {
+			return nil, err
+		}
+		named = sim.Query
+	}
+	req.Query, req.OperationName = named, operationName
+
+	// If variables were provided, attach them to the request along with
+	// the deployment-wide defaults
+	vars, err := parseVariables(variablesJSON)
+	if err != nil {
+		return nil, err
+	}
+	req.Variables = applyDefaultVariables(operation, vars)
+	if opts.Extensions != "" {
+		if err := json.Unmarshal([]byte(opts.Extensions), &req.Extensions); err != nil {
+			return nil, fmt.Errorf("failed to parse extensions JSON: %w", err)
+		}
+	}
+
+	// Mutations may need an explicit confirmation before they run, unless
+	// they are only simulated
+	if err := checkMutationConfirmed(ctx, operation, req.Variables, opts.Confirmed || opts.Simulate); err != nil {
+		return nil, err
+	}
+
+	// Optionally coerce the variables to the types the operation declares
+	out := &invokeResult{}
+	if opts.CoerceVariables {
+		schema, err := getSchema(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("coerceVariables requires the schema: %w", err)
+		}
+		if req.Variables, out.Coercions, err = coerceVariables(schema, operation, req.Variables); err != nil {
+			return nil, fmt.Errorf("failed to coerce variables: %w", err)
+		}
+	}
+
+	// Custom scalars with a format from SCALAR_FORMATS are checked, and close
+	// variants converted, before anything is sent
+	if len(scalarFormats) > 0 && len(req.Variables) > 0 {
+		if schema, err := getSchema(ctx); err != nil {
+			log.Printf("Warning: SCALAR_FORMATS not applied, the schema is unavailable: %v", err)
+		} else {
+			var changes []string
+			if req.Variables, changes, err = applyScalarFormats(schema, operation, req.Variables); err != nil {
+				return nil, err
+			}
+			out.Coercions = append(out.Coercions, changes...)
+		}
+	}
 
-	// Build the GraphQL request with the raw operation
-	req := graphqlMB.NewRequest(operation)
+	// Resolve ${env:NAME} references last, on a copy of the request, so the
+	// secrets never reach the audit log, coercion reports or the caller
+	sendReq := req
+	var secrets []string
+	if sendReq.Variables, secrets, err = resolveSecretReferences(req.Variables); err != nil {
+		return nil, err
+	}
+	if sim != nil {
+		sendReq.header = sim.Header
+		out.Simulation = sim.Note
+	}
+	// The key is generated once, so the re-authenticated retry below reuses it
+	if opts.IdempotencyKey != "" {
+		if out.IdempotencyKey, err = resolveIdempotencyKey(opts.IdempotencyKey); err != nil {
+			return nil, err
+		}
+		if sendReq.header == nil {
+			sendReq.header = http.Header{}
+		}
+		sendReq.header.Set(idempotencyKeyHeader, out.IdempotencyKey)
+	}
 
-	// If variables were provided, attach them to the request
-	if variablesJSON != "" {
-		var vars map[string]interface{}
-		if err := json.Unmarshal([]byte(variablesJSON), &vars); err != nil {
-			return "", fmt.Errorf("failed to parse variables JSON: %w", err)
+	// Send the request with the current headers, unless the response cache
+	// holds the response of the query; with NoCache, the fresh response
+	// replaces the cached one
+	// elapsed is the round trip of the last request sent
+	var elapsed time.Duration
+	cacheKey := responseCacheKey(sendReq)
+	send := func() (*graphqlResponse, error) {
+		if res, age := lookupResponse(cacheKey); res != nil && !opts.NoCache {
+			out.CachedAge = &age
+			return res, nil
+		}
+		sent := time.Now()
+		res, err := executeGraphQL(ctx, sendReq)
+		elapsed = time.Since(sent)
+		if err != nil {
+			return nil, scrubSecretsError(err, secrets)
 		}
-		for k, v := range vars {
-			req.Var(k, v)
+		scrubSecretsResponse(res, secrets)
+		storeResponse(cacheKey, res)
+		if len(res.Errors) > 0 {
+			return res, &graphqlResponseError{Errors: res.Errors, Data: res.Data}
 		}
+		return res, nil
+	}
+	res, err := send()
+
+	// When the credentials were rejected, re-authenticate and retry once
+	if isAuthFailure(err) {
+		var reauthErr error
+		if reauthCommand != "" {
+			if reauthErr = reauthenticate(ctx, started); reauthErr == nil {
+				res, err = send()
+			}
+		} else if reset := resetOAuthTokens(); resetLoginToken() || reset {
+			// The OAuth or session token may have been revoked before it expired
+			res, err = send()
+		}
+		if isAuthFailure(err) {
+			err = authFailure(err, reauthErr)
+		}
+	}
+
+	// The full and errors-only shapes report GraphQL errors in the result
+	// rather than failing, except for authentication failures, which need
+	// the guidance of the error
+	var gqlErr *graphqlResponseError
+	if opts.ResponseShape != "" && opts.ResponseShape != responseShapeData && res != nil && errors.As(err, &gqlErr) && !isAuthFailure(err) {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// A mutation may change what the cached queries return
+	if responseCacheTTL > 0 && isMutationRequest(sendReq) {
+		clearResponseCache()
 	}
 
-	// Add the current headers to the request
-	headers := getHeaders()
-	for key, values := range headers {
-		for _, value := range values {
-			req.Header.Set(key, value)
+	out.Headers = res.headers
+	if opts.IncludeMeta {
+		out.Request = &requestMeta{DurationMs: elapsed.Milliseconds(), ResponseBytes: res.size, HTTPStatus: res.status}
+	}
+	if opts.ReturnCost {
+		if out.Cost = costExtensions(res.Extensions); out.Cost == nil {
+			out.Cost = map[string]interface{}{}
 		}
 	}
 
+	var data interface{}
+	if len(res.Data) > 0 {
+		if err := json.Unmarshal(res.Data, &data); err != nil {
+			return nil, fmt.Errorf("decoding response data: %w", err)
+		}
+	}
+	if opts.Pagination {
+		out.Pagination = detectPagination(data)
+	}
+	if opts.ValidateResponse {
+		schema, err := getSchema(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("validateResponse requires the schema: %w", err)
+		}
+		if out.Violations, err = checkResponseShape(schema, req.Query, req.OperationName, data); err != nil {
+			return nil, err
+		}
+	}
+	// An encoded file is returned decoded instead of the data
+	if opts.DecodePath != "" {
+		if out.Decoded, err = decodeResponseValue(data, opts.DecodePath, opts.Decode); err != nil {
+			return nil, err
+		}
+		if opts.SaveTo != "" {
+			if out.Decoded.SavedTo, err = saveDownload(opts.SaveTo, out.Decoded.Data); err != nil {
+				return nil, err
+			}
+		}
+		out.Body = out.Decoded.String()
+		return out, nil
+	}
 	var result interface{}
-	if err := client.Run(ctx, req, &result); err != nil {
-		return "", err
+	switch opts.ResponseShape {
+	case responseShapeFull:
+		result = responseEnvelope{Data: data, Errors: res.Errors, Extensions: res.Extensions}
+	case responseShapeErrorsOnly:
+		if res.Errors == nil {
+			res.Errors = []graphqlError{}
+		}
+		result = res.Errors
+	default:
+		result = data
 	}
 
-	// Marshal the result into a pretty JSON string
-	resBytes, err := json.MarshalIndent(result, "", "  ")
+	// Marshal the result into a pretty (or, if requested, minified) JSON string
+	var resBytes []byte
+	if opts.Compact {
+		resBytes, err = json.Marshal(result)
+	} else {
+		resBytes, err = json.MarshalIndent(result, "", "  ")
+	}
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	// YAML is converted from the JSON, so that it has the same keys; the
+	// JSON is kept when the conversion fails
+	if opts.Format == outputFormatYAML {
+		if converted, err := jsonToYAML(resBytes); err != nil {
+			out.FormatNote = yamlFallbackNote(err)
+		} else {
+			resBytes = converted
+		}
 	}
-	return string(resBytes), nil
+	out.Body, out.Truncation = truncateResponse(string(resBytes), result, maxResponseBytes)
+	return out, nil
+}
+
+// parseVariables decodes the JSON-encoded variables of an operation. An empty
+// string yields no variables.
+func parseVariables(variablesJSON string) (map[string]interface{}, error) {
+	if variablesJSON == "" {
+		return nil, nil
+	}
+	var vars map[string]interface{}
+	if err := json.Unmarshal([]byte(variablesJSON), &vars); err != nil {
+		return nil, fmt.Errorf("failed to parse variables JSON: %w", err)
+	}
+	return vars, nil
 }
 
 // toolSuccess formats a successful tool response by wrapping
@@ -414,6 +1882,110 @@ func toolSuccess(message string) *mcp.CallToolResult {
 	}
 }
 
+// invokeSuccess formats the result of invokeGraphQLOperation. The cost,
+// request metadata, cache use, idempotency key, variable coercions,
+// pagination, response validation and truncation are reported in the result
+// metadata and as notes, the cost right after the data. Response headers are
+// only part of the metadata, and a failed YAML conversion only a note.
+func invokeSuccess(res *invokeResult) *mcp.CallToolResult {
+	result := toolSuccess(res.Body)
+	chars := utf8.RuneCountInString(res.Body)
+	result.Meta = map[string]interface{}{"responseChars": chars, "estimatedTokens": estimateTokens(chars)}
+	if res.Cost != nil {
+		note := "The response carried no cost information in its extensions."
+		if len(res.Cost) > 0 {
+			result.Meta["cost"] = res.Cost
+			encoded, _ := json.Marshal(res.Cost)
+			note = "Cost: " + string(encoded)
+		}
+		result.Content = append(result.Content, mcp.NewTextContent(note))
+	}
+	if len(res.Headers) > 0 {
+		result.Meta["responseHeaders"] = res.Headers
+	}
+	if r := res.Request; r != nil {
+		result.Meta["requestDurationMs"] = r.DurationMs
+		result.Meta["responseBytes"] = r.ResponseBytes
+		result.Meta["httpStatus"] = r.HTTPStatus
+		result.Content = append(result.Content, mcp.NewTextContent(fmt.Sprintf("Request: %d ms, %s, HTTP %d", r.DurationMs, pluralize(r.ResponseBytes, "byte", "bytes"), r.HTTPStatus)))
+	}
+	if d := res.Decoded; d != nil {
+		decoded := map[string]interface{}{"path": d.Path, "bytes": len(d.Data), "mimeType": d.MIMEType}
+		if d.SavedTo != "" {
+			decoded["savedTo"] = d.SavedTo
+		} else {
+			result.Content = append(result.Content, d.content())
+		}
+		result.Meta["decoded"] = decoded
+	}
+	if res.CachedAge != nil {
+		result.Meta["cached"] = true
+		result.Content = append(result.Content, mcp.NewTextContent(cachedResponseNote(*res.CachedAge)))
+	}
+	if res.IdempotencyKey != "" {
+		result.Meta["idempotencyKey"] = res.IdempotencyKey
+		result.Content = append(result.Content, mcp.NewTextContent(idempotencyNote(res.IdempotencyKey)))
+	}
+	if res.Simulation != "" {
+		result.Meta["simulated"] = true
+		result.Content = append(result.Content, mcp.NewTextContent(res.Simulation))
+	}
+	if len(res.Coercions) > 0 {
+		result.Meta["coercions"] = res.Coercions
+		result.Content = append(result.Content, mcp.NewTextContent("Coerced variables:\n"+strings.Join(res.Coercions, "\n")))
+	}
+	if res.Pagination != nil {
+		note := "No pagination fields were found in the response."
+		if len(res.Pagination) > 0 {
+			result.Meta["pagination"] = res.Pagination
+			lines := make([]string, len(res.Pagination))
+			for i, p := range res.Pagination {
+				lines[i] = p.String()
+			}
+			note = "Pagination:\n" + strings.Join(lines, "\n")
+		}
+		result.Content = append(result.Content, mcp.NewTextContent(note))
+	}
+	if res.Violations != nil {
+		note := "The response matches the operation's selection set and the schema types."
+		if len(res.Violations) > 0 {
+			result.Meta["responseViolations"] = res.Violations
+			note = "The response doesn't match the operation:\n- " + strings.Join(res.Violations, "\n- ")
+		}
+		result.Content = append(result.Content, mcp.NewTextContent(note))
+	}
+	if res.FormatNote != "" {
+		result.Content = append(result.Content, mcp.NewTextContent(res.FormatNote))
+	}
+	if t := res.Truncation; t != nil {
+		result.Meta["truncated"] = true
+		result.Meta["totalBytes"] = t.TotalBytes
+		result.Meta["limitBytes"] = t.LimitBytes
+		note := fmt.Sprintf("The response was truncated: it is %d bytes and the limit is %d bytes. Select fewer fields or paginate to narrow it.", t.TotalBytes, t.LimitBytes)
+		if len(t.LargestFields) > 0 {
+			note += " Largest fields: " + strings.Join(t.LargestFields, ", ") + "."
+		}
+		result.Content = append(result.Content, mcp.NewTextContent(note))
+	}
+	return result
+}
+
+// charsPerToken is the average number of characters per token of JSON text,
+// used to estimate the context a response takes.
+const charsPerToken = 4
+
+// estimateTokens roughly estimates the tokens taken by chars characters.
+func estimateTokens(chars int) int {
+	return (chars + charsPerToken - 1) / charsPerToken
+}
+
+// debugf logs a message when DEBUG is enabled.
+func debugf(format string, args ...interface{}) {
+	if debugLogging {
+		log.Printf("debug: "+format, args...)
+	}
+}
+
 // toolError formats an error tool response by wrapping
 // the provided error message in an MCP CallToolResult structure.
 func toolError(message string) *mcp.CallToolResult {
@@ -430,41 +2002,99 @@ func setHeaders(headersJSON string) error {
 		return fmt.Errorf("failed to parse headers JSON: %w", err)
 	}
 
+	currentHeaders.Lock()
 	// Load headers from environment
-	envHeadersJSON := os.Getenv("GRAPHQL_HEADERS")
-	if envHeadersJSON != "" {
-		var envHeaders map[string]string
-		if err := json.Unmarshal([]byte(envHeadersJSON), &envHeaders); err != nil {
-			return fmt.Errorf("failed to parse env headers JSON: %w", err)
-		}
-		for k, v := range envHeaders {
-			currentHeaders.Set(k, v)
-		}
+	for k, v := range envHeaders {
+		currentHeaders.header.Set(k, v)
 	}
 
 	// Overwrite with user-provided headers
 	for k, v := range newHeaders {
-		currentHeaders.Set(k, v)
+		currentHeaders.header.Set(k, v)
 	}
+	currentHeaders.Unlock()
+
+	leaveHeaderProfile()
+
+	// The visible schema may depend on the credentials, so introspect again
+	invalidateSchemaCache()
 
 	return nil
 }
 
-// getHeaders retrieves the currently stored headers
+// userHeaders returns a copy of the headers set by the user, initialized
+// from GRAPHQL_HEADERS while none are set.
+func userHeaders() http.Header {
+	currentHeaders.RLock()
+	if len(currentHeaders.header) > 0 {
+		defer currentHeaders.RUnlock()
+		return currentHeaders.header.Clone()
+	}
+	currentHeaders.RUnlock()
+
+	currentHeaders.Lock()
+	defer currentHeaders.Unlock()
+	if len(currentHeaders.header) == 0 {
+		for k, v := range envHeaders {
+			currentHeaders.header.Set(k, v)
+		}
+	}
+	return currentHeaders.header.Clone()
+}
+
+// replaceHeaders replaces the headers set by the user with a copy of header.
+func replaceHeaders(header http.Header) {
+	currentHeaders.Lock()
+	defer currentHeaders.Unlock()
+	currentHeaders.header = header.Clone()
+}
+
+// parseEnvHeaders decodes GRAPHQL_HEADERS, a JSON object of strings. The
+// error says where a malformed value goes wrong, without echoing it, since
+// it usually holds credentials.
+func parseEnvHeaders(headersJSON string) (map[string]string, error) {
+	if headersJSON == "" {
+		return nil, nil
+	}
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(headersJSON), &headers); err != nil {
+		return nil, fmt.Errorf("invalid GRAPHQL_HEADERS, expected a JSON object of strings such as {\"Authorization\": \"Bearer ...\"}: %s", jsonErrorPosition(headersJSON, err))
+	}
+	return headers, nil
+}
+
+// jsonErrorPosition prefixes a JSON decoding error with the line and column
+// of src where it occurred, when the error reports one.
+func jsonErrorPosition(src string, err error) string {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err.Error()
+	}
+	before := src[:min(int(offset), len(src))]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndexByte(before, '\n')+1:])
+	return fmt.Sprintf("line %d, column %d (byte %d): %v", line, column, offset, err)
+}
+
+// getHeaders retrieves the currently stored headers, adding basic auth
+// unless an explicit Authorization header is set, and the API version unless
+// its header is set
 func getHeaders() http.Header {
-	// If headers are empty, initialize from environment
-	if len(currentHeaders) == 0 {
-		headersJSON := os.Getenv("GRAPHQL_HEADERS")
-		if headersJSON != "" {
-			var tmp map[string]string
-			if err := json.Unmarshal([]byte(headersJSON), &tmp); err != nil {
-				log.Println("Warning: Failed to parse headers JSON:", err)
-			} else {
-				for k, v := range tmp {
-					currentHeaders.Set(k, v)
-				}
-			}
+	headers := userHeaders()
+	if headers.Get("Authorization") == "" {
+		if auth, ok := basicAuthHeader(); ok {
+			headers.Set("Authorization", auth)
 		}
 	}
-	return currentHeaders
+	if apiVersion != "" && headers.Get(apiVersionHeader) == "" {
+		headers.Set(apiVersionHeader, apiVersion)
+	}
+	return headers
 }
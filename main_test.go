@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// TestHeadersConcurrentAccess replaces the headers while other calls read
+// them, as REAUTH_COMMAND does during in-flight invocations; run it with
+// -race.
+func TestHeadersConcurrentAccess(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if err := setHeaders(`{"Authorization": "Bearer token"}`); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = getHeaders()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := getHeaders().Get("Authorization"); got != "Bearer token" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer token")
+	}
+}
+
+func TestInvokeGraphQLOperationCancellation(t *testing.T) {
+	newBlockingServer(t)
+	assertCancelledPromptly(t, func(ctx context.Context) error {
+		_, err := invokeGraphQLOperation(ctx, `query { candidates { id } }`, "", invokeOptions{})
+		return err
+	})
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Tool: list_named_queries
+const listNamedQueriesToolDescription = `List the canonical operations available in the named query library.
+The library is a folder of vetted .graphql files maintained alongside the server, so these operations are known to work.
+
+Best Practices:
+- Check this list before writing an operation by hand; prefer a named query when one fits.
+- Use run_named_query with the listed name and the variables shown in the signature.
+
+Arguments:
+- None
+
+Example Usage:
+Request:
+  list_named_queries()
+
+Response:
+  Named Queries:
+  getCandidate: query GetCandidate($id: ID!)
+  createJob: mutation CreateJob($input: JobInput!)
+`
+
+// Tool: run_named_query
+const runNamedQueryToolDescription = `Execute an operation from the named query library.
+
+Best Practices:
+- Use list_named_queries to discover the available names and the variables each operation declares.
+- Provide 'variables' as a JSON-encoded string, exactly as for invoke_graphql.
+
+Arguments:
+- name (string, Required): The name of the operation (its file name without the .graphql extension).
+- variables (string, Optional): A JSON-encoded string representing variables for the operation.
+- compact (boolean, Optional): Return minified JSON instead of pretty-printed JSON.
+- confirm (boolean, Optional): Required to execute mutations when mutation confirmation is enabled.
+
+Example Usage:
+Request:
+  run_named_query(name: "getCandidate", variables: "{\"id\": \"123\"}")
+
+Response:
+  {
+	"candidate": {
+	  "id": "123",
+	  "name": "John Doe"
+	}
+  }
+`
+
+// queriesDir is the folder holding the named query library.
+var queriesDir = getenv("QUERIES_DIR")
+
+// namedQueryExtensions are the file extensions recognized as operations.
+var namedQueryExtensions = []string{".graphql", ".gql"}
+
+// listNamedQueries returns the names of the operations in queriesDir, sorted.
+func listNamedQueries() ([]string, error) {
+	if queriesDir == "" {
+		return nil, fmt.Errorf("QUERIES_DIR is not set")
+	}
+	entries, err := os.ReadDir(queriesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read QUERIES_DIR: %w", err)
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(e.Name())
+		for _, known := range namedQueryExtensions {
+			if ext == known {
+				names = append(names, strings.TrimSuffix(e.Name(), ext))
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// loadNamedQuery reads the operation stored under name in queriesDir.
+func loadNamedQuery(name string) (string, error) {
+	if queriesDir == "" {
+		return "", fmt.Errorf("QUERIES_DIR is not set")
+	}
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid query name %q", name)
+	}
+	for _, ext := range namedQueryExtensions {
+		data, err := os.ReadFile(filepath.Join(queriesDir, name+ext))
+		if err == nil {
+			return string(data), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read query %q: %w", name, err)
+		}
+	}
+
+	names, err := listNamedQueries()
+	if err != nil || len(names) == 0 {
+		return "", fmt.Errorf("named query %q not found", name)
+	}
+	return "", fmt.Errorf("named query %q not found. Did you mean: %s?", name, strings.Join(suggestNames(name, names, maxSuggestions), ", "))
+}
+
+// formatNamedQueries lists the library with the signature of each operation.
+func formatNamedQueries() (string, error) {
+	names, err := listNamedQueries()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	sb.WriteString("Named Queries:\n")
+	for _, name := range names {
+		signature := "(unreadable)"
+		if src, err := loadNamedQuery(name); err == nil {
+			signature = operationSignature(src)
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", name, signature)
+	}
+	return sb.String(), nil
+}
+
+// operationSignature renders the header of the first operation in src, e.g.
+// "query GetCandidate($id: ID!)", or a note when src doesn't parse.
+func operationSignature(src string) string {
+	doc, err := parseDocument(src)
+	if err != nil {
+		return "(invalid: " + err.Error() + ")"
+	}
+	if len(doc.Operations) == 0 {
+		return "(no operation)"
+	}
+	op := doc.Operations[0]
+	signature := op.Operation
+	if op.Name != "" {
+		signature += " " + op.Name
+	}
+	if len(op.VariableDefinitions) > 0 {
+		vars := make([]string, 0, len(op.VariableDefinitions))
+		for _, v := range op.VariableDefinitions {
+			vars = append(vars, "$"+v.Name+": "+v.Type.String())
+		}
+		signature += "(" + strings.Join(vars, ", ") + ")"
+	}
+	return signature
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// operationsDir is the only directory invoke_graphql may read operation files
+// from. Reading operation files is disabled when it is empty.
+var operationsDir = getenv("OPERATIONS_DIR")
+
+// readOperationFile reads an operation from a file inside operationsDir.
+// Relative paths are resolved against operationsDir; symlinks are followed
+// before checking that the file doesn't escape it.
+func readOperationFile(path string) (string, error) {
+	if operationsDir == "" {
+		return "", errors.New("operationFile requires OPERATIONS_DIR to be set")
+	}
+	root, err := filepath.EvalSymlinks(operationsDir)
+	if err != nil {
+		return "", fmt.Errorf("invalid OPERATIONS_DIR: %w", err)
+	}
+	root, err = filepath.Abs(root)
+	if err != nil {
+		return "", fmt.Errorf("invalid OPERATIONS_DIR: %w", err)
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read operation file: %w", err)
+	}
+	resolved, err = filepath.Abs(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to read operation file: %w", err)
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("operation file %s is outside OPERATIONS_DIR", path)
+	}
+
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to read operation file: %w", err)
+	}
+	return string(data), nil
+}
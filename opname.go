@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// operationNamePrefix starts the names generated for anonymous operations, so
+// servers that trace by operation name can tell them apart from their own.
+var operationNamePrefix = stringFromEnv("OPERATION_NAME_PREFIX", "MCP")
+
+// maxGeneratedNameFields is the number of root fields that go into a
+// generated operation name.
+const maxGeneratedNameFields = 3
+
+// resolveOperationName picks the operation to execute and returns the
+// document to send along with its operation name. A single anonymous
+// operation is given a name generated from its type and root fields, which
+// is written into the document. Documents with several operations need an
+// explicit name. Documents that don't parse are returned unchanged, for the
+// server to report.
+func resolveOperationName(operation, requested string) (string, string, error) {
+	doc, err := parseDocument(operation)
+	if err != nil || len(doc.Operations) == 0 {
+		return operation, requested, nil
+	}
+
+	if requested != "" {
+		for _, op := range doc.Operations {
+			if op.Name == requested {
+				return operation, requested, nil
+			}
+		}
+		return "", "", fmt.Errorf("operationName %q does not match an operation in the document (%s)", requested, strings.Join(operationNames(doc), ", "))
+	}
+	if len(doc.Operations) > 1 {
+		return "", "", fmt.Errorf("the document contains %d operations (%s); pass operationName to choose one", len(doc.Operations), strings.Join(operationNames(doc), ", "))
+	}
+
+	op := doc.Operations[0]
+	if op.Name != "" {
+		return operation, op.Name, nil
+	}
+	name := generatedOperationName(doc, op)
+	return nameAnonymousOperation(operation, op, name), name, nil
+}
+
+// generatedOperationName builds a stable name for an anonymous operation from
+// its type and root fields, e.g. "MCPQuery_candidate_jobs".
+func generatedOperationName(doc *astDocument, op *astOperation) string {
+	name := operationNamePrefix + strings.ToUpper(op.Operation[:1]) + op.Operation[1:]
+	fields := doc.rootFields(op)
+	if len(fields) > maxGeneratedNameFields {
+		fields = append(fields[:maxGeneratedNameFields:maxGeneratedNameFields], "etc")
+	}
+	for _, f := range fields {
+		name += "_" + f
+	}
+	return name
+}
+
+// nameAnonymousOperation writes name into the anonymous operation op of
+// src, turning the "{ ... }" shorthand into "query name { ... }".
+func nameAnonymousOperation(src string, op *astOperation, name string) string {
+	offset := byteOffset(src, op.Line, op.Column)
+	if strings.HasPrefix(src[offset:], "{") {
+		return src[:offset] + "query " + name + " " + src[offset:]
+	}
+	offset += len(op.Operation)
+	return src[:offset] + " " + name + src[offset:]
+}
+
+// byteOffset converts a 1-based line and rune column, as reported by the
+// lexer, into a byte offset of src.
+func byteOffset(src string, line, column int) int {
+	offset := 0
+	for ; line > 1; line-- {
+		i := strings.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return len(src)
+		}
+		offset += i + 1
+	}
+	for ; column > 1 && offset < len(src); column-- {
+		_, size := utf8.DecodeRuneInString(src[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// operationNames lists the operations of a document, "(anonymous)" for
+// unnamed ones.
+func operationNames(doc *astDocument) []string {
+	names := make([]string, len(doc.Operations))
+	for i, op := range doc.Operations {
+		names[i] = op.Name
+		if names[i] == "" {
+			names[i] = "(anonymous)"
+		}
+	}
+	return names
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tool: operation_types
+const operationTypesToolDescription = `List every type an operation involves: the types of its arguments and its return type, followed transitively through fields and input fields.
+Each type comes with a short summary and the field it was reached through, giving a focused slice of the schema for the operation.
+
+Best Practices:
+- Use this tool before calling a complex operation to see the whole graph of types it touches.
+- Use describe or get_input_type on the listed types for their full definitions.
+- Lower 'depth' for large schemas where everything is reachable from everything.
+
+Arguments:
+- name (string, Required): The operation, e.g. "candidate" or "mutation.createCandidate".
+- depth (number, Optional): How many levels of fields to follow (default 5).
+
+Example Usage:
+Request:
+  operation_types(name: "candidate")
+
+Response:
+  Types involved in query.candidate:
+  Arguments:
+  	CandidateFilter (INPUT_OBJECT, 2 input fields) via candidate(filter)
+  Result:
+  	Candidate (OBJECT, 4 fields) via candidate — A job candidate.
+  	CandidateStatus (ENUM, 3 values) via Candidate.status
+  	Address (OBJECT, 3 fields) via Candidate.address
+`
+
+// defaultOperationTypesDepth is how many levels of fields operation_types
+// follows when no depth is given.
+const defaultOperationTypesDepth = 5
+
+// reachedType is a type found while walking an operation's types.
+type reachedType struct {
+	t   *schemaType
+	via string
+}
+
+// listOperationTypes walks the argument and return types of a root field and
+// lists every type reached within depth levels.
+func listOperationTypes(ctx context.Context, name string, depth int) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	if depth <= 0 {
+		depth = defaultOperationTypesDepth
+	}
+
+	operation, fieldName := "", name
+	if i := strings.Index(name, "."); i >= 0 {
+		operation, fieldName = name[:i], name[i+1:]
+	}
+	var field *schemaField
+	for _, op := range []string{"query", "mutation", "subscription"} {
+		if operation != "" && operation != op {
+			continue
+		}
+		if root := schema.typeByName(schema.rootType(op)); root != nil {
+			if field = root.field(fieldName); field != nil {
+				operation = op
+				break
+			}
+		}
+	}
+	if field == nil {
+		var names []string
+		for _, f := range schema.queries() {
+			names = append(names, f.Name)
+		}
+		for _, f := range schema.mutations() {
+			names = append(names, f.Name)
+		}
+		return "", fmt.Errorf("operation '%s' not found in schema. Did you mean: %s?", name, strings.Join(suggestNames(fieldName, names, maxSuggestions), ", "))
+	}
+
+	truncated := false
+	// walk does a breadth-first traversal from the start types, so each type
+	// is listed with the shortest path that reaches it
+	walk := func(start []reachedType) []reachedType {
+		var out []reachedType
+		seen := make(map[string]bool)
+		level := start
+		for d := 0; len(level) > 0; d++ {
+			var next []reachedType
+			for _, r := range level {
+				if seen[r.t.Name] {
+					continue
+				}
+				if d > depth {
+					truncated = true
+					continue
+				}
+				seen[r.t.Name] = true
+				out = append(out, r)
+				for _, f := range r.t.Fields {
+					if t := schema.typeByName(f.Type.namedType()); t != nil && !builtinScalars[t.Name] {
+						next = append(next, reachedType{t, r.t.Name + "." + f.Name})
+					}
+				}
+				for _, f := range r.t.InputFields {
+					if t := schema.typeByName(f.Type.namedType()); t != nil && !builtinScalars[t.Name] {
+						next = append(next, reachedType{t, r.t.Name + "." + f.Name})
+					}
+				}
+				for _, ref := range r.t.PossibleTypes {
+					if t := schema.typeByName(ref.namedType()); t != nil {
+						next = append(next, reachedType{t, r.t.Name + " member"})
+					}
+				}
+			}
+			level = next
+		}
+		return out
+	}
+
+	var argStart []reachedType
+	for _, a := range field.Args {
+		if t := schema.typeByName(a.Type.namedType()); t != nil && !builtinScalars[t.Name] {
+			argStart = append(argStart, reachedType{t, fmt.Sprintf("%s(%s)", field.Name, a.Name)})
+		}
+	}
+	args := walk(argStart)
+	var result []reachedType
+	if t := schema.typeByName(field.Type.namedType()); t != nil && !builtinScalars[t.Name] {
+		result = walk([]reachedType{{t, field.Name}})
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Types involved in %s.%s:\n", operation, field.Name)
+	for _, section := range []struct {
+		title string
+		types []reachedType
+	}{{"Arguments", args}, {"Result", result}} {
+		if len(section.types) == 0 {
+			continue
+		}
+		sb.WriteString(section.title + ":\n")
+		for _, r := range section.types {
+			sb.WriteString("\t" + summarizeType(r.t) + " via " + r.via)
+			if desc := firstLine(r.t.Description); desc != "" {
+				sb.WriteString(" — " + desc)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	if len(args) == 0 && len(result) == 0 {
+		sb.WriteString("Only built-in scalars are involved.\n")
+	}
+	if truncated {
+		fmt.Fprintf(&sb, "Stopped at depth %d; more types are reachable through the last level's fields.\n", depth)
+	}
+	return sb.String(), nil
+}
+
+// summarizeType renders "Name (KIND, N fields)".
+func summarizeType(t *schemaType) string {
+	var count string
+	switch {
+	case len(t.Fields) > 0:
+		count = pluralize(len(t.Fields), "field", "fields")
+	case len(t.InputFields) > 0:
+		count = pluralize(len(t.InputFields), "input field", "input fields")
+	case len(t.EnumValues) > 0:
+		count = pluralize(len(t.EnumValues), "value", "values")
+	case len(t.PossibleTypes) > 0:
+		count = pluralize(len(t.PossibleTypes), "member", "members")
+	}
+	if count == "" {
+		return fmt.Sprintf("%s (%s)", t.Name, t.Kind)
+	}
+	return fmt.Sprintf("%s (%s, %s)", t.Name, t.Kind, count)
+}
+
+// pluralize renders "1 field" or "3 fields".
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// firstLine returns the first line of a description.
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// paginationHints lists, per role, the field names that reveal pagination in
+// a response. PAGINATION_HINTS replaces the names of the roles it gives, e.g.
+// {"total": ["numFound"], "hasMore": ["more"]}.
+var paginationHints, paginationHintsErr = parsePaginationHints(getenv("PAGINATION_HINTS"))
+
+// defaultPaginationHints covers Relay connections and the usual offset and
+// page based shapes.
+var defaultPaginationHints = map[string][]string{
+	// Objects whose fields describe the pagination of their parent
+	"container":  {"pageInfo", "pagination", "paging"},
+	"total":      {"totalCount", "total", "totalItems", "totalResults", "totalElements"},
+	"hasMore":    {"hasMore", "hasNextPage", "hasNext", "moreAvailable"},
+	"next":       {"nextPage", "nextCursor", "nextToken", "nextOffset"},
+	"cursor":     {"endCursor"},
+	"page":       {"page", "currentPage", "pageNumber"},
+	"totalPages": {"totalPages", "pageCount"},
+}
+
+// maxPaginationSummaries bounds the summaries of a response, which may
+// contain a paginated list in every item of another list.
+const maxPaginationSummaries = 20
+
+// paginationSummary is the normalized pagination of a list in a response.
+type paginationSummary struct {
+	// Path is where the paginated object is in the data, e.g. "search.jobs".
+	Path string `json:"path"`
+	// Items is the number of items returned and ItemsField the field holding
+	// them, when the object has a list field.
+	Items      *int   `json:"items,omitempty"`
+	ItemsField string `json:"itemsField,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+	// HasMore is nil when the response doesn't tell whether more exist.
+	HasMore    *bool       `json:"hasMore,omitempty"`
+	Next       interface{} `json:"next,omitempty"`
+	Cursor     interface{} `json:"cursor,omitempty"`
+	Page       *int        `json:"page,omitempty"`
+	TotalPages *int        `json:"totalPages,omitempty"`
+}
+
+// parsePaginationHints decodes PAGINATION_HINTS over the default hints.
+func parsePaginationHints(config string) (map[string][]string, error) {
+	if config == "" {
+		return defaultPaginationHints, nil
+	}
+	var given map[string][]string
+	if err := json.Unmarshal([]byte(config), &given); err != nil {
+		return nil, fmt.Errorf("invalid PAGINATION_HINTS, expected a JSON object mapping roles to lists of field names: %s", jsonErrorPosition(config, err))
+	}
+	roles := make([]string, 0, len(defaultPaginationHints))
+	for role := range defaultPaginationHints {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	hints := make(map[string][]string, len(defaultPaginationHints))
+	for role, names := range defaultPaginationHints {
+		hints[role] = names
+	}
+	for role, names := range given {
+		if _, ok := defaultPaginationHints[role]; !ok {
+			return nil, fmt.Errorf("invalid PAGINATION_HINTS: %s", unknownKeyProblem(role, "unknown role, expected one of "+strings.Join(roles, ", "), role, roles))
+		}
+		hints[role] = names
+	}
+	return hints, nil
+}
+
+// detectPagination finds the objects of data that carry pagination fields and
+// summarizes them, with the fields of each object visited by name.
+func detectPagination(data interface{}) []*paginationSummary {
+	roles := make(map[string]string)
+	for role, names := range paginationHints {
+		for _, name := range names {
+			roles[name] = role
+		}
+	}
+	summaries := []*paginationSummary{}
+	var walk func(v interface{}, path string)
+	walk = func(v interface{}, path string) {
+		if len(summaries) >= maxPaginationSummaries {
+			return
+		}
+		switch v := v.(type) {
+		case []interface{}:
+			for i, item := range v {
+				walk(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if s := summarizePagination(v, keys, roles); s != nil {
+				s.Path = path
+				summaries = append(summaries, s)
+			}
+			for _, k := range keys {
+				if roles[k] == "container" {
+					continue
+				}
+				child := k
+				if path != "" {
+					child = path + "." + k
+				}
+				walk(v[k], child)
+			}
+		}
+	}
+	walk(data, "")
+	return summaries
+}
+
+// summarizePagination returns the pagination of obj, or nil when none of its
+// fields, or the fields of its pagination containers, are pagination hints.
+func summarizePagination(obj map[string]interface{}, keys []string, roles map[string]string) *paginationSummary {
+	s := &paginationSummary{}
+	found, nextFound := false, false
+	apply := func(fields map[string]interface{}, names []string) {
+		for _, k := range names {
+			v := fields[k]
+			switch roles[k] {
+			case "total":
+				found = setPaginationInt(&s.Total, v) || found
+			case "page":
+				found = setPaginationInt(&s.Page, v) || found
+			case "totalPages":
+				found = setPaginationInt(&s.TotalPages, v) || found
+			case "hasMore":
+				if b, ok := v.(bool); ok && s.HasMore == nil {
+					s.HasMore = &b
+					found = true
+				}
+			case "next":
+				if s.Next == nil {
+					s.Next = v
+					found, nextFound = true, true
+				}
+			case "cursor":
+				if v != nil && s.Cursor == nil {
+					s.Cursor = v
+					found = true
+				}
+			}
+		}
+	}
+	apply(obj, keys)
+	for _, k := range keys {
+		if roles[k] != "container" {
+			continue
+		}
+		if container, ok := obj[k].(map[string]interface{}); ok {
+			names := make([]string, 0, len(container))
+			for name := range container {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			apply(container, names)
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	// Without an explicit flag, a next page or page count tells whether more
+	// results exist
+	if s.HasMore == nil && nextFound {
+		hasMore := s.Next != nil
+		s.HasMore = &hasMore
+	} else if s.HasMore == nil && s.Page != nil && s.TotalPages != nil {
+		hasMore := *s.Page < *s.TotalPages
+		s.HasMore = &hasMore
+	}
+	for _, k := range keys {
+		if list, ok := obj[k].([]interface{}); ok {
+			n := len(list)
+			s.Items, s.ItemsField = &n, k
+			break
+		}
+	}
+	return s
+}
+
+// setPaginationInt stores v in *dst when it is a whole number and *dst is
+// unset.
+func setPaginationInt(dst **int, v interface{}) bool {
+	f, ok := v.(float64)
+	if !ok || *dst != nil || f != float64(int(f)) {
+		return false
+	}
+	n := int(f)
+	*dst = &n
+	return true
+}
+
+// String renders the summary on one line, e.g. `jobs: 20 items in edges, 134
+// in total, more available (cursor: "Y3Vyc29yOjIw")`.
+func (s *paginationSummary) String() string {
+	path := s.Path
+	if path == "" {
+		path = "data"
+	}
+	var parts []string
+	if s.Items != nil {
+		parts = append(parts, pluralize(*s.Items, "item", "items")+" in "+s.ItemsField)
+	}
+	if s.Total != nil {
+		parts = append(parts, fmt.Sprintf("%d in total", *s.Total))
+	}
+	if s.Page != nil {
+		page := fmt.Sprintf("page %d", *s.Page)
+		if s.TotalPages != nil {
+			page += fmt.Sprintf(" of %d", *s.TotalPages)
+		}
+		parts = append(parts, page)
+	} else if s.TotalPages != nil {
+		parts = append(parts, pluralize(*s.TotalPages, "page", "pages"))
+	}
+	switch {
+	case s.HasMore == nil:
+		parts = append(parts, "unknown whether more exist")
+	case *s.HasMore:
+		parts = append(parts, "more available")
+	default:
+		parts = append(parts, "no more results")
+	}
+	var continueWith []string
+	if s.Next != nil {
+		continueWith = append(continueWith, "next: "+describeJSONValue(s.Next))
+	}
+	if s.Cursor != nil {
+		continueWith = append(continueWith, "cursor: "+describeJSONValue(s.Cursor))
+	}
+	line := path + ": " + strings.Join(parts, ", ")
+	if len(continueWith) > 0 {
+		line += " (" + strings.Join(continueWith, ", ") + ")"
+	}
+	return line
+}
@@ -0,0 +1,727 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// astDocument is a parsed GraphQL executable document.
+type astDocument struct {
+	Operations []*astOperation
+	Fragments  []*astFragment
+}
+
+// astOperation is an operation definition (query, mutation or subscription).
+type astOperation struct {
+	Operation           string
+	Name                string
+	VariableDefinitions []*astVariableDefinition
+	Directives          []*astDirective
+	SelectionSet        []*astSelection
+	Line                int
+	Column              int
+}
+
+// astFragment is a named fragment definition.
+type astFragment struct {
+	Name          string
+	TypeCondition string
+	Directives    []*astDirective
+	SelectionSet  []*astSelection
+	Line          int
+	Column        int
+}
+
+// astVariableDefinition is a variable declared by an operation, e.g. "$id: ID! = 1".
+type astVariableDefinition struct {
+	Name         string
+	Type         *astType
+	DefaultValue *astValue
+	Directives   []*astDirective
+}
+
+// astType is a type reference such as "String", "[ID!]" or "CandidateInput!".
+// List types have Elem set; named types have Name set.
+type astType struct {
+	Name    string
+	Elem    *astType
+	NonNull bool
+}
+
+// String renders the type reference in GraphQL notation.
+func (t *astType) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// namedType returns the innermost named type, unwrapping lists and non-null.
+func (t *astType) namedType() string {
+	for t.Elem != nil {
+		t = t.Elem
+	}
+	return t.Name
+}
+
+type astSelectionKind int
+
+const (
+	selectionField astSelectionKind = iota
+	selectionFragmentSpread
+	selectionInlineFragment
+)
+
+// astSelection is a field, fragment spread or inline fragment. For fragment
+// spreads, Name holds the fragment name.
+type astSelection struct {
+	Kind          astSelectionKind
+	Alias         string
+	Name          string
+	TypeCondition string
+	Arguments     []*astArgument
+	Directives    []*astDirective
+	SelectionSet  []*astSelection
+	Line          int
+	Column        int
+}
+
+// responseKey returns the key under which a field appears in the response.
+func (s *astSelection) responseKey() string {
+	if s.Alias != "" {
+		return s.Alias
+	}
+	return s.Name
+}
+
+// astArgument is a name/value pair, used for arguments and object fields.
+type astArgument struct {
+	Name  string
+	Value *astValue
+}
+
+// astDirective is a directive applied in a document, e.g. "@include(if: $x)".
+type astDirective struct {
+	Name      string
+	Arguments []*astArgument
+	Line      int
+	Column    int
+}
+
+type astValueKind int
+
+const (
+	valueVariable astValueKind = iota
+	valueInt
+	valueFloat
+	valueString
+	valueBoolean
+	valueNull
+	valueEnum
+	valueList
+	valueObject
+)
+
+// astValue is an input value literal. Raw holds the variable name, the
+// number/boolean/enum text or the decoded string.
+type astValue struct {
+	Kind   astValueKind
+	Raw    string
+	Block  bool
+	List   []*astValue
+	Fields []*astArgument
+}
+
+// String renders the value as a GraphQL literal, e.g. {limit: 10, tags: ["a"]}.
+func (v *astValue) String() string {
+	switch v.Kind {
+	case valueVariable:
+		return "$" + v.Raw
+	case valueString:
+		if v.Block {
+			return `"""` + strings.ReplaceAll(v.Raw, `"""`, `\"""`) + `"""`
+		}
+		return quoteString(v.Raw)
+	case valueList:
+		items := make([]string, 0, len(v.List))
+		for _, item := range v.List {
+			items = append(items, item.String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case valueObject:
+		fields := make([]string, 0, len(v.Fields))
+		for _, f := range v.Fields {
+			fields = append(fields, f.Name+": "+f.Value.String())
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	}
+	return v.Raw
+}
+
+// quoteString renders s as a GraphQL string literal.
+func quoteString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// parser is a recursive descent parser for GraphQL documents.
+type parser struct {
+	lex *lexer
+	tok token
+}
+
+// parseDocument parses a GraphQL executable document (operations and fragments).
+func parseDocument(src string) (*astDocument, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &astDocument{}
+	if p.tok.kind == tokenEOF {
+		return nil, p.errorf("empty document, expected an operation")
+	}
+	for p.tok.kind != tokenEOF {
+		switch {
+		case p.peek(tokenPunct, "{"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case p.peek(tokenName, "fragment"):
+			frag, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			doc.Fragments = append(doc.Fragments, frag)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+// parseConstValue parses a constant input value, such as the default value
+// of an argument or input field reported by introspection.
+func parseConstValue(src string) (*astValue, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	v, err := p.parseValue(true)
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+	return v, nil
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &syntaxError{Message: fmt.Sprintf(format, args...), Line: p.tok.line, Column: p.tok.column}
+}
+
+func (p *parser) unexpected() error {
+	if p.tok.kind == tokenEOF {
+		return p.errorf("unexpected end of document")
+	}
+	return p.errorf("unexpected %q", p.tok.value)
+}
+
+// peek reports whether the current token has the given kind and value.
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.tok.kind == kind && p.tok.value == value
+}
+
+// skip consumes the current token if it matches and reports whether it did.
+func (p *parser) skip(kind tokenKind, value string) (bool, error) {
+	if !p.peek(kind, value) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+// expect consumes the given punctuator or returns a syntax error.
+func (p *parser) expect(value string) error {
+	if !p.peek(tokenPunct, value) {
+		if p.tok.kind == tokenEOF {
+			return p.errorf("expected %q, found end of document", value)
+		}
+		return p.errorf("expected %q, found %q", value, p.tok.value)
+	}
+	return p.advance()
+}
+
+// expectKeyword consumes the given keyword or returns a syntax error.
+func (p *parser) expectKeyword(value string) error {
+	if !p.peek(tokenName, value) {
+		return p.errorf("expected %q, found %q", value, p.tok.value)
+	}
+	return p.advance()
+}
+
+// parseName consumes a name token and returns its value.
+func (p *parser) parseName() (string, error) {
+	if p.tok.kind != tokenName {
+		if p.tok.kind == tokenEOF {
+			return "", p.errorf("expected name, found end of document")
+		}
+		return "", p.errorf("expected name, found %q", p.tok.value)
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+func (p *parser) parseOperation() (*astOperation, error) {
+	op := &astOperation{Operation: "query", Line: p.tok.line, Column: p.tok.column}
+	if p.peek(tokenPunct, "{") {
+		sel, err := p.parseSelectionSet()
+		if err != nil {
+			return nil, err
+		}
+		op.SelectionSet = sel
+		return op, nil
+	}
+
+	op.Operation = p.tok.value
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind == tokenName {
+		op.Name = p.tok.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek(tokenPunct, "(") {
+		defs, err := p.parseVariableDefinitions()
+		if err != nil {
+			return nil, err
+		}
+		op.VariableDefinitions = defs
+	}
+	dirs, err := p.parseDirectives(false)
+	if err != nil {
+		return nil, err
+	}
+	op.Directives = dirs
+	sel, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.SelectionSet = sel
+	return op, nil
+}
+
+func (p *parser) parseFragment() (*astFragment, error) {
+	frag := &astFragment{Line: p.tok.line, Column: p.tok.column}
+	if err := p.expectKeyword("fragment"); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenName, "on") {
+		return nil, p.errorf("fragment name expected, found \"on\"")
+	}
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	frag.Name = name
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+	if frag.TypeCondition, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if frag.Directives, err = p.parseDirectives(false); err != nil {
+		return nil, err
+	}
+	if frag.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+func (p *parser) parseVariableDefinitions() ([]*astVariableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var defs []*astVariableDefinition
+	for !p.peek(tokenPunct, ")") {
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		typ, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		def := &astVariableDefinition{Name: name, Type: typ}
+		if ok, err := p.skip(tokenPunct, "="); err != nil {
+			return nil, err
+		} else if ok {
+			if def.DefaultValue, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+		if def.Directives, err = p.parseDirectives(true); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+	if len(defs) == 0 {
+		return nil, p.errorf("expected at least one variable definition")
+	}
+	return defs, p.advance()
+}
+
+func (p *parser) parseType() (*astType, error) {
+	var typ *astType
+	if ok, err := p.skip(tokenPunct, "["); err != nil {
+		return nil, err
+	} else if ok {
+		elem, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		typ = &astType{Elem: elem}
+	} else {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		typ = &astType{Name: name}
+	}
+	if ok, err := p.skip(tokenPunct, "!"); err != nil {
+		return nil, err
+	} else if ok {
+		typ.NonNull = true
+	}
+	return typ, nil
+}
+
+func (p *parser) parseSelectionSet() ([]*astSelection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []*astSelection
+	for !p.peek(tokenPunct, "}") {
+		if p.tok.kind == tokenEOF {
+			return nil, p.errorf("expected \"}\", found end of document")
+		}
+		sel, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	if len(sels) == 0 {
+		return nil, p.errorf("selection set cannot be empty")
+	}
+	return sels, p.advance()
+}
+
+func (p *parser) parseSelection() (*astSelection, error) {
+	sel := &astSelection{Line: p.tok.line, Column: p.tok.column}
+	var err error
+	if ok, err := p.skip(tokenPunct, "..."); err != nil {
+		return nil, err
+	} else if ok {
+		if p.tok.kind == tokenName && p.tok.value != "on" {
+			sel.Kind = selectionFragmentSpread
+			if sel.Name, err = p.parseName(); err != nil {
+				return nil, err
+			}
+			sel.Directives, err = p.parseDirectives(false)
+			return sel, err
+		}
+		sel.Kind = selectionInlineFragment
+		if ok, err := p.skip(tokenName, "on"); err != nil {
+			return nil, err
+		} else if ok {
+			if sel.TypeCondition, err = p.parseName(); err != nil {
+				return nil, err
+			}
+		}
+		if sel.Directives, err = p.parseDirectives(false); err != nil {
+			return nil, err
+		}
+		sel.SelectionSet, err = p.parseSelectionSet()
+		return sel, err
+	}
+
+	sel.Kind = selectionField
+	if sel.Name, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(tokenPunct, ":"); err != nil {
+		return nil, err
+	} else if ok {
+		sel.Alias = sel.Name
+		if sel.Name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek(tokenPunct, "(") {
+		if sel.Arguments, err = p.parseArguments(false); err != nil {
+			return nil, err
+		}
+	}
+	if sel.Directives, err = p.parseDirectives(false); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunct, "{") {
+		if sel.SelectionSet, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+func (p *parser) parseArguments(isConst bool) ([]*astArgument, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var args []*astArgument
+	for !p.peek(tokenPunct, ")") {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		val, err := p.parseValue(isConst)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, &astArgument{Name: name, Value: val})
+	}
+	if len(args) == 0 {
+		return nil, p.errorf("expected at least one argument")
+	}
+	return args, p.advance()
+}
+
+func (p *parser) parseDirectives(isConst bool) ([]*astDirective, error) {
+	var dirs []*astDirective
+	for p.peek(tokenPunct, "@") {
+		dir := &astDirective{Line: p.tok.line, Column: p.tok.column}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		dir.Name = name
+		if p.peek(tokenPunct, "(") {
+			if dir.Arguments, err = p.parseArguments(isConst); err != nil {
+				return nil, err
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// parseValue parses an input value. When isConst is set, variables are rejected.
+func (p *parser) parseValue(isConst bool) (*astValue, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tokenPunct:
+		switch tok.value {
+		case "$":
+			if isConst {
+				return nil, p.errorf("unexpected variable in constant value")
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.parseName()
+			if err != nil {
+				return nil, err
+			}
+			return &astValue{Kind: valueVariable, Raw: name}, nil
+		case "[":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			val := &astValue{Kind: valueList}
+			for !p.peek(tokenPunct, "]") {
+				item, err := p.parseValue(isConst)
+				if err != nil {
+					return nil, err
+				}
+				val.List = append(val.List, item)
+			}
+			return val, p.advance()
+		case "{":
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			val := &astValue{Kind: valueObject}
+			for !p.peek(tokenPunct, "}") {
+				name, err := p.parseName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(":"); err != nil {
+					return nil, err
+				}
+				field, err := p.parseValue(isConst)
+				if err != nil {
+					return nil, err
+				}
+				val.Fields = append(val.Fields, &astArgument{Name: name, Value: field})
+			}
+			return val, p.advance()
+		}
+	case tokenInt:
+		return &astValue{Kind: valueInt, Raw: tok.value}, p.advance()
+	case tokenFloat:
+		return &astValue{Kind: valueFloat, Raw: tok.value}, p.advance()
+	case tokenString, tokenBlockString:
+		return &astValue{Kind: valueString, Raw: tok.value, Block: tok.kind == tokenBlockString}, p.advance()
+	case tokenName:
+		switch tok.value {
+		case "true", "false":
+			return &astValue{Kind: valueBoolean, Raw: tok.value}, p.advance()
+		case "null":
+			return &astValue{Kind: valueNull, Raw: tok.value}, p.advance()
+		}
+		return &astValue{Kind: valueEnum, Raw: tok.value}, p.advance()
+	}
+	return nil, p.unexpected()
+}
+
+// fragment returns the fragment definition with the given name, or nil.
+func (d *astDocument) fragment(name string) *astFragment {
+	for _, f := range d.Fragments {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// rootFields returns the names of the top-level fields selected by op,
+// following fragment spreads and inline fragments.
+func (d *astDocument) rootFields(op *astOperation) []string {
+	var names []string
+	seen := make(map[string]bool)
+	visited := make(map[string]bool)
+	var walk func(sels []*astSelection)
+	walk = func(sels []*astSelection) {
+		for _, sel := range sels {
+			switch sel.Kind {
+			case selectionField:
+				if !seen[sel.Name] {
+					seen[sel.Name] = true
+					names = append(names, sel.Name)
+				}
+			case selectionInlineFragment:
+				walk(sel.SelectionSet)
+			case selectionFragmentSpread:
+				if visited[sel.Name] {
+					continue
+				}
+				visited[sel.Name] = true
+				if frag := d.fragment(sel.Name); frag != nil {
+					walk(frag.SelectionSet)
+				}
+			}
+		}
+	}
+	walk(op.SelectionSet)
+	return names
+}
+
+// fieldSelection is a field selected directly or through fragments, together
+// with the type condition of the innermost fragment that selected it.
+type fieldSelection struct {
+	Field         *astSelection
+	TypeCondition string
+}
+
+// fieldSelections flattens a selection set into the fields it selects,
+// following fragment spreads and inline fragments.
+func (d *astDocument) fieldSelections(sels []*astSelection) []fieldSelection {
+	var fields []fieldSelection
+	visited := make(map[string]bool)
+	var walk func(sels []*astSelection, typeCondition string)
+	walk = func(sels []*astSelection, typeCondition string) {
+		for _, sel := range sels {
+			switch sel.Kind {
+			case selectionField:
+				fields = append(fields, fieldSelection{Field: sel, TypeCondition: typeCondition})
+			case selectionInlineFragment:
+				cond := typeCondition
+				if sel.TypeCondition != "" {
+					cond = sel.TypeCondition
+				}
+				walk(sel.SelectionSet, cond)
+			case selectionFragmentSpread:
+				if visited[sel.Name] {
+					continue
+				}
+				visited[sel.Name] = true
+				if frag := d.fragment(sel.Name); frag != nil {
+					walk(frag.SelectionSet, frag.TypeCondition)
+				}
+			}
+		}
+	}
+	walk(sels, "")
+	return fields
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDocument(t *testing.T) {
+	doc, err := parseDocument(`
+# Candidates with their applications
+query Candidates($status: Status = ACTIVE, $ids: [ID!]!, $withJobs: Boolean!) @cached {
+  list: candidates(
+    ids: $ids
+    filter: {status: $status, tags: ["a", "b"], note: """multi
+  line""", escaped: "tab\tquote\"", score: -1.5e3, archived: null}
+  ) {
+    id
+    ...Contact
+    ... on Employee @include(if: $withJobs) { team }
+  }
+}
+
+mutation { archive(id: 1) { id } }
+
+fragment Contact on Candidate { email phone }
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Operations) != 2 || len(doc.Fragments) != 1 {
+		t.Fatalf("got %d operations and %d fragments, want 2 and 1", len(doc.Operations), len(doc.Fragments))
+	}
+
+	op := doc.Operations[0]
+	if op.Operation != "query" || op.Name != "Candidates" || op.Line != 3 || op.Column != 1 {
+		t.Errorf("operation = %s %s at %d:%d, want query Candidates at 3:1", op.Operation, op.Name, op.Line, op.Column)
+	}
+	var vars []string
+	for _, v := range op.VariableDefinitions {
+		s := "$" + v.Name + ": " + v.Type.String()
+		if v.DefaultValue != nil {
+			s += " = " + v.DefaultValue.String()
+		}
+		vars = append(vars, s)
+	}
+	if got, want := strings.Join(vars, ", "), "$status: Status = ACTIVE, $ids: [ID!]!, $withJobs: Boolean!"; got != want {
+		t.Errorf("variables = %s, want %s", got, want)
+	}
+	if len(op.Directives) != 1 || op.Directives[0].Name != "cached" {
+		t.Errorf("operation directives = %v, want @cached", op.Directives)
+	}
+
+	list := op.SelectionSet[0]
+	if list.Kind != selectionField || list.Alias != "list" || list.Name != "candidates" || list.responseKey() != "list" {
+		t.Errorf("first selection = %+v, want candidates aliased list", list)
+	}
+	var args []string
+	for _, a := range list.Arguments {
+		args = append(args, a.Name+": "+a.Value.String())
+	}
+	wantArgs := `ids: $ids, filter: {status: $status, tags: ["a", "b"], note: """multi
+line""", escaped: "tab\tquote\"", score: -1.5e3, archived: null}`
+	if got := strings.Join(args, ", "); got != wantArgs {
+		t.Errorf("arguments =\n%s\nwant\n%s", got, wantArgs)
+	}
+
+	sels := list.SelectionSet
+	if len(sels) != 3 || sels[1].Kind != selectionFragmentSpread || sels[1].Name != "Contact" {
+		t.Fatalf("selections = %+v, want a field, a spread of Contact and an inline fragment", sels)
+	}
+	inline := sels[2]
+	if inline.Kind != selectionInlineFragment || inline.TypeCondition != "Employee" || len(inline.Directives) != 1 || inline.Directives[0].Arguments[0].Value.String() != "$withJobs" {
+		t.Errorf("inline fragment = %+v, want one on Employee with @include(if: $withJobs)", inline)
+	}
+
+	if got, want := doc.rootFields(doc.Operations[1]), []string{"archive"}; len(got) != 1 || got[0] != want[0] {
+		t.Errorf("mutation root fields = %v, want %v", got, want)
+	}
+	if frag := doc.fragment("Contact"); frag == nil || frag.TypeCondition != "Candidate" || len(frag.SelectionSet) != 2 {
+		t.Errorf("fragment Contact = %+v, want one on Candidate selecting 2 fields", frag)
+	}
+}
+
+func TestParseDocumentErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{``, "line 1, column 1: empty document, expected an operation"},
+		{`query { a(`, "line 1, column 11: expected name, found end of document"},
+		{`query { a(x: "abc) }`, "line 1, column 14: unterminated string"},
+		{`{ a } }`, `line 1, column 7: unexpected "}"`},
+		{`query ($id: ID! = ) { a }`, `line 1, column 19: unexpected ")"`},
+		{`fragment F { a }`, `line 1, column 12: expected "on", found "{"`},
+		{`query { a(x: 1.) }`, "line 1, column 16: invalid number, expected digit"},
+		{"query {\n  a @skip(if: $x\n}", `line 3, column 1: expected name, found "}"`},
+	}
+	for _, tt := range tests {
+		_, err := parseDocument(tt.src)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseDocument(%q) error = %v, want one containing %q", tt.src, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Operation policy configuration. Entries are root field names, optionally
+// qualified by operation type (e.g. "deleteCandidate" or "mutation.deleteCandidate").
+var (
+	// allowedOperations, when non-empty, is the only set of root fields that may be invoked.
+	allowedOperations = listFromEnv("ALLOWED_OPERATIONS")
+	// deniedOperations lists root fields that may never be invoked.
+	deniedOperations = listFromEnv("DENIED_OPERATIONS")
+	// readOnlyMode blocks every mutation regardless of the lists above. An
+	// invalid value is a startup error rather than leaving the guard off.
+	readOnlyMode, readOnlyModeErr = strictBoolFromEnv("READ_ONLY")
+)
+
+// errOperationNotAllowed is returned when the operation policy rejects an operation.
+var errOperationNotAllowed = errors.New("operation not allowed")
+
+// checkOperationAllowed parses the operation and verifies it against the
+// hash allow-list, then every root field it selects against the read-only
+// mode, the allow-list and the deny-list.
+func checkOperationAllowed(operation string) error {
+	if err := checkOperationHash(operation); err != nil {
+		return err
+	}
+	if len(allowedOperations) == 0 && len(deniedOperations) == 0 && !readOnlyMode {
+		return nil
+	}
+
+	doc, err := parseDocument(operation)
+	if err != nil {
+		return fmt.Errorf("%w: unable to verify the operation: %v", errOperationNotAllowed, err)
+	}
+	for _, op := range doc.Operations {
+		if readOnlyMode && op.Operation == "mutation" {
+			return fmt.Errorf("%w: mutations are disabled in read-only mode", errOperationNotAllowed)
+		}
+		for _, field := range doc.rootFields(op) {
+			// Meta fields such as __typename expose nothing worth restricting
+			if strings.HasPrefix(field, "__") {
+				continue
+			}
+			if len(allowedOperations) > 0 && !matchesOperationName(allowedOperations, op.Operation, field) {
+				return fmt.Errorf("%w: %s.%s is not in the allow-list", errOperationNotAllowed, op.Operation, field)
+			}
+			if matchesOperationName(deniedOperations, op.Operation, field) {
+				return fmt.Errorf("%w: %s.%s is in the deny-list", errOperationNotAllowed, op.Operation, field)
+			}
+		}
+	}
+	return nil
+}
+
+// matchesOperationName reports whether names contains the root field, either
+// bare or qualified with the operation type.
+func matchesOperationName(names []string, operationType, field string) bool {
+	for _, name := range names {
+		if name == field || name == operationType+"."+field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckOperationAllowed(t *testing.T) {
+	const multiOperation = `query ListJobs { jobs { id } } mutation DropJobs { deleteJobs }`
+	tests := []struct {
+		name          string
+		allowed       []string
+		denied        []string
+		readOnly      bool
+		operation     string
+		operationName string
+		wantErr       bool
+	}{
+		{name: "no policy", operation: `mutation { deleteJobs }`},
+		{name: "allowed field", allowed: []string{"jobs"}, operation: `{ jobs { id } }`},
+		{name: "allowed qualified field", allowed: []string{"query.jobs"}, operation: `query Jobs { jobs { id } }`},
+		{name: "qualifier of another type", allowed: []string{"mutation.jobs"}, operation: `{ jobs { id } }`, wantErr: true},
+		{name: "field outside the allow-list", allowed: []string{"jobs"}, operation: `{ jobs { id } candidates { id } }`, wantErr: true},
+		{name: "field in a fragment outside the allow-list", allowed: []string{"jobs"}, operation: `{ ...F } fragment F on Query { candidates { id } }`, wantErr: true},
+		{name: "denied field", denied: []string{"mutation.deleteJobs"}, operation: `mutation { deleteJobs }`, wantErr: true},
+		{name: "other field than the denied one", denied: []string{"deleteJobs"}, operation: `mutation { createJob }`},
+		{name: "denied wins over allowed", allowed: []string{"deleteJobs"}, denied: []string{"deleteJobs"}, operation: `mutation { deleteJobs }`, wantErr: true},
+		{name: "meta field", allowed: []string{"jobs"}, operation: `{ __typename }`},
+		{name: "query in read-only mode", readOnly: true, operation: `{ jobs { id } }`},
+		{name: "mutation in read-only mode", readOnly: true, operation: `mutation CreateJob { createJob }`, wantErr: true},
+		{name: "anonymous mutation in read-only mode", readOnly: true, operation: `mutation { createJob }`, wantErr: true},
+		{name: "anonymous query", denied: []string{"candidates"}, operation: `{ jobs { id } }`},
+		// Every operation of the document is checked, since the whole
+		// document is sent whichever one operationName selects
+		{name: "multiple operations in read-only mode", readOnly: true, operation: multiOperation, operationName: "ListJobs", wantErr: true},
+		{name: "multiple operations with a denied one", denied: []string{"deleteJobs"}, operation: multiOperation, operationName: "ListJobs", wantErr: true},
+		{name: "multiple allowed operations", allowed: []string{"jobs", "deleteJobs"}, operation: multiOperation, operationName: "DropJobs"},
+		{name: "unparseable operation", readOnly: true, operation: `{ jobs {`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previousAllowed, previousDenied, previousReadOnly := allowedOperations, deniedOperations, readOnlyMode
+			allowedOperations, deniedOperations, readOnlyMode = tt.allowed, tt.denied, tt.readOnly
+			t.Cleanup(func() {
+				allowedOperations, deniedOperations, readOnlyMode = previousAllowed, previousDenied, previousReadOnly
+			})
+
+			operation, _, err := resolveOperationName(tt.operation, tt.operationName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = checkOperationAllowed(operation)
+			if tt.wantErr {
+				if !errors.Is(err, errOperationNotAllowed) {
+					t.Errorf("error = %v, want the operation to be rejected", err)
+				}
+			} else if err != nil {
+				t.Errorf("the operation was rejected: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tool: probe_fields
+const probeFieldsToolDescription = `Check which fields of a type are accessible with the current headers.
+The tool queries the fields of the type in batches and reports, field by field, which ones resolve and which ones return authorization (or other) errors.
+
+Best Practices:
+- Use this tool when fields may be hidden per role, to learn the surface your credentials can actually query.
+- For the query root type no entry point is needed. For other types, pass 'entry': a query field (with arguments) that returns an object of the type, e.g. candidate(id: "1").
+- When 'entry' is omitted for a non-root type, a query field returning the type that takes no required arguments is used when there is one.
+- Fields that require arguments are skipped and reported as such.
+- Mutation and subscription fields are never probed.
+
+Arguments:
+- type (string, Required): The name of the type to probe.
+- entry (string, Optional): A query field returning the type, with its arguments, e.g. candidate(id: "1").
+
+Example Usage:
+Request:
+  probe_fields(type: "Candidate", entry: "candidate(id: \"1\")")
+
+Response:
+  Probed Candidate via candidate(id: "1") with 3 requests:
+  Accessible (2):
+  	id
+  	name
+  Denied (1):
+  	salary: Not authorized to access this field
+  Skipped (1):
+  	applications: requires arguments (status: ApplicationStatus!)
+`
+
+// maxProbeBatch is the number of fields selected per probe request. Batches
+// whose errors can't be attributed to single fields are split in half.
+const maxProbeBatch = 25
+
+// fieldProbeResult is the outcome of probing a single field.
+type fieldProbeResult struct {
+	Field  string
+	Status string // "accessible", "denied", "failed" or "skipped"
+	Reason string
+}
+
+// fieldProber runs the probe requests for one type.
+type fieldProber struct {
+	// wrap turns a field selection into an operation
+	wrap func(selection string) string
+	// rootKey is the response key of the entry field, empty for the query root
+	rootKey  string
+	requests int
+	results  map[string]fieldProbeResult
+}
+
+// probeFields reports which fields of typeName are accessible with the
+// current headers, reaching the type through entry when it isn't the query
+// root type.
+func probeFields(ctx context.Context, typeName, entry string) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	t := schema.typeByName(typeName)
+	if t == nil {
+		return "", fmt.Errorf("type %q not found in schema", typeName)
+	}
+	if t.Kind != "OBJECT" && t.Kind != "INTERFACE" {
+		return "", fmt.Errorf("type %q is a %s; only object and interface types have fields to probe", typeName, t.Kind)
+	}
+	if typeName == schema.rootType("mutation") || typeName == schema.rootType("subscription") {
+		return "", fmt.Errorf("refusing to probe %s: selecting its fields would run mutations or subscriptions", typeName)
+	}
+
+	isQueryRoot := typeName == schema.rootType("query")
+	p := &fieldProber{results: make(map[string]fieldProbeResult)}
+	via := ""
+	if isQueryRoot {
+		p.wrap = func(selection string) string { return "query ProbeFields { " + selection + " }" }
+	} else {
+		if strings.TrimSpace(entry) == "" {
+			if entry, err = defaultProbeEntry(schema, typeName); err != nil {
+				return "", err
+			}
+		}
+		entry = strings.TrimSpace(entry)
+		doc, err := parseDocument("{ " + entry + " }")
+		if err != nil || len(doc.Operations) != 1 || len(doc.Operations[0].SelectionSet) != 1 {
+			return "", fmt.Errorf("invalid entry %q: expected a single query field such as candidate(id: \"1\")", entry)
+		}
+		sel := doc.Operations[0].SelectionSet[0]
+		if len(sel.SelectionSet) > 0 {
+			return "", fmt.Errorf("invalid entry %q: omit the selection set, the probed fields are selected automatically", entry)
+		}
+		if err := checkOperationAllowed("{ " + entry + " }"); err != nil {
+			return "", err
+		}
+		p.rootKey = sel.responseKey()
+		p.wrap = func(selection string) string { return "query ProbeFields { " + entry + " { " + selection + " } }" }
+		via = " via " + entry
+	}
+
+	var batch []string
+	selections := make(map[string]string)
+	for _, f := range t.Fields {
+		if strings.HasPrefix(f.Name, "__") {
+			continue
+		}
+		if required := requiredArgs(f); len(required) > 0 {
+			p.results[f.Name] = fieldProbeResult{Field: f.Name, Status: "skipped", Reason: "requires arguments (" + strings.Join(required, ", ") + ")"}
+			continue
+		}
+		if isQueryRoot {
+			if err := checkOperationAllowed("{ " + f.Name + " }"); err != nil {
+				p.results[f.Name] = fieldProbeResult{Field: f.Name, Status: "skipped", Reason: err.Error()}
+				continue
+			}
+		}
+		selections[f.Name] = probeSelection(schema, f)
+		batch = append(batch, f.Name)
+	}
+
+	for start := 0; start < len(batch); start += maxProbeBatch {
+		end := start + maxProbeBatch
+		if end > len(batch) {
+			end = len(batch)
+		}
+		if err := p.probe(ctx, batch[start:end], selections); err != nil {
+			return "", err
+		}
+	}
+	return p.report(typeName+via, t), nil
+}
+
+// probe queries fields in one request and records the outcome of each. When
+// an error can't be attributed to a single field, the batch is split.
+func (p *fieldProber) probe(ctx context.Context, fields []string, selections map[string]string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = selections[f]
+	}
+	operation := p.wrap(strings.Join(parts, " "))
+
+	started := time.Now()
+	p.requests++
+	res, err := executeGraphQL(ctx, graphqlRequest{Query: operation, OperationName: "ProbeFields"})
+	recordAudit(operation, "ProbeFields", nil, started, err)
+	var gqlErrors []graphqlError
+	var data json.RawMessage
+	switch e := err.(type) {
+	case nil:
+		gqlErrors, data = res.Errors, res.Data
+	case *graphqlResponseError:
+		gqlErrors, data = e.Errors, e.Data
+	default:
+		return err
+	}
+
+	if p.rootKey != "" && len(gqlErrors) == 0 {
+		var obj map[string]interface{}
+		if err := json.Unmarshal(data, &obj); err == nil {
+			if v := obj[p.rootKey]; v == nil || isEmptyList(v) {
+				return fmt.Errorf("the entry returned no object, so nothing was probed; pass arguments that resolve to an existing object")
+			}
+		}
+	}
+
+	inBatch := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		inBatch[f] = true
+	}
+	fieldErrors := make(map[string]graphqlError)
+	var unattributed []graphqlError
+	for _, gqlErr := range gqlErrors {
+		if f := p.errorField(gqlErr.Path, inBatch); f != "" {
+			if _, ok := fieldErrors[f]; !ok {
+				fieldErrors[f] = gqlErr
+			}
+			continue
+		}
+		unattributed = append(unattributed, gqlErr)
+	}
+
+	if len(unattributed) > 0 {
+		if len(fields) == 1 {
+			fieldErrors[fields[0]] = unattributed[0]
+		} else {
+			mid := len(fields) / 2
+			if err := p.probe(ctx, fields[:mid], selections); err != nil {
+				return err
+			}
+			return p.probe(ctx, fields[mid:], selections)
+		}
+	}
+
+	for _, f := range fields {
+		gqlErr, failed := fieldErrors[f]
+		switch {
+		case !failed:
+			p.results[f] = fieldProbeResult{Field: f, Status: "accessible"}
+		case isUnauthenticatedError(gqlErr) || isForbiddenError(gqlErr):
+			p.results[f] = fieldProbeResult{Field: f, Status: "denied", Reason: gqlErr.Message}
+		default:
+			p.results[f] = fieldProbeResult{Field: f, Status: "failed", Reason: gqlErr.Message}
+		}
+	}
+	return nil
+}
+
+// errorField returns the probed field an error path points at, or "" when
+// the path doesn't lead to one of fields.
+func (p *fieldProber) errorField(path []interface{}, fields map[string]bool) string {
+	if len(path) == 0 {
+		return ""
+	}
+	rest := path
+	if p.rootKey != "" {
+		if key, _ := path[0].(string); key != p.rootKey {
+			return ""
+		}
+		rest = path[1:]
+		// Skip list indexes when the entry returns a list
+		for len(rest) > 0 {
+			if _, ok := rest[0].(float64); !ok {
+				break
+			}
+			rest = rest[1:]
+		}
+	}
+	if len(rest) == 0 {
+		return ""
+	}
+	if key, _ := rest[0].(string); fields[key] {
+		return key
+	}
+	return ""
+}
+
+// report renders the probe results grouped by status, in schema order.
+func (p *fieldProber) report(target string, t *schemaType) string {
+	groups := []struct{ status, title string }{
+		{"accessible", "Accessible"},
+		{"denied", "Denied"},
+		{"failed", "Failed"},
+		{"skipped", "Skipped"},
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Probed %s with %d requests:\n", target, p.requests)
+	for _, g := range groups {
+		var lines []string
+		for _, f := range t.Fields {
+			r, ok := p.results[f.Name]
+			if !ok || r.Status != g.status {
+				continue
+			}
+			line := "\t" + r.Field
+			if r.Reason != "" {
+				line += ": " + r.Reason
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&sb, "%s (%d):\n%s\n", g.title, len(lines), strings.Join(lines, "\n"))
+		}
+	}
+	return sb.String()
+}
+
+// defaultProbeEntry picks a query field returning typeName that takes no
+// required arguments.
+func defaultProbeEntry(schema *schemaModel, typeName string) (string, error) {
+	var candidates []string
+	for _, f := range schema.queries() {
+		if f.Type.namedType() == typeName && len(requiredArgs(f)) == 0 {
+			candidates = append(candidates, f.Name)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no query field without required arguments returns %s; pass 'entry', e.g. a field with its arguments", typeName)
+	}
+	sort.Strings(candidates)
+	return candidates[0], nil
+}
+
+// requiredArgs returns the signatures of the arguments of f that must be
+// provided: non-null ones without a default value.
+func requiredArgs(f *schemaField) []string {
+	var required []string
+	for _, a := range f.Args {
+		if a.Type.isNonNull() && a.DefaultValue == nil {
+			required = append(required, a.Name+": "+a.Type.String())
+		}
+	}
+	return required
+}
+
+// probeSelection selects f minimally: leaf fields by name, composite fields
+// with __typename.
+func probeSelection(schema *schemaModel, f *schemaField) string {
+	if t := schema.typeByName(f.Type.namedType()); t != nil && (t.Kind == "SCALAR" || t.Kind == "ENUM") {
+		return f.Name
+	}
+	return f.Name + " { __typename }"
+}
+
+// isEmptyList reports whether v is a decoded empty JSON array.
+func isEmptyList(v interface{}) bool {
+	list, ok := v.([]interface{})
+	return ok && len(list) == 0
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// progressChunkBytes is how many response bytes are received between two
+// progress notifications.
+var progressChunkBytes = int64(intFromEnv("PROGRESS_CHUNK_BYTES", 256<<10))
+
+// progressFunc is told how many bytes of a response were received so far, and
+// the total when the endpoint announced it (0 otherwise).
+type progressFunc func(received, total int64)
+
+type progressKey struct{}
+
+// withProgress returns a context whose GraphQL responses report their
+// download progress to fn.
+func withProgress(ctx context.Context, fn progressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// progressFromContext returns the progressFunc set by withProgress, or nil.
+func progressFromContext(ctx context.Context) progressFunc {
+	fn, _ := ctx.Value(progressKey{}).(progressFunc)
+	return fn
+}
+
+// progressReader calls report every progressChunkBytes while r is read.
+type progressReader struct {
+	r        io.Reader
+	report   progressFunc
+	total    int64
+	received int64
+	reported int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.received += int64(n)
+	if p.received-p.reported >= progressChunkBytes || (err == io.EOF && p.received > p.reported) {
+		p.reported = p.received
+		p.report(p.received, p.total)
+	}
+	return n, err
+}
+
+// toolCallProgress returns a progressFunc sending MCP progress notifications
+// for a tool call, or nil when they aren't wanted: the client didn't pass a
+// progress token, or the transport isn't SSE. Under stdio the result simply
+// arrives as one message.
+func toolCallProgress(srv *server.MCPServer, request mcp.CallToolRequest) progressFunc {
+	if transport != "sse" || progressChunkBytes <= 0 || request.Params.Meta == nil || request.Params.Meta.ProgressToken == nil {
+		return nil
+	}
+	token := request.Params.Meta.ProgressToken
+	return func(received, total int64) {
+		params := map[string]interface{}{"progressToken": token, "progress": received}
+		if total > 0 {
+			params["total"] = total
+		}
+		// Progress is best effort and never fails the call
+		if err := srv.SendNotificationToClient("notifications/progress", params); err != nil {
+			log.Printf("Warning: failed to send progress notification: %v", err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Tool: operation_hash
+const operationHashToolDescription = `Print the sha256 hash identifying an operation in the ALLOWED_QUERY_HASHES allow-list, and whether it is currently allowed.
+The hash is computed over the operation in canonical form (as printed by format_operation), so whitespace, commas and comments don't change it, but any change to the selection, arguments or names does.
+
+Best Practices:
+- Operators use this tool to build the allow-list from the operations they approve.
+- An operation that is rejected as not allow-listed must be approved by an operator; changing it won't help.
+
+Arguments:
+- operation (string, Required): The GraphQL operation to hash.
+
+Example Usage:
+Request:
+  operation_hash(operation: "query { jobs { id name } }")
+
+Response:
+  sha256: be1895f974bdaf2d8cc0178a7c1b010c47ea1a6c03f5dfdb54e3df9999c56f31
+  Not in ALLOWED_QUERY_HASHES.
+`
+
+// allowedQueryHashes, when non-empty, is the only set of operations that may
+// run, identified by the sha256 of their canonical form. It is read from
+// ALLOWED_QUERY_HASHES (comma separated) and ALLOWED_QUERY_HASHES_FILE (one
+// hash per line, # starts a comment).
+var allowedQueryHashes, allowedQueryHashesErr = loadAllowedQueryHashes(listFromEnv("ALLOWED_QUERY_HASHES"), getenv("ALLOWED_QUERY_HASHES_FILE"))
+
+// loadAllowedQueryHashes merges the hashes listed inline and in the file.
+// Every entry must be a hex sha256, so a typo doesn't silently leave an
+// operation out.
+func loadAllowedQueryHashes(inline []string, file string) (map[string]bool, error) {
+	hashes := make(map[string]bool)
+	for _, h := range inline {
+		if !isSHA256Hex(h) {
+			return nil, fmt.Errorf("invalid ALLOWED_QUERY_HASHES entry %q: want a hex sha256", h)
+		}
+		hashes[strings.ToLower(h)] = true
+	}
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading ALLOWED_QUERY_HASHES_FILE: %w", err)
+		}
+		for i, line := range strings.Split(string(content), "\n") {
+			if j := strings.Index(line, "#"); j >= 0 {
+				line = line[:j]
+			}
+			if line = strings.TrimSpace(line); line == "" {
+				continue
+			}
+			if !isSHA256Hex(line) {
+				return nil, fmt.Errorf("invalid ALLOWED_QUERY_HASHES_FILE entry %q on line %d of %s: want a hex sha256", line, i+1, file)
+			}
+			hashes[strings.ToLower(line)] = true
+		}
+	}
+	return hashes, nil
+}
+
+// isSHA256Hex reports whether s is a sha256 in hex, in either case.
+func isSHA256Hex(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// operationHash returns the hex sha256 of the canonical form of operation.
+func operationHash(operation string) (string, error) {
+	doc, err := parseDocument(operation)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(printDocument(doc)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// checkOperationHash rejects operations missing from the hash allow-list.
+// When the allow-list can't be loaded, every operation is rejected.
+func checkOperationHash(operation string) error {
+	if allowedQueryHashesErr != nil {
+		return fmt.Errorf("%w: the hash allow-list could not be loaded: %v", errOperationNotAllowed, allowedQueryHashesErr)
+	}
+	if len(allowedQueryHashes) == 0 {
+		return nil
+	}
+	hash, err := operationHash(operation)
+	if err != nil {
+		return fmt.Errorf("%w: unable to hash the operation: %v", errOperationNotAllowed, err)
+	}
+	if !allowedQueryHashes[hash] {
+		return fmt.Errorf("%w: the operation (sha256 %s) is not in the hash allow-list", errOperationNotAllowed, hash)
+	}
+	return nil
+}
+
+// describeOperationHash renders the hash of operation and its allow-list
+// status.
+func describeOperationHash(operation string) (string, error) {
+	hash, err := operationHash(operation)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse operation: %w", err)
+	}
+	status := "No hash allow-list is configured; every operation is allowed by hash."
+	switch {
+	case allowedQueryHashesErr != nil:
+		status = "The hash allow-list could not be loaded, so every operation is rejected: " + allowedQueryHashesErr.Error()
+	case len(allowedQueryHashes) == 0:
+	case allowedQueryHashes[hash]:
+		status = "Allowed by ALLOWED_QUERY_HASHES."
+	default:
+		status = "Not in ALLOWED_QUERY_HASHES."
+	}
+	return "sha256: " + hash + "\n" + status, nil
+}
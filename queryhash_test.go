@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAllowedQueryHashes(t *testing.T) {
+	hashA, err := operationHash("{ a }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashB, err := operationHash("{ b }")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	valid := writeFile("valid", "# allowed operations\n"+strings.ToUpper(hashB)+"  # b\n\n")
+	malformed := writeFile("malformed", hashB+"\nnot-a-hash\n")
+
+	tests := []struct {
+		name    string
+		inline  []string
+		file    string
+		want    []string
+		wantErr string
+	}{
+		{"inline and file", []string{hashA}, valid, []string{hashA, hashB}, ""},
+		{"malformed inline entry", []string{hashA[:63]}, "", nil, "invalid ALLOWED_QUERY_HASHES entry"},
+		{"malformed file entry", nil, malformed, nil, "on line 2"},
+		{"missing file", nil, filepath.Join(dir, "missing"), nil, "reading ALLOWED_QUERY_HASHES_FILE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hashes, err := loadAllowedQueryHashes(tt.inline, tt.file)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(hashes) != len(tt.want) {
+				t.Errorf("got %d hashes, want %d", len(hashes), len(tt.want))
+			}
+			for _, h := range tt.want {
+				if !hashes[h] {
+					t.Errorf("hash %s is missing", h)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Re-authentication configuration. REAUTH_COMMAND is run through the shell
+// when a call fails authentication; it must print a JSON object of headers
+// (the same format set_headers takes), which replace the current ones before
+// the operation is retried once.
+var (
+	reauthCommand = getenv("REAUTH_COMMAND")
+	reauthTimeout = durationFromEnv("REAUTH_TIMEOUT", 30*time.Second)
+)
+
+// errAuthenticationFailed is wrapped around authentication failures that
+// re-authentication didn't resolve.
+var errAuthenticationFailed = errors.New("authentication failed")
+
+// reauthState serializes re-authentication, so concurrent calls failing with
+// the same expired token run the command only once.
+var reauthState struct {
+	sync.Mutex
+	last time.Time
+}
+
+// isAuthFailure reports whether a call failed because the credentials were
+// missing, expired or rejected: a 401/403 status, or a GraphQL error saying
+// the request wasn't authenticated.
+func isAuthFailure(err error) bool {
+	if errors.Is(err, errHTTPAuth) {
+		return true
+	}
+	var gqlErr *graphqlResponseError
+	if errors.As(err, &gqlErr) {
+		for _, e := range gqlErr.Errors {
+			if isUnauthenticatedError(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reauthenticate runs REAUTH_COMMAND and installs the headers it prints. If
+// another call re-authenticated after started, the fresh headers are reused
+// instead of running the command again.
+func reauthenticate(ctx context.Context, started time.Time) error {
+	reauthState.Lock()
+	defer reauthState.Unlock()
+	if reauthState.last.After(started) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reauthTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", reauthCommand)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	log.Printf("Authentication failed, running REAUTH_COMMAND")
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("REAUTH_COMMAND failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("REAUTH_COMMAND failed: %w", err)
+	}
+	if err := setHeaders(strings.TrimSpace(stdout.String())); err != nil {
+		return fmt.Errorf("REAUTH_COMMAND output: %w", err)
+	}
+	reauthState.last = time.Now()
+	return nil
+}
+
+// authFailure explains an authentication failure that wasn't resolved,
+// depending on whether re-authentication is configured.
+func authFailure(err, reauthErr error) error {
+	switch {
+	case reauthCommand == "":
+		return fmt.Errorf("%w, call set_headers with valid credentials (e.g. a fresh Authorization header): %w", errAuthenticationFailed, err)
+	case reauthErr != nil:
+		return fmt.Errorf("%w and re-authentication failed (%v), call set_headers with valid credentials: %w", errAuthenticationFailed, reauthErr, err)
+	}
+	return fmt.Errorf("%w even after re-authenticating, call set_headers with valid credentials: %w", errAuthenticationFailed, err)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// disallowRedirects makes redirects fail with the Location instead of being
+// followed.
+var disallowRedirects = boolFromEnv("DISALLOW_REDIRECTS")
+
+// maxRedirects is the number of redirects followed before giving up.
+const maxRedirects = 10
+
+// loginPathPattern matches URLs of typical login and SSO pages.
+var loginPathPattern = regexp.MustCompile(`(?i)(^|[/._-])(login|log-in|signin|sign-in|sso|saml|oauth2?|authorize|auth)([/._?-]|$)`)
+
+// redirectError reports a redirect that wasn't followed: redirects are
+// disallowed, the redirect would have turned the POST into a GET, or it led
+// to a login page, which usually means the credentials are missing or expired.
+type redirectError struct {
+	StatusCode int
+	Location   string
+	Login      bool
+	Reason     string
+}
+
+func (e *redirectError) Error() string {
+	if e.Login {
+		return fmt.Sprintf("the endpoint redirected to what looks like a login page (%s): the credentials are probably missing or expired, set valid ones with set_headers", e.Location)
+	}
+	return fmt.Sprintf("the endpoint redirected (%d) to %s, which was not followed because %s; point ADDRESS at the new location", e.StatusCode, e.Location, e.Reason)
+}
+
+// Unwrap classifies login redirects as authentication errors.
+func (e *redirectError) Unwrap() error {
+	if e.Login {
+		return errHTTPAuth
+	}
+	return nil
+}
+
+// checkRedirect is the CheckRedirect policy of httpClient.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	status := 0
+	if req.Response != nil {
+		status = req.Response.StatusCode
+	}
+	switch {
+	case disallowRedirects:
+		// Hand the 3xx response back so redirectResponseError can report it
+		return http.ErrUseLastResponse
+	case isLoginURL(req.URL):
+		return &redirectError{StatusCode: status, Location: req.URL.String(), Login: true}
+	case via[0].Method == http.MethodPost && req.Method != http.MethodPost:
+		return &redirectError{StatusCode: status, Location: req.URL.String(), Reason: "it would resend the operation as a " + req.Method + " without its body"}
+	case len(via) >= maxRedirects:
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+
+	// Never forward credentials to another host
+	if req.URL.Host != via[0].URL.Host {
+		stripped := append([]string{"Authorization", "Cookie"}, authHeaderNames()...)
+		for k := range getHeaders() {
+			stripped = append(stripped, k)
+		}
+		for _, k := range stripped {
+			req.Header.Del(k)
+		}
+		log.Printf("Redirected from %s to %s; dropped the configured headers", via[0].URL.Host, req.URL.Host)
+	}
+	return nil
+}
+
+// redirectResponseError turns a response that is a redirect, or the result of
+// redirects ending on an HTML page, into a *redirectError.
+func redirectResponseError(req *http.Request, res *http.Response) error {
+	if res.StatusCode >= 300 && res.StatusCode < 400 {
+		location := res.Header.Get("Location")
+		target, err := req.URL.Parse(location)
+		login := err == nil && isLoginURL(target)
+		if err == nil {
+			location = target.String()
+		}
+		return &redirectError{StatusCode: res.StatusCode, Location: location, Login: login, Reason: "DISALLOW_REDIRECTS is set"}
+	}
+	if res.Request != nil && res.Request.URL.String() != req.URL.String() && strings.Contains(res.Header.Get("Content-Type"), "text/html") {
+		return &redirectError{Location: res.Request.URL.String(), Login: true}
+	}
+	return nil
+}
+
+// isLoginURL reports whether u looks like a login or SSO page.
+func isLoginURL(u *url.URL) bool {
+	return u != nil && loginPathPattern.MatchString(u.Path)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tool: resolve_path
+const resolvePathToolDescription = `Follow a dotted path of fields through the schema, e.g. Candidate.interviews.scorecard, and return the type reached at each step.
+This answers "what is the type of ..." precisely, without describing every type along the way. Lists are followed to their items, as in a selection set.
+
+Best Practices:
+- Start the path with a type name, or with query, mutation or subscription for a root operation (e.g. query.jobs.applications).
+- Use describe or describe_field on the final type or field for its details.
+- Union members have no fields in common: continue the path from the member type you need.
+
+Arguments:
+- path (string, Required): The path, e.g. "Candidate.interviews.scorecard".
+
+Example Usage:
+Request:
+  resolve_path(path: "Candidate.interviews.scorecard")
+
+Response:
+  Candidate.interviews(first: Int = 10): [Interview!]! (non-null list of non-null Interview, an OBJECT)
+  Interview.scorecard: InterviewScorecard (InterviewScorecard, an OBJECT)
+  Candidate.interviews.scorecard resolves to InterviewScorecard, an OBJECT.
+`
+
+// resolveSchemaPath walks the fields of path from its first segment, a type
+// or root operation, and renders the field and type of each step.
+func resolveSchemaPath(ctx context.Context, path string) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	path = strings.TrimSpace(path)
+	segments := strings.Split(path, ".")
+	if len(segments) < 2 {
+		return "", fmt.Errorf("invalid path %q, expected a type followed by fields, e.g. Candidate.interviews.scorecard", path)
+	}
+	for _, s := range segments {
+		if strings.TrimSpace(s) == "" {
+			return "", fmt.Errorf("invalid path %q: it has an empty segment", path)
+		}
+	}
+
+	typeName := strings.TrimSpace(segments[0])
+	if root := schema.rootType(typeName); root != "" {
+		typeName = root
+	}
+	t := schema.typeByName(typeName)
+	if t == nil {
+		names := make([]string, 0, len(schema.Types))
+		for _, t := range schema.Types {
+			names = append(names, t.Name)
+		}
+		return "", fmt.Errorf("%s", unknownKeyProblem(segments[0], "unknown type "+typeName, typeName, names))
+	}
+
+	var sb strings.Builder
+	var named string
+	for i, segment := range segments[1:] {
+		walked := strings.Join(segments[:i+1], ".")
+		fieldName := strings.TrimSpace(segment)
+		if len(t.Fields) == 0 {
+			return "", fmt.Errorf("%s: %s", walked, noFieldsProblem(schema, t))
+		}
+		f := t.field(fieldName)
+		if f == nil {
+			names := make([]string, 0, len(t.Fields))
+			for _, f := range t.Fields {
+				names = append(names, f.Name)
+			}
+			return "", fmt.Errorf("%s", unknownKeyProblem(walked+"."+fieldName, t.Name+" has no field "+fieldName, fieldName, names))
+		}
+		sb.WriteString(t.Name + "." + argsSignature(f) + " (" + resolvedTypeString(schema, f.Type) + ")\n")
+		named = f.Type.namedType()
+		t = schema.typeByName(named)
+		if t == nil && i < len(segments)-2 {
+			return "", fmt.Errorf("%s: %s is not defined in the schema", walked+"."+fieldName, named)
+		}
+	}
+	fmt.Fprintf(&sb, "%s resolves to %s, %s.", path, named, kindArticle(schema, named))
+	return sb.String(), nil
+}
+
+// noFieldsProblem explains why a path can't continue past t.
+func noFieldsProblem(schema *schemaModel, t *schemaType) string {
+	if t.Kind == "UNION" {
+		members := make([]string, 0, len(t.PossibleTypes))
+		for _, p := range t.PossibleTypes {
+			members = append(members, p.Name)
+		}
+		return fmt.Sprintf("%s is a UNION of %s, which has no fields of its own; continue the path from one of them", t.Name, strings.Join(members, ", "))
+	}
+	return fmt.Sprintf("%s is %s, which has no fields", t.Name, kindArticle(schema, t.Name))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Tool: clear_response_cache
+const clearResponseCacheToolDescription = `Remove every response stored in the query response cache, so the next queries fetch fresh data.
+The cache is off unless RESPONSE_CACHE_TTL is set; it then keeps the responses of successful queries (never mutations) for that long, keyed by the operation, its variables and the request headers. A successful mutation run through this server clears it.
+
+Best Practices:
+- Clear the cache after data changed outside of this server, e.g. in another client or a background job.
+- To bypass the cache for a single call, pass noCache to invoke_graphql instead.
+
+Example Usage:
+Request:
+  clear_response_cache()
+
+Response:
+  Cleared 3 cached responses.
+`
+
+// Response cache settings: how long a query response is reused (0, the
+// default, disables the cache) and how many responses are kept.
+var (
+	responseCacheTTL        = durationFromEnv("RESPONSE_CACHE_TTL", 0)
+	responseCacheMaxEntries = intFromEnv("RESPONSE_CACHE_MAX_ENTRIES", 100)
+)
+
+// cachedResponse is a query response kept in the response cache.
+type cachedResponse struct {
+	res      *graphqlResponse
+	storedAt time.Time
+}
+
+// responseCache holds the responses of queries by responseCacheKey.
+var responseCache = struct {
+	sync.Mutex
+	entries map[string]cachedResponse
+}{entries: make(map[string]cachedResponse)}
+
+// responseCacheKey returns the cache key of a query request, or "" when its
+// response must not be cached: the cache is off or the operation to execute
+// isn't a query.
+func responseCacheKey(req graphqlRequest) string {
+	if responseCacheTTL <= 0 {
+		return ""
+	}
+	if _, op, err := parseSingleOperation(req.Query, req.OperationName); err != nil || op.Operation != "query" {
+		return ""
+	}
+
+	// Responses depend on the credentials, so the headers are part of the key
+	header := getHeaders()
+	for k, v := range req.header {
+		header[k] = v
+	}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	encoded, _ := json.Marshal(req)
+	fmt.Fprintf(h, "%s\n%s\n", graphqlEndpoint, encoded)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s: %q\n", name, header[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// lookupResponse returns a copy of the cached response for key and its age,
+// or nil when there is none or it expired.
+func lookupResponse(key string) (*graphqlResponse, time.Duration) {
+	if key == "" {
+		return nil, 0
+	}
+	responseCache.Lock()
+	defer responseCache.Unlock()
+	entry, ok := responseCache.entries[key]
+	if !ok {
+		return nil, 0
+	}
+	age := time.Since(entry.storedAt)
+	if age >= responseCacheTTL {
+		delete(responseCache.entries, key)
+		return nil, 0
+	}
+	res := *entry.res
+	return &res, age
+}
+
+// storeResponse caches a successful response under key. When the cache is
+// full, expired responses are dropped first, then the oldest one.
+func storeResponse(key string, res *graphqlResponse) {
+	if key == "" || len(res.Errors) > 0 || responseCacheMaxEntries <= 0 {
+		return
+	}
+	stored := *res
+	responseCache.Lock()
+	defer responseCache.Unlock()
+	if _, ok := responseCache.entries[key]; !ok && len(responseCache.entries) >= responseCacheMaxEntries {
+		oldestKey, oldest := "", time.Now()
+		for k, entry := range responseCache.entries {
+			if time.Since(entry.storedAt) >= responseCacheTTL {
+				delete(responseCache.entries, k)
+			} else if entry.storedAt.Before(oldest) {
+				oldestKey, oldest = k, entry.storedAt
+			}
+		}
+		if len(responseCache.entries) >= responseCacheMaxEntries {
+			delete(responseCache.entries, oldestKey)
+		}
+	}
+	responseCache.entries[key] = cachedResponse{res: &stored, storedAt: time.Now()}
+}
+
+// clearResponseCache removes every cached response and returns how many
+// there were.
+func clearResponseCache() int {
+	responseCache.Lock()
+	defer responseCache.Unlock()
+	n := len(responseCache.entries)
+	responseCache.entries = make(map[string]cachedResponse)
+	return n
+}
+
+// cachedResponseNote tells the caller that the data came from the cache.
+func cachedResponseNote(age time.Duration) string {
+	return fmt.Sprintf("Served from the response cache, fetched %s ago. Pass noCache to fetch fresh data.", age.Round(time.Second))
+}
+
+// isMutationRequest reports whether the operation req executes is a
+// mutation. Operations that can't be parsed count as mutations, so that a
+// successful one conservatively clears the response cache.
+func isMutationRequest(req graphqlRequest) bool {
+	_, op, err := parseSingleOperation(req.Query, req.OperationName)
+	return err != nil || op.Operation == "mutation"
+}
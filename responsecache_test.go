@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// enableResponseCache turns the response cache on for a test, empty, and
+// restores the headers, basic auth credentials and cache afterwards.
+func enableResponseCache(t *testing.T) {
+	t.Helper()
+	previousTTL, previousHeaders := responseCacheTTL, userHeaders()
+	basicAuth.Lock()
+	previousUser, previousPass := basicAuth.user, basicAuth.pass
+	basicAuth.Unlock()
+	responseCacheTTL = time.Minute
+	clearResponseCache()
+	t.Cleanup(func() {
+		responseCacheTTL = previousTTL
+		replaceHeaders(previousHeaders)
+		setBasicAuth(previousUser, previousPass)
+		clearResponseCache()
+	})
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	enableResponseCache(t)
+	query := graphqlRequest{Query: `query Me { me { id } }`, OperationName: "Me"}
+	key := func(req graphqlRequest) string {
+		t.Helper()
+		k := responseCacheKey(req)
+		if k == "" {
+			t.Fatalf("the request %q isn't cached", req.Query)
+		}
+		return k
+	}
+
+	if err := setHeaders(`{"Authorization": "Bearer alice"}`); err != nil {
+		t.Fatal(err)
+	}
+	alice := key(query)
+	if again := key(query); again != alice {
+		t.Error("the same request with the same headers got different keys")
+	}
+	if withVars := key(graphqlRequest{Query: query.Query, OperationName: "Me", Variables: map[string]interface{}{"x": 1}}); withVars == alice {
+		t.Error("different variables got the same key")
+	}
+	if err := setHeaders(`{"Authorization": "Bearer bob"}`); err != nil {
+		t.Fatal(err)
+	}
+	if bob := key(query); bob == alice {
+		t.Error("different Authorization headers got the same key")
+	}
+	perRequest := query
+	perRequest.header = http.Header{"X-Tenant": {"acme"}}
+	if key(perRequest) == key(query) {
+		t.Error("a header set for the request alone didn't change the key")
+	}
+
+	// Profiles switch the headers and basic auth credentials
+	replaceHeaders(http.Header{})
+	setBasicAuth("alice", "alice-pass")
+	if _, err := saveHeaderProfile("cache-alice"); err != nil {
+		t.Fatal(err)
+	}
+	setBasicAuth("bob", "bob-pass")
+	if _, err := saveHeaderProfile("cache-bob"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := useHeaderProfile("cache-alice"); err != nil {
+		t.Fatal(err)
+	}
+	aliceProfile := key(query)
+	if _, err := useHeaderProfile("cache-bob"); err != nil {
+		t.Fatal(err)
+	}
+	if key(query) == aliceProfile {
+		t.Error("different header profiles got the same key")
+	}
+
+	for _, mutation := range []graphqlRequest{
+		{Query: `mutation { deleteJobs }`},
+		{Query: `query Q { jobs { id } } mutation M { deleteJobs }`, OperationName: "M"},
+	} {
+		if k := responseCacheKey(mutation); k != "" {
+			t.Errorf("the mutation %q got the cache key %s", mutation.Query, k)
+		}
+	}
+	responseCacheTTL = 0
+	if k := responseCacheKey(query); k != "" {
+		t.Errorf("the cache is off but the query got the key %s", k)
+	}
+}
+
+// TestResponseCacheInvoke checks that queries are served from the cache
+// only for the same credentials, and that mutations bypass and clear it.
+func TestResponseCacheInvoke(t *testing.T) {
+	enableResponseCache(t)
+	resetCircuit(t, 0)
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var body struct {
+			Query string `json:"query"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasPrefix(strings.TrimSpace(body.Query), "mutation") {
+			w.Write([]byte(`{"data":{"deleteJobs":true}}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"me": r.Header.Get("Authorization")}})
+	}))
+	defer srv.Close()
+	previous := graphqlEndpoint
+	graphqlEndpoint = srv.URL
+	t.Cleanup(func() { graphqlEndpoint = previous })
+
+	invoke := func(operation string) string {
+		t.Helper()
+		res, err := invokeGraphQLOperation(context.Background(), operation, "", invokeOptions{Confirmed: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Body
+	}
+	expectRequests := func(want int32) {
+		t.Helper()
+		if got := requests.Load(); got != want {
+			t.Errorf("the endpoint received %d requests, want %d", got, want)
+		}
+	}
+
+	const query = `query Me { me }`
+	if err := setHeaders(`{"Authorization": "Bearer alice"}`); err != nil {
+		t.Fatal(err)
+	}
+	invoke(query)
+	if data := invoke(query); !strings.Contains(data, "Bearer alice") {
+		t.Errorf("cached data = %s, want alice's", data)
+	}
+	expectRequests(1)
+
+	if err := setHeaders(`{"Authorization": "Bearer bob"}`); err != nil {
+		t.Fatal(err)
+	}
+	if data := invoke(query); !strings.Contains(data, "Bearer bob") {
+		t.Errorf("data = %s, want bob's rather than alice's cached response", data)
+	}
+	expectRequests(2)
+
+	invoke(`mutation { deleteJobs }`)
+	invoke(`mutation { deleteJobs }`)
+	expectRequests(4)
+	invoke(query)
+	expectRequests(5)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// maxResponseViolations bounds the problems reported by checkResponseShape.
+const maxResponseViolations = 20
+
+// checkResponseShape compares the data of a response with what the operation
+// selects and the schema declares: selected fields missing from their
+// object, fields that weren't selected, nulls in non-null fields, and values
+// of the wrong kind, such as an object where a list is expected. Fields with
+// @skip or @include, and fields of fragments on another type than the
+// object's __typename, may be missing.
+func checkResponseShape(schema *schemaModel, operation, operationName string, data interface{}) ([]string, error) {
+	doc, op, err := parseSingleOperation(operation, operationName)
+	if err != nil {
+		return nil, err
+	}
+	violations := []string{}
+	omitted := 0
+	report := func(path, problem string) {
+		if len(violations) == maxResponseViolations {
+			omitted++
+			return
+		}
+		violations = append(violations, path+": "+problem)
+	}
+
+	var checkValue func(path string, ref *typeRef, sels []*astSelection, value interface{}, depth int)
+	var checkObject func(path, typeName string, sels []*astSelection, obj map[string]interface{}, depth int)
+	checkValue = func(path string, ref *typeRef, sels []*astSelection, value interface{}, depth int) {
+		if value == nil {
+			if ref.isNonNull() {
+				report(path, "null, but "+ref.String()+" is non-null")
+			}
+			return
+		}
+		if ref.Kind == "NON_NULL" {
+			ref = ref.OfType
+		}
+		if ref.Kind == "LIST" {
+			items, ok := value.([]interface{})
+			if !ok {
+				report(path, fmt.Sprintf("expected a list (%s), got %s", ref, describeJSONType(value)))
+				return
+			}
+			for i, item := range items {
+				checkValue(path+"."+strconv.Itoa(i), ref.OfType, sels, item, depth)
+			}
+			return
+		}
+		t := schema.typeByName(ref.Name)
+		if t == nil {
+			return
+		}
+		switch t.Kind {
+		case "OBJECT", "INTERFACE", "UNION":
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				report(path, fmt.Sprintf("expected an object (%s), got %s", t.Name, describeJSONType(value)))
+				return
+			}
+			checkObject(path, t.Name, sels, obj, depth+1)
+		case "ENUM":
+			s, ok := value.(string)
+			if !ok {
+				report(path, fmt.Sprintf("expected %s, got %s", t.Name, describeJSONType(value)))
+				return
+			}
+			for _, v := range t.EnumValues {
+				if v.Name == s {
+					return
+				}
+			}
+			report(path, fmt.Sprintf("%q is not a value of the enum %s", s, t.Name))
+		case "SCALAR":
+			if problem := scalarKindProblem(t.Name, value); problem != "" {
+				report(path, problem)
+			}
+		}
+	}
+	checkObject = func(path, typeName string, sels []*astSelection, obj map[string]interface{}, depth int) {
+		// Guard against fragments that (invalidly) select themselves
+		if depth > 64 {
+			return
+		}
+		concrete, _ := obj["__typename"].(string)
+		selected := make(map[string]bool)
+		for _, fs := range doc.fieldSelections(sels) {
+			key := fs.Field.responseKey()
+			selected[key] = true
+			parent := typeName
+			applies := true
+			if fs.TypeCondition != "" && fs.TypeCondition != typeName {
+				parent = fs.TypeCondition
+				applies = concrete != "" && typeConditionMatches(schema, fs.TypeCondition, concrete)
+			}
+			value, present := obj[key]
+			if !present {
+				if applies && !isConditional(fs.Field) {
+					report(joinResponsePath(path, key), "selected but missing from the response")
+				}
+				continue
+			}
+			if fs.Field.Name == "__typename" {
+				if _, ok := value.(string); !ok {
+					report(joinResponsePath(path, key), "expected the type name as a string, got "+describeJSONType(value))
+				}
+				continue
+			}
+			if f := lookupField(schema, parent, fs.Field.Name); f != nil {
+				checkValue(joinResponsePath(path, key), f.Type, fs.Field.SelectionSet, value, depth)
+			}
+		}
+		var unexpected []string
+		for key := range obj {
+			if !selected[key] {
+				unexpected = append(unexpected, key)
+			}
+		}
+		sort.Strings(unexpected)
+		for _, key := range unexpected {
+			report(joinResponsePath(path, key), "not selected by the operation")
+		}
+	}
+
+	if obj, ok := data.(map[string]interface{}); ok {
+		checkObject("", schema.rootType(op.Operation), op.SelectionSet, obj, 0)
+	}
+	if omitted > 0 {
+		violations = append(violations, fmt.Sprintf("... and %d more", omitted))
+	}
+	return violations, nil
+}
+
+// joinResponsePath appends key to a path of response keys, e.g. "jobs.0".
+func joinResponsePath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// isConditional reports whether a field carries @skip or @include, and so
+// may be left out of the response.
+func isConditional(sel *astSelection) bool {
+	for _, d := range sel.Directives {
+		if d.Name == "skip" || d.Name == "include" {
+			return true
+		}
+	}
+	return false
+}
+
+// typeConditionMatches reports whether the fields of a fragment on condition
+// apply to an object of the type concrete.
+func typeConditionMatches(schema *schemaModel, condition, concrete string) bool {
+	if condition == concrete {
+		return true
+	}
+	if t := schema.typeByName(condition); t != nil {
+		for _, p := range t.PossibleTypes {
+			if p.Name == concrete {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scalarKindProblem checks the JSON kind of a value of a builtin scalar;
+// custom scalars may be serialized as anything.
+func scalarKindProblem(scalar string, value interface{}) string {
+	ok := true
+	switch scalar {
+	case "Int":
+		n, isNumber := value.(float64)
+		ok = isNumber && n == float64(int64(n))
+	case "Float":
+		_, ok = value.(float64)
+	case "String":
+		_, ok = value.(string)
+	case "ID":
+		switch value.(type) {
+		case string, float64:
+		default:
+			ok = false
+		}
+	case "Boolean":
+		_, ok = value.(bool)
+	}
+	if ok {
+		return ""
+	}
+	return fmt.Sprintf("expected %s, got %s", scalar, describeJSONType(value))
+}
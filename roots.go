@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tool: schema_roots
+const schemaRootsToolDescription = `Return the names of the schema's root operation types, and whether each exists.
+Schemas don't have to name their roots Query, Mutation and Subscription; introspection reports the actual names.
+
+Best Practices:
+- Use this tool before writing fragments or "Type.field" references on root types of an unfamiliar schema.
+- A schema without a mutation root type accepts no mutations at all.
+
+Arguments:
+- None
+
+Example Usage:
+Request:
+  schema_roots()
+
+Response:
+  query: QueryRoot (24 fields)
+  mutation: MutationRoot (9 fields)
+  subscription: none
+`
+
+// describeSchemaRoots renders one line per operation type with the name of
+// its root type and field count, or "none".
+func describeSchemaRoots(ctx context.Context) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, operation := range []string{"query", "mutation", "subscription"} {
+		name := schema.rootType(operation)
+		t := schema.typeByName(name)
+		switch {
+		case name == "":
+			fmt.Fprintf(&sb, "%s: none\n", operation)
+		case t == nil:
+			fmt.Fprintf(&sb, "%s: %s (declared but missing from the schema types)\n", operation, name)
+		default:
+			fmt.Fprintf(&sb, "%s: %s (%s)\n", operation, name, pluralize(len(t.Fields), "field", "fields"))
+		}
+	}
+	return sb.String(), nil
+}
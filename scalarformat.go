@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scalarFormats holds the format custom scalars must be sent in, from
+// SCALAR_FORMATS, a JSON object mapping scalar names to a predefined format
+// ("date", "date-time", "time" or "uuid") or a regular expression, e.g.
+// {"Date": "date", "Phone": "\\+[0-9]{6,15}"}.
+var scalarFormats, scalarFormatsErr = parseScalarFormats(getenv("SCALAR_FORMATS"))
+
+// scalarFormat checks and normalizes the string values of a custom scalar.
+type scalarFormat struct {
+	// Hint describes the expected format to agents.
+	Hint string
+	// Name is the name of a predefined format, which is also its JSON Schema
+	// format, and Pattern the regular expression of a custom one.
+	Name    string
+	Pattern string
+	// normalize returns the value in the expected format, converting close
+	// variants (e.g. a timestamp for a date), or false when it can't.
+	normalize func(s string) (string, bool)
+}
+
+// uuidPattern matches a UUID in its canonical textual form.
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// predefinedScalarFormats are the formats SCALAR_FORMATS may refer to by name.
+var predefinedScalarFormats = map[string]*scalarFormat{
+	"date": {
+		Name: "date",
+		Hint: "a date as YYYY-MM-DD (timestamps are cut to their date)",
+		normalize: func(s string) (string, bool) {
+			if _, err := time.Parse(time.DateOnly, s); err == nil {
+				return s, true
+			}
+			if t, ok := parseTimestamp(s); ok {
+				return t.Format(time.DateOnly), true
+			}
+			return "", false
+		},
+	},
+	"date-time": {
+		Name: "date-time",
+		Hint: "an RFC 3339 timestamp such as 2024-05-01T10:00:00Z (dates become midnight UTC)",
+		normalize: func(s string) (string, bool) {
+			if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+				return s, true
+			}
+			if t, ok := parseTimestamp(s); ok {
+				return t.Format(time.RFC3339Nano), true
+			}
+			if t, err := time.Parse(time.DateOnly, s); err == nil {
+				return t.Format(time.RFC3339), true
+			}
+			return "", false
+		},
+	},
+	"time": {
+		Name: "time",
+		Hint: "a time of day as HH:MM:SS",
+		normalize: func(s string) (string, bool) {
+			if _, err := time.Parse(time.TimeOnly, s); err == nil {
+				return s, true
+			}
+			if t, err := time.Parse("15:04", s); err == nil {
+				return t.Format(time.TimeOnly), true
+			}
+			return "", false
+		},
+	},
+	"uuid": {
+		Name: "uuid",
+		Hint: "a UUID such as 123e4567-e89b-12d3-a456-426614174000",
+		normalize: func(s string) (string, bool) {
+			if !uuidPattern.MatchString(s) {
+				return "", false
+			}
+			return strings.ToLower(s), true
+		},
+	},
+}
+
+// parseTimestamp parses the common timestamp layouts, assuming UTC when no
+// offset is given.
+func parseTimestamp(s string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseScalarFormats decodes SCALAR_FORMATS.
+func parseScalarFormats(config string) (map[string]*scalarFormat, error) {
+	if config == "" {
+		return nil, nil
+	}
+	var specs map[string]string
+	if err := json.Unmarshal([]byte(config), &specs); err != nil {
+		return nil, fmt.Errorf("invalid SCALAR_FORMATS, expected a JSON object mapping scalar names to formats: %s", jsonErrorPosition(config, err))
+	}
+	formats := make(map[string]*scalarFormat, len(specs))
+	for scalar, spec := range specs {
+		if builtinScalars[scalar] {
+			return nil, fmt.Errorf("invalid SCALAR_FORMATS: %s is a built-in scalar, only custom scalars can have a format", scalar)
+		}
+		if f, ok := predefinedScalarFormats[spec]; ok {
+			formats[scalar] = f
+			continue
+		}
+		re, err := regexp.Compile("^(?:" + spec + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCALAR_FORMATS entry for %s: %q is neither date, date-time, time, uuid nor a valid regular expression: %w", scalar, spec, err)
+		}
+		formats[scalar] = &scalarFormat{
+			Hint:    "a string matching " + spec,
+			Pattern: "^(?:" + spec + ")$",
+			normalize: func(s string) (string, bool) {
+				return s, re.MatchString(s)
+			},
+		}
+	}
+	return formats, nil
+}
+
+// scalarFormatHint describes the configured format of a scalar, or returns ""
+// when it has none.
+func scalarFormatHint(scalar string) string {
+	if f := scalarFormats[scalar]; f != nil {
+		return f.Hint
+	}
+	return ""
+}
+
+// applyScalarFormats checks the values of custom scalars with a configured
+// format and converts close variants, returning the changes made. Values
+// that don't match are reported together, before anything is sent.
+func applyScalarFormats(schema *schemaModel, operation string, vars map[string]interface{}) (map[string]interface{}, []string, error) {
+	var changes, problems []string
+	out, err := mapVariableScalars(schema, operation, vars, func(scalar string, v interface{}, path string) interface{} {
+		f := scalarFormats[scalar]
+		if f == nil {
+			return v
+		}
+		s, ok := v.(string)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: expected %s for %s, got %s", path, f.Hint, scalar, describeJSONType(v)))
+			return v
+		}
+		normalized, ok := f.normalize(s)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: %s is not %s, expected %s", path, describeJSONValue(s), scalar, f.Hint))
+			return v
+		}
+		if normalized != s {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s (%s)", path, describeJSONValue(s), describeJSONValue(normalized), scalar))
+		}
+		return normalized
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, nil, fmt.Errorf("variables don't match the formats of their scalars:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return out, changes, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// Tool: list_scalars
+const listScalarsToolDescription = `Retrieve all scalar types of your GraphQL schema, including custom scalars such as DateTime, JSON or UUID, with their descriptions.
+Custom scalars determine how variable values must be formatted (for example, a DateTime usually has to be an ISO-8601 string).
+
+Best Practices:
+- Use this tool before building variables for arguments typed with a custom scalar.
+- Read each description for the expected format; built-in scalars are listed last.
+
+Arguments:
+- None
+
+Example Usage:
+Request:
+  list_scalars()
+
+Response:
+  Scalars:
+  Date
+  	Format: a date as YYYY-MM-DD (timestamps are cut to their date)
+  DateTime
+  	An ISO-8601 encoded UTC date string.
+  UUID
+  Boolean (built-in)
+  Float (built-in)
+  ID (built-in)
+  Int (built-in)
+  String (built-in)
+`
+
+// listGraphQLScalars lists the scalar types of the schema, custom scalars
+// first, each followed by its description on an indented line when present.
+func listGraphQLScalars(ctx context.Context) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var scalars []*schemaType
+	for _, t := range schema.Types {
+		if t.Kind == "SCALAR" {
+			scalars = append(scalars, t)
+		}
+	}
+	sort.SliceStable(scalars, func(i, j int) bool {
+		bi, bj := builtinScalars[scalars[i].Name], builtinScalars[scalars[j].Name]
+		if bi != bj {
+			return bj
+		}
+		return scalars[i].Name < scalars[j].Name
+	})
+
+	var sb strings.Builder
+	sb.WriteString("Scalars:\n")
+	for _, t := range scalars {
+		sb.WriteString(t.Name)
+		if builtinScalars[t.Name] {
+			sb.WriteString(" (built-in)")
+		}
+		sb.WriteString("\n")
+		if desc := strings.TrimSpace(t.Description); desc != "" && !builtinScalars[t.Name] {
+			sb.WriteString("\t" + strings.ReplaceAll(desc, "\n", "\n\t") + "\n")
+		}
+		if hint := scalarFormatHint(t.Name); hint != "" {
+			sb.WriteString("\tFormat: " + hint + "\n")
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// schemaModel is the in-memory representation of a GraphQL schema. It is
+// built once, either from introspection or from a local SDL file, and every
+// schema tool reads from it instead of going over the network.
+type schemaModel struct {
+	QueryType        string
+	MutationType     string
+	SubscriptionType string
+	Types            []*schemaType
+	Directives       []*schemaDirective
+	// Partial is set when the schema was loaded with a reduced introspection
+	// query; see partialSchemaNote.
+	Partial bool
+
+	types    map[string]*schemaType
+	entities map[string]string
+	// describeJSON memoizes entityJSON values by entity name. It lives with
+	// the model, so a refreshed schema starts with an empty cache.
+	describeJSON sync.Map
+}
+
+// schemaType is a named type of the schema (mirrors __Type).
+type schemaType struct {
+	Kind          string              `json:"kind"`
+	Name          string              `json:"name"`
+	Description   string              `json:"description"`
+	Fields        []*schemaField      `json:"fields"`
+	InputFields   []*schemaInputValue `json:"inputFields"`
+	Interfaces    []*typeRef          `json:"interfaces"`
+	EnumValues    []*schemaEnumValue  `json:"enumValues"`
+	PossibleTypes []*typeRef          `json:"possibleTypes"`
+}
+
+// schemaField is a field of an object or interface type (mirrors __Field).
+type schemaField struct {
+	Name              string              `json:"name"`
+	Description       string              `json:"description"`
+	Args              []*schemaInputValue `json:"args"`
+	Type              *typeRef            `json:"type"`
+	IsDeprecated      bool                `json:"isDeprecated"`
+	DeprecationReason string              `json:"deprecationReason"`
+}
+
+// schemaInputValue is an argument or input field (mirrors __InputValue).
+// DefaultValue is nil when no default is declared.
+type schemaInputValue struct {
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Type         *typeRef `json:"type"`
+	DefaultValue *string  `json:"defaultValue"`
+}
+
+// schemaEnumValue is a value of an enum type (mirrors __EnumValue).
+type schemaEnumValue struct {
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	IsDeprecated      bool   `json:"isDeprecated"`
+	DeprecationReason string `json:"deprecationReason"`
+}
+
+// schemaDirective is a directive declared by the schema (mirrors __Directive).
+type schemaDirective struct {
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Locations   []string            `json:"locations"`
+	Args        []*schemaInputValue `json:"args"`
+}
+
+// typeRef is a (possibly wrapped) reference to a named type.
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+// String renders the reference in GraphQL notation, e.g. "[String!]!".
+func (t *typeRef) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case "NON_NULL":
+		return t.OfType.String() + "!"
+	case "LIST":
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// namedType returns the innermost named type, unwrapping lists and non-null.
+func (t *typeRef) namedType() string {
+	for t != nil && t.OfType != nil {
+		t = t.OfType
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name
+}
+
+// isNonNull reports whether the reference is a non-null type.
+func (t *typeRef) isNonNull() bool {
+	return t != nil && t.Kind == "NON_NULL"
+}
+
+// isList reports whether the reference is a list, ignoring a non-null wrapper.
+func (t *typeRef) isList() bool {
+	if t.isNonNull() {
+		t = t.OfType
+	}
+	return t != nil && t.Kind == "LIST"
+}
+
+// newSchemaModel indexes the given types and directives into a schemaModel.
+func newSchemaModel(queryType, mutationType, subscriptionType string, types []*schemaType, directives []*schemaDirective) *schemaModel {
+	m := &schemaModel{
+		QueryType:        queryType,
+		MutationType:     mutationType,
+		SubscriptionType: subscriptionType,
+		Types:            types,
+		Directives:       directives,
+		types:            make(map[string]*schemaType, len(types)),
+	}
+	for _, t := range types {
+		m.types[t.Name] = t
+	}
+	m.entities = buildEntityMap(m)
+	return m
+}
+
+// typeByName returns the named type or nil when it doesn't exist.
+func (m *schemaModel) typeByName(name string) *schemaType {
+	return m.types[name]
+}
+
+// rootFields returns the fields of the named root type, or nil when the
+// schema doesn't define it.
+func (m *schemaModel) rootFields(rootType string) []*schemaField {
+	if rootType == "" {
+		return nil
+	}
+	if t := m.types[rootType]; t != nil {
+		return t.Fields
+	}
+	return nil
+}
+
+// queries returns the fields of the query root type.
+func (m *schemaModel) queries() []*schemaField {
+	return m.rootFields(m.QueryType)
+}
+
+// mutations returns the fields of the mutation root type.
+func (m *schemaModel) mutations() []*schemaField {
+	return m.rootFields(m.MutationType)
+}
+
+// subscriptions returns the fields of the subscription root type.
+func (m *schemaModel) subscriptions() []*schemaField {
+	return m.rootFields(m.SubscriptionType)
+}
+
+// isRootType reports whether name is one of the schema's root operation types.
+func (m *schemaModel) isRootType(name string) bool {
+	return name != "" && (name == m.QueryType || name == m.MutationType || name == m.SubscriptionType)
+}
+
+// rootType returns the root type name for an operation type ("query",
+// "mutation" or "subscription").
+func (m *schemaModel) rootType(operation string) string {
+	switch operation {
+	case "query":
+		return m.QueryType
+	case "mutation":
+		return m.MutationType
+	case "subscription":
+		return m.SubscriptionType
+	}
+	return ""
+}
+
+// field returns the named field of the type, or nil.
+func (t *schemaType) field(name string) *schemaField {
+	for _, f := range t.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// builtinScalars are the scalars every GraphQL schema provides.
+var builtinScalars = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+
+// prettyPrintField renders a field as "name(arg: Type, ...): ReturnType".
+func prettyPrintField(f *schemaField) string {
+	return fmt.Sprintf("%s(%s): %s", f.Name, argsToString(f.Args), f.Type.String())
+}
+
+// labelledFieldString renders a field like prettyPrintField, with the default
+// value of each argument and whether it is required, e.g.
+// "jobs(status: JobStatus! (required), first: Int = 10 (optional)): [Job!]!".
+func labelledFieldString(f *schemaField) string {
+	parts := make([]string, 0, len(f.Args))
+	for _, a := range f.Args {
+		label := "optional"
+		if isRequiredArgument(a) {
+			label = "required"
+		}
+		parts = append(parts, inputValueString(a)+" ("+label+")")
+	}
+	return fmt.Sprintf("%s(%s): %s", f.Name, strings.Join(parts, ", "), f.Type.String())
+}
+
+// isRequiredArgument reports whether an argument must be passed: it is
+// non-null and has no default value.
+func isRequiredArgument(a *schemaInputValue) bool {
+	return a.Type.isNonNull() && a.DefaultValue == nil
+}
+
+// compactFieldList renders one "name(arg: Type): ReturnType" line per field,
+// sorted by name, leaving out the parentheses of fields without arguments.
+// With labelled, arguments are rendered as labelledFieldString does.
+func compactFieldList(fields []*schemaField, labelled bool) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	sorted := append([]*schemaField(nil), fields...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	lines := make([]string, 0, len(sorted))
+	for _, f := range sorted {
+		if labelled && len(f.Args) > 0 {
+			lines = append(lines, labelledFieldString(f))
+		} else {
+			lines = append(lines, usageString(f))
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// argsToString renders arguments as "name: Type, other: Type".
+func argsToString(args []*schemaInputValue) string {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		parts = append(parts, fmt.Sprintf("%s: %s", a.Name, a.Type.String()))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// argsWithDefaultsToString renders arguments as "name: Type = default, ...".
+func argsWithDefaultsToString(args []*schemaInputValue) string {
+	parts := make([]string, 0, len(args))
+	for _, a := range args {
+		parts = append(parts, inputValueString(a))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// inputValueString renders an argument or input field as "name: Type",
+// followed by " = default" when it declares a default value.
+func inputValueString(v *schemaInputValue) string {
+	s := v.Name + ": " + v.Type.String()
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+// describeInputValue renders an argument or input field with its default
+// value and description, e.g. "page: Int = 1 — the 1-based page number".
+func describeInputValue(v *schemaInputValue) string {
+	return describeInputValueNote(v, "")
+}
+
+// describeInputValueNote is describeInputValue with a note in parentheses
+// between the type and the description, when note isn't empty.
+func describeInputValueNote(v *schemaInputValue, note string) string {
+	s := inputValueString(v)
+	if note != "" {
+		s += " (" + note + ")"
+	}
+	if desc := strings.Join(strings.Fields(v.Description), " "); desc != "" {
+		s += " — " + desc
+	}
+	return s
+}
+
+// describeField renders a root field for describe: its signature, its
+// description and one line per argument with defaults and descriptions.
+func describeField(f *schemaField) string {
+	var sb strings.Builder
+	sb.WriteString(prettyPrintField(f))
+	if desc := strings.TrimSpace(f.Description); desc != "" {
+		sb.WriteString("\n\t" + strings.ReplaceAll(desc, "\n", "\n\t"))
+	}
+	if len(f.Args) > 0 {
+		sb.WriteString("\nArguments:")
+		for _, a := range f.Args {
+			sb.WriteString("\n\t" + describeInputValue(a))
+		}
+	}
+	return sb.String()
+}
+
+// prettyPrintType renders a named type as an SDL-like snippet. Built-in
+// scalars render as an empty string.
+func prettyPrintType(t *schemaType) string {
+	return prettyPrintTypeFields(t, nil)
+}
+
+// recursiveNote is the note of the fields of a type that refer to the type
+// itself, e.g. "children: [Category] (recursive)": expanding them loops.
+const recursiveNote = "recursive"
+
+// requiresArgumentsNote is the note of the fields with a required argument,
+// which can't be selected without arguments.
+const requiresArgumentsNote = "requires arguments"
+
+// isSelfReference reports whether a field or input field of t has the type t,
+// possibly wrapped in lists and non-null.
+func isSelfReference(t *schemaType, ref *typeRef) bool {
+	return ref.namedType() == t.Name
+}
+
+// prettyPrintTypeFields is prettyPrintType limited to the fields, input
+// fields and enum values named in keep; a nil keep shows them all. Fields
+// referring to the type itself are marked recursive, and those with a
+// required argument are marked too.
+func prettyPrintTypeFields(t *schemaType, keep map[string]bool) string {
+	shown := func(name string) bool { return keep == nil || keep[name] }
+	var sb strings.Builder
+	switch t.Kind {
+	case "OBJECT":
+		fmt.Fprintf(&sb, "type %s%s {\n", t.Name, implementsClause(t))
+	case "INTERFACE":
+		fmt.Fprintf(&sb, "interface %s%s {\n", t.Name, implementsClause(t))
+	case "INPUT_OBJECT":
+		fmt.Fprintf(&sb, "input %s {\n", t.Name)
+	case "ENUM":
+		fmt.Fprintf(&sb, "enum %s {\n", t.Name)
+	case "SCALAR":
+		if builtinScalars[t.Name] {
+			return ""
+		}
+		if hint := scalarFormatHint(t.Name); hint != "" {
+			return "scalar " + t.Name + "\n\t# Format: " + hint
+		}
+		return "scalar " + t.Name
+	case "UNION":
+		members := make([]string, 0, len(t.PossibleTypes))
+		for _, p := range t.PossibleTypes {
+			members = append(members, p.Name)
+		}
+		return fmt.Sprintf("union %s = %s", t.Name, strings.Join(members, " | "))
+	default:
+		return fmt.Sprintf("# %s %s", t.Kind, t.Name)
+	}
+
+	for _, f := range t.InputFields {
+		if !shown(f.Name) {
+			continue
+		}
+		note := ""
+		if isSelfReference(t, f.Type) {
+			note = recursiveNote
+		}
+		fmt.Fprintf(&sb, "\t%s\n", describeInputValueNote(f, note))
+	}
+	for _, f := range t.Fields {
+		if !shown(f.Name) {
+			continue
+		}
+		line := f.Name + ": " + f.Type.String()
+		if len(f.Args) > 0 {
+			line = fmt.Sprintf("%s(%s): %s", f.Name, argsWithDefaultsToString(f.Args), f.Type.String())
+		}
+		if isSelfReference(t, f.Type) {
+			line += " (" + recursiveNote + ")"
+		}
+		if requiresArguments(f) {
+			line += " (" + requiresArgumentsNote + ")"
+		}
+		fmt.Fprintf(&sb, "\t%s\n", line)
+	}
+	for _, v := range t.EnumValues {
+		if shown(v.Name) {
+			fmt.Fprintf(&sb, "\t%s\n", v.Name)
+		}
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// implementsClause renders " implements A & B" for types with interfaces.
+func implementsClause(t *schemaType) string {
+	if len(t.Interfaces) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(t.Interfaces))
+	for _, i := range t.Interfaces {
+		names = append(names, i.Name)
+	}
+	return " implements " + strings.Join(names, " & ")
+}
+
+// buildEntityMap maps every describable entity to its description. Each
+// entity is reachable both by its bare name and by a kind-qualified name such
+// as "query.jobs", "mutation.createCandidate", "type.Job" or "enum.Status".
+func buildEntityMap(m *schemaModel) map[string]string {
+	entities := make(map[string]string)
+	for _, t := range m.Types {
+		var prefix string
+		switch {
+		case t.Name == m.QueryType:
+			prefix = "query."
+		case t.Name == m.MutationType:
+			prefix = "mutation."
+		case t.Name == m.SubscriptionType:
+			prefix = "subscription."
+		}
+		if prefix != "" {
+			for _, f := range t.Fields {
+				entities[prefix+f.Name] = describeField(f)
+				entities[f.Name] = describeField(f)
+			}
+			continue
+		}
+
+		switch t.Kind {
+		case "SCALAR":
+			prefix = "scalar."
+		case "ENUM":
+			prefix = "enum."
+		case "INTERFACE":
+			prefix = "interface."
+		case "INPUT_OBJECT":
+			prefix = "input."
+		case "UNION":
+			prefix = "union."
+		default:
+			prefix = "type."
+		}
+		entities[prefix+t.Name] = prettyPrintType(t)
+		entities[t.Name] = prettyPrintType(t)
+	}
+	return entities
+}
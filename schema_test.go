@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// parseTestSchema builds a schema model from SDL.
+func parseTestSchema(t *testing.T, sdl string) *schemaModel {
+	t.Helper()
+	doc, err := parseSchemaDocument(sdl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := schemaFromSDL(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+func TestLabelledFieldString(t *testing.T) {
+	schema := parseTestSchema(t, `
+type Query {
+  jobs(status: JobStatus!, first: Int, after: String = "start", limit: Int! = 10): [Job!]!
+}
+type Job { id: ID! }
+enum JobStatus { OPEN CLOSED }
+`)
+	jobs := schema.typeByName("Query").field("jobs")
+	want := `jobs(status: JobStatus! (required), first: Int (optional), after: String = "start" (optional), limit: Int! = 10 (optional)): [Job!]!`
+	if got := labelledFieldString(jobs); got != want {
+		t.Errorf("labelledFieldString() =\n%s\nwant\n%s", got, want)
+	}
+	// The unlabelled form keeps the bang of non-null arguments only
+	if got, want := prettyPrintField(jobs), "jobs(status: JobStatus!, first: Int, after: String, limit: Int!): [Job!]!"; got != want {
+		t.Errorf("prettyPrintField() = %s, want %s", got, want)
+	}
+}
+
+// recursiveSchema has self-referencing and mutually recursive types, both
+// output and input ones.
+const recursiveSchema = `
+type Query {
+  category(id: ID!): Category
+  people(filter: PersonFilter): [Person!]!
+}
+type Category {
+  name: String!
+  parent: Category
+  children: [Category!]!
+}
+type Person {
+  name: String!
+  employer: Company
+}
+type Company {
+  name: String!
+  employees: [Person!]!
+}
+input PersonFilter {
+  name: String
+  and: [PersonFilter!]
+  employer: CompanyFilter
+}
+input CompanyFilter {
+  name: String
+  employee: PersonFilter
+}
+`
+
+func TestRecursiveTypes(t *testing.T) {
+	schema := parseTestSchema(t, recursiveSchema)
+
+	category := prettyPrintType(schema.typeByName("Category"))
+	for _, want := range []string{"\tparent: Category (recursive)\n", "\tchildren: [Category!]! (recursive)\n", "\tname: String!\n"} {
+		if !strings.Contains(category, want) {
+			t.Errorf("Category doesn't contain %q:\n%s", want, category)
+		}
+	}
+	// Mutually recursive types refer to each other, not to themselves
+	if person := prettyPrintType(schema.typeByName("Person")); strings.Contains(person, recursiveNote) {
+		t.Errorf("Person has no self-referencing field:\n%s", person)
+	}
+	if filter := prettyPrintType(schema.typeByName("PersonFilter")); !strings.Contains(filter, "and: [PersonFilter!] (recursive)") {
+		t.Errorf("PersonFilter.and isn't marked recursive:\n%s", filter)
+	}
+
+	e, ok := lookupEntityJSON(schema, "Category")
+	if !ok {
+		t.Fatal("Category not found")
+	}
+	for _, f := range e.Fields {
+		if want := f.Name != "name"; f.Recursive != want {
+			t.Errorf("Category.%s recursive = %v, want %v", f.Name, f.Recursive, want)
+		}
+	}
+
+	// Expanding the input types stops at the cycles
+	filter := schema.typeByName("PersonFilter")
+	var sb strings.Builder
+	writeInputTree(&sb, schema, filter, 1, map[string]bool{filter.Name: true})
+	wantTree := `	name: String
+	and: [PersonFilter!] (recursive, see PersonFilter above)
+	employer: CompanyFilter
+		name: String
+		employee: PersonFilter (recursive, see PersonFilter above)
+`
+	if sb.String() != wantTree {
+		t.Errorf("input tree =\n%s\nwant\n%s", sb.String(), wantTree)
+	}
+	skeleton, err := json.Marshal(inputSkeleton(schema, filter, map[string]bool{filter.Name: true}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"","and":[null],"employer":{"name":"","employee":null}}`; string(skeleton) != want {
+		t.Errorf("skeleton = %s, want %s", skeleton, want)
+	}
+}
@@ -0,0 +1,598 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// astSchemaDocument is a parsed GraphQL type system document (SDL).
+type astSchemaDocument struct {
+	// RootTypes maps "query", "mutation" and "subscription" to type names
+	// when the document contains a schema definition.
+	RootTypes  map[string]string
+	Types      []*astTypeDefinition
+	Directives []*astDirectiveDefinition
+}
+
+// astTypeDefinition is a type definition or extension. Kind uses the
+// introspection names (OBJECT, INPUT_OBJECT, ...).
+type astTypeDefinition struct {
+	Kind        string
+	Name        string
+	Description string
+	Extend      bool
+	Interfaces  []string
+	Fields      []*astFieldDefinition
+	InputFields []*astInputValueDefinition
+	EnumValues  []*astEnumValueDefinition
+	Members     []string
+	Directives  []*astDirective
+}
+
+// astFieldDefinition is a field of an object or interface type definition.
+type astFieldDefinition struct {
+	Name        string
+	Description string
+	Args        []*astInputValueDefinition
+	Type        *astType
+	Directives  []*astDirective
+}
+
+// astInputValueDefinition is an argument or input field definition.
+type astInputValueDefinition struct {
+	Name         string
+	Description  string
+	Type         *astType
+	DefaultValue *astValue
+	Directives   []*astDirective
+}
+
+// astEnumValueDefinition is a value of an enum type definition.
+type astEnumValueDefinition struct {
+	Name        string
+	Description string
+	Directives  []*astDirective
+}
+
+// astDirectiveDefinition is a directive definition.
+type astDirectiveDefinition struct {
+	Name        string
+	Description string
+	Args        []*astInputValueDefinition
+	Repeatable  bool
+	Locations   []string
+}
+
+// sdlTypeKinds maps SDL keywords to introspection type kinds.
+var sdlTypeKinds = map[string]string{
+	"scalar":    "SCALAR",
+	"type":      "OBJECT",
+	"interface": "INTERFACE",
+	"union":     "UNION",
+	"enum":      "ENUM",
+	"input":     "INPUT_OBJECT",
+}
+
+// parseSchemaDocument parses a GraphQL type system document (SDL).
+func parseSchemaDocument(src string) (*astSchemaDocument, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &astSchemaDocument{RootTypes: make(map[string]string)}
+	for p.tok.kind != tokenEOF {
+		description, err := p.parseDescription()
+		if err != nil {
+			return nil, err
+		}
+		extend, err := p.skip(tokenName, "extend")
+		if err != nil {
+			return nil, err
+		}
+		if p.tok.kind != tokenName {
+			return nil, p.unexpected()
+		}
+
+		keyword := p.tok.value
+		switch {
+		case keyword == "schema":
+			if err := p.parseSchemaDefinition(doc); err != nil {
+				return nil, err
+			}
+		case sdlTypeKinds[keyword] != "":
+			def, err := p.parseTypeDefinition(keyword, description, extend)
+			if err != nil {
+				return nil, err
+			}
+			doc.Types = append(doc.Types, def)
+		case keyword == "directive" && !extend:
+			def, err := p.parseDirectiveDefinition(description)
+			if err != nil {
+				return nil, err
+			}
+			doc.Directives = append(doc.Directives, def)
+		default:
+			return nil, p.errorf("unexpected %q, expected a type system definition", keyword)
+		}
+	}
+	return doc, nil
+}
+
+// parseDescription consumes an optional description string.
+func (p *parser) parseDescription() (string, error) {
+	if p.tok.kind != tokenString && p.tok.kind != tokenBlockString {
+		return "", nil
+	}
+	description := p.tok.value
+	return description, p.advance()
+}
+
+func (p *parser) parseSchemaDefinition(doc *astSchemaDocument) error {
+	if err := p.expectKeyword("schema"); err != nil {
+		return err
+	}
+	if _, err := p.parseDirectives(true); err != nil {
+		return err
+	}
+	if !p.peek(tokenPunct, "{") {
+		return nil
+	}
+	if err := p.advance(); err != nil {
+		return err
+	}
+	for !p.peek(tokenPunct, "}") {
+		operation, err := p.parseName()
+		if err != nil {
+			return err
+		}
+		if operation != "query" && operation != "mutation" && operation != "subscription" {
+			return p.errorf("unknown root operation type %q", operation)
+		}
+		if err := p.expect(":"); err != nil {
+			return err
+		}
+		name, err := p.parseName()
+		if err != nil {
+			return err
+		}
+		doc.RootTypes[operation] = name
+	}
+	return p.advance()
+}
+
+func (p *parser) parseTypeDefinition(keyword, description string, extend bool) (*astTypeDefinition, error) {
+	def := &astTypeDefinition{Kind: sdlTypeKinds[keyword], Description: description, Extend: extend}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.parseName()
+	if err != nil {
+		return nil, err
+	}
+	def.Name = name
+
+	if keyword == "type" || keyword == "interface" {
+		if ok, err := p.skip(tokenName, "implements"); err != nil {
+			return nil, err
+		} else if ok {
+			if _, err := p.skip(tokenPunct, "&"); err != nil {
+				return nil, err
+			}
+			for {
+				iface, err := p.parseName()
+				if err != nil {
+					return nil, err
+				}
+				def.Interfaces = append(def.Interfaces, iface)
+				if ok, err := p.skip(tokenPunct, "&"); err != nil {
+					return nil, err
+				} else if !ok {
+					break
+				}
+			}
+		}
+	}
+	if def.Directives, err = p.parseDirectives(true); err != nil {
+		return nil, err
+	}
+
+	switch keyword {
+	case "type", "interface":
+		if p.peek(tokenPunct, "{") {
+			def.Fields, err = p.parseFieldDefinitions()
+		}
+	case "input":
+		if p.peek(tokenPunct, "{") {
+			def.InputFields, err = p.parseInputValueDefinitions("{", "}")
+		}
+	case "enum":
+		if p.peek(tokenPunct, "{") {
+			def.EnumValues, err = p.parseEnumValueDefinitions()
+		}
+	case "union":
+		if ok, skipErr := p.skip(tokenPunct, "="); skipErr != nil {
+			return nil, skipErr
+		} else if ok {
+			if _, err := p.skip(tokenPunct, "|"); err != nil {
+				return nil, err
+			}
+			for {
+				member, err := p.parseName()
+				if err != nil {
+					return nil, err
+				}
+				def.Members = append(def.Members, member)
+				if ok, err := p.skip(tokenPunct, "|"); err != nil {
+					return nil, err
+				} else if !ok {
+					break
+				}
+			}
+		}
+	}
+	return def, err
+}
+
+func (p *parser) parseFieldDefinitions() ([]*astFieldDefinition, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var fields []*astFieldDefinition
+	for !p.peek(tokenPunct, "}") {
+		description, err := p.parseDescription()
+		if err != nil {
+			return nil, err
+		}
+		field := &astFieldDefinition{Description: description}
+		if field.Name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+		if p.peek(tokenPunct, "(") {
+			if field.Args, err = p.parseInputValueDefinitions("(", ")"); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if field.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if field.Directives, err = p.parseDirectives(true); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, p.advance()
+}
+
+// parseInputValueDefinitions parses arguments (between parentheses) or input
+// fields (between braces).
+func (p *parser) parseInputValueDefinitions(open, close string) ([]*astInputValueDefinition, error) {
+	if err := p.expect(open); err != nil {
+		return nil, err
+	}
+	var values []*astInputValueDefinition
+	for !p.peek(tokenPunct, close) {
+		description, err := p.parseDescription()
+		if err != nil {
+			return nil, err
+		}
+		value := &astInputValueDefinition{Description: description}
+		if value.Name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if value.Type, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip(tokenPunct, "="); err != nil {
+			return nil, err
+		} else if ok {
+			if value.DefaultValue, err = p.parseValue(true); err != nil {
+				return nil, err
+			}
+		}
+		if value.Directives, err = p.parseDirectives(true); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, p.advance()
+}
+
+func (p *parser) parseEnumValueDefinitions() ([]*astEnumValueDefinition, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var values []*astEnumValueDefinition
+	for !p.peek(tokenPunct, "}") {
+		description, err := p.parseDescription()
+		if err != nil {
+			return nil, err
+		}
+		value := &astEnumValueDefinition{Description: description}
+		if value.Name, err = p.parseName(); err != nil {
+			return nil, err
+		}
+		if value.Directives, err = p.parseDirectives(true); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, p.advance()
+}
+
+func (p *parser) parseDirectiveDefinition(description string) (*astDirectiveDefinition, error) {
+	def := &astDirectiveDefinition{Description: description}
+	if err := p.expectKeyword("directive"); err != nil {
+		return nil, err
+	}
+	if err := p.expect("@"); err != nil {
+		return nil, err
+	}
+	var err error
+	if def.Name, err = p.parseName(); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunct, "(") {
+		if def.Args, err = p.parseInputValueDefinitions("(", ")"); err != nil {
+			return nil, err
+		}
+	}
+	if def.Repeatable, err = p.skip(tokenName, "repeatable"); err != nil {
+		return nil, err
+	}
+	if err := p.expectKeyword("on"); err != nil {
+		return nil, err
+	}
+	if _, err := p.skip(tokenPunct, "|"); err != nil {
+		return nil, err
+	}
+	for {
+		location, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		def.Locations = append(def.Locations, location)
+		if ok, err := p.skip(tokenPunct, "|"); err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+	}
+	return def, nil
+}
+
+// builtinDirectives are declared by every schema even when the SDL omits them.
+const builtinDirectives = `
+"Directs the executor to include this field or fragment only when the ` + "`if`" + ` argument is true."
+directive @include("Included when true." if: Boolean!) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+"Directs the executor to skip this field or fragment when the ` + "`if`" + ` argument is true."
+directive @skip("Skipped when true." if: Boolean!) on FIELD | FRAGMENT_SPREAD | INLINE_FRAGMENT
+
+"Marks an element of a GraphQL schema as no longer supported."
+directive @deprecated(reason: String = "No longer supported") on FIELD_DEFINITION | ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION | ENUM_VALUE
+
+"Exposes a URL that specifies the behavior of this scalar."
+directive @specifiedBy(url: String!) on SCALAR
+`
+
+// loadSchemaFile reads and parses an SDL file into a schema model.
+func loadSchemaFile(path string) (*schemaModel, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema file: %w", err)
+	}
+	doc, err := parseSchemaDocument(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema file %s: %w", path, err)
+	}
+	return schemaFromSDL(doc)
+}
+
+// schemaFromSDL builds a schema model from a parsed SDL document, merging type
+// extensions and adding the built-in scalars and directives.
+func schemaFromSDL(doc *astSchemaDocument) (*schemaModel, error) {
+	builtins, err := parseSchemaDocument(builtinDirectives)
+	if err != nil {
+		return nil, err
+	}
+
+	// Merge definitions and extensions by name
+	defs := make(map[string]*astTypeDefinition)
+	var order []string
+	for _, extend := range []bool{false, true} {
+		for _, def := range doc.Types {
+			if def.Extend != extend {
+				continue
+			}
+			existing, ok := defs[def.Name]
+			if !ok {
+				if extend {
+					return nil, fmt.Errorf("cannot extend unknown type %q", def.Name)
+				}
+				copied := *def
+				defs[def.Name] = &copied
+				order = append(order, def.Name)
+				continue
+			}
+			if !extend {
+				return nil, fmt.Errorf("type %q is defined more than once", def.Name)
+			}
+			if existing.Kind != def.Kind {
+				return nil, fmt.Errorf("cannot extend %s %q as a different kind", existing.Kind, def.Name)
+			}
+			existing.Interfaces = append(existing.Interfaces, def.Interfaces...)
+			existing.Fields = append(existing.Fields, def.Fields...)
+			existing.InputFields = append(existing.InputFields, def.InputFields...)
+			existing.EnumValues = append(existing.EnumValues, def.EnumValues...)
+			existing.Members = append(existing.Members, def.Members...)
+		}
+	}
+	for _, name := range []string{"Int", "Float", "String", "Boolean", "ID"} {
+		if _, ok := defs[name]; !ok {
+			defs[name] = &astTypeDefinition{Kind: "SCALAR", Name: name}
+			order = append(order, name)
+		}
+	}
+
+	ref := func(t *astType) (*typeRef, error) {
+		return sdlTypeRef(t, defs)
+	}
+	inputValues := func(values []*astInputValueDefinition) ([]*schemaInputValue, error) {
+		var out []*schemaInputValue
+		for _, v := range values {
+			typ, err := ref(v.Type)
+			if err != nil {
+				return nil, err
+			}
+			iv := &schemaInputValue{Name: v.Name, Description: v.Description, Type: typ}
+			if v.DefaultValue != nil {
+				def := v.DefaultValue.String()
+				iv.DefaultValue = &def
+			}
+			out = append(out, iv)
+		}
+		return out, nil
+	}
+
+	types := make([]*schemaType, 0, len(order))
+	byName := make(map[string]*schemaType, len(order))
+	for _, name := range order {
+		def := defs[name]
+		t := &schemaType{Kind: def.Kind, Name: def.Name, Description: def.Description}
+		for _, f := range def.Fields {
+			typ, err := ref(f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", def.Name, f.Name, err)
+			}
+			args, err := inputValues(f.Args)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", def.Name, f.Name, err)
+			}
+			deprecated, reason := deprecation(f.Directives)
+			t.Fields = append(t.Fields, &schemaField{
+				Name:              f.Name,
+				Description:       f.Description,
+				Args:              args,
+				Type:              typ,
+				IsDeprecated:      deprecated,
+				DeprecationReason: reason,
+			})
+		}
+		inputFields, err := inputValues(def.InputFields)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", def.Name, err)
+		}
+		t.InputFields = inputFields
+		for _, v := range def.EnumValues {
+			deprecated, reason := deprecation(v.Directives)
+			t.EnumValues = append(t.EnumValues, &schemaEnumValue{
+				Name:              v.Name,
+				Description:       v.Description,
+				IsDeprecated:      deprecated,
+				DeprecationReason: reason,
+			})
+		}
+		for _, name := range def.Interfaces {
+			if defs[name] == nil || defs[name].Kind != "INTERFACE" {
+				return nil, fmt.Errorf("%s implements unknown interface %q", def.Name, name)
+			}
+			t.Interfaces = append(t.Interfaces, &typeRef{Kind: "INTERFACE", Name: name})
+		}
+		for _, name := range def.Members {
+			if defs[name] == nil || defs[name].Kind != "OBJECT" {
+				return nil, fmt.Errorf("union %s has unknown member type %q", def.Name, name)
+			}
+			t.PossibleTypes = append(t.PossibleTypes, &typeRef{Kind: "OBJECT", Name: name})
+		}
+		types = append(types, t)
+		byName[name] = t
+	}
+
+	// Interfaces list the object types implementing them as possible types
+	for _, t := range types {
+		for _, iface := range t.Interfaces {
+			if it := byName[iface.Name]; it != nil && t.Kind == "OBJECT" {
+				it.PossibleTypes = append(it.PossibleTypes, &typeRef{Kind: "OBJECT", Name: t.Name})
+			}
+		}
+	}
+
+	var directives []*schemaDirective
+	declared := make(map[string]bool)
+	for _, def := range append(doc.Directives, builtins.Directives...) {
+		if declared[def.Name] {
+			continue
+		}
+		declared[def.Name] = true
+		args, err := inputValues(def.Args)
+		if err != nil {
+			return nil, fmt.Errorf("@%s: %w", def.Name, err)
+		}
+		directives = append(directives, &schemaDirective{
+			Name:        def.Name,
+			Description: def.Description,
+			Locations:   def.Locations,
+			Args:        args,
+		})
+	}
+
+	root := func(operation, defaultName string) string {
+		if name, ok := doc.RootTypes[operation]; ok {
+			return name
+		}
+		if len(doc.RootTypes) == 0 && byName[defaultName] != nil {
+			return defaultName
+		}
+		return ""
+	}
+	queryType := root("query", "Query")
+	if queryType == "" || byName[queryType] == nil {
+		return nil, fmt.Errorf("schema has no query root type")
+	}
+	return newSchemaModel(queryType, root("mutation", "Mutation"), root("subscription", "Subscription"), types, directives), nil
+}
+
+// sdlTypeRef converts an SDL type reference into a typeRef, resolving the
+// kind of the named type from the document's definitions.
+func sdlTypeRef(t *astType, defs map[string]*astTypeDefinition) (*typeRef, error) {
+	var ref *typeRef
+	if t.Elem != nil {
+		elem, err := sdlTypeRef(t.Elem, defs)
+		if err != nil {
+			return nil, err
+		}
+		ref = &typeRef{Kind: "LIST", OfType: elem}
+	} else {
+		def, ok := defs[t.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown type %q", t.Name)
+		}
+		ref = &typeRef{Kind: def.Kind, Name: t.Name}
+	}
+	if t.NonNull {
+		return &typeRef{Kind: "NON_NULL", OfType: ref}, nil
+	}
+	return ref, nil
+}
+
+// deprecation reports whether the directives include @deprecated and its reason.
+func deprecation(dirs []*astDirective) (bool, string) {
+	for _, d := range dirs {
+		if d.Name != "deprecated" {
+			continue
+		}
+		for _, arg := range d.Arguments {
+			if arg.Name == "reason" && arg.Value.Kind == valueString {
+				return true, arg.Value.Raw
+			}
+		}
+		return true, defaultDeprecationReason
+	}
+	return false, ""
+}
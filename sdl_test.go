@@ -0,0 +1,112 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaFromSDL(t *testing.T) {
+	schema := parseTestSchema(t, `
+schema { query: Root mutation: Changes }
+
+"Something with an ID."
+interface Node { id: ID! }
+
+type Root {
+  node(id: ID!): Node
+  search(term: String!, first: Int = 10): [SearchResult!]!
+}
+type Changes { archive(id: ID!): Candidate }
+
+type Candidate implements Node {
+  id: ID!
+  tags: [[String!]]!
+}
+type Job implements Node { id: ID! }
+union SearchResult = Candidate | Job
+
+extend type Candidate { status: Status }
+enum Status { ACTIVE ARCHIVED }
+extend enum Status { HIRED }
+input CandidateFilter { status: Status = ACTIVE }
+scalar DateTime @specifiedBy(url: "https://tools.ietf.org/html/rfc3339")
+`)
+	if schema.QueryType != "Root" || schema.MutationType != "Changes" || schema.SubscriptionType != "" {
+		t.Errorf("root types = %q, %q, %q, want Root, Changes and none", schema.QueryType, schema.MutationType, schema.SubscriptionType)
+	}
+
+	candidate := schema.typeByName("Candidate")
+	var fields []string
+	for _, f := range candidate.Fields {
+		fields = append(fields, f.Name+": "+f.Type.String())
+	}
+	if got, want := strings.Join(fields, ", "), "id: ID!, tags: [[String!]]!, status: Status"; got != want {
+		t.Errorf("Candidate fields = %s, want %s (extensions merged)", got, want)
+	}
+	if status := candidate.field("status").Type; status.Kind != "ENUM" || status.Name != "Status" {
+		t.Errorf("Candidate.status type = %+v, want the ENUM Status", status)
+	}
+	if got := len(schema.typeByName("Status").EnumValues); got != 3 {
+		t.Errorf("Status has %d values, want 3 (extensions merged)", got)
+	}
+
+	var possible []string
+	for _, ref := range schema.typeByName("Node").PossibleTypes {
+		possible = append(possible, ref.Name)
+	}
+	if got := strings.Join(possible, ", "); got != "Candidate, Job" {
+		t.Errorf("Node possible types = %s, want its implementations Candidate, Job", got)
+	}
+	if got := schema.typeByName("SearchResult").PossibleTypes; len(got) != 2 {
+		t.Errorf("SearchResult has %d members, want 2", len(got))
+	}
+	if d := schema.typeByName("Node").Description; d != "Something with an ID." {
+		t.Errorf("Node description = %q", d)
+	}
+
+	search := schema.typeByName("Root").field("search")
+	if first := search.Args[1]; first.DefaultValue == nil || *first.DefaultValue != "10" {
+		t.Errorf("search(first:) default = %v, want 10", first.DefaultValue)
+	}
+	for _, name := range []string{"Int", "Float", "String", "Boolean", "ID"} {
+		if schema.typeByName(name) == nil {
+			t.Errorf("built-in scalar %s missing", name)
+		}
+	}
+	declared := make(map[string]bool)
+	for _, d := range schema.Directives {
+		declared[d.Name] = true
+	}
+	for _, name := range []string{"skip", "include", "deprecated", "specifiedBy"} {
+		if !declared[name] {
+			t.Errorf("built-in directive @%s missing", name)
+		}
+	}
+}
+
+func TestSchemaFromSDLErrors(t *testing.T) {
+	tests := []struct {
+		sdl  string
+		want string
+	}{
+		{`type Query { a: Missing }`, `Query.a: unknown type "Missing"`},
+		{`type Query { a: Int } type Query { b: Int }`, `type "Query" is defined more than once`},
+		{`extend type Nope { a: Int }`, `cannot extend unknown type "Nope"`},
+		{`type Query { a: Int } extend enum Query { A }`, `cannot extend OBJECT "Query" as a different kind`},
+		{`type Query implements Nope { a: Int }`, `Query implements unknown interface "Nope"`},
+		{`type Query { a: Int } union U = Nope`, `union U has unknown member type "Nope"`},
+		{`type Mutation { a: Int }`, "schema has no query root type"},
+		{`type Query { a(x: Int = ): Int }`, `line 1, column 25: unexpected ")"`},
+		{`type Query { a: Int`, "line 1, column 20: expected name, found end of document"},
+		{`"unterminated type Query { a: Int }`, "line 1, column 1: unterminated string"},
+	}
+	for _, tt := range tests {
+		doc, err := parseSchemaDocument(tt.sdl)
+		if err == nil {
+			_, err = schemaFromSDL(doc)
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("loading %q: error = %v, want one containing %q", tt.sdl, err, tt.want)
+		}
+	}
+}
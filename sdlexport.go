@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"strings"
+)
+
+// Tool: export_sdl
+const exportSDLToolDescription = `Export the whole schema as GraphQL SDL, for use in other tools or as a SCHEMA_FILE.
+Descriptions are kept as GraphQL description strings (triple-quoted when they span several lines), deprecated fields and enum values keep their @deprecated directive and reason, and default values, interfaces, unions and custom directives are preserved, so the exported SDL describes the same schema.
+
+Best Practices:
+- Prefer describe, search_schema or list_queries to explore the schema; the full SDL of a large API uses many tokens.
+- Save the output as a file and point SCHEMA_FILE at it to work offline or when introspection is disabled.
+- When the schema was loaded with a reduced introspection query, the descriptions it left out can't be exported.
+
+Example Usage:
+Request:
+  export_sdl()
+
+Response:
+  type Query {
+    "Find a job by id."
+    job(id: ID!): Job
+  }
+
+  """
+  A job opening.
+  Closed jobs are kept for reporting.
+  """
+  type Job {
+    id: ID!
+    title: String!
+    location: String @deprecated(reason: "Use locations.")
+  }
+`
+
+// specifiedDirectives are declared by every schema, so they aren't exported.
+var specifiedDirectives = map[string]bool{"include": true, "skip": true, "deprecated": true, "specifiedBy": true}
+
+// defaultDeprecationReason is the reason @deprecated declares by default.
+const defaultDeprecationReason = "No longer supported"
+
+// exportSDL renders the schema as SDL, leaving out the built-in scalars and
+// directives and the introspection types.
+func exportSDL(ctx context.Context) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	return printSchemaSDL(schema), nil
+}
+
+// printSchemaSDL renders schema as an SDL document.
+func printSchemaSDL(schema *schemaModel) string {
+	var blocks []string
+
+	// The schema definition is only needed for non-default root type names
+	roots := []struct{ operation, name, conventional string }{
+		{"query", schema.QueryType, "Query"},
+		{"mutation", schema.MutationType, "Mutation"},
+		{"subscription", schema.SubscriptionType, "Subscription"},
+	}
+	var rootLines []string
+	conventional := true
+	for _, r := range roots {
+		if r.name == "" {
+			conventional = conventional && schema.typeByName(r.conventional) == nil
+			continue
+		}
+		conventional = conventional && r.name == r.conventional
+		rootLines = append(rootLines, "  "+r.operation+": "+r.name+"\n")
+	}
+	if !conventional {
+		blocks = append(blocks, "schema {\n"+strings.Join(rootLines, "")+"}")
+	}
+
+	for _, d := range schema.Directives {
+		if specifiedDirectives[d.Name] {
+			continue
+		}
+		blocks = append(blocks, sdlDescription(d.Description, "")+"directive @"+d.Name+sdlArguments(d.Args, "")+" on "+strings.Join(d.Locations, " | "))
+	}
+	for _, t := range schema.Types {
+		if strings.HasPrefix(t.Name, "__") || builtinScalars[t.Name] {
+			continue
+		}
+		blocks = append(blocks, sdlDescription(t.Description, "")+sdlTypeDefinition(t))
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// sdlTypeDefinition renders a named type without its description.
+func sdlTypeDefinition(t *schemaType) string {
+	var sb strings.Builder
+	switch t.Kind {
+	case "SCALAR":
+		return "scalar " + t.Name
+	case "UNION":
+		members := make([]string, len(t.PossibleTypes))
+		for i, p := range t.PossibleTypes {
+			members[i] = p.Name
+		}
+		return "union " + t.Name + " = " + strings.Join(members, " | ")
+	case "OBJECT":
+		sb.WriteString("type " + t.Name + implementsClause(t))
+	case "INTERFACE":
+		sb.WriteString("interface " + t.Name + implementsClause(t))
+	case "INPUT_OBJECT":
+		sb.WriteString("input " + t.Name)
+	case "ENUM":
+		sb.WriteString("enum " + t.Name)
+	}
+
+	sb.WriteString(" {\n")
+	for _, f := range t.Fields {
+		sb.WriteString(sdlDescription(f.Description, "  "))
+		sb.WriteString("  " + f.Name + sdlArguments(f.Args, "  ") + ": " + f.Type.String())
+		sb.WriteString(sdlDeprecation(f.IsDeprecated, f.DeprecationReason) + "\n")
+	}
+	for _, f := range t.InputFields {
+		sb.WriteString(sdlDescription(f.Description, "  ") + "  " + inputValueString(f) + "\n")
+	}
+	for _, v := range t.EnumValues {
+		sb.WriteString(sdlDescription(v.Description, "  "))
+		sb.WriteString("  " + v.Name + sdlDeprecation(v.IsDeprecated, v.DeprecationReason) + "\n")
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+// sdlArguments renders an argument list, one argument per line when any of
+// them has a description, and nothing when there are none.
+func sdlArguments(args []*schemaInputValue, indent string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	described := false
+	for _, a := range args {
+		described = described || a.Description != ""
+	}
+	if !described {
+		return "(" + argsWithDefaultsToString(args) + ")"
+	}
+	var sb strings.Builder
+	sb.WriteString("(\n")
+	for _, a := range args {
+		sb.WriteString(sdlDescription(a.Description, indent+"  ") + indent + "  " + inputValueString(a) + "\n")
+	}
+	sb.WriteString(indent + ")")
+	return sb.String()
+}
+
+// sdlDescription renders a description on its own line(s) before the element
+// it describes: a string literal, or a block string when it spans lines.
+func sdlDescription(desc, indent string) string {
+	if desc == "" {
+		return ""
+	}
+	if !strings.Contains(desc, "\n") {
+		return indent + quoteString(desc) + "\n"
+	}
+	lines := strings.Split(strings.ReplaceAll(desc, `"""`, `\"""`), "\n")
+	var sb strings.Builder
+	sb.WriteString(indent + `"""` + "\n")
+	for _, line := range lines {
+		if line == "" {
+			sb.WriteString("\n")
+		} else {
+			sb.WriteString(indent + line + "\n")
+		}
+	}
+	sb.WriteString(indent + `"""` + "\n")
+	return sb.String()
+}
+
+// sdlDeprecation renders the @deprecated directive of a deprecated field or
+// enum value, with its reason unless it's the default one.
+func sdlDeprecation(deprecated bool, reason string) string {
+	if !deprecated {
+		return ""
+	}
+	if reason == "" || reason == defaultDeprecationReason {
+		return " @deprecated"
+	}
+	return " @deprecated(reason: " + quoteString(reason) + ")"
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// documentation lists the descriptions and deprecations of every element of
+// schema, keyed by schema coordinate, e.g. "Job.title(format:)".
+func documentation(schema *schemaModel) map[string]string {
+	docs := make(map[string]string)
+	add := func(coordinate, description string, deprecated bool, reason string) {
+		if description != "" {
+			docs[coordinate] = description
+		}
+		if deprecated {
+			docs[coordinate+" @deprecated"] = reason
+		}
+	}
+	addArgs := func(coordinate string, args []*schemaInputValue) {
+		for _, a := range args {
+			add(coordinate+"("+a.Name+":)", a.Description, false, "")
+		}
+	}
+	for _, d := range schema.Directives {
+		if !specifiedDirectives[d.Name] {
+			add("@"+d.Name, d.Description, false, "")
+			addArgs("@"+d.Name, d.Args)
+		}
+	}
+	for _, t := range schema.Types {
+		if strings.HasPrefix(t.Name, "__") || builtinScalars[t.Name] {
+			continue
+		}
+		add(t.Name, t.Description, false, "")
+		for _, f := range t.Fields {
+			add(t.Name+"."+f.Name, f.Description, f.IsDeprecated, f.DeprecationReason)
+			addArgs(t.Name+"."+f.Name, f.Args)
+		}
+		for _, f := range t.InputFields {
+			add(t.Name+"."+f.Name, f.Description, false, "")
+		}
+		for _, v := range t.EnumValues {
+			add(t.Name+"."+v.Name, v.Description, v.IsDeprecated, v.DeprecationReason)
+		}
+	}
+	return docs
+}
+
+// TestExportSDLRoundTrip exports a schema as SDL, loads the SDL back and
+// checks that every description and deprecation survived.
+func TestExportSDLRoundTrip(t *testing.T) {
+	schema := parseTestSchema(t, `
+"Marks fields whose values are cached, for how long."
+directive @cached(
+  "Seconds the value is cached."
+  ttl: Int = 60
+) on FIELD_DEFINITION
+
+type Query {
+  "A job by ID."
+  job(id: ID!): Job
+  """
+  Jobs matching a status.
+
+  Results are sorted by:
+    - creation date
+    - title
+  """
+  jobs(
+    "Only jobs with this status."
+    status: JobStatus = OPEN
+    first: Int
+  ): [Job!]! @cached(ttl: 30)
+}
+
+"""
+A job opening, with "quotes", a backslash \ and a block quote: \"""
+"""
+type Job {
+  id: ID!
+  "The title, e.g. \"Engineer\"."
+  title(format: String): String!
+  legacyCode: String @deprecated
+  salary: Int @deprecated(reason: "Use compensation, which has the currency.")
+}
+
+"The status of a job."
+enum JobStatus {
+  "Accepting applications."
+  OPEN
+  CLOSED @deprecated(reason: "Use ARCHIVED.")
+  ARCHIVED
+}
+
+input JobFilter {
+  "Case-insensitive substring of the title."
+  title: String
+}
+`)
+	want := documentation(schema)
+	for _, coordinate := range []string{"Job", "Query.jobs", "Job.salary @deprecated", "Job.legacyCode @deprecated", "JobStatus.CLOSED @deprecated", "Query.jobs(status:)", "@cached(ttl:)", "JobFilter.title"} {
+		if _, ok := want[coordinate]; !ok {
+			t.Fatalf("the fixture lacks %s: %v", coordinate, want)
+		}
+	}
+
+	exported := printSchemaSDL(schema)
+	doc, err := parseSchemaDocument(exported)
+	if err != nil {
+		t.Fatalf("the exported SDL doesn't parse: %v\n%s", err, exported)
+	}
+	reloaded, err := schemaFromSDL(doc)
+	if err != nil {
+		t.Fatalf("the exported SDL doesn't load: %v\n%s", err, exported)
+	}
+	if got := documentation(reloaded); !reflect.DeepEqual(got, want) {
+		var diff []string
+		for coordinate, w := range want {
+			if got[coordinate] != w {
+				diff = append(diff, fmt.Sprintf("%s: got %q, want %q", coordinate, got[coordinate], w))
+			}
+		}
+		for coordinate, g := range got {
+			if _, ok := want[coordinate]; !ok {
+				diff = append(diff, fmt.Sprintf("%s: got %q, want nothing", coordinate, g))
+			}
+		}
+		t.Errorf("the documentation changed on the round trip:\n%s\nexported SDL:\n%s", strings.Join(diff, "\n"), exported)
+	}
+	if again := printSchemaSDL(reloaded); again != exported {
+		t.Errorf("exporting the reloaded schema gives a different SDL:\n%s\nwant\n%s", again, exported)
+	}
+}
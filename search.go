@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tool: search_schema
+const searchSchemaToolDescription = `Search the schema for types, root operations, fields, input fields and enum values matching a term, most relevant first.
+Exact name matches rank first, then names starting with the term, then names containing it, then matches in descriptions only. At equal match quality, root operations rank above types, and types above nested fields.
+
+Best Practices:
+- Use this tool to find where a concept lives in a large schema when you don't know the exact names.
+- Follow up with describe on the best matches.
+
+Arguments:
+- term (string, Required): The text to look for, case-insensitively.
+- limit (number, Optional): The maximum number of results (default 20).
+
+Example Usage:
+Request:
+  search_schema(term: "job")
+
+Response:
+  110 query.job(id: ID!): Job
+  105 type Job (OBJECT)
+  85 query.jobs(page: Int, size: Int): JobsPage
+  80 type JobQueryParams (INPUT_OBJECT)
+  50 Candidate.appliedJobs: [Job!]!
+  35 query.openings: [Position!]! (description)
+`
+
+// defaultSearchLimit is the number of search_schema results when no limit is
+// given.
+const defaultSearchLimit = 20
+
+// Scores of a search match, by how the term matched and what matched.
+const (
+	searchScoreExact       = 100
+	searchScorePrefix      = 75
+	searchScoreSubstring   = 50
+	searchScoreDescription = 25
+
+	searchBonusRoot = 10
+	searchBonusType = 5
+)
+
+// searchResult is a schema element matching a search term.
+type searchResult struct {
+	Score int
+	Label string
+}
+
+// searchSchema ranks the schema elements matching term by relevance and
+// renders the first limit of them, one "score label" line each.
+func searchSchema(ctx context.Context, term string, limit int) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return "", fmt.Errorf("the search term is empty")
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	var results []searchResult
+	add := func(name, description string, bonus int, label string) {
+		score := searchScore(term, name, description)
+		if score == 0 {
+			return
+		}
+		if score == searchScoreDescription {
+			label += " (description)"
+		}
+		results = append(results, searchResult{Score: score + bonus, Label: label})
+	}
+	for _, operation := range []string{"query", "mutation", "subscription"} {
+		for _, f := range schema.rootFields(schema.rootType(operation)) {
+			add(f.Name, f.Description, searchBonusRoot, operation+"."+usageString(f))
+		}
+	}
+	for _, t := range schema.Types {
+		if schema.isRootType(t.Name) || strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		add(t.Name, t.Description, searchBonusType, fmt.Sprintf("type %s (%s)", t.Name, t.Kind))
+		for _, f := range t.Fields {
+			add(f.Name, f.Description, 0, t.Name+"."+usageString(f))
+		}
+		for _, f := range t.InputFields {
+			add(f.Name, f.Description, 0, t.Name+"."+inputValueString(f))
+		}
+		for _, v := range t.EnumValues {
+			add(v.Name, v.Description, 0, t.Name+"."+v.Name)
+		}
+	}
+
+	if len(results) == 0 {
+		return fmt.Sprintf("Nothing in the schema matches %q.", term), nil
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Label < results[j].Label
+	})
+	var sb strings.Builder
+	for i, r := range results {
+		if i == limit {
+			fmt.Fprintf(&sb, "... and %d more, raise limit to see them\n", len(results)-limit)
+			break
+		}
+		fmt.Fprintf(&sb, "%d %s\n", r.Score, r.Label)
+	}
+	return sb.String(), nil
+}
+
+// searchScore rates how well name and description match the lowercase term,
+// 0 meaning no match.
+func searchScore(term, name, description string) int {
+	name = strings.ToLower(name)
+	switch {
+	case name == term:
+		return searchScoreExact
+	case strings.HasPrefix(name, term):
+		return searchScorePrefix
+	case strings.Contains(name, term):
+		return searchScoreSubstring
+	case strings.Contains(strings.ToLower(description), term):
+		return searchScoreDescription
+	}
+	return 0
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// secretEnvPrefix restricts which environment variables "${env:NAME}"
+// references in operation variables may read. References are rejected when
+// it is unset.
+var secretEnvPrefix = getenv("SECRET_ENV_PREFIX")
+
+// secretReferencePattern matches "${env:NAME}" inside a string variable.
+var secretReferencePattern = regexp.MustCompile(`\$\{env:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// redactedSecret replaces resolved secret values echoed back by the server.
+const redactedSecret = "[REDACTED]"
+
+// resolveSecretReferences returns a copy of vars where every "${env:NAME}"
+// reference in a string value (at any depth) is replaced with the value of
+// the environment variable, along with the resolved values so they can be
+// scrubbed from the response. vars itself is left untouched, so the
+// references, not the secrets, are what gets logged and reported.
+func resolveSecretReferences(vars map[string]interface{}) (map[string]interface{}, []string, error) {
+	var secrets []string
+	var resolveErr error
+	var resolve func(v interface{}) interface{}
+	resolve = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case string:
+			return secretReferencePattern.ReplaceAllStringFunc(v, func(ref string) string {
+				name := secretReferencePattern.FindStringSubmatch(ref)[1]
+				value, err := lookupSecret(name)
+				if err != nil {
+					if resolveErr == nil {
+						resolveErr = err
+					}
+					return ref
+				}
+				secrets = append(secrets, value)
+				return value
+			})
+		case []interface{}:
+			out := make([]interface{}, len(v))
+			for i, item := range v {
+				out[i] = resolve(item)
+			}
+			return out
+		case map[string]interface{}:
+			out := make(map[string]interface{}, len(v))
+			for k, item := range v {
+				out[k] = resolve(item)
+			}
+			return out
+		}
+		return v
+	}
+
+	if len(vars) == 0 {
+		return vars, nil, nil
+	}
+	resolved, _ := resolve(vars).(map[string]interface{})
+	if resolveErr != nil {
+		return nil, nil, resolveErr
+	}
+	return resolved, secrets, nil
+}
+
+// lookupSecret reads the environment variable a reference points at.
+func lookupSecret(name string) (string, error) {
+	if secretEnvPrefix == "" {
+		return "", fmt.Errorf("secret reference ${env:%s} can't be resolved: SECRET_ENV_PREFIX is not set", name)
+	}
+	if !strings.HasPrefix(name, secretEnvPrefix) {
+		return "", fmt.Errorf("secret reference ${env:%s} is not allowed: only variables starting with %s can be referenced", name, secretEnvPrefix)
+	}
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("secret reference ${env:%s} can't be resolved: the variable is not set", name)
+	}
+	return value, nil
+}
+
+// scrubSecrets replaces every resolved secret in s.
+func scrubSecrets(s string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redactedSecret)
+		}
+	}
+	return s
+}
+
+// scrubSecretsJSON replaces every resolved secret in a raw JSON document,
+// matching the secrets in their JSON-escaped form.
+func scrubSecretsJSON(raw json.RawMessage, secrets []string) json.RawMessage {
+	for _, secret := range secrets {
+		encoded, err := json.Marshal(secret)
+		if err != nil || secret == "" {
+			continue
+		}
+		// Drop the quotes so the secret also matches inside longer strings
+		raw = bytes.ReplaceAll(raw, encoded[1:len(encoded)-1], []byte(redactedSecret))
+	}
+	return raw
+}
+
+// scrubSecretsResponse removes resolved secrets echoed back in a response,
+// e.g. in a validation error quoting a variable value.
+func scrubSecretsResponse(res *graphqlResponse, secrets []string) {
+	if len(secrets) == 0 || res == nil {
+		return
+	}
+	res.Data = scrubSecretsJSON(res.Data, secrets)
+	res.Errors = scrubSecretsErrors(res.Errors, secrets)
+	res.Extensions = scrubSecretsMap(res.Extensions, secrets)
+}
+
+// scrubSecretsError removes resolved secrets from the errors returned by
+// executeGraphQL.
+func scrubSecretsError(err error, secrets []string) error {
+	if len(secrets) == 0 {
+		return err
+	}
+	switch e := err.(type) {
+	case *graphqlResponseError:
+		e.Data = scrubSecretsJSON(e.Data, secrets)
+		e.Errors = scrubSecretsErrors(e.Errors, secrets)
+	case *httpStatusError:
+		e.Body = scrubSecrets(e.Body, secrets)
+	}
+	return err
+}
+
+// scrubSecretsErrors scrubs the messages and extensions of GraphQL errors.
+func scrubSecretsErrors(errs []graphqlError, secrets []string) []graphqlError {
+	for i := range errs {
+		errs[i].Message = scrubSecrets(errs[i].Message, secrets)
+		errs[i].Extensions = scrubSecretsMap(errs[i].Extensions, secrets)
+	}
+	return errs
+}
+
+// scrubSecretsMap scrubs a decoded JSON object such as extensions.
+func scrubSecretsMap(m map[string]interface{}, secrets []string) map[string]interface{} {
+	if len(m) == 0 {
+		return m
+	}
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return m
+	}
+	var scrubbed map[string]interface{}
+	if err := json.Unmarshal(scrubSecretsJSON(encoded, secrets), &scrubbed); err != nil {
+		return m
+	}
+	return scrubbed
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// setSecretEnvPrefix sets SECRET_ENV_PREFIX for a test.
+func setSecretEnvPrefix(t *testing.T, prefix string) {
+	t.Helper()
+	previous := secretEnvPrefix
+	secretEnvPrefix = prefix
+	t.Cleanup(func() { secretEnvPrefix = previous })
+}
+
+func TestResolveSecretReferencesPrefix(t *testing.T) {
+	t.Setenv("TEST_SECRET_TOKEN", "s3cret")
+	t.Setenv("HOME_TOKEN", "not for you")
+	tests := []struct {
+		name    string
+		prefix  string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{"allowed reference", "TEST_SECRET_", "Bearer ${env:TEST_SECRET_TOKEN}", "Bearer s3cret", ""},
+		{"plain value", "TEST_SECRET_", "no reference", "no reference", ""},
+		{"prefix not set", "", "${env:TEST_SECRET_TOKEN}", "", "SECRET_ENV_PREFIX is not set"},
+		{"outside the prefix", "TEST_SECRET_", "${env:HOME_TOKEN}", "", "only variables starting with TEST_SECRET_"},
+		{"unset variable", "TEST_SECRET_", "${env:TEST_SECRET_MISSING}", "", "the variable is not set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setSecretEnvPrefix(t, tt.prefix)
+			resolved, _, err := resolveSecretReferences(map[string]interface{}{"v": tt.value})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resolved["v"] != tt.want {
+				t.Errorf("resolved value = %q, want %q", resolved["v"], tt.want)
+			}
+		})
+	}
+}
+
+// TestResolveSecretReferencesNested checks that references are resolved in
+// nested objects and lists, without changing the original variables.
+func TestResolveSecretReferencesNested(t *testing.T) {
+	setSecretEnvPrefix(t, "TEST_SECRET_")
+	t.Setenv("TEST_SECRET_USER", "alice")
+	t.Setenv("TEST_SECRET_PASS", "pa\"ss")
+	vars := map[string]interface{}{
+		"input": map[string]interface{}{
+			"login":  "${env:TEST_SECRET_USER}",
+			"tokens": []interface{}{"${env:TEST_SECRET_PASS}", 42.0, map[string]interface{}{"deep": "${env:TEST_SECRET_USER}:${env:TEST_SECRET_PASS}"}},
+		},
+		"count": 3.0,
+	}
+	resolved, secrets, err := resolveSecretReferences(vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"input": map[string]interface{}{
+			"login":  "alice",
+			"tokens": []interface{}{"pa\"ss", 42.0, map[string]interface{}{"deep": "alice:pa\"ss"}},
+		},
+		"count": 3.0,
+	}
+	if !reflect.DeepEqual(resolved, want) {
+		t.Errorf("resolved = %v, want %v", resolved, want)
+	}
+	if input := vars["input"].(map[string]interface{}); input["login"] != "${env:TEST_SECRET_USER}" {
+		t.Errorf("the original variables were changed: %v", vars)
+	}
+	for _, secret := range []string{"alice", "pa\"ss"} {
+		found := false
+		for _, s := range secrets {
+			found = found || s == secret
+		}
+		if !found {
+			t.Errorf("secret %q is missing from %q", secret, secrets)
+		}
+	}
+}
+
+// TestScrubSecretsResponse checks that secrets are scrubbed from the data,
+// errors and extensions of a response, including in their JSON-escaped form.
+func TestScrubSecretsResponse(t *testing.T) {
+	secrets := []string{`pa"ss\word`}
+	escaped := `pa\"ss\\word`
+	res := &graphqlResponse{
+		Data: json.RawMessage(`{"echo":"token ` + escaped + ` echoed"}`),
+		Errors: []graphqlError{{
+			Message:    `invalid value "pa"ss\word"`,
+			Extensions: map[string]interface{}{"input": map[string]interface{}{"password": `pa"ss\word`}},
+		}},
+		Extensions: map[string]interface{}{"debug": []interface{}{`variables: pa"ss\word`}},
+	}
+	scrubSecretsResponse(res, secrets)
+
+	var data map[string]string
+	if err := json.Unmarshal(res.Data, &data); err != nil {
+		t.Fatalf("the scrubbed data is no longer valid JSON: %v", err)
+	}
+	if data["echo"] != "token "+redactedSecret+" echoed" {
+		t.Errorf("data echo = %q, want the secret redacted", data["echo"])
+	}
+	if res.Errors[0].Message != `invalid value "`+redactedSecret+`"` {
+		t.Errorf("error message = %q, want the secret redacted", res.Errors[0].Message)
+	}
+	if got := res.Errors[0].Extensions["input"].(map[string]interface{})["password"]; got != redactedSecret {
+		t.Errorf("error extension = %q, want the secret redacted", got)
+	}
+	if got := res.Extensions["debug"].([]interface{})[0]; got != "variables: "+redactedSecret {
+		t.Errorf("response extension = %q, want the secret redacted", got)
+	}
+}
+
+func TestScrubSecretsError(t *testing.T) {
+	secrets := []string{"s3cret"}
+	t.Run("HTTP status", func(t *testing.T) {
+		err := scrubSecretsError(&httpStatusError{StatusCode: 400, Body: `{"error":"bad token s3cret"}`}, secrets)
+		if strings.Contains(err.Error(), "s3cret") || !strings.Contains(err.(*httpStatusError).Body, redactedSecret) {
+			t.Errorf("error = %v, want the secret redacted from the body", err)
+		}
+	})
+	t.Run("GraphQL errors", func(t *testing.T) {
+		err := scrubSecretsError(&graphqlResponseError{
+			Errors: []graphqlError{{Message: "bad token s3cret", Extensions: map[string]interface{}{"token": "s3cret"}}},
+			Data:   json.RawMessage(`{"token":"s3cret"}`),
+		}, secrets)
+		gqlErr := err.(*graphqlResponseError)
+		if strings.Contains(err.Error(), "s3cret") || strings.Contains(string(gqlErr.Data), "s3cret") || gqlErr.Errors[0].Extensions["token"] != redactedSecret {
+			t.Errorf("error = %v (data %s, extensions %v), want the secret redacted", err, gqlErr.Data, gqlErr.Errors[0].Extensions)
+		}
+	})
+}
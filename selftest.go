@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+)
+
+// selfTestFlag runs the startup checks and exits instead of serving, for
+// container healthchecks and CI.
+var selfTestFlag = flag.Bool("selftest", boolFromEnv("SELFTEST"), "check the configuration, the endpoint and introspection, then exit")
+
+// selfTestCheck is one line of the self-test report.
+type selfTestCheck struct {
+	name string
+	// config checks only look at the environment and always run
+	config bool
+	run    func(ctx context.Context) (string, error)
+}
+
+// runSelfTest checks that the configuration is valid, the endpoint answers
+// and the schema loads, writing a report to w. It returns false if any check
+// failed. The network checks are skipped once a check fails, since they
+// depend on the earlier ones.
+func runSelfTest(ctx context.Context, w io.Writer) bool {
+	var checks []selfTestCheck
+	for _, setting := range configSettings() {
+		checks = append(checks, selfTestCheck{setting.name, true, func(ctx context.Context) (string, error) {
+			if setting.err != nil {
+				return "", setting.err
+			}
+			if setting.detail != nil {
+				return setting.detail(), nil
+			}
+			if getenv(setting.name) == "" {
+				return "not set", nil
+			}
+			return "valid", nil
+		}})
+	}
+	checks = append(checks, []selfTestCheck{
+		{"endpoint", false, func(ctx context.Context) (string, error) {
+			res, err := executeGraphQL(ctx, graphqlRequest{Query: "{ __typename }"})
+			if err != nil {
+				return "", err
+			}
+			if len(res.Errors) > 0 {
+				return "", &graphqlResponseError{Errors: res.Errors, Data: res.Data}
+			}
+			return "reachable", nil
+		}},
+		{"schema", false, func(ctx context.Context) (string, error) {
+			schema, err := getSchema(ctx)
+			if err != nil {
+				return "", fmt.Errorf("%w%s", err, schemaErrorHint(err))
+			}
+			source := "introspection"
+			if schemaFile != "" {
+				source = schemaFile
+			}
+			return fmt.Sprintf("%s, %s, %s (from %s)", pluralize(len(schema.Types), "type", "types"), pluralize(len(schema.queries()), "query", "queries"), pluralize(len(schema.mutations()), "mutation", "mutations"), source), nil
+		}},
+	}...)
+
+	ok := true
+	for _, check := range checks {
+		if !ok && !check.config {
+			fmt.Fprintf(w, "SKIP  %s\n", check.name)
+			continue
+		}
+		detail, err := check.run(ctx)
+		if err != nil {
+			ok = false
+			fmt.Fprintf(w, "FAIL  %s: %v\n", check.name, err)
+			continue
+		}
+		fmt.Fprintf(w, "OK    %s: %s\n", check.name, detail)
+	}
+	if ok {
+		fmt.Fprintln(w, "Self-test passed.")
+	} else {
+		fmt.Fprintln(w, "Self-test failed.")
+	}
+	return ok
+}
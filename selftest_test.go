@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestSelfTestConfigErrors checks that the self-test fails on the settings
+// main refuses to start with, without contacting the endpoint.
+func TestSelfTestConfigErrors(t *testing.T) {
+	previousEndpoint, previousBodyTemplateErr, previousReadOnlyErr := graphqlEndpoint, bodyTemplateErr, readOnlyModeErr
+	graphqlEndpoint = "http://example.invalid/graphql"
+	bodyTemplateErr = errors.New("invalid BODY_TEMPLATE: bad template")
+	readOnlyModeErr = errors.New(`invalid READ_ONLY "yes": expected true or false`)
+	t.Cleanup(func() {
+		graphqlEndpoint, bodyTemplateErr, readOnlyModeErr = previousEndpoint, previousBodyTemplateErr, previousReadOnlyErr
+	})
+
+	var out strings.Builder
+	if runSelfTest(context.Background(), &out) {
+		t.Fatalf("the self-test passed:\n%s", out.String())
+	}
+	for _, want := range []string{
+		"FAIL  BODY_TEMPLATE: invalid BODY_TEMPLATE: bad template",
+		`FAIL  READ_ONLY: invalid READ_ONLY "yes"`,
+		"OK    ADDRESS: http://example.invalid/graphql",
+		"SKIP  endpoint",
+		"SKIP  schema",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("the report doesn't contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestConfigSettingsKnown checks that the checked settings can be set in a
+// config file under the name the self-test reports.
+func TestConfigSettingsKnown(t *testing.T) {
+	known := make(map[string]bool)
+	for _, name := range knownSettings {
+		known[name] = true
+	}
+	for _, setting := range configSettings() {
+		if setting.name != "CONFIG_FILE" && !known[setting.name] {
+			t.Errorf("%s is not a known setting", setting.name)
+		}
+	}
+}
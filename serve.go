@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Transport configuration. TRANSPORT selects "stdio" (the default) or "sse";
+// the SSE server listens on SSE_ADDR and advertises SSE_BASE_URL to clients.
+var (
+	transport           = getenv("TRANSPORT")
+	sseAddr             = getenv("SSE_ADDR")
+	sseBaseURL          = getenv("SSE_BASE_URL")
+	shutdownGracePeriod = durationFromEnv("SHUTDOWN_GRACE_PERIOD", 10*time.Second)
+)
+
+// defaultSSEAddr is used when SSE_ADDR is not set.
+const defaultSSEAddr = ":8080"
+
+// errShuttingDown is returned for tool calls that arrive during shutdown.
+var errShuttingDown = errors.New("server is shutting down")
+
+// toolCalls tracks in-flight tool calls so shutdown can wait for them.
+var toolCalls struct {
+	sync.Mutex
+	active  int
+	closing bool
+	idle    chan struct{}
+}
+
+// beginToolCall registers a tool call, reporting false once shutdown started.
+func beginToolCall() bool {
+	toolCalls.Lock()
+	defer toolCalls.Unlock()
+	if toolCalls.closing {
+		return false
+	}
+	toolCalls.active++
+	return true
+}
+
+// endToolCall marks a tool call as finished.
+func endToolCall() {
+	toolCalls.Lock()
+	defer toolCalls.Unlock()
+	toolCalls.active--
+	if toolCalls.closing && toolCalls.active == 0 && toolCalls.idle != nil {
+		close(toolCalls.idle)
+		toolCalls.idle = nil
+	}
+}
+
+// drainToolCalls stops accepting tool calls and waits up to timeout for the
+// in-flight ones to finish. It returns the number of calls still running.
+func drainToolCalls(timeout time.Duration) int {
+	toolCalls.Lock()
+	toolCalls.closing = true
+	if toolCalls.active == 0 {
+		toolCalls.Unlock()
+		return 0
+	}
+	idle := make(chan struct{})
+	toolCalls.idle = idle
+	log.Printf("Waiting up to %s for %d in-flight tool call(s)", timeout, toolCalls.active)
+	toolCalls.Unlock()
+
+	select {
+	case <-idle:
+		return 0
+	case <-time.After(timeout):
+		toolCalls.Lock()
+		defer toolCalls.Unlock()
+		return toolCalls.active
+	}
+}
+
+// toolCallStateKey is the context key of the *toolCallState of a tool call.
+type toolCallStateKey struct{}
+
+// toolCallState collects what happened during a tool call that its result
+// must mention.
+type toolCallState struct {
+	partialSchema atomic.Bool
+}
+
+// markPartialSchema records that the tool call running with ctx used a
+// partial schema.
+func markPartialSchema(ctx context.Context) {
+	if state, ok := ctx.Value(toolCallStateKey{}).(*toolCallState); ok {
+		state.partialSchema.Store(true)
+	}
+}
+
+// addTool registers a tool whose handler is tracked for graceful shutdown and
+// traced when tracing is enabled. Calls arriving after shutdown started are
+// rejected. Successful results built from a partial schema say so.
+func addTool(srv *server.MCPServer, tool mcp.Tool, handler server.ToolHandlerFunc) {
+	srv.AddTool(tool, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !beginToolCall() {
+			return toolError(fmt.Sprintf("Failed to run %s: %v", tool.Name, errShuttingDown)), nil
+		}
+		defer endToolCall()
+		state := &toolCallState{}
+		ctx, span := startSpan(ctx, "tool "+tool.Name, spanKindServer)
+		span.setAttribute("mcp.tool.name", tool.Name)
+		result, err := handler(context.WithValue(ctx, toolCallStateKey{}, state), request)
+		if result != nil && result.IsError && len(result.Content) > 0 {
+			if text, ok := result.Content[0].(mcp.TextContent); ok {
+				span.fail(text.Text)
+			}
+		}
+		span.end(err)
+		if result != nil && !result.IsError && state.partialSchema.Load() {
+			result.Content = append(result.Content, mcp.NewTextContent(partialSchemaNote))
+			if result.Meta == nil {
+				result.Meta = make(map[string]interface{})
+			}
+			result.Meta["partialSchema"] = true
+		}
+		return result, err
+	})
+}
+
+// serve runs the MCP server on the configured transport until ctx is
+// cancelled (e.g. by SIGINT/SIGTERM), then stops accepting tool calls and
+// gives in-flight ones SHUTDOWN_GRACE_PERIOD to complete before returning.
+func serve(ctx context.Context, srv *server.MCPServer) error {
+	switch transport {
+	case "", "stdio":
+		return serveStdio(ctx, srv)
+	case "sse":
+		return serveSSE(ctx, srv)
+	}
+	return fmt.Errorf("unknown TRANSPORT %q, expected \"stdio\" or \"sse\"", transport)
+}
+
+// serveStdio serves over standard I/O. Tool calls run with a context that is
+// only cancelled once the grace period is over.
+func serveStdio(ctx context.Context, srv *server.MCPServer) error {
+	serveCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stdio := server.NewStdioServer(srv)
+	stdio.SetErrorLogger(log.New(os.Stderr, "", log.LstdFlags))
+	done := make(chan error, 1)
+	go func() { done <- stdio.Listen(serveCtx, os.Stdin, os.Stdout) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown(cancel)
+	<-done
+	log.Printf("Shutdown complete")
+	return nil
+}
+
+// serveSSE serves over HTTP with server-sent events.
+func serveSSE(ctx context.Context, srv *server.MCPServer) error {
+	addr := sseAddr
+	if addr == "" {
+		addr = defaultSSEAddr
+	}
+	baseURL := sseBaseURL
+	if baseURL == "" {
+		baseURL = "http://" + addr
+		if strings.HasPrefix(addr, ":") {
+			baseURL = "http://localhost" + addr
+		}
+	}
+
+	sse := server.NewSSEServer(srv, baseURL)
+	done := make(chan error, 1)
+	go func() { done <- sse.Start(addr) }()
+	log.Printf("Serving MCP over SSE on %s (base URL %s)", addr, baseURL)
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdown(func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := sse.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down SSE server: %v", err)
+		}
+	})
+	if err := <-done; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Printf("Shutdown complete")
+	return nil
+}
+
+// shutdown logs the shutdown sequence, drains in-flight tool calls and then
+// calls stop to tear down the transport.
+func shutdown(stop func()) {
+	log.Printf("Shutdown requested, no longer accepting tool calls")
+	if remaining := drainToolCalls(shutdownGracePeriod); remaining > 0 {
+		log.Printf("Grace period elapsed, cancelling %d tool call(s)", remaining)
+	} else {
+		log.Printf("All in-flight tool calls completed")
+	}
+	stop()
+}
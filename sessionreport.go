@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tool: session_report
+const sessionReportToolDescription = `Summarize the operations executed in this session: each distinct operation with its number of calls, how many succeeded and failed, and the response data received.
+Unlike list_history, which keeps the last calls for replay, the report covers every call since the server started. Sensitive variables (passwords, tokens, ...) are shown as [REDACTED].
+
+Best Practices:
+- Use this tool to report what was done at the end of a task, or to check which operations keep failing.
+- Operations are identified by their type and root fields, so the same query with different variables or selections counts once.
+- Use list_history for the details of the most recent calls.
+
+Example Usage:
+Request:
+  session_report()
+
+Response:
+  Since 2026-05-04T10:02:11Z: 7 calls to 3 operations, 5 succeeded and 2 failed, 2905 bytes of data received.
+
+  query candidates: 4 calls, 4 succeeded, 2410 bytes
+  	last variables: {"status":"ACTIVE"}
+  mutation updateCandidate: 2 calls, 1 succeeded, 1 failed, 495 bytes
+  	last variables: {"id":"42","token":"[REDACTED]"}
+  	last error: graphql: Candidate not found
+  query jobs: 1 call, 1 failed
+  	last error: graphql: Unknown argument "state"
+`
+
+// operationStats aggregates the calls to one operation.
+type operationStats struct {
+	Name          string
+	Calls         int
+	Succeeded     int
+	Failed        int
+	BytesReceived int
+	LastVariables map[string]interface{}
+	LastError     string
+}
+
+// session aggregates every operation executed since the server started.
+var session = struct {
+	sync.Mutex
+	started    time.Time
+	operations map[string]*operationStats
+}{started: time.Now(), operations: make(map[string]*operationStats)}
+
+// recordSession counts a call to operation in the session report.
+func recordSession(operation, operationName string, vars map[string]interface{}, res *invokeResult, callErr error) {
+	name := sessionOperationName(operation, operationName)
+
+	session.Lock()
+	defer session.Unlock()
+	stats := session.operations[name]
+	if stats == nil {
+		stats = &operationStats{Name: name}
+		session.operations[name] = stats
+	}
+	stats.Calls++
+	stats.LastVariables = redactVariables(vars)
+	if callErr != nil {
+		stats.Failed++
+		stats.LastError = truncateRunes(firstLine(callErr.Error()), maxHistorySummary)
+		return
+	}
+	stats.Succeeded++
+	stats.BytesReceived += len(res.Body)
+}
+
+// sessionOperationName identifies an operation by its type and root fields,
+// e.g. "query candidate, jobs", falling back to its name, or to its first
+// line when it doesn't parse.
+func sessionOperationName(operation, operationName string) string {
+	doc, op, err := parseSingleOperation(operation, operationName)
+	if err == nil {
+		if fields := doc.rootFields(op); len(fields) > 0 {
+			return op.Operation + " " + strings.Join(fields, ", ")
+		}
+	}
+	if operationName != "" {
+		return operationName
+	}
+	return truncateRunes(firstLine(strings.TrimSpace(operation)), maxHistorySummary)
+}
+
+// sessionReport renders the session's operations, the most called first.
+func sessionReport() (string, error) {
+	session.Lock()
+	started := session.started
+	operations := make([]operationStats, 0, len(session.operations))
+	for _, stats := range session.operations {
+		operations = append(operations, *stats)
+	}
+	session.Unlock()
+	if len(operations) == 0 {
+		return "No operation has been executed yet.", nil
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].Calls != operations[j].Calls {
+			return operations[i].Calls > operations[j].Calls
+		}
+		return operations[i].Name < operations[j].Name
+	})
+
+	calls, succeeded, failed, received := 0, 0, 0, 0
+	for _, o := range operations {
+		calls += o.Calls
+		succeeded += o.Succeeded
+		failed += o.Failed
+		received += o.BytesReceived
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Since %s: %s to %s, %d succeeded and %d failed, %s of data received.\n\n",
+		started.UTC().Format(time.RFC3339), pluralize(calls, "call", "calls"), pluralize(len(operations), "operation", "operations"),
+		succeeded, failed, pluralize(received, "byte", "bytes"))
+	for _, o := range operations {
+		outcomes := []string{pluralize(o.Calls, "call", "calls")}
+		if o.Succeeded > 0 {
+			outcomes = append(outcomes, fmt.Sprintf("%d succeeded", o.Succeeded))
+		}
+		if o.Failed > 0 {
+			outcomes = append(outcomes, fmt.Sprintf("%d failed", o.Failed))
+		}
+		if o.Succeeded > 0 {
+			outcomes = append(outcomes, pluralize(o.BytesReceived, "byte", "bytes"))
+		}
+		sb.WriteString(o.Name + ": " + strings.Join(outcomes, ", ") + "\n")
+		if len(o.LastVariables) > 0 {
+			vars, err := json.Marshal(o.LastVariables)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString("\tlast variables: " + string(vars) + "\n")
+		}
+		if o.LastError != "" {
+			sb.WriteString("\tlast error: " + o.LastError + "\n")
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Tool: simulate_mutation
+const simulateMutationToolDescription = `Preview the effects of a mutation without committing them, using the server's dry-run mode.
+The mutation is sent with the dry-run header (DRY_RUN_HEADER) and/or directive (DRY_RUN_DIRECTIVE) the server supports, so it validates and reports what would change, then rolls back.
+When no dry-run mode is configured, or the schema doesn't declare the directive, the mutation is not sent at all and the result says simulation is not supported.
+
+Best Practices:
+- Use this tool before running a destructive or bulk mutation for real with invoke_graphql.
+- The preview is only as faithful as the server's dry-run mode: side effects outside its transaction (emails, webhooks) may still be reported as if they happened.
+
+Arguments:
+- mutation (string, Required): The GraphQL mutation to simulate.
+- variables (string, Optional): A JSON-encoded object of variables.
+- operationName (string, Optional): The mutation to simulate when the document defines several.
+- timeoutMs (number, Optional): Timeout for this call in milliseconds, overriding REQUEST_TIMEOUT. Values above the server's maximum are clamped, and the response says so.
+
+Example Usage:
+Request:
+  simulate_mutation(mutation: "mutation { deleteCandidate(id: \"42\") { id } }")
+
+Response:
+  {
+    "deleteCandidate": {
+      "id": "42"
+    }
+  }
+  Simulated with the X-Dry-Run header: the server was asked not to commit the mutation.
+`
+
+// dryRunHeader ("Name: value", or "Name" to send "true") and dryRunDirective
+// (e.g. "dryRun") tell the server to run a mutation without committing it.
+var (
+	dryRunHeader    = getenv("DRY_RUN_HEADER")
+	dryRunDirective = strings.TrimPrefix(strings.TrimSpace(getenv("DRY_RUN_DIRECTIVE")), "@")
+)
+
+// errSimulationNotSupported is returned instead of running a mutation that
+// can't be simulated.
+var errSimulationNotSupported = errors.New("simulation not supported")
+
+// simulation describes how a mutation is sent in dry-run mode.
+type simulation struct {
+	Query  string
+	Header http.Header
+	Note   string
+}
+
+// prepareSimulation adds the dry-run directive to the selected mutation of
+// query and returns the dry-run header. It fails with
+// errSimulationNotSupported when the server can't be asked for a dry run.
+func prepareSimulation(ctx context.Context, query, operationName string) (*simulation, error) {
+	if dryRunHeader == "" && dryRunDirective == "" {
+		return nil, fmt.Errorf("%w: no dry-run mode is configured; set DRY_RUN_HEADER or DRY_RUN_DIRECTIVE to what the server supports. The mutation was not sent", errSimulationNotSupported)
+	}
+	doc, err := parseDocument(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse operation: %w", err)
+	}
+	var op *astOperation
+	for _, o := range doc.Operations {
+		if o.Name == operationName || len(doc.Operations) == 1 {
+			op = o
+		}
+	}
+	if op == nil || op.Operation != "mutation" {
+		return nil, fmt.Errorf("only mutations can be simulated; run queries with invoke_graphql")
+	}
+
+	sim := &simulation{Query: query, Header: make(http.Header)}
+	var via []string
+	if dryRunDirective != "" {
+		schema, err := getSchema(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("checking that the schema declares @%s: %w", dryRunDirective, err)
+		}
+		if !schemaAllowsDirective(schema, dryRunDirective, "MUTATION") {
+			return nil, fmt.Errorf("%w: the schema doesn't declare @%s on mutations. The mutation was not sent", errSimulationNotSupported, dryRunDirective)
+		}
+		op.Directives = append(op.Directives, &astDirective{Name: dryRunDirective})
+		sim.Query = printDocument(doc)
+		via = append(via, "the @"+dryRunDirective+" directive")
+	}
+	if dryRunHeader != "" {
+		name, value, ok := strings.Cut(dryRunHeader, ":")
+		if value = strings.TrimSpace(value); !ok || value == "" {
+			value = "true"
+		}
+		name = strings.TrimSpace(name)
+		sim.Header.Set(name, value)
+		via = append(via, "the "+http.CanonicalHeaderKey(name)+" header")
+	}
+	sim.Note = "Simulated with " + strings.Join(via, " and ") + ": the server was asked not to commit the mutation."
+	return sim, nil
+}
+
+// schemaAllowsDirective reports whether the schema declares the directive
+// with the given location.
+func schemaAllowsDirective(schema *schemaModel, name, location string) bool {
+	for _, d := range schema.Directives {
+		if d.Name != name {
+			continue
+		}
+		for _, l := range d.Locations {
+			if l == location {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tool: schema_stats
+const schemaStatsToolDescription = `Summarize the size of your GraphQL schema with counts of its operations, types and directives.
+This tool gives a quick sense of the API's scale before diving into details.
+
+Best Practices:
+- Use this tool first on an unfamiliar API to decide how to explore it.
+- For small schemas, listing queries and mutations is usually enough; for large ones, describe specific entities instead.
+
+Arguments:
+- None
+
+Example Usage:
+Request:
+  schema_stats()
+
+Response:
+  Schema Stats:
+  Queries: 12
+  Mutations: 8
+  Subscriptions: 1
+  Object Types: 40
+  Input Types: 15
+  Enums: 6
+  Interfaces: 2
+  Unions: 1
+  Scalars: 7
+  Directives: 4
+
+  Largest Types (by field count):
+  Candidate (OBJECT): 42 fields
+  Job (OBJECT): 30 fields
+  CandidateInput (INPUT_OBJECT): 18 fields
+`
+
+// largestTypesLimit is the number of types reported in the "Largest Types" section.
+const largestTypesLimit = 5
+
+// getSchemaStats computes counts of the schema's operations, types and
+// directives from the schema model and formats them as a string.
+// Built-in introspection types (those starting with "__") and the root
+// operation types are not counted as object types.
+func getSchemaStats(ctx context.Context) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	kinds := make(map[string]int)
+	type typeSize struct {
+		name, kind string
+		fields     int
+	}
+	var sizes []typeSize
+	for _, typ := range schema.Types {
+		if strings.HasPrefix(typ.Name, "__") {
+			continue
+		}
+		if schema.isRootType(typ.Name) {
+			continue
+		}
+		kinds[typ.Kind]++
+		if n := len(typ.Fields) + len(typ.InputFields); n > 0 {
+			sizes = append(sizes, typeSize{name: typ.Name, kind: typ.Kind, fields: n})
+		}
+	}
+	sort.SliceStable(sizes, func(i, j int) bool {
+		if sizes[i].fields != sizes[j].fields {
+			return sizes[i].fields > sizes[j].fields
+		}
+		return sizes[i].name < sizes[j].name
+	})
+	if len(sizes) > largestTypesLimit {
+		sizes = sizes[:largestTypesLimit]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Schema Stats:\n")
+	fmt.Fprintf(&sb, "Queries: %d\n", len(schema.queries()))
+	fmt.Fprintf(&sb, "Mutations: %d\n", len(schema.mutations()))
+	fmt.Fprintf(&sb, "Subscriptions: %d\n", len(schema.subscriptions()))
+	fmt.Fprintf(&sb, "Object Types: %d\n", kinds["OBJECT"])
+	fmt.Fprintf(&sb, "Input Types: %d\n", kinds["INPUT_OBJECT"])
+	fmt.Fprintf(&sb, "Enums: %d\n", kinds["ENUM"])
+	fmt.Fprintf(&sb, "Interfaces: %d\n", kinds["INTERFACE"])
+	fmt.Fprintf(&sb, "Unions: %d\n", kinds["UNION"])
+	fmt.Fprintf(&sb, "Scalars: %d\n", kinds["SCALAR"])
+	fmt.Fprintf(&sb, "Directives: %d\n", len(schema.Directives))
+
+	if len(sizes) > 0 {
+		sb.WriteString("\nLargest Types (by field count):\n")
+		for _, s := range sizes {
+			fmt.Fprintf(&sb, "%s (%s): %d fields\n", s.name, s.kind, s.fields)
+		}
+	}
+	return sb.String(), nil
+}
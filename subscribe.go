@@ -0,0 +1,272 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Tool: subscribe
+const subscribeToolDescription = `Run a GraphQL subscription over WebSocket and collect the events it produces.
+
+Best Practices:
+- Use this tool to observe real-time events exposed by the schema's subscriptions.
+- Keep 'maxMessages' and 'maxDurationSeconds' small; the tool returns when either limit is reached or the server completes the subscription.
+- Authentication uses the current headers, which are sent both with the WebSocket upgrade and in the connection_init payload.
+- To watch for events while doing other work, pass background: true and call unsubscribe with the returned ID to stop the subscription and get its events.
+
+Arguments:
+- subscription (string, Required): The entire GraphQL subscription text.
+- variables (string, Optional): A JSON-encoded string representing variables for the subscription; string values may reference secrets as "${env:NAME}", as with invoke_graphql.
+- maxMessages (number, Optional): Stop after this many events. Defaults to 10.
+- maxDurationSeconds (number, Optional): Stop after this many seconds. Defaults to 30.
+- background (boolean, Optional): Return a subscription ID right away and keep collecting events until unsubscribe is called or a limit is reached.
+
+Example Usage:
+Request:
+  subscribe(
+	subscription: "subscription { candidateCreated { id name } }",
+	maxMessages: 2
+  )
+
+Response:
+  [
+	{ "data": { "candidateCreated": { "id": "123", "name": "John Doe" } } },
+	{ "data": { "candidateCreated": { "id": "124", "name": "Jane Doe" } } }
+  ]
+`
+
+// Subscription limits used when the caller doesn't provide them.
+const (
+	defaultSubscriptionMessages = 10
+	defaultSubscriptionDuration = 30 * time.Second
+)
+
+// WebSocket subprotocols understood by runSubscription, in order of preference.
+const (
+	protocolGraphQLTransportWS = "graphql-transport-ws"
+	protocolGraphQLWS          = "graphql-ws"
+)
+
+// subscriptionsEndpoint is the WebSocket URL used for subscriptions. It
+// defaults to ADDRESS with its scheme switched to ws:// or wss://.
+var subscriptionsEndpoint = getenv("SUBSCRIPTIONS_ADDRESS")
+
+// wsMessage is a message of the graphql-transport-ws and graphql-ws protocols.
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// subscriptionRequest is a subscription ready to be started: its variables
+// with secret references resolved, and a snapshot of the headers, so that a
+// running subscription isn't affected when the headers change.
+type subscriptionRequest struct {
+	operation string
+	variables map[string]interface{}
+	// secrets are the resolved secret values, scrubbed from the events
+	secrets []string
+	headers http.Header
+}
+
+// newSubscriptionRequest checks operation and its variables, resolves their
+// "${env:NAME}" references and takes the current headers.
+func newSubscriptionRequest(operation, variablesJSON string) (*subscriptionRequest, error) {
+	if err := checkOperationAllowed(operation); err != nil {
+		return nil, err
+	}
+	vars, err := parseVariables(variablesJSON)
+	if err != nil {
+		return nil, err
+	}
+	sendVars, secrets, err := resolveSecretReferences(vars)
+	if err != nil {
+		return nil, err
+	}
+	return &subscriptionRequest{operation: operation, variables: sendVars, secrets: secrets, headers: getHeaders()}, nil
+}
+
+// runSubscription starts a subscription and collects up to maxMessages events
+// for at most maxDuration, returning them as a JSON array. The WebSocket is
+// closed when the subscription ends or ctx is cancelled.
+func runSubscription(ctx context.Context, operation, variablesJSON string, maxMessages int, maxDuration time.Duration) (string, error) {
+	req, err := newSubscriptionRequest(operation, variablesJSON)
+	if err != nil {
+		return "", err
+	}
+	events := []json.RawMessage{}
+	err = streamSubscription(ctx, req, maxMessages, maxDuration, func(event json.RawMessage) {
+		events = append(events, event)
+	})
+	if err != nil {
+		return "", err
+	}
+	return marshalEvents(events)
+}
+
+// streamSubscription runs a subscription, passing each event to onEvent,
+// until maxMessages events were received, maxDuration elapsed or the server
+// completed it. It returns ctx.Err() when ctx is cancelled first. The
+// WebSocket is closed before it returns.
+func streamSubscription(ctx context.Context, req *subscriptionRequest, maxMessages int, maxDuration time.Duration, onEvent func(json.RawMessage)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	wsURL, err := subscriptionURL()
+	if err != nil {
+		return err
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, maxDuration)
+	defer cancel()
+
+	headers := make(http.Header)
+	setUserAgent(headers)
+	for k, v := range req.headers {
+		headers[k] = v
+	}
+	if err := applyConfiguredAuth(runCtx, headers, wsURL); err != nil {
+		return err
+	}
+	conn, err := dialWebSocket(runCtx, wsURL, headers, []string{protocolGraphQLTransportWS, protocolGraphQLWS})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Read messages in the background so we can also wait on the context
+	messages := make(chan wsMessage)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			data, err := conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			var msg wsMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				readErr <- fmt.Errorf("invalid subscription message: %w", err)
+				return
+			}
+			select {
+			case messages <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	send := func(msg interface{}) error {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		return conn.WriteText(data)
+	}
+
+	// Authenticate with the current headers in the connection_init payload
+	initPayload := make(map[string]string)
+	for k := range headers {
+		initPayload[k] = headers.Get(k)
+	}
+	if err := send(map[string]interface{}{"type": "connection_init", "payload": initPayload}); err != nil {
+		return err
+	}
+
+	legacy := conn.protocol == protocolGraphQLWS
+	startType, stopType := "subscribe", "complete"
+	if legacy {
+		startType, stopType = "start", "stop"
+	}
+	const subscriptionID = "1"
+
+	// stop tells the server to stop the subscription before the socket is
+	// closed; it is best effort
+	stop := func() {
+		send(map[string]string{"id": subscriptionID, "type": stopType})
+	}
+
+	received := 0
+	acked := false
+	for received < maxMessages {
+		select {
+		case <-runCtx.Done():
+			if ctx.Err() != nil {
+				if acked {
+					stop()
+				}
+				return ctx.Err()
+			}
+			if !acked {
+				return fmt.Errorf("timed out waiting for connection_ack")
+			}
+			// Max duration reached: keep what was collected so far
+			stop()
+			return nil
+		case err := <-readErr:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		case msg := <-messages:
+			switch msg.Type {
+			case "connection_ack":
+				acked = true
+				payload := map[string]interface{}{"query": req.operation}
+				if len(req.variables) > 0 {
+					payload["variables"] = req.variables
+				}
+				if err := send(map[string]interface{}{"id": subscriptionID, "type": startType, "payload": payload}); err != nil {
+					return err
+				}
+			case "ping":
+				if err := send(map[string]string{"type": "pong"}); err != nil {
+					return err
+				}
+			case "next", "data":
+				received++
+				onEvent(scrubSecretsJSON(msg.Payload, req.secrets))
+			case "complete":
+				return nil
+			case "error", "connection_error":
+				return fmt.Errorf("subscription error: %s", scrubSecretsJSON(msg.Payload, req.secrets))
+			}
+		}
+	}
+	stop()
+	return nil
+}
+
+// marshalEvents formats the collected events as a pretty JSON array.
+func marshalEvents(events []json.RawMessage) (string, error) {
+	resBytes, err := json.MarshalIndent(events, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(resBytes), nil
+}
+
+// subscriptionURL returns the WebSocket endpoint for subscriptions.
+func subscriptionURL() (string, error) {
+	if subscriptionsEndpoint != "" {
+		return subscriptionsEndpoint, nil
+	}
+	u, err := url.Parse(graphqlEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid ADDRESS: %w", err)
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsTestConn is the server side of a WebSocket connection opened by
+// newWebSocketTestServer. It reads the client's masked frames with the
+// client's own frame reader and writes unmasked frames, as servers do.
+type wsTestConn struct {
+	*wsConn
+}
+
+// writeServerFrame sends a single unmasked frame.
+func (c *wsTestConn) writeServerFrame(fin bool, opcode byte, payload []byte) error {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	header := []byte{first}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	_, err := c.conn.Write(append(header, payload...))
+	return err
+}
+
+// readJSON decodes the next message from the client into v.
+func (c *wsTestConn) readJSON(v interface{}) error {
+	data, err := c.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON sends v as a text message.
+func (c *wsTestConn) writeJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeServerFrame(true, wsOpText, data)
+}
+
+// newWebSocketTestServer starts a server completing the WebSocket handshake
+// with protocol and handing each connection to handle, and points
+// subscriptions at it.
+func newWebSocketTestServer(t *testing.T, protocol string, handle func(r *http.Request, c *wsTestConn)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+			http.Error(w, "not a websocket upgrade", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		sum := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + wsAcceptGUID))
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n")
+		if protocol != "" {
+			rw.WriteString("Sec-WebSocket-Protocol: " + protocol + "\r\n")
+		}
+		rw.WriteString("\r\n")
+		if err := rw.Flush(); err != nil {
+			t.Error(err)
+			return
+		}
+		handle(r, &wsTestConn{&wsConn{conn: conn, br: bufio.NewReader(rw)}})
+	}))
+	t.Cleanup(srv.Close)
+
+	previous := subscriptionsEndpoint
+	subscriptionsEndpoint = "ws://" + strings.TrimPrefix(srv.URL, "http://")
+	t.Cleanup(func() { subscriptionsEndpoint = previous })
+	return srv
+}
+
+// TestBackgroundSubscriptionHeadersAndSecrets checks that a background
+// subscription keeps the headers in use when it started, and that its
+// secret references are resolved when sent and scrubbed from its events.
+func TestBackgroundSubscriptionHeadersAndSecrets(t *testing.T) {
+	previousPrefix := secretEnvPrefix
+	secretEnvPrefix = "TEST_SECRET_"
+	t.Cleanup(func() { secretEnvPrefix = previousPrefix })
+	t.Setenv("TEST_SECRET_TOKEN", "s3cret")
+	if err := setHeaders(`{"Authorization": "Bearer first"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	type received struct {
+		authorization string
+		token         interface{}
+	}
+	got := make(chan received, 1)
+	newWebSocketTestServer(t, protocolGraphQLTransportWS, func(r *http.Request, c *wsTestConn) {
+		var init struct {
+			Payload map[string]string `json:"payload"`
+		}
+		if err := c.readJSON(&init); err != nil {
+			t.Error(err)
+			return
+		}
+		c.writeJSON(map[string]string{"type": "connection_ack"})
+		var subscribe struct {
+			Payload struct {
+				Variables map[string]interface{} `json:"variables"`
+			} `json:"payload"`
+		}
+		if err := c.readJSON(&subscribe); err != nil {
+			t.Error(err)
+			return
+		}
+		got <- received{init.Payload["Authorization"], subscribe.Payload.Variables["token"]}
+		c.writeJSON(map[string]interface{}{"id": "1", "type": "next", "payload": map[string]interface{}{"data": map[string]string{"echo": "s3cret"}}})
+		// Wait for the client to close the connection
+		for {
+			if _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	})
+
+	id, err := startBackgroundSubscription(`subscription ($token: String) { echo(token: $token) }`, `{"token": "${env:TEST_SECRET_TOKEN}"}`, 1, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Changing the headers doesn't affect the running subscription
+	if err := setHeaders(`{"Authorization": "Bearer second"}`); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case r := <-got:
+		if r.authorization != "Bearer first" {
+			t.Errorf("connection_init Authorization = %q, want %q", r.authorization, "Bearer first")
+		}
+		if r.token != "s3cret" {
+			t.Errorf("token variable = %v, want the resolved secret", r.token)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the server didn't receive the subscription")
+	}
+
+	subscriptions.Lock()
+	sub := subscriptions.byID[id]
+	subscriptions.Unlock()
+	select {
+	case <-sub.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscription didn't end after maxMessages")
+	}
+	report, err := stopBackgroundSubscription(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(report, "s3cret") || !strings.Contains(report, redactedSecret) {
+		t.Errorf("the secret wasn't scrubbed from the events:\n%s", report)
+	}
+}
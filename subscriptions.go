@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tool: unsubscribe
+const unsubscribeToolDescription = `Stop a subscription started with subscribe(background: true) and return the events it collected.
+The WebSocket is closed and the subscription is forgotten, so each ID can be unsubscribed once.
+
+Best Practices:
+- Unsubscribe as soon as you have seen enough events; background subscriptions otherwise keep their connection open until maxMessages or maxDurationSeconds is reached.
+- Subscriptions that already ended (limit reached, completed by the server or failed) can still be unsubscribed to collect their events and how they ended.
+
+Arguments:
+- id (string, Required): The subscription ID returned by subscribe, e.g. sub-1.
+
+Example Usage:
+Request:
+  unsubscribe(id: "sub-1")
+
+Response:
+  Subscription sub-1 stopped after 1 event.
+  [
+	{ "data": { "candidateCreated": { "id": "123", "name": "John Doe" } } }
+  ]
+`
+
+// backgroundSubscription is a subscription started with subscribe(background:
+// true), running until it ends on its own or is unsubscribed.
+type backgroundSubscription struct {
+	ID     string
+	cancel context.CancelFunc
+	// done is closed once the WebSocket is closed.
+	done chan struct{}
+
+	mu     sync.Mutex
+	events []json.RawMessage
+	err    error
+}
+
+// subscriptions is the registry of background subscriptions, keyed by ID.
+// Entries are removed when unsubscribed, whether or not they already ended.
+var subscriptions = struct {
+	sync.Mutex
+	byID   map[string]*backgroundSubscription
+	nextID int
+}{byID: map[string]*backgroundSubscription{}}
+
+// startBackgroundSubscription starts a subscription that outlives the tool
+// call and returns its ID. The operation and variables are checked first so
+// that mistakes are reported right away rather than on unsubscribe, and the
+// headers are taken now, so later changes don't affect the subscription.
+func startBackgroundSubscription(operation, variablesJSON string, maxMessages int, maxDuration time.Duration) (string, error) {
+	req, err := newSubscriptionRequest(operation, variablesJSON)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub := &backgroundSubscription{cancel: cancel, done: make(chan struct{})}
+	subscriptions.Lock()
+	subscriptions.nextID++
+	sub.ID = fmt.Sprintf("sub-%d", subscriptions.nextID)
+	subscriptions.byID[sub.ID] = sub
+	subscriptions.Unlock()
+
+	go func() {
+		defer close(sub.done)
+		defer cancel()
+		err := streamSubscription(ctx, req, maxMessages, maxDuration, func(event json.RawMessage) {
+			sub.mu.Lock()
+			sub.events = append(sub.events, event)
+			sub.mu.Unlock()
+		})
+		sub.mu.Lock()
+		sub.err = err
+		sub.mu.Unlock()
+		debugf("background subscription %s ended: %v", sub.ID, err)
+	}()
+	return sub.ID, nil
+}
+
+// stopBackgroundSubscription cancels the subscription with the given ID,
+// waits for its WebSocket to be closed and returns the events it collected,
+// noting whether it had already ended.
+func stopBackgroundSubscription(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	subscriptions.Lock()
+	sub := subscriptions.byID[id]
+	delete(subscriptions.byID, id)
+	active := make([]string, 0, len(subscriptions.byID))
+	for other := range subscriptions.byID {
+		active = append(active, other)
+	}
+	subscriptions.Unlock()
+	if sub == nil {
+		if len(active) == 0 {
+			return "", fmt.Errorf("no subscription %q: there are no background subscriptions; it may already have been unsubscribed", id)
+		}
+		sort.Strings(active)
+		return "", fmt.Errorf("no subscription %q, the background subscriptions are: %s", id, strings.Join(active, ", "))
+	}
+
+	alreadyEnded := false
+	select {
+	case <-sub.done:
+		alreadyEnded = true
+	default:
+		sub.cancel()
+		<-sub.done
+	}
+
+	sub.mu.Lock()
+	events, err := sub.events, sub.err
+	sub.mu.Unlock()
+	if events == nil {
+		events = []json.RawMessage{}
+	}
+	body, marshalErr := marshalEvents(events)
+	if marshalErr != nil {
+		return "", marshalErr
+	}
+	count := pluralize(len(events), "event", "events")
+	var status string
+	switch {
+	case !alreadyEnded:
+		status = fmt.Sprintf("Subscription %s stopped after %s.", id, count)
+	case err != nil && !errors.Is(err, context.Canceled):
+		status = fmt.Sprintf("Subscription %s had already ended with an error after %s: %v", id, count, err)
+	default:
+		status = fmt.Sprintf("Subscription %s had already ended after %s.", id, count)
+	}
+	return status + "\n" + body, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the number of "did you mean" candidates returned.
+const maxSuggestions = 3
+
+// suggestNames returns up to limit candidates closest to target by edit
+// distance, ignoring case. Ties are broken alphabetically.
+func suggestNames(target string, candidates []string, limit int) []string {
+	type scored struct {
+		name     string
+		distance int
+	}
+	target = strings.ToLower(target)
+	scores := make([]scored, 0, len(candidates))
+	for _, c := range candidates {
+		scores = append(scores, scored{c, editDistance(target, strings.ToLower(c))})
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].distance != scores[j].distance {
+			return scores[i].distance < scores[j].distance
+		}
+		return scores[i].name < scores[j].name
+	})
+
+	var names []string
+	for _, s := range scores {
+		if len(names) == limit {
+			break
+		}
+		names = append(names, s.name)
+	}
+	return names
+}
+
+// editDistance computes the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Operation timeouts. REQUEST_TIMEOUT bounds every invoke_graphql and
+// run_named_query call; an invoke_graphql call may ask for a different
+// timeout with timeoutMs, up to MAX_REQUEST_TIMEOUT.
+var (
+	defaultRequestTimeout = durationFromEnv("REQUEST_TIMEOUT", 60*time.Second)
+	maxRequestTimeout     = durationFromEnv("MAX_REQUEST_TIMEOUT", 10*time.Minute)
+)
+
+// requestTimeout returns the timeout for a call asking for timeoutMs
+// milliseconds (0 for the default), and a note when the value was clamped to
+// MAX_REQUEST_TIMEOUT.
+func requestTimeout(timeoutMs float64) (time.Duration, string) {
+	if timeoutMs <= 0 {
+		return defaultRequestTimeout, ""
+	}
+	timeout := time.Duration(timeoutMs * float64(time.Millisecond))
+	if maxRequestTimeout > 0 && timeout > maxRequestTimeout {
+		return maxRequestTimeout, fmt.Sprintf("Note: timeoutMs %.0f exceeds the maximum of %d, so the call used a timeout of %s.", timeoutMs, maxRequestTimeout.Milliseconds(), maxRequestTimeout)
+	}
+	return timeout, ""
+}
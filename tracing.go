@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracing is configured with the standard OpenTelemetry variables: spans are
+// exported with OTLP over HTTP (JSON encoding) to
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, or to OTEL_EXPORTER_OTLP_ENDPOINT plus
+// "/v1/traces". Tracing is off when neither is set, when OTEL_TRACES_EXPORTER
+// is "none" or when OTEL_SDK_DISABLED is true. Other OTLP protocols than
+// http/json stop the server at startup, since the collector would reject
+// every export.
+var (
+	otlpTracesEndpoint, otlpConfigErr = otlpEndpointFromEnv()
+	otlpHeaders                       = otlpHeadersFromEnv()
+	otlpTimeout                       = time.Duration(intFromEnv("OTEL_EXPORTER_OTLP_TIMEOUT", 10000)) * time.Millisecond
+	otelResource                      = otelResourceAttributes()
+)
+
+// otlpClient posts spans to the collector, giving up after
+// OTEL_EXPORTER_OTLP_TIMEOUT even on a collector that stops responding.
+var otlpClient = &http.Client{Timeout: otlpTimeout}
+
+// tracingEnabled reports whether spans are recorded and exported.
+var tracingEnabled = otlpTracesEndpoint != ""
+
+// otlpEndpointFromEnv returns the URL spans are posted to, or "" when
+// tracing is disabled. It fails when tracing is enabled with an OTLP protocol
+// other than http/json.
+func otlpEndpointFromEnv() (string, error) {
+	if boolFromEnv("OTEL_SDK_DISABLED") {
+		return "", nil
+	}
+	switch exporter := strings.TrimSpace(getenv("OTEL_TRACES_EXPORTER")); exporter {
+	case "", "otlp":
+	case "none":
+		return "", nil
+	default:
+		log.Printf("Warning: OTEL_TRACES_EXPORTER %q is not supported, only \"otlp\"; tracing is disabled", exporter)
+		return "", nil
+	}
+	var endpoint string
+	if traces := strings.TrimSpace(getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")); traces != "" {
+		endpoint = traces
+	} else if base := strings.TrimSpace(getenv("OTEL_EXPORTER_OTLP_ENDPOINT")); base != "" {
+		endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+	} else {
+		return "", nil
+	}
+	for _, name := range []string{"OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"} {
+		protocol := strings.TrimSpace(getenv(name))
+		if protocol == "" {
+			continue
+		}
+		if protocol != "http/json" {
+			return "", fmt.Errorf("invalid %s %q: spans can only be exported as OTLP over HTTP with the JSON encoding; set it to http/json and point the endpoint at the collector's OTLP/HTTP receiver (port 4318 by default), or unset the endpoint to disable tracing", name, protocol)
+		}
+		break
+	}
+	return endpoint, nil
+}
+
+// otlpHeadersFromEnv parses the "key=value,key2=value2" headers sent with
+// every export, with URL-encoded values.
+func otlpHeadersFromEnv() map[string]string {
+	raw := stringFromEnv("OTEL_EXPORTER_OTLP_TRACES_HEADERS", getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
+// Span kinds, as numbered by OTLP.
+const (
+	spanKindServer = 2
+	spanKindClient = 3
+)
+
+// span is an operation being traced. A nil *span is a no-op, which is what
+// startSpan returns when tracing is disabled.
+type span struct {
+	traceID    [16]byte
+	spanID     [8]byte
+	parentID   [8]byte
+	name       string
+	kind       int
+	start      time.Time
+	attributes []spanAttribute
+	failed     bool
+	message    string
+}
+
+// spanAttribute is a string or integer attribute of a span.
+type spanAttribute struct {
+	Key   string
+	Value interface{}
+}
+
+type spanKey struct{}
+
+// startSpan starts a span as a child of the span in ctx, if any, and returns
+// a context carrying it. The span must be ended with end.
+func startSpan(ctx context.Context, name string, kind int) (context.Context, *span) {
+	if !tracingEnabled {
+		return ctx, nil
+	}
+	s := &span{name: name, kind: kind, start: time.Now()}
+	if parent, ok := ctx.Value(spanKey{}).(*span); ok && parent != nil {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		_, _ = rand.Read(s.traceID[:])
+	}
+	_, _ = rand.Read(s.spanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// setAttribute records a string or integer attribute.
+func (s *span) setAttribute(key string, value interface{}) {
+	if s != nil {
+		s.attributes = append(s.attributes, spanAttribute{key, value})
+	}
+}
+
+// fail marks the span as failed with message.
+func (s *span) fail(message string) {
+	if s != nil {
+		s.failed, s.message = true, message
+	}
+}
+
+// end ends the span, marking it failed when err is set, and queues it for
+// export.
+func (s *span) end(err error) {
+	if s == nil {
+		return
+	}
+	if err != nil {
+		s.fail(err.Error())
+	}
+	spanExporter.add(s.export(time.Now()))
+}
+
+// injectTraceContext sets the W3C traceparent header of an outbound request
+// to the span in ctx, so the endpoint's spans join the same trace.
+func injectTraceContext(ctx context.Context, h http.Header) {
+	if s, ok := ctx.Value(spanKey{}).(*span); ok && s != nil {
+		h.Set("traceparent", "00-"+hex.EncodeToString(s.traceID[:])+"-"+hex.EncodeToString(s.spanID[:])+"-01")
+	}
+}
+
+// startGraphQLSpan starts the client span of a request to the endpoint,
+// named after the operation as in "query Jobs".
+func startGraphQLSpan(ctx context.Context, query, operationName string) (context.Context, *span) {
+	if !tracingEnabled {
+		return ctx, nil
+	}
+	operationType := "query"
+	if _, op, err := parseSingleOperation(query, operationName); err == nil {
+		operationType, operationName = op.Operation, op.Name
+	}
+	name := operationType
+	if operationName != "" {
+		name += " " + operationName
+	}
+	ctx, s := startSpan(ctx, name, spanKindClient)
+	s.setAttribute("graphql.operation.type", operationType)
+	if operationName != "" {
+		s.setAttribute("graphql.operation.name", operationName)
+	}
+	s.setAttribute("http.request.method", http.MethodPost)
+	s.setAttribute("url.full", graphqlEndpoint)
+	if u, err := url.Parse(graphqlEndpoint); err == nil {
+		s.setAttribute("server.address", u.Hostname())
+	}
+	return ctx, s
+}
+
+// otlpSpan is a span in the OTLP JSON encoding.
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpStatus struct {
+	// Code is 0 (unset) or 2 (error)
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// export converts the span, ended at end, to its OTLP encoding.
+func (s *span) export(end time.Time) otlpSpan {
+	out := otlpSpan{
+		TraceID:           hex.EncodeToString(s.traceID[:]),
+		SpanID:            hex.EncodeToString(s.spanID[:]),
+		Name:              s.name,
+		Kind:              s.kind,
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Attributes:        otlpAttributes(s.attributes),
+	}
+	if s.parentID != [8]byte{} {
+		out.ParentSpanID = hex.EncodeToString(s.parentID[:])
+	}
+	if s.failed {
+		out.Status = otlpStatus{Code: 2, Message: s.message}
+	}
+	return out
+}
+
+// otlpAttributes encodes attributes; integers are strings in OTLP JSON.
+func otlpAttributes(attributes []spanAttribute) []otlpAttribute {
+	out := make([]otlpAttribute, 0, len(attributes))
+	for _, a := range attributes {
+		switch v := a.Value.(type) {
+		case int:
+			out = append(out, otlpAttribute{a.Key, map[string]interface{}{"intValue": strconv.Itoa(v)}})
+		case bool:
+			out = append(out, otlpAttribute{a.Key, map[string]interface{}{"boolValue": v}})
+		default:
+			out = append(out, otlpAttribute{a.Key, map[string]interface{}{"stringValue": fmt.Sprint(v)}})
+		}
+	}
+	return out
+}
+
+// Batching of exported spans: a batch is sent when it is full or when the
+// oldest span has waited otlpExportDelay. At most otlpMaxQueue spans are
+// buffered; more are dropped while the collector is unreachable.
+const (
+	otlpBatchSize   = 512
+	otlpExportDelay = 5 * time.Second
+	otlpMaxQueue    = 2048
+)
+
+// spanExporter batches ended spans and posts them to the collector.
+var spanExporter = &otlpExporter{}
+
+type otlpExporter struct {
+	mu      sync.Mutex
+	queue   []otlpSpan
+	timer   *time.Timer
+	dropped int
+	// sending serializes exports, so batches arrive in order
+	sending sync.Mutex
+}
+
+// add queues a span and schedules an export.
+func (e *otlpExporter) add(s otlpSpan) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.queue) >= otlpMaxQueue {
+		e.dropped++
+		return
+	}
+	e.queue = append(e.queue, s)
+	switch {
+	case len(e.queue) >= otlpBatchSize:
+		go e.flush(context.Background())
+	case e.timer == nil:
+		e.timer = time.AfterFunc(otlpExportDelay, func() { e.flush(context.Background()) })
+	}
+}
+
+// flush exports every queued span.
+func (e *otlpExporter) flush(ctx context.Context) {
+	e.sending.Lock()
+	defer e.sending.Unlock()
+	for {
+		e.mu.Lock()
+		if e.timer != nil {
+			e.timer.Stop()
+			e.timer = nil
+		}
+		n := min(len(e.queue), otlpBatchSize)
+		batch := e.queue[:n:n]
+		e.queue = e.queue[n:]
+		dropped := e.dropped
+		e.dropped = 0
+		e.mu.Unlock()
+
+		if dropped > 0 {
+			log.Printf("Warning: dropped %s, the export queue was full", pluralize(dropped, "span", "spans"))
+		}
+		if len(batch) == 0 {
+			return
+		}
+		if err := postSpans(ctx, batch); err != nil {
+			log.Printf("Warning: failed to export %s to %s: %v", pluralize(len(batch), "span", "spans"), otlpTracesEndpoint, err)
+		}
+	}
+}
+
+// postSpans sends a batch of spans to the collector.
+func postSpans(ctx context.Context, spans []otlpSpan) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": otlpAttributes(otelResource)},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]string{"name": "github.com/wricardo/graphql-mcp", "version": serverVersion},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, otlpTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, otlpTracesEndpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setUserAgent(req.Header)
+	for k, v := range otlpHeaders {
+		req.Header.Set(k, v)
+	}
+	res, err := otlpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if !isSuccessStatus(res.StatusCode) {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("collector returned %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// otelResourceAttributes describes this process: OTEL_RESOURCE_ATTRIBUTES
+// ("key=value,key2=value2"), the service version and the service name, which
+// OTEL_SERVICE_NAME sets and defaults to graphql-mcp.
+func otelResourceAttributes() []spanAttribute {
+	serviceName := "graphql-mcp"
+	var attributes []spanAttribute
+	for _, pair := range strings.Split(getenv("OTEL_RESOURCE_ATTRIBUTES"), ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			continue
+		}
+		if decoded, err := url.QueryUnescape(strings.TrimSpace(value)); err == nil {
+			value = decoded
+		}
+		if key == "service.name" {
+			serviceName = strings.TrimSpace(value)
+			continue
+		}
+		attributes = append(attributes, spanAttribute{key, strings.TrimSpace(value)})
+	}
+	if name := strings.TrimSpace(getenv("OTEL_SERVICE_NAME")); name != "" {
+		serviceName = name
+	}
+	return append([]spanAttribute{{"service.name", serviceName}, {"service.version", serverVersion}}, attributes...)
+}
+
+// shutdownTracing exports the spans still queued, before the process exits.
+func shutdownTracing() {
+	if !tracingEnabled {
+		return
+	}
+	spanExporter.flush(context.Background())
+}
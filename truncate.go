@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// maxResponseBytes caps the size of the JSON returned by invoke_graphql. A
+// value of 0 disables the limit.
+var maxResponseBytes = intFromEnv("MAX_RESPONSE_BYTES", 0)
+
+// largestFieldsReported is the number of fields listed as narrowing hints.
+const largestFieldsReported = 3
+
+// responseTruncation describes a response cut to maxResponseBytes.
+type responseTruncation struct {
+	TotalBytes int `json:"totalBytes"`
+	LimitBytes int `json:"limitBytes"`
+	// LargestFields lists the biggest fields of the response with their
+	// size, as hints for narrowing the selection.
+	LargestFields []string `json:"largestFields,omitempty"`
+}
+
+// truncateResponse cuts body to limit bytes (on a UTF-8 boundary) and appends
+// a marker. It returns nil truncation details when body fits.
+func truncateResponse(body string, data interface{}, limit int) (string, *responseTruncation) {
+	if limit <= 0 || len(body) <= limit {
+		return body, nil
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	t := &responseTruncation{
+		TotalBytes:    len(body),
+		LimitBytes:    limit,
+		LargestFields: largestFields(data, 2),
+	}
+	marker := fmt.Sprintf("\n... [truncated: response is %d bytes, limit is %d bytes]", t.TotalBytes, t.LimitBytes)
+	return body[:cut] + marker, t
+}
+
+// largestFields returns the biggest object fields of data, up to depth levels
+// deep, formatted as "path (N bytes)".
+func largestFields(data interface{}, depth int) []string {
+	type sized struct {
+		path string
+		size int
+	}
+	var fields []sized
+	var walk func(v interface{}, prefix string, depth int)
+	walk = func(v interface{}, prefix string, depth int) {
+		obj, ok := v.(map[string]interface{})
+		if !ok || depth == 0 {
+			return
+		}
+		for k, child := range obj {
+			encoded, err := json.Marshal(child)
+			if err != nil {
+				continue
+			}
+			path := k
+			if prefix != "" {
+				path = prefix + "." + k
+			}
+			fields = append(fields, sized{path, len(encoded)})
+			walk(child, path, depth-1)
+		}
+	}
+	walk(data, "", depth)
+
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].size != fields[j].size {
+			return fields[i].size > fields[j].size
+		}
+		return fields[i].path < fields[j].path
+	})
+	var out []string
+	for _, f := range fields {
+		if len(out) == largestFieldsReported {
+			break
+		}
+		out = append(out, fmt.Sprintf("%s (%d bytes)", f.path, f.size))
+	}
+	return out
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// Tool: generate_types
+const generateTypesToolDescription = `Generate TypeScript or Go type definitions from your GraphQL schema.
+Objects, interfaces and input objects become interfaces (TypeScript) or structs (Go), enums become string unions or string constants, and nullability and lists are preserved. Every type the requested ones reference is included, so the output is ready to use.
+
+Best Practices:
+- Pass the names of the types you work with, or "all" for the whole schema.
+- Pass a query or mutation name to get an <Name>Args type for its arguments along with its return type.
+- Custom scalars are emitted as aliases of unknown (TypeScript) or any (Go); narrow them to the representation your API uses.
+
+Arguments:
+- names (string, Required): Comma-separated type or operation names, or "all".
+- language (string, Required): "typescript" or "go".
+
+Example Usage:
+Request:
+  generate_types(names: "candidate", language: "typescript")
+
+Response:
+  export interface CandidateArgs {
+    id: string;
+  }
+
+  export interface Candidate {
+    id: string;
+    name: string | null;
+    status: CandidateStatus;
+  }
+
+  export type CandidateStatus = "ACTIVE" | "INACTIVE";
+`
+
+// typeGenLanguages are the languages generate_types supports.
+var typeGenLanguages = []string{"typescript", "go"}
+
+// generateTypes renders type definitions for the named types and operations
+// (or every type when names is "all") and the types they reference.
+func generateTypes(ctx context.Context, names, language string) (string, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	switch language {
+	case "ts":
+		language = "typescript"
+	case "golang":
+		language = "go"
+	}
+	if language != "typescript" && language != "go" {
+		return "", fmt.Errorf("unsupported language %q, expected one of: %s", language, strings.Join(typeGenLanguages, ", "))
+	}
+
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var roots []*schemaField
+	include := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		t := schema.typeByName(name)
+		if t == nil || include[name] || builtinScalars[name] || strings.HasPrefix(name, "__") {
+			return
+		}
+		include[name] = true
+		for _, f := range t.Fields {
+			visit(f.Type.namedType())
+		}
+		for _, f := range t.InputFields {
+			visit(f.Type.namedType())
+		}
+		for _, ref := range t.Interfaces {
+			visit(ref.namedType())
+		}
+		for _, ref := range t.PossibleTypes {
+			visit(ref.namedType())
+		}
+	}
+
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if strings.EqualFold(name, "all") {
+			for _, t := range schema.Types {
+				visit(t.Name)
+			}
+			continue
+		}
+		if schema.typeByName(name) != nil {
+			visit(name)
+			continue
+		}
+		f := typeGenRootField(schema, name)
+		if f == nil {
+			return "", fmt.Errorf("'%s' is neither a type nor an operation of the schema", name)
+		}
+		roots = append(roots, f)
+		for _, a := range f.Args {
+			visit(a.Type.namedType())
+		}
+		visit(f.Type.namedType())
+	}
+	if len(roots) == 0 && len(include) == 0 {
+		return "", fmt.Errorf("no types to generate; pass type or operation names, or \"all\"")
+	}
+
+	typeNames := make([]string, 0, len(include))
+	for name := range include {
+		typeNames = append(typeNames, name)
+	}
+	sort.Strings(typeNames)
+
+	var blocks []string
+	if language == "go" {
+		blocks = append(blocks, "// Code generated from the GraphQL schema. DO NOT EDIT.\n\npackage graphqltypes")
+	}
+	for _, f := range roots {
+		name := exportedName(f.Name) + "Args"
+		if language == "go" {
+			blocks = append(blocks, goStruct(name, "Arguments of "+f.Name+".", f.Args, true))
+		} else {
+			blocks = append(blocks, tsInputInterface(name, "Arguments of "+f.Name+".", f.Args))
+		}
+	}
+	for _, name := range typeNames {
+		t := schema.typeByName(name)
+		if language == "go" {
+			blocks = append(blocks, goTypeDefinition(t))
+		} else {
+			blocks = append(blocks, tsTypeDefinition(t))
+		}
+	}
+	code := strings.Join(blocks, "\n\n") + "\n"
+	if language == "go" {
+		// gofmt aligns the struct fields and tags
+		if formatted, err := format.Source([]byte(code)); err == nil {
+			code = string(formatted)
+		}
+	}
+	return code, nil
+}
+
+// typeGenRootField finds a query, mutation or subscription field by name.
+func typeGenRootField(schema *schemaModel, name string) *schemaField {
+	for _, fields := range [][]*schemaField{schema.queries(), schema.mutations(), schema.subscriptions()} {
+		for _, f := range fields {
+			if f.Name == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// exportedName converts a GraphQL name to an exported identifier, e.g.
+// "candidate_id" to "CandidateID" and "IN_PROGRESS" to "InProgress".
+func exportedName(name string) string {
+	initialisms := map[string]bool{"ID": true, "URL": true, "API": true, "HTTP": true, "JSON": true, "UUID": true}
+	parts := strings.Split(name, "_")
+	allCaps := strings.ToUpper(name) == name
+	var sb strings.Builder
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		if initialisms[strings.ToUpper(part)] {
+			sb.WriteString(strings.ToUpper(part))
+			continue
+		}
+		if allCaps {
+			part = strings.ToLower(part)
+		}
+		// Uppercase a trailing "Id"/"Url" of camelCase names
+		for suffix := range initialisms {
+			cased := suffix[:1] + strings.ToLower(suffix[1:])
+			if len(part) > len(cased) && strings.HasSuffix(part, cased) {
+				part = strings.TrimSuffix(part, cased) + suffix
+				break
+			}
+		}
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	if sb.Len() == 0 {
+		return "X" + name
+	}
+	return sb.String()
+}
+
+// docComment renders a description as comment lines with the given prefix
+// ("// " or " * ").
+func docComment(description, prefix string) []string {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return nil
+	}
+	var lines []string
+	for _, line := range strings.Split(description, "\n") {
+		lines = append(lines, strings.TrimRight(prefix+strings.TrimSpace(line), " "))
+	}
+	return lines
+}
+
+// tsTypeDefinition renders a schema type as TypeScript.
+func tsTypeDefinition(t *schemaType) string {
+	var lines []string
+	if doc := docComment(t.Description, " * "); doc != nil {
+		lines = append(lines, "/**")
+		lines = append(lines, doc...)
+		lines = append(lines, " */")
+	}
+	switch t.Kind {
+	case "SCALAR":
+		lines = append(lines, fmt.Sprintf("export type %s = unknown;", t.Name))
+	case "ENUM":
+		values := make([]string, len(t.EnumValues))
+		for i, v := range t.EnumValues {
+			values[i] = fmt.Sprintf("%q", v.Name)
+		}
+		lines = append(lines, fmt.Sprintf("export type %s = %s;", t.Name, strings.Join(values, " | ")))
+	case "UNION":
+		members := make([]string, len(t.PossibleTypes))
+		for i, ref := range t.PossibleTypes {
+			members[i] = ref.namedType()
+		}
+		lines = append(lines, fmt.Sprintf("export type %s = %s;", t.Name, strings.Join(members, " | ")))
+	case "INPUT_OBJECT":
+		return tsInputInterface(t.Name, t.Description, t.InputFields)
+	default:
+		header := "export interface " + t.Name
+		if len(t.Interfaces) > 0 {
+			names := make([]string, len(t.Interfaces))
+			for i, ref := range t.Interfaces {
+				names[i] = ref.namedType()
+			}
+			header += " extends " + strings.Join(names, ", ")
+		}
+		lines = append(lines, header+" {")
+		for _, f := range t.Fields {
+			for _, doc := range docComment(f.Description, "") {
+				lines = append(lines, "  // "+doc)
+			}
+			lines = append(lines, fmt.Sprintf("  %s: %s;", f.Name, tsTypeRef(f.Type)))
+		}
+		lines = append(lines, "}")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tsInputInterface renders input values (input fields or arguments) as a
+// TypeScript interface. Nullable values are optional.
+func tsInputInterface(name, description string, values []*schemaInputValue) string {
+	var lines []string
+	if doc := docComment(description, " * "); doc != nil {
+		lines = append(lines, "/**")
+		lines = append(lines, doc...)
+		lines = append(lines, " */")
+	}
+	lines = append(lines, "export interface "+name+" {")
+	for _, v := range values {
+		for _, doc := range docComment(v.Description, "") {
+			lines = append(lines, "  // "+doc)
+		}
+		optional := ""
+		if !v.Type.isNonNull() || v.DefaultValue != nil {
+			optional = "?"
+		}
+		lines = append(lines, fmt.Sprintf("  %s%s: %s;", v.Name, optional, tsTypeRef(v.Type)))
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+// tsTypeRef renders a type reference, adding "| null" for nullable types.
+func tsTypeRef(ref *typeRef) string {
+	if ref == nil {
+		return "unknown"
+	}
+	if ref.Kind == "NON_NULL" {
+		return tsNonNullType(ref.OfType)
+	}
+	return tsNonNullType(ref) + " | null"
+}
+
+// tsNonNullType renders a type reference without its nullability.
+func tsNonNullType(ref *typeRef) string {
+	switch {
+	case ref == nil:
+		return "unknown"
+	case ref.Kind == "LIST":
+		return "Array<" + tsTypeRef(ref.OfType) + ">"
+	}
+	switch ref.Name {
+	case "ID", "String":
+		return "string"
+	case "Int", "Float":
+		return "number"
+	case "Boolean":
+		return "boolean"
+	}
+	return ref.Name
+}
+
+// goTypeDefinition renders a schema type as Go.
+func goTypeDefinition(t *schemaType) string {
+	var lines []string
+	lines = append(lines, docComment(t.Description, "// ")...)
+	switch t.Kind {
+	case "SCALAR":
+		lines = append(lines, fmt.Sprintf("type %s = any", exportedName(t.Name)))
+	case "ENUM":
+		name := exportedName(t.Name)
+		lines = append(lines, fmt.Sprintf("type %s string", name), "", "const (")
+		for _, v := range t.EnumValues {
+			for _, doc := range docComment(v.Description, "// ") {
+				lines = append(lines, "\t"+doc)
+			}
+			lines = append(lines, fmt.Sprintf("\t%s%s %s = %q", name, exportedName(v.Name), name, v.Name))
+		}
+		lines = append(lines, ")")
+	case "UNION", "INTERFACE":
+		// Go can't decode JSON into an interface, so abstract types carry the
+		// discriminator and their shared fields
+		var fields []*schemaInputValue
+		fields = append(fields, &schemaInputValue{Name: "__typename", Type: &typeRef{Kind: "NON_NULL", OfType: &typeRef{Kind: "SCALAR", Name: "String"}}})
+		for _, f := range t.Fields {
+			fields = append(fields, &schemaInputValue{Name: f.Name, Description: f.Description, Type: f.Type})
+		}
+		return goStruct(t.Name, t.Description, fields, false)
+	case "INPUT_OBJECT":
+		return goStruct(t.Name, t.Description, t.InputFields, true)
+	default:
+		fields := make([]*schemaInputValue, len(t.Fields))
+		for i, f := range t.Fields {
+			fields[i] = &schemaInputValue{Name: f.Name, Description: f.Description, Type: f.Type}
+		}
+		return goStruct(t.Name, t.Description, fields, false)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// goStruct renders values as a Go struct with JSON tags. Nullable values of
+// input types are omitted when empty.
+func goStruct(name, description string, values []*schemaInputValue, input bool) string {
+	var lines []string
+	lines = append(lines, docComment(description, "// ")...)
+	lines = append(lines, fmt.Sprintf("type %s struct {", exportedName(name)))
+	for _, v := range values {
+		for _, doc := range docComment(v.Description, "// ") {
+			lines = append(lines, "\t"+doc)
+		}
+		tag := v.Name
+		if input && !v.Type.isNonNull() {
+			tag += ",omitempty"
+		}
+		fieldName := exportedName(v.Name)
+		if v.Name == "__typename" {
+			fieldName = "Typename"
+		}
+		lines = append(lines, fmt.Sprintf("\t%s %s `json:\"%s\"`", fieldName, goTypeRef(v.Type), tag))
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+// goTypeRef renders a type reference. Nullable values and references to
+// composite types are pointers (the latter so recursive types compile);
+// lists are slices.
+func goTypeRef(ref *typeRef) string {
+	if ref == nil {
+		return "any"
+	}
+	nonNull := ref.Kind == "NON_NULL"
+	if nonNull {
+		ref = ref.OfType
+	}
+	if ref.Kind == "LIST" {
+		return "[]" + goTypeRef(ref.OfType)
+	}
+	var name string
+	switch ref.Name {
+	case "ID", "String":
+		name = "string"
+	case "Int":
+		name = "int"
+	case "Float":
+		name = "float64"
+	case "Boolean":
+		name = "bool"
+	default:
+		name = exportedName(ref.Name)
+	}
+	switch {
+	case ref.Kind == "SCALAR" && !builtinScalars[ref.Name]:
+		// Custom scalars are aliases of any, which is already nil-able
+		return name
+	case ref.Kind == "OBJECT" || ref.Kind == "INTERFACE" || ref.Kind == "UNION" || ref.Kind == "INPUT_OBJECT":
+		return "*" + name
+	case !nonNull:
+		return "*" + name
+	}
+	return name
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tool: type_graph
+const typeGraphToolDescription = `Map the relationships around a type as a graph: the nodes are the object, interface, union and input types reachable from it, and the edges are the fields connecting them, with their cardinality.
+Scalar and enum fields are left out, so the graph shows the structure of the schema neighborhood: what can be navigated to from the type, and through which fields.
+
+Best Practices:
+- Use this tool to plan how to reach related data, e.g. from a Candidate to the scorecards of its interviews, before writing a nested query.
+- Keep depth small: the graph grows quickly in large schemas. Nodes marked "frontier" are at the depth limit and have edges that aren't shown; call the tool again from them.
+- Use format "dot" to render the graph with Graphviz (dot -Tsvg).
+- Union members are linked to their union by an edge without a field.
+
+Arguments:
+- type (string, Required): The root type, or query, mutation or subscription for a root operation type.
+- depth (number, Optional): How many fields away from the root to follow, 1 to 5 (default 2).
+- format (string, Optional): "json" (default) for an adjacency list of the nodes and their edges, or "dot" for a Graphviz digraph.
+
+Example Usage:
+Request:
+  type_graph(type: "Candidate", depth: 1)
+
+Response:
+  {
+    "root": "Candidate",
+    "depth": 1,
+    "nodes": {
+      "Application": {
+        "kind": "OBJECT",
+        "frontier": true
+      },
+      "Candidate": {
+        "kind": "OBJECT",
+        "edges": [
+          {
+            "field": "applications",
+            "to": "Application",
+            "type": "[Application!]!",
+            "cardinality": "many",
+            "required": true
+          },
+          {
+            "field": "referrer",
+            "to": "Candidate",
+            "type": "Candidate",
+            "cardinality": "one"
+          }
+        ]
+      }
+    }
+  }
+`
+
+// maxTypeGraphDepth bounds the depth of type_graph.
+const maxTypeGraphDepth = 5
+
+// typeGraph is the neighborhood of a type, as an adjacency list.
+type typeGraph struct {
+	Root  string                    `json:"root"`
+	Depth int                       `json:"depth"`
+	Nodes map[string]*typeGraphNode `json:"nodes"`
+}
+
+// typeGraphNode is a type of the graph with the edges leaving it.
+type typeGraphNode struct {
+	Kind string `json:"kind"`
+	// Frontier is set on the types at the depth limit, whose edges aren't
+	// followed.
+	Frontier bool             `json:"frontier,omitempty"`
+	Edges    []*typeGraphEdge `json:"edges,omitempty"`
+	depth    int
+}
+
+// typeGraphEdge is a field leading to another type, or the link from a union
+// to one of its members when Field is empty.
+type typeGraphEdge struct {
+	Field string `json:"field,omitempty"`
+	To    string `json:"to"`
+	Type  string `json:"type,omitempty"`
+	// Cardinality is "one", or "many" for lists; Required is set for
+	// non-null fields.
+	Cardinality string `json:"cardinality,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// describeTypeGraph builds the graph of the types reachable from root within
+// depth fields and renders it as JSON or DOT.
+func describeTypeGraph(ctx context.Context, root string, depth int, format string) (string, error) {
+	switch format {
+	case "", "json", "dot":
+	default:
+		return "", fmt.Errorf("unknown format %q, expected \"json\" or \"dot\"", format)
+	}
+	if depth == 0 {
+		depth = 2
+	}
+	if depth < 1 || depth > maxTypeGraphDepth {
+		return "", fmt.Errorf("depth must be between 1 and %d, got %d", maxTypeGraphDepth, depth)
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	graph, err := buildTypeGraph(schema, strings.TrimSpace(root), depth)
+	if err != nil {
+		return "", err
+	}
+	if format == "dot" {
+		return graph.dot(), nil
+	}
+	encoded, err := json.MarshalIndent(graph, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+// buildTypeGraph walks the schema breadth-first from the type root.
+func buildTypeGraph(schema *schemaModel, root string, depth int) (*typeGraph, error) {
+	name := root
+	if rootType := schema.rootType(root); rootType != "" {
+		name = rootType
+	}
+	t := schema.typeByName(name)
+	if t == nil {
+		names := make([]string, 0, len(schema.Types))
+		for _, t := range schema.Types {
+			names = append(names, t.Name)
+		}
+		return nil, fmt.Errorf("%s", unknownKeyProblem(root, "unknown type "+name, name, names))
+	}
+	if !isGraphNode(t) {
+		return nil, fmt.Errorf("%s is %s, which has no relationships to other types", t.Name, kindArticle(schema, t.Name))
+	}
+
+	graph := &typeGraph{Root: t.Name, Depth: depth, Nodes: map[string]*typeGraphNode{t.Name: {Kind: t.Kind}}}
+	queue := []*schemaType{t}
+	for len(queue) > 0 {
+		t, queue = queue[0], queue[1:]
+		node := graph.Nodes[t.Name]
+		for _, e := range graphEdges(t) {
+			target := schema.typeByName(e.To)
+			if target == nil || !isGraphNode(target) {
+				continue
+			}
+			if node.depth == depth {
+				node.Frontier = true
+				continue
+			}
+			node.Edges = append(node.Edges, e)
+			if graph.Nodes[target.Name] == nil {
+				graph.Nodes[target.Name] = &typeGraphNode{Kind: target.Kind, depth: node.depth + 1}
+				queue = append(queue, target)
+			}
+		}
+	}
+	return graph, nil
+}
+
+// isGraphNode reports whether a type is a node of type graphs, i.e. has
+// fields or members; scalars and enums are leaves left out of the graph.
+func isGraphNode(t *schemaType) bool {
+	switch t.Kind {
+	case "OBJECT", "INTERFACE", "UNION", "INPUT_OBJECT":
+		return true
+	}
+	return false
+}
+
+// graphEdges returns the fields (or input fields) of t, and the members of a
+// union, as edges.
+func graphEdges(t *schemaType) []*typeGraphEdge {
+	var edges []*typeGraphEdge
+	add := func(field string, ref *typeRef) {
+		cardinality := "one"
+		if ref.isList() {
+			cardinality = "many"
+		}
+		edges = append(edges, &typeGraphEdge{Field: field, To: ref.namedType(), Type: ref.String(), Cardinality: cardinality, Required: ref.isNonNull()})
+	}
+	for _, f := range t.Fields {
+		add(f.Name, f.Type)
+	}
+	for _, f := range t.InputFields {
+		add(f.Name, f.Type)
+	}
+	if t.Kind == "UNION" {
+		for _, p := range t.PossibleTypes {
+			edges = append(edges, &typeGraphEdge{To: p.Name})
+		}
+	}
+	return edges
+}
+
+// dot renders the graph as a Graphviz digraph. Frontier types are dashed and
+// union members are linked by dotted edges.
+func (g *typeGraph) dot() string {
+	names := make([]string, 0, len(g.Nodes))
+	for name := range g.Nodes {
+		names = append(names, name)
+	}
+	// The root first, then the others by name
+	sort.Slice(names, func(i, j int) bool {
+		if names[i] == g.Root || names[j] == g.Root {
+			return names[i] == g.Root
+		}
+		return names[i] < names[j]
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "digraph %q {\n", g.Root)
+	sb.WriteString("  node [shape=box];\n")
+	for _, name := range names {
+		node := g.Nodes[name]
+		attrs := fmt.Sprintf("label=%q", name+"\n"+node.Kind)
+		if node.Frontier {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&sb, "  %q [%s];\n", name, attrs)
+	}
+	for _, name := range names {
+		for _, e := range g.Nodes[name].Edges {
+			if e.Field == "" {
+				fmt.Fprintf(&sb, "  %q -> %q [style=dotted];\n", name, e.To)
+			} else {
+				fmt.Fprintf(&sb, "  %q -> %q [label=%q];\n", name, e.To, e.Field+": "+e.Type)
+			}
+		}
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
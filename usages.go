@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tool: find_usages
+const findUsagesToolDescription = `Find the fields that return a given type, across all types and root operations.
+This is a reverse lookup: starting from a known type, it shows the queries and mutations that return it directly (the entry points) and the fields of other types that lead to it.
+Fields returning an interface the type implements or a union it belongs to are listed separately, since they may return it too.
+
+Best Practices:
+- Use this tool when you know the type you need (e.g. from describe) but not how to query it.
+- Prefer the entry points; otherwise follow the listed fields back with find_usages on their parent types.
+
+Arguments:
+- type (string, Required): The name of the type, e.g. Candidate.
+
+Example Usage:
+Request:
+  find_usages(type: "Candidate")
+
+Response:
+  Fields returning Candidate:
+  Entry points:
+  	query.candidate(id: ID!): Candidate
+  	query.candidates: [Candidate!]!
+  Fields:
+  	Job.candidates: [Candidate!]!
+  Through interfaces and unions:
+  	query.search(term: String!): [SearchResult!]! (Candidate is a member of SearchResult)
+`
+
+// findTypeUsages lists the fields whose return type, with list and non-null
+// wrappers removed, is the named type or one of its interfaces and unions.
+func findTypeUsages(ctx context.Context, name string) (string, error) {
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+	name = strings.TrimSpace(name)
+	target := schema.typeByName(name)
+	if target == nil {
+		names := make([]string, 0, len(schema.Types))
+		for _, t := range schema.Types {
+			names = append(names, t.Name)
+		}
+		return "", fmt.Errorf("type '%s' not found in schema. Did you mean: %s?", name, strings.Join(suggestNames(name, names, maxSuggestions), ", "))
+	}
+
+	// The abstract types that may resolve to the target
+	abstract := make(map[string]string)
+	for _, ref := range target.Interfaces {
+		abstract[ref.namedType()] = fmt.Sprintf("%s implements %s", target.Name, ref.namedType())
+	}
+	for _, t := range schema.Types {
+		if t.Kind != "UNION" {
+			continue
+		}
+		for _, ref := range t.PossibleTypes {
+			if ref.namedType() == target.Name {
+				abstract[t.Name] = fmt.Sprintf("%s is a member of %s", target.Name, t.Name)
+			}
+		}
+	}
+
+	var entryPoints, fields, indirect []string
+	for _, operation := range []string{"query", "mutation", "subscription"} {
+		for _, f := range schema.rootFields(schema.rootType(operation)) {
+			named := f.Type.namedType()
+			if named == target.Name {
+				entryPoints = append(entryPoints, operation+"."+usageString(f))
+			} else if reason, ok := abstract[named]; ok {
+				indirect = append(indirect, fmt.Sprintf("%s.%s (%s)", operation, usageString(f), reason))
+			}
+		}
+	}
+	for _, t := range schema.Types {
+		if schema.isRootType(t.Name) || strings.HasPrefix(t.Name, "__") {
+			continue
+		}
+		for _, f := range t.Fields {
+			named := f.Type.namedType()
+			if named == target.Name {
+				fields = append(fields, t.Name+"."+usageString(f))
+			} else if reason, ok := abstract[named]; ok && t.Name != target.Name {
+				indirect = append(indirect, fmt.Sprintf("%s.%s (%s)", t.Name, usageString(f), reason))
+			}
+		}
+	}
+
+	if len(entryPoints) == 0 && len(fields) == 0 && len(indirect) == 0 {
+		return fmt.Sprintf("No field returns %s.", target.Name), nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Fields returning %s:\n", target.Name)
+	for _, section := range []struct {
+		title string
+		lines []string
+	}{{"Entry points", entryPoints}, {"Fields", fields}, {"Through interfaces and unions", indirect}} {
+		if len(section.lines) == 0 {
+			continue
+		}
+		sb.WriteString(section.title + ":\n")
+		for _, line := range section.lines {
+			sb.WriteString("\t" + line + "\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// usageString renders a field as "name(arg: Type): ReturnType", leaving out
+// the parentheses when it has no arguments.
+func usageString(f *schemaField) string {
+	if len(f.Args) == 0 {
+		return f.Name + ": " + f.Type.String()
+	}
+	return prettyPrintField(f)
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Tool: validate_variables
+const validateVariablesToolDescription = `Check variables against the variable types an operation declares, without executing it.
+Reports missing required variables, unknown keys and type mismatches (including inside input objects and lists), each with the path of the offending value, and @skip/@include directives whose if argument isn't a declared Boolean! variable.
+
+Best Practices:
+- Run this before invoke_graphql when building variables for complex input types.
+- Fix every reported problem; the paths (e.g. $input.address.zip) point at the exact value.
+- Deployment-wide default variables are taken into account, as they are when invoking.
+
+Arguments:
+- operation (string, Required): The GraphQL operation declaring the variables.
+- variables (string, Optional): A JSON-encoded string of the variables to check.
+
+Example Usage:
+Request:
+  validate_variables(operation: "mutation ($input: CandidateInput!) { createCandidate(input: $input) { id } }", variables: "{\"input\": {\"nmae\": \"Ann\", \"age\": \"30\"}}")
+
+Response:
+  Found 3 problems:
+  - $input.nmae: unknown field of CandidateInput. Did you mean: name?
+  - $input.age: expected Int, got string "30"
+  - $input.name: required field of type String! is missing
+`
+
+// validateOperationVariables checks variablesJSON against the variable
+// definitions of operation and returns a report of the problems found.
+func validateOperationVariables(ctx context.Context, operation, variablesJSON string) (string, error) {
+	doc, err := parseDocument(operation)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse operation: %w", err)
+	}
+	if len(doc.Operations) == 0 {
+		return "", fmt.Errorf("the document contains no operation")
+	}
+	vars, err := parseVariables(variablesJSON)
+	if err != nil {
+		return "", err
+	}
+	vars = applyDefaultVariables(operation, vars)
+
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var problems []string
+	declared := make(map[string]bool)
+	for _, op := range doc.Operations {
+		problems = append(problems, conditionalDirectiveProblems(doc, op)...)
+		for _, def := range op.VariableDefinitions {
+			declared[def.Name] = true
+			path := "$" + def.Name
+			v, ok := vars[def.Name]
+			if !ok {
+				if def.Type.NonNull && def.DefaultValue == nil {
+					problems = append(problems, fmt.Sprintf("%s: required variable of type %s is missing", path, def.Type))
+				}
+				continue
+			}
+			problems = append(problems, validateValue(schema, def.Type, v, path)...)
+		}
+	}
+
+	var names []string
+	for name := range declared {
+		names = append(names, name)
+	}
+	var unknown []string
+	for name := range vars {
+		if !declared[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, unknownKeyProblem("$"+name, "variable not declared by the operation", name, names))
+	}
+
+	if len(problems) == 0 {
+		return "Variables are valid.", nil
+	}
+	noun := "problems"
+	if len(problems) == 1 {
+		noun = "problem"
+	}
+	return fmt.Sprintf("Found %d %s:\n- %s", len(problems), noun, strings.Join(problems, "\n- ")), nil
+}
+
+// validateValue checks a decoded JSON value against a declared type,
+// following the GraphQL input coercion rules.
+func validateValue(schema *schemaModel, t *astType, v interface{}, path string) []string {
+	if v == nil {
+		if t.NonNull {
+			return []string{fmt.Sprintf("%s: expected %s, got null", path, t)}
+		}
+		return nil
+	}
+	if t.Elem != nil {
+		list, ok := v.([]interface{})
+		if !ok {
+			// A single value is accepted where a list is expected
+			return validateValue(schema, t.Elem, v, path)
+		}
+		var problems []string
+		for i, item := range list {
+			problems = append(problems, validateValue(schema, t.Elem, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return problems
+	}
+
+	typ := schema.typeByName(t.Name)
+	if typ == nil {
+		return []string{fmt.Sprintf("%s: type %s is not defined in the schema", path, t.Name)}
+	}
+	mismatch := func() []string {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, t.Name, describeJSONType(v))}
+	}
+	switch typ.Kind {
+	case "SCALAR":
+		if !validScalarValue(t.Name, v) {
+			return mismatch()
+		}
+	case "ENUM":
+		s, ok := v.(string)
+		if !ok {
+			return mismatch()
+		}
+		names := make([]string, len(typ.EnumValues))
+		for i, ev := range typ.EnumValues {
+			if ev.Name == s {
+				return nil
+			}
+			names[i] = ev.Name
+		}
+		return []string{fmt.Sprintf("%s: %q is not a value of %s. Did you mean: %s?", path, s, t.Name, strings.Join(suggestNames(s, names, maxSuggestions), ", "))}
+	case "INPUT_OBJECT":
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		// Unknown keys come first: they usually explain the missing fields
+		var problems []string
+		names := make([]string, len(typ.InputFields))
+		known := make(map[string]bool, len(typ.InputFields))
+		for i, f := range typ.InputFields {
+			names[i] = f.Name
+			known[f.Name] = true
+		}
+		var unknown []string
+		for key := range obj {
+			if !known[key] {
+				unknown = append(unknown, key)
+			}
+		}
+		sort.Strings(unknown)
+		for _, key := range unknown {
+			problems = append(problems, unknownKeyProblem(path+"."+key, "unknown field of "+t.Name, key, names))
+		}
+		for _, f := range typ.InputFields {
+			fieldPath := path + "." + f.Name
+			val, ok := obj[f.Name]
+			if !ok {
+				if f.Type.isNonNull() && f.DefaultValue == nil {
+					problems = append(problems, fmt.Sprintf("%s: required field of type %s is missing", fieldPath, f.Type))
+				}
+				continue
+			}
+			problems = append(problems, validateValue(schema, astTypeFromRef(f.Type), val, fieldPath)...)
+		}
+		return problems
+	default:
+		return []string{fmt.Sprintf("%s: %s is a %s, which can't be used as an input type", path, t.Name, typ.Kind)}
+	}
+	return nil
+}
+
+// validScalarValue reports whether v is a valid JSON value for a scalar.
+// Custom scalars accept any value.
+func validScalarValue(scalar string, v interface{}) bool {
+	switch scalar {
+	case "String":
+		_, ok := v.(string)
+		return ok
+	case "Boolean":
+		_, ok := v.(bool)
+		return ok
+	case "Float":
+		_, ok := v.(float64)
+		return ok
+	case "Int":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n) && n >= math.MinInt32 && n <= math.MaxInt32
+	case "ID":
+		switch v := v.(type) {
+		case string:
+			return true
+		case float64:
+			return v == math.Trunc(v)
+		}
+		return false
+	}
+	return true
+}
+
+// unknownKeyProblem reports an unexpected key, suggesting close names.
+func unknownKeyProblem(path, problem, key string, candidates []string) string {
+	msg := path + ": " + problem
+	if suggestions := suggestNames(key, candidates, maxSuggestions); len(suggestions) > 0 {
+		msg += ". Did you mean: " + strings.Join(suggestions, ", ") + "?"
+	}
+	return msg
+}
+
+// describeJSONType names the JSON type of a decoded value, with the value
+// for scalars, e.g. `string "30"`.
+func describeJSONType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string " + describeJSONValue(v)
+	case float64:
+		return "number " + describeJSONValue(v)
+	case bool:
+		return "boolean " + describeJSONValue(v)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
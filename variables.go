@@ -0,0 +1,102 @@
+package main
+
+import "fmt"
+
+// defaultVariables are merged into the variables of every invoke_graphql call.
+// Variables passed with the call take precedence over these defaults.
+var defaultVariables = jsonObjectFromEnv("GRAPHQL_DEFAULT_VARIABLES")
+
+// applyDefaultVariables merges defaultVariables into vars without overriding
+// keys the caller set. Only variables declared by the operation are added, so
+// servers that reject unknown variables keep working; when the operation
+// can't be parsed every default is added.
+func applyDefaultVariables(operation string, vars map[string]interface{}) map[string]interface{} {
+	if len(defaultVariables) == 0 {
+		return vars
+	}
+
+	var declared map[string]bool
+	if doc, err := parseDocument(operation); err == nil {
+		declared = make(map[string]bool)
+		for _, op := range doc.Operations {
+			for _, v := range op.VariableDefinitions {
+				declared[v.Name] = true
+			}
+		}
+	}
+
+	merged := make(map[string]interface{}, len(vars)+len(defaultVariables))
+	for k, v := range defaultVariables {
+		if declared == nil || declared[k] {
+			merged[k] = v
+		}
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+// conditionalDirectiveProblems checks the @skip and @include directives of
+// op and of the fragments it spreads: their "if" argument must be a Boolean
+// literal, or a variable the operation declares as Boolean! (or as Boolean
+// with a default value).
+func conditionalDirectiveProblems(doc *astDocument, op *astOperation) []string {
+	declared := make(map[string]*astVariableDefinition, len(op.VariableDefinitions))
+	names := make([]string, 0, len(op.VariableDefinitions))
+	for _, def := range op.VariableDefinitions {
+		declared[def.Name] = def
+		names = append(names, def.Name)
+	}
+
+	var problems []string
+	check := func(dirs []*astDirective) {
+		for _, dir := range dirs {
+			if dir.Name != "skip" && dir.Name != "include" {
+				continue
+			}
+			at := fmt.Sprintf("line %d, column %d: @%s", dir.Line, dir.Column, dir.Name)
+			var cond *astValue
+			for _, arg := range dir.Arguments {
+				if arg.Name == "if" {
+					cond = arg.Value
+				}
+			}
+			switch {
+			case cond == nil:
+				problems = append(problems, at+" needs an if argument, e.g. @"+dir.Name+"(if: $flag)")
+			case cond.Kind == valueBoolean:
+			case cond.Kind != valueVariable:
+				problems = append(problems, fmt.Sprintf("%s(if:) expects a Boolean, got %s", at, cond))
+			case declared[cond.Raw] == nil:
+				problems = append(problems, unknownKeyProblem(at+"(if: $"+cond.Raw+")", "variable not declared by the operation; declare it as $"+cond.Raw+": Boolean!", cond.Raw, names))
+			default:
+				def := declared[cond.Raw]
+				if def.Type.Name != "Boolean" || (!def.Type.NonNull && def.DefaultValue == nil) {
+					problems = append(problems, fmt.Sprintf("%s(if: $%s): the variable is declared as %s, but if expects Boolean!; declare it as $%s: Boolean!", at, cond.Raw, def.Type, cond.Raw))
+				}
+			}
+		}
+	}
+
+	visited := make(map[string]bool)
+	var walk func(sels []*astSelection)
+	walk = func(sels []*astSelection) {
+		for _, sel := range sels {
+			check(sel.Directives)
+			if sel.Kind == selectionFragmentSpread {
+				if frag := doc.fragment(sel.Name); frag != nil && !visited[frag.Name] {
+					visited[frag.Name] = true
+					walk(frag.SelectionSet)
+				}
+				continue
+			}
+			walk(sel.SelectionSet)
+		}
+	}
+	walk(op.SelectionSet)
+	return problems
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConditionalDirectiveProblems(t *testing.T) {
+	tests := []struct {
+		name      string
+		operation string
+		// want is a substring of the only problem expected, or "" for none
+		want string
+	}{
+		{
+			name:      "Boolean! variable",
+			operation: `query ($withEmail: Boolean!) { candidate { name email @include(if: $withEmail) } }`,
+		},
+		{
+			name:      "Boolean variable with a default",
+			operation: `query ($brief: Boolean = false) { candidate { name email @skip(if: $brief) } }`,
+		},
+		{
+			name:      "Boolean literal",
+			operation: `query { candidate { name email @include(if: true) } }`,
+		},
+		{
+			name:      "undeclared variable",
+			operation: `query ($withMail: Boolean!) { candidate { name email @include(if: $withEmail) } }`,
+			want:      "line 1, column 54: @include(if: $withEmail): variable not declared by the operation",
+		},
+		{
+			name:      "non-Boolean variable",
+			operation: `query ($withEmail: String!) { candidate { name email @include(if: $withEmail) } }`,
+			want:      "the variable is declared as String!, but if expects Boolean!",
+		},
+		{
+			name:      "nullable Boolean variable without a default",
+			operation: `query ($brief: Boolean) { candidate { name email @skip(if: $brief) } }`,
+			want:      "the variable is declared as Boolean, but if expects Boolean!",
+		},
+		{
+			name:      "non-Boolean literal",
+			operation: `query { candidate { name email @skip(if: "yes") } }`,
+			want:      `@skip(if:) expects a Boolean, got "yes"`,
+		},
+		{
+			name:      "missing if argument",
+			operation: `query { candidate { name email @skip } }`,
+			want:      "@skip needs an if argument",
+		},
+		{
+			name:      "inside a fragment",
+			operation: `query ($withEmail: Int) { candidate { ...Contact } } fragment Contact on Candidate { email @include(if: $withEmail) }`,
+			want:      "the variable is declared as Int, but if expects Boolean!",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, op, err := parseSingleOperation(tt.operation, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			problems := conditionalDirectiveProblems(doc, op)
+			if tt.want == "" {
+				if len(problems) > 0 {
+					t.Errorf("unexpected problems: %q", problems)
+				}
+				return
+			}
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.want) {
+				t.Errorf("problems = %q, want one containing %q", problems, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// WebSocket opcodes (RFC 6455, section 5.2).
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// wsAcceptGUID is appended to the client key to compute Sec-WebSocket-Accept.
+const wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsMaxMessageSize bounds the size of a single incoming message.
+const wsMaxMessageSize = 16 << 20
+
+// errWebSocketClosed is returned when the peer closes the connection.
+var errWebSocketClosed = errors.New("websocket closed by server")
+
+// wsConn is a minimal client-side WebSocket connection supporting text
+// messages, ping/pong and the closing handshake.
+type wsConn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	protocol string
+	writeMu  sync.Mutex
+}
+
+// dialWebSocket opens a WebSocket connection to rawURL (ws:// or wss://),
+// sending headers with the upgrade request and offering the given subprotocols.
+func dialWebSocket(ctx context.Context, rawURL string, headers http.Header, protocols []string) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket URL: %w", err)
+	}
+	host := u.Host
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	case "wss":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported websocket scheme %q", u.Scheme)
+	}
+
+	// Abort the connection if the context ends while we wait for the proxy
+	// or the server
+	var conn net.Conn
+	var connMu sync.Mutex
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			connMu.Lock()
+			if conn != nil {
+				conn.Close()
+			}
+			connMu.Unlock()
+		case <-stop:
+		}
+	}()
+	setConn := func(c net.Conn) {
+		connMu.Lock()
+		conn = c
+		connMu.Unlock()
+	}
+
+	tcpConn, err := dialWebSocketTCP(ctx, u, host, setConn)
+	if err != nil {
+		return nil, ctxErrOr(ctx, err)
+	}
+	if u.Scheme == "wss" {
+		tlsConn := tls.Client(tcpConn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			tcpConn.Close()
+			return nil, err
+		}
+		setConn(tlsConn)
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req := &http.Request{
+		Method:     "GET",
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(protocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(protocols, ", "))
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, ctxErrOr(ctx, err)
+	}
+
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, ctxErrOr(ctx, err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		conn.Close()
+		return nil, fmt.Errorf("websocket upgrade failed: unexpected HTTP status %s", res.Status)
+	}
+	sum := sha1.Sum([]byte(key + wsAcceptGUID))
+	if res.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, errors.New("websocket upgrade failed: invalid Sec-WebSocket-Accept header")
+	}
+
+	return &wsConn{conn: conn, br: br, protocol: res.Header.Get("Sec-WebSocket-Protocol")}, nil
+}
+
+// webSocketProxy returns the proxy for a request, from HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY as for the HTTP client.
+var webSocketProxy = http.ProxyFromEnvironment
+
+// dialWebSocketTCP opens the TCP connection to host ("host:port") of the
+// WebSocket URL u, tunneled with CONNECT through the proxy the environment
+// configures for it. Every connection it opens is passed to setConn, so that
+// it can be closed when the context ends.
+func dialWebSocketTCP(ctx context.Context, u *url.URL, host string, setConn func(net.Conn)) (net.Conn, error) {
+	// The proxy variables are keyed by the HTTP scheme
+	httpScheme := "http"
+	if u.Scheme == "wss" {
+		httpScheme = "https"
+	}
+	proxyURL, err := webSocketProxy(&http.Request{URL: &url.URL{Scheme: httpScheme, Host: u.Host}})
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy configuration: %w", err)
+	}
+	var dialer net.Dialer
+	if proxyURL == nil {
+		conn, err := dialer.DialContext(ctx, "tcp", host)
+		if err != nil {
+			return nil, err
+		}
+		setConn(conn)
+		return conn, nil
+	}
+
+	proxyHost := proxyURL.Host
+	switch proxyURL.Scheme {
+	case "http":
+		if proxyURL.Port() == "" {
+			proxyHost = net.JoinHostPort(proxyURL.Hostname(), "80")
+		}
+	case "https":
+		if proxyURL.Port() == "" {
+			proxyHost = net.JoinHostPort(proxyURL.Hostname(), "443")
+		}
+	default:
+		return nil, fmt.Errorf("proxy %s is not supported for subscriptions, only http and https proxies are", proxyURL.Redacted())
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyHost)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	setConn(conn)
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("connecting to proxy %s: %w", proxyURL.Redacted(), err)
+		}
+		conn = tlsConn
+		setConn(conn)
+	}
+
+	req := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: host}, Host: host, Header: make(http.Header)}
+	if proxyURL.User != nil {
+		pass, _ := proxyURL.User.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username()+":"+pass)))
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// The proxy sends nothing after its response until the tunnel is used,
+	// so reading it through a buffer loses no data
+	res, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("connecting through proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", proxyURL.Redacted(), host, res.Status)
+	}
+	return conn, nil
+}
+
+// writeFrame sends a single masked frame, as required for client frames.
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, 0x80|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	header = append(header, mask...)
+
+	masked := make([]byte, len(payload))
+	for i, b := range payload {
+		masked[i] = b ^ mask[i%4]
+	}
+	if _, err := c.conn.Write(append(header, masked...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WriteText sends a text message.
+func (c *wsConn) WriteText(data []byte) error {
+	return c.writeFrame(wsOpText, data)
+}
+
+// ReadMessage returns the next text or binary message, transparently
+// answering pings and reassembling fragmented messages.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			// Echo the close frame to complete the closing handshake
+			c.writeFrame(wsOpClose, payload)
+			if len(payload) >= 2 {
+				code := binary.BigEndian.Uint16(payload)
+				return nil, fmt.Errorf("%w (code %d: %s)", errWebSocketClosed, code, payload[2:])
+			}
+			return nil, errWebSocketClosed
+		case wsOpText, wsOpBinary, wsOpContinuation:
+			message = append(message, payload...)
+			if len(message) > wsMaxMessageSize {
+				return nil, fmt.Errorf("websocket message exceeds %d bytes", wsMaxMessageSize)
+			}
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("unexpected websocket opcode %d", opcode)
+		}
+	}
+}
+
+// readFrame reads a single frame from the server.
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin = head[0]&0x80 != 0
+	opcode = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > wsMaxMessageSize {
+		err = fmt.Errorf("websocket frame exceeds %d bytes", wsMaxMessageSize)
+		return
+	}
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// Close performs a best-effort closing handshake and closes the connection.
+func (c *wsConn) Close() error {
+	c.writeFrame(wsOpClose, []byte{0x03, 0xE8}) // 1000: normal closure
+	return c.conn.Close()
+}
+
+// ctxErrOr returns the context error when ctx is done, otherwise err. It is
+// used where closing the connection on cancellation surfaces as an I/O error.
+func ctxErrOr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
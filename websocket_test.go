@@ -0,0 +1,335 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dialTestServer opens a WebSocket connection to a server started with
+// newWebSocketTestServer.
+func dialTestServer(t *testing.T, srv *httptest.Server, headers http.Header, protocols ...string) *wsConn {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := dialWebSocket(ctx, "ws://"+strings.TrimPrefix(srv.URL, "http://"), headers, protocols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.conn.Close() })
+	return conn
+}
+
+// readRawFrame reads a frame from the client without unmasking it, and
+// returns its first byte, whether it was masked and its unmasked payload.
+func readRawFrame(br *bufio.Reader) (first byte, masked bool, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(br, head[:]); err != nil {
+		return 0, false, nil, err
+	}
+	n := uint64(head[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(br, ext[:]); err != nil {
+			return 0, false, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(br, ext[:]); err != nil {
+			return 0, false, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	masked = head[1]&0x80 != 0
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(br, mask[:]); err != nil {
+			return 0, false, nil, err
+		}
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		return 0, false, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return head[0], masked, payload, nil
+}
+
+func TestWebSocketHandshake(t *testing.T) {
+	got := make(chan *http.Request, 1)
+	srv := newWebSocketTestServer(t, protocolGraphQLTransportWS, func(r *http.Request, c *wsTestConn) {
+		got <- r
+	})
+	conn := dialTestServer(t, srv, http.Header{"Authorization": {"Bearer token"}}, protocolGraphQLTransportWS, protocolGraphQLWS)
+	if conn.protocol != protocolGraphQLTransportWS {
+		t.Errorf("negotiated protocol = %q, want %q", conn.protocol, protocolGraphQLTransportWS)
+	}
+	r := <-got
+	if r.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("Authorization = %q, want the configured header", r.Header.Get("Authorization"))
+	}
+	if want := protocolGraphQLTransportWS + ", " + protocolGraphQLWS; r.Header.Get("Sec-WebSocket-Protocol") != want {
+		t.Errorf("Sec-WebSocket-Protocol = %q, want %q", r.Header.Get("Sec-WebSocket-Protocol"), want)
+	}
+	if key, err := base64.StdEncoding.DecodeString(r.Header.Get("Sec-WebSocket-Key")); err != nil || len(key) != 16 {
+		t.Errorf("Sec-WebSocket-Key = %q, want 16 random bytes in base64", r.Header.Get("Sec-WebSocket-Key"))
+	}
+}
+
+func TestWebSocketHandshakeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  string
+	}{
+		{"not upgraded", "HTTP/1.1 400 Bad Request\r\nContent-Length: 0\r\n\r\n", "unexpected HTTP status 400"},
+		{"wrong accept", "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: bm90IHRoZSBrZXk=\r\n\r\n", "invalid Sec-WebSocket-Accept"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, rw, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				defer conn.Close()
+				rw.WriteString(tt.response)
+				rw.Flush()
+			}))
+			defer srv.Close()
+			_, err := dialWebSocket(context.Background(), "ws://"+strings.TrimPrefix(srv.URL, "http://"), nil, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestWebSocketMasking checks that client frames are masked, with each
+// payload length encoding.
+func TestWebSocketMasking(t *testing.T) {
+	messages := []string{"short", strings.Repeat("m", 300), strings.Repeat("l", 70000)}
+	type frame struct {
+		first   byte
+		masked  bool
+		payload []byte
+	}
+	got := make(chan frame, len(messages))
+	srv := newWebSocketTestServer(t, "", func(r *http.Request, c *wsTestConn) {
+		for range messages {
+			first, masked, payload, err := readRawFrame(c.br)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got <- frame{first, masked, payload}
+		}
+	})
+	conn := dialTestServer(t, srv, nil)
+	for _, msg := range messages {
+		if err := conn.WriteText([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+		f := <-got
+		if f.first != 0x80|wsOpText {
+			t.Errorf("first byte = %#x, want a final text frame", f.first)
+		}
+		if !f.masked {
+			t.Errorf("the %d-byte frame wasn't masked", len(msg))
+		}
+		if string(f.payload) != msg {
+			t.Errorf("the %d-byte frame unmasked to %d different bytes", len(msg), len(f.payload))
+		}
+	}
+}
+
+// TestWebSocketFragmentation checks that fragmented messages are
+// reassembled, and that pings between fragments are answered.
+func TestWebSocketFragmentation(t *testing.T) {
+	pong := make(chan []byte, 1)
+	srv := newWebSocketTestServer(t, "", func(r *http.Request, c *wsTestConn) {
+		c.writeServerFrame(false, wsOpText, []byte("hel"))
+		c.writeServerFrame(true, wsOpPing, []byte("are you there"))
+		c.writeServerFrame(false, wsOpContinuation, []byte("lo, "))
+		c.writeServerFrame(true, wsOpContinuation, []byte("world"))
+		first, _, payload, err := readRawFrame(c.br)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if first != 0x80|wsOpPong {
+			t.Errorf("first byte = %#x, want a pong", first)
+		}
+		pong <- payload
+	})
+	conn := dialTestServer(t, srv, nil)
+	msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "hello, world" {
+		t.Errorf("message = %q, want %q", msg, "hello, world")
+	}
+	select {
+	case payload := <-pong:
+		if string(payload) != "are you there" {
+			t.Errorf("pong payload = %q, want the ping payload", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the ping wasn't answered")
+	}
+}
+
+func TestWebSocketMessageTooLarge(t *testing.T) {
+	srv := newWebSocketTestServer(t, "", func(r *http.Request, c *wsTestConn) {
+		c.writeServerFrame(false, wsOpText, make([]byte, wsMaxMessageSize))
+		c.writeServerFrame(true, wsOpContinuation, []byte("x"))
+	})
+	conn := dialTestServer(t, srv, nil)
+	if _, err := conn.ReadMessage(); err == nil {
+		t.Error("a message over the size limit was accepted")
+	}
+}
+
+func TestWebSocketClose(t *testing.T) {
+	t.Run("by the server", func(t *testing.T) {
+		echoed := make(chan []byte, 1)
+		srv := newWebSocketTestServer(t, "", func(r *http.Request, c *wsTestConn) {
+			c.writeServerFrame(true, wsOpClose, append([]byte{0x03, 0xE9}, "going away"...))
+			first, _, payload, err := readRawFrame(c.br)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if first != 0x80|wsOpClose {
+				t.Errorf("first byte = %#x, want a close frame", first)
+			}
+			echoed <- payload
+		})
+		conn := dialTestServer(t, srv, nil)
+		_, err := conn.ReadMessage()
+		if !errors.Is(err, errWebSocketClosed) {
+			t.Fatalf("error = %v, want the connection to be closed", err)
+		}
+		if !strings.Contains(err.Error(), "1001") || !strings.Contains(err.Error(), "going away") {
+			t.Errorf("error = %v, want the close code and reason", err)
+		}
+		select {
+		case payload := <-echoed:
+			if len(payload) < 2 || binary.BigEndian.Uint16(payload) != 1001 {
+				t.Errorf("close reply = %v, want it to echo code 1001", payload)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the close frame wasn't answered")
+		}
+	})
+
+	t.Run("by the client", func(t *testing.T) {
+		got := make(chan []byte, 1)
+		srv := newWebSocketTestServer(t, "", func(r *http.Request, c *wsTestConn) {
+			first, masked, payload, err := readRawFrame(c.br)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if first != 0x80|wsOpClose || !masked {
+				t.Errorf("first byte = %#x, masked = %v, want a masked close frame", first, masked)
+			}
+			got <- payload
+		})
+		conn := dialTestServer(t, srv, nil)
+		conn.Close()
+		select {
+		case payload := <-got:
+			if len(payload) < 2 || binary.BigEndian.Uint16(payload) != 1000 {
+				t.Errorf("close payload = %v, want normal closure (1000)", payload)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("the server didn't receive a close frame")
+		}
+	})
+}
+
+// TestWebSocketProxy checks that connections are tunneled through the
+// configured proxy with CONNECT, authenticating with its credentials.
+func TestWebSocketProxy(t *testing.T) {
+	srv := newWebSocketTestServer(t, "", func(r *http.Request, c *wsTestConn) {
+		c.writeServerFrame(true, wsOpText, []byte("through the proxy"))
+	})
+	target := strings.TrimPrefix(srv.URL, "http://")
+
+	var connectTarget, proxyAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		connectTarget, proxyAuth = r.Host, r.Header.Get("Proxy-Authorization")
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer upstream.Close()
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 Connection Established\r\n\r\n")
+		rw.Flush()
+		go io.Copy(upstream, rw)
+		io.Copy(conn, upstream)
+	}))
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("user", "pass")
+	previous := webSocketProxy
+	webSocketProxy = func(*http.Request) (*url.URL, error) { return proxyURL, nil }
+	t.Cleanup(func() { webSocketProxy = previous })
+
+	conn := dialTestServer(t, srv, nil)
+	msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "through the proxy" {
+		t.Errorf("message = %q, want the server's message", msg)
+	}
+	if connectTarget != target {
+		t.Errorf("CONNECT target = %q, want %q", connectTarget, target)
+	}
+	if want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass")); proxyAuth != want {
+		t.Errorf("Proxy-Authorization = %q, want %q", proxyAuth, want)
+	}
+
+	t.Run("refused", func(t *testing.T) {
+		proxyURL.User = nil
+		refusing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "authentication required", http.StatusProxyAuthRequired)
+		}))
+		defer refusing.Close()
+		proxyURL.Host = strings.TrimPrefix(refusing.URL, "http://")
+		_, err := dialWebSocket(context.Background(), "ws://"+target, nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "407") {
+			t.Errorf("error = %v, want the proxy's refusal", err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tool: whoami
+const whoamiToolDescription = `Check that the current credentials work and report who they authenticate, by running the API's "current user" query with the current headers.
+The query is WHOAMI_QUERY: either a query field (e.g. "me"), selected with its scalar fields, or a whole operation. By default the first of viewer, me and currentUser that the schema has is used.
+
+Best Practices:
+- Run this right after set_headers or set_basic_auth, before doing real work.
+- "Not authenticated" means the credentials were rejected or identify nobody; set valid ones and try again.
+
+Example Usage:
+Request:
+  whoami()
+
+Response:
+  Authenticated as (query.viewer):
+  {
+    "viewer": {
+      "email": "ann@example.com",
+      "id": "42",
+      "login": "ann"
+    }
+  }
+`
+
+// whoamiQuery is the query field or operation whoami runs; see
+// defaultWhoamiFields.
+var whoamiQuery = getenv("WHOAMI_QUERY")
+
+// defaultWhoamiFields are the usual names of the query returning the
+// authenticated user, tried in order when WHOAMI_QUERY is unset.
+var defaultWhoamiFields = []string{"viewer", "me", "currentUser"}
+
+// errNotAuthenticated is returned by whoami when the endpoint rejects the
+// credentials or answers that nobody is authenticated.
+var errNotAuthenticated = errors.New("not authenticated")
+
+// whoami runs the WHOAMI_QUERY operation with the current headers and returns
+// its data, or an error wrapping errNotAuthenticated.
+func whoami(ctx context.Context) (string, error) {
+	operation, label, err := whoamiOperation(ctx)
+	if err != nil {
+		return "", err
+	}
+	res, err := invokeGraphQLOperation(ctx, operation, "", invokeOptions{Timeout: defaultRequestTimeout, NoCache: true})
+	if isAuthFailure(err) || errors.Is(err, errAuthenticationFailed) {
+		return "", fmt.Errorf("%w: %s was rejected: %v", errNotAuthenticated, label, err)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// A null user means the endpoint accepted the request anonymously
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(res.Body), &data); err == nil {
+		anonymous := true
+		for _, v := range data {
+			anonymous = anonymous && v == nil
+		}
+		if anonymous {
+			return "", fmt.Errorf("%w: %s returned null with the current headers; set credentials with set_headers", errNotAuthenticated, label)
+		}
+	}
+	return "Authenticated as (" + label + "):\n" + res.Body, nil
+}
+
+// whoamiOperation returns the operation to run and how to refer to it.
+func whoamiOperation(ctx context.Context) (string, string, error) {
+	if strings.Contains(whoamiQuery, "{") {
+		return whoamiQuery, "WHOAMI_QUERY", nil
+	}
+	schema, err := getSchema(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	var f *schemaField
+	if name := strings.TrimSpace(whoamiQuery); name != "" {
+		if f = lookupField(schema, schema.QueryType, name); f == nil {
+			names := make([]string, 0, len(schema.queries()))
+			for _, q := range schema.queries() {
+				names = append(names, q.Name)
+			}
+			return "", "", fmt.Errorf("%s", unknownKeyProblem("WHOAMI_QUERY", "unknown query "+name, name, names))
+		}
+	} else {
+		for _, name := range defaultWhoamiFields {
+			if f = lookupField(schema, schema.QueryType, name); f != nil {
+				break
+			}
+		}
+		if f == nil {
+			return "", "", fmt.Errorf("the schema has no %s query; set WHOAMI_QUERY to the query returning the authenticated user", strings.Join(defaultWhoamiFields, ", "))
+		}
+	}
+	if requiresArguments(f) {
+		return "", "", fmt.Errorf("query.%s has required arguments; set WHOAMI_QUERY to a whole operation instead", f.Name)
+	}
+
+	return "query WhoAmI { " + f.Name + leafSelection(schema, f.Type) + " }", "query." + f.Name, nil
+}
+
+// leafSelection returns the selection set of the scalar and enum fields of
+// the type ref names that take no required arguments, e.g. " { id name }",
+// or "" when it is itself a scalar or an enum. Types without such fields,
+// like unions, select __typename.
+func leafSelection(schema *schemaModel, ref *typeRef) string {
+	t := schema.typeByName(ref.namedType())
+	if t == nil || t.Kind == "SCALAR" || t.Kind == "ENUM" {
+		return ""
+	}
+	var selected []string
+	for _, field := range t.Fields {
+		if leafType(schema, field.Type) && !requiresArguments(field) {
+			selected = append(selected, field.Name)
+		}
+	}
+	if len(selected) == 0 {
+		selected = []string{"__typename"}
+	}
+	return " { " + strings.Join(selected, " ") + " }"
+}
+
+// leafType reports whether ref names a scalar or an enum.
+func leafType(schema *schemaModel, ref *typeRef) bool {
+	t := schema.typeByName(ref.namedType())
+	return t != nil && (t.Kind == "SCALAR" || t.Kind == "ENUM")
+}
+
+// requiresArguments reports whether f has an argument that must be given.
+func requiresArguments(f *schemaField) bool {
+	for _, a := range f.Args {
+		if isRequiredArgument(a) {
+			return true
+		}
+	}
+	return false
+}
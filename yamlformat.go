@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// jsonToYAML converts a JSON document to block-style YAML, keeping the order
+// of its keys.
+func jsonToYAML(data []byte) ([]byte, error) {
+	// JSON is a subset of YAML, so the document parses as YAML in flow style
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("converting JSON to YAML: %w", err)
+	}
+	var clearStyle func(n *yaml.Node)
+	clearStyle = func(n *yaml.Node) {
+		n.Style = 0
+		for _, child := range n.Content {
+			clearStyle(child)
+		}
+	}
+	clearStyle(&node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return nil, fmt.Errorf("converting JSON to YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("converting JSON to YAML: %w", err)
+	}
+	return []byte(strings.TrimSuffix(buf.String(), "\n")), nil
+}
+
+// yamlFallbackNote explains why a response requested as YAML is JSON.
+func yamlFallbackNote(err error) string {
+	return "The response couldn't be converted to YAML (" + err.Error() + "), so it is returned as JSON."
+}